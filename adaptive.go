@@ -0,0 +1,138 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"math"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+// AdaptiveSampling is the configuration of an AdaptiveSampler.
+type AdaptiveSampling struct {
+	// MinRate is the lowest fraction of events kept, defaults to 0.01.
+	MinRate float64
+
+	// If the event queue is filled more then High, a fraction of its
+	// capacity, or events were dropped by bad EventWriters, see
+	// WriterStats.Dropped, the rate is halved. If its filled less then Low,
+	// and no events were dropped, the rate is doubled. Defaults to 0.5 and
+	// 0.1.
+	High, Low float64
+
+	// Interval is the minimum time between rate adjustments, defaults to
+	// 100 milliseconds.
+	Interval time.Duration
+
+	// Key, if set, is used to sample the events consistently by the key, see
+	// HashSampler. Otherwise the events are sampled randomly.
+	Key func(Event) string
+
+	// KeepType is the minimum EventType of events that are always kept,
+	// defaults to ErrorEvent if zero.
+	KeepType EventType
+}
+
+// AdaptiveSampler is a Sampler that tightens its rate when the event queue
+// fills up, e.g. when the EventWriters can't keep up during an incident, or
+// when events are dropped, and relaxes it when the queue is (almost) empty
+// again. This keeps the overhead of logging bounded, while keeping all events
+// when idle. Use its Sample method with SetSampler:
+//
+//	sampler := logger.NewAdaptiveSampler(logger.AdaptiveSampling{})
+//	logger.SetSampler(sampler.Sample)
+type AdaptiveSampler struct {
+	config AdaptiveSampling
+
+	// The current rate, as float64 bits, and the time (in Unix nanoseconds)
+	// of the last adjustment. Both must be accessed atomically.
+	rate       uint64
+	lastAdjust int64
+
+	// Number of dropped events at the last adjustment. Must be accessed
+	// atomically.
+	lastDropped uint64
+
+	// Stubbed for testing.
+	queueFill func() float64
+	dropped   func() uint64
+}
+
+// NewAdaptiveSampler creates a new AdaptiveSampler, starting with a rate of 1,
+// i.e. keeping all events.
+func NewAdaptiveSampler(config AdaptiveSampling) *AdaptiveSampler {
+	if config.MinRate == 0 {
+		config.MinRate = 0.01
+	}
+	if config.High == 0 {
+		config.High = 0.5
+	}
+	if config.Low == 0 {
+		config.Low = 0.1
+	}
+	if config.Interval == 0 {
+		config.Interval = 100 * time.Millisecond
+	}
+	if config.KeepType == 0 {
+		config.KeepType = ErrorEvent
+	}
+	return &AdaptiveSampler{
+		config:    config,
+		rate:      math.Float64bits(1),
+		queueFill: eventQueueFill,
+		dropped:   func() uint64 { return droppedEvents(writerStates) },
+	}
+}
+
+// eventQueueFill returns the fraction of the capacity of the event channel
+// that is used.
+func eventQueueFill() float64 {
+	return float64(len(eventChannel)) / float64(cap(eventChannel))
+}
+
+// Rate returns the current fraction of events kept.
+func (s *AdaptiveSampler) Rate() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&s.rate))
+}
+
+// Sample implements a Sampler, it returns true if the event should be kept.
+// It's safe for concurrent use.
+func (s *AdaptiveSampler) Sample(event Event) bool {
+	s.adjust()
+	if event.Type >= s.config.KeepType {
+		return true
+	}
+
+	rate := s.Rate()
+	if rate >= 1 {
+		return true
+	} else if s.config.Key == nil {
+		return rand.Float64() < rate
+	}
+	k := s.config.Key(event)
+	return k == "" || float64(hashKey(k)) < rate*math.MaxUint64
+}
+
+// adjust adjusts the rate, if the last adjustment was more then the interval
+// ago. Only a single goroutine adjusts the rate at a time.
+func (s *AdaptiveSampler) adjust() {
+	t := now().UnixNano()
+	last := atomic.LoadInt64(&s.lastAdjust)
+	if t-last < int64(s.config.Interval) || !atomic.CompareAndSwapInt64(&s.lastAdjust, last, t) {
+		return
+	}
+
+	rate, fill := s.Rate(), s.queueFill()
+	dropped := s.dropped()
+	newDrops := atomic.SwapUint64(&s.lastDropped, dropped) != dropped
+	switch {
+	case fill > s.config.High || newDrops:
+		rate = math.Max(rate/2, s.config.MinRate)
+	case fill < s.config.Low:
+		rate = math.Min(rate*2, 1)
+	}
+	atomic.StoreUint64(&s.rate, math.Float64bits(rate))
+}
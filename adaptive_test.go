@@ -0,0 +1,72 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAdaptiveSampler(t *testing.T) {
+	oldNow := now
+	defer func() { now = oldNow }()
+	current := t1
+	now = func() time.Time { return current }
+
+	s := NewAdaptiveSampler(AdaptiveSampling{MinRate: 0.25, Interval: time.Second})
+	var fill float64
+	var dropped uint64
+	s.queueFill = func() float64 { return fill }
+	s.dropped = func() uint64 { return dropped }
+
+	tests := []struct {
+		fill    float64
+		dropped uint64
+		advance time.Duration
+		rate    float64
+	}{
+		{0.0, 0, time.Second, 1},
+		{0.9, 0, time.Second, 0.5},
+		{0.9, 0, time.Millisecond, 0.5}, // Within the interval.
+		{0.9, 0, time.Second, 0.25},
+		{0.9, 0, time.Second, 0.25}, // MinRate.
+		{0.3, 0, time.Second, 0.25},
+		{0.0, 0, time.Second, 0.5},
+		{0.0, 10, time.Second, 0.25}, // Dropped events.
+		{0.0, 10, time.Second, 0.5},
+		{0.0, 10, time.Second, 1},
+		{0.0, 10, time.Second, 1},
+	}
+
+	for i, test := range tests {
+		fill, dropped = test.fill, test.dropped
+		current = current.Add(test.advance)
+		s.Sample(Event{Type: InfoEvent})
+		if got := s.Rate(); got != test.rate {
+			t.Fatalf("Expected the rate to be %f after step %d, but got %f", test.rate, i, got)
+		}
+	}
+}
+
+func TestAdaptiveSamplerSample(t *testing.T) {
+	s := NewAdaptiveSampler(AdaptiveSampling{MinRate: 0.1, Interval: time.Hour, Key: TagKey("user")})
+	s.queueFill = func() float64 { return 1 }
+	s.dropped = func() uint64 { return 0 }
+	s.Sample(Event{Type: InfoEvent}) // Tighten to 0.5.
+
+	hashSampler := HashSampler(0.5, TagKey("user"))
+	for i := 0; i < 100; i++ {
+		event := Event{Type: InfoEvent, Tags: Tags{Tag("user", i)}}
+		if got, expected := s.Sample(event), hashSampler(event); got != expected {
+			t.Fatalf("Expected the event of user %d to be kept (%t) as with HashSampler, but got %t",
+				i, expected, got)
+		}
+
+		event.Type = ErrorEvent
+		if !s.Sample(event) {
+			t.Fatalf("Expected the Error event of user %d to be kept", i)
+		}
+	}
+}
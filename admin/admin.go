@@ -0,0 +1,157 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+// Package admin provides a gRPC service to remotely control the logger package,
+// e.g. by orchestration tooling. It allows querying the status of the
+// EventWriters, changing the minimum EventType and flushing or rotating the
+// EventWriters. The schema of the service is in admin.proto, which can be used
+// to generate clients in any language, a Go client is provided by Client.
+//
+// The service allows anyone that can connect to it to change the logger, by
+// default without any authentication. It must only listen on localhost, or be
+// protected by either TokenAuth or mutual TLS, e.g.:
+//
+//	creds := credentials.NewTLS(tlsConfigRequiringClientCerts)
+//	server := admin.NewServer(grpc.Creds(creds), admin.TokenAuth(token))
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net"
+
+	"github.com/Thomasdezeeuw/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ServiceName is the full name of the gRPC service.
+const ServiceName = "logger.admin.Admin"
+
+// serviceDesc describes the Admin service, as defined in admin.proto.
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*interface{})(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Status", Handler: unaryHandler("Status", readStatus)},
+		{MethodName: "SetMinLevel", Handler: unaryHandler("SetMinLevel", setMinLevel)},
+		{MethodName: "Flush", Handler: unaryHandler("Flush", flush)},
+		{MethodName: "Rotate", Handler: unaryHandler("Rotate", rotate)},
+	},
+	Metadata: "admin.proto",
+}
+
+// NewServer returns a gRPC server that serves the Admin service, the options
+// are passed to grpc.NewServer. Without any options the server doesn't use TLS
+// nor authentication, see the package documentation.
+//
+// The server encodes the messages of the Admin service itself, other services
+// registered on the server use the default Protocol Buffers codec.
+func NewServer(opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts, grpc.ForceServerCodec(codec{}))
+	server := grpc.NewServer(opts...)
+	server.RegisterService(&serviceDesc, struct{}{})
+	return server
+}
+
+// ListenAndServe serves the Admin service on the TCP address addr, see
+// NewServer.
+func ListenAndServe(addr string, opts ...grpc.ServerOption) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return NewServer(opts...).Serve(listener)
+}
+
+// TokenAuth returns a server option that requires all calls to include the
+// token in the authorization metadata, as "Bearer <token>", see
+// TokenCredentials. Calls without the correct token fail with the
+// Unauthenticated code. Without TLS the token is send in plain text.
+func TokenAuth(token string) grpc.ServerOption {
+	expected := []byte("Bearer " + token)
+	return grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get("authorization")
+		if len(values) != 1 || subtle.ConstantTimeCompare([]byte(values[0]), expected) != 1 {
+			return nil, status.Error(codes.Unauthenticated, "invalid token")
+		}
+		return handler(ctx, req)
+	})
+}
+
+// unaryHandler returns the handler of a unary method, decoding the request
+// into a new request message.
+func unaryHandler(method string, fn func(context.Context, *message) (*message, error)) func(interface{}, context.Context, func(interface{}) error, grpc.UnaryServerInterceptor) (interface{}, error) {
+	fullMethod := "/" + ServiceName + "/" + method
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		var req message
+		if err := dec(&req); err != nil {
+			return nil, err
+		}
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return fn(ctx, req.(*message))
+		}
+		if interceptor == nil {
+			return handler(ctx, &req)
+		}
+		info := &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod}
+		return interceptor(ctx, &req, info, handler)
+	}
+}
+
+func readStatus(ctx context.Context, req *message) (*message, error) {
+	stats := logger.ReadStats()
+	st := Status{
+		MinLevel:      logger.MinEventType().String(),
+		QueueDepth:    stats.QueueDepth,
+		QueueCapacity: stats.QueueCapacity,
+		Writers:       make([]WriterStatus, len(stats.Writers)),
+	}
+	for i, writer := range stats.Writers {
+		st.Writers[i] = WriterStatus{
+			Type:            fmt.Sprintf("%T", writer.Writer),
+			Bad:             writer.Bad,
+			Writes:          writer.WriteLatency.Count,
+			WriteLatencyP99: writer.WriteLatency.P99,
+			WriteLatencyMax: writer.WriteLatency.Max,
+		}
+	}
+	return &message{buf: st.appendProtobuf(nil)}, nil
+}
+
+func setMinLevel(ctx context.Context, req *message) (*message, error) {
+	level, err := stringField(req.buf, 1)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	var eventType logger.EventType
+	if err := eventType.UnmarshalText([]byte(level)); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown level %q", level)
+	}
+	previous := logger.MinEventType()
+	logger.SetMinEventType(eventType)
+	return &message{buf: protobufString(1, previous.String())}, nil
+}
+
+func flush(ctx context.Context, req *message) (*message, error) {
+	return &message{}, controlError(logger.Flush())
+}
+
+func rotate(ctx context.Context, req *message) (*message, error) {
+	return &message{}, controlError(logger.Rotate())
+}
+
+// controlError converts an error returned by logger.Flush or logger.Rotate.
+func controlError(err error) error {
+	if err == nil {
+		return nil
+	} else if err == logger.ErrNotRunning {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
@@ -0,0 +1,151 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/Thomasdezeeuw/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// eventWriter records the number of events and flushes.
+type eventWriter struct {
+	events  int
+	flushes int
+}
+
+func (ew *eventWriter) Write(event logger.Event) error {
+	ew.events++
+	return nil
+}
+
+func (ew *eventWriter) HandleError(err error) {}
+func (ew *eventWriter) Close() error          { return nil }
+
+func (ew *eventWriter) Flush() error {
+	ew.flushes++
+	return nil
+}
+
+func startServer(t *testing.T, opts ...grpc.ServerOption) (*grpc.Server, string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Unexpected error listening: " + err.Error())
+	}
+	server := NewServer(opts...)
+	go server.Serve(listener)
+	return server, listener.Addr().String()
+}
+
+func dial(t *testing.T, addr string, opts ...grpc.DialOption) *Client {
+	client, err := Dial(addr, opts...)
+	if err != nil {
+		t.Fatal("Unexpected error dialing: " + err.Error())
+	}
+	return client
+}
+
+func TestAdmin(t *testing.T) {
+	logger.Reset()
+	defer logger.Reset()
+	server, addr := startServer(t)
+	defer server.Stop()
+	client := dial(t, addr)
+	defer client.Close()
+	ctx := context.Background()
+
+	if err := client.Flush(ctx); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("Expected a failed precondition error, but got %v", err)
+	}
+
+	var ew eventWriter
+	logger.Start(&ew)
+	logger.Info(logger.Tags{"admin"}, "Info message")
+	if err := client.Flush(ctx); err != nil {
+		t.Fatal("Unexpected error flushing: " + err.Error())
+	} else if err := client.Rotate(ctx); err != nil {
+		t.Fatal("Unexpected error rotating: " + err.Error())
+	}
+
+	testSetMinLevel(t, ctx, client)
+	testStatus(t, ctx, client)
+
+	if err := logger.Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+	if ew.events != 1 || ew.flushes != 1 {
+		t.Fatalf("Expected 1 event and 1 flush, but got %d and %d", ew.events, ew.flushes)
+	}
+}
+
+func testSetMinLevel(t *testing.T, ctx context.Context, client *Client) {
+	previous, err := client.SetMinLevel(ctx, "Warn")
+	if err != nil {
+		t.Fatal("Unexpected error setting min level: " + err.Error())
+	} else if previous != "Trace" {
+		t.Fatalf("Expected the previous level to be Trace, but got %q", previous)
+	}
+	_, err = client.SetMinLevel(ctx, "Unknown")
+	if st, _ := status.FromError(err); st.Code() != codes.InvalidArgument || st.Message() != `unknown level "Unknown"` {
+		t.Fatalf("Expected an invalid argument error, but got %v", err)
+	}
+}
+
+func testStatus(t *testing.T, ctx context.Context, client *Client) {
+	status, err := client.Status(ctx)
+	if err != nil {
+		t.Fatal("Unexpected error getting status: " + err.Error())
+	}
+	if status.MinLevel != "Warn" || status.QueueCapacity == 0 || len(status.Writers) != 1 ||
+		status.Writers[0].Type != "*admin.eventWriter" || status.Writers[0].Bad {
+		t.Fatalf("Unexpected status: %#v", status)
+	}
+}
+
+func TestAdminUnknownMethod(t *testing.T) {
+	server, addr := startServer(t)
+	defer server.Stop()
+	client := dial(t, addr)
+	defer client.Close()
+
+	_, err := client.call(context.Background(), "Unknown", nil)
+	if status.Code(err) != codes.Unimplemented {
+		t.Fatalf("Expected an unimplemented error, but got %v", err)
+	}
+}
+
+func TestTokenAuth(t *testing.T) {
+	logger.Reset()
+	defer logger.Reset()
+	server, addr := startServer(t, TokenAuth("secret"))
+	defer server.Stop()
+	ctx := context.Background()
+
+	client := dial(t, addr)
+	defer client.Close()
+	if _, err := client.SetMinLevel(ctx, "Warn"); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("Expected an unauthenticated error, but got %v", err)
+	}
+	wrong := dial(t, addr, grpc.WithPerRPCCredentials(TokenCredentials("wrong")))
+	defer wrong.Close()
+	if _, err := wrong.SetMinLevel(ctx, "Warn"); status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("Expected an unauthenticated error, but got %v", err)
+	} else if got := logger.MinEventType(); got != logger.TraceEvent {
+		t.Fatalf("Expected the minimum EventType not to change, but got %s", got)
+	}
+
+	authed := dial(t, addr, grpc.WithPerRPCCredentials(TokenCredentials("secret")))
+	defer authed.Close()
+	if _, err := authed.SetMinLevel(ctx, "Warn"); err != nil {
+		t.Fatal("Unexpected error setting min level: " + err.Error())
+	} else if got := logger.MinEventType(); got != logger.WarnEvent {
+		t.Fatalf("Expected the minimum EventType to be Warn, but got %s", got)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Client is a client of the Admin service.
+type Client struct {
+	conn grpc.ClientConnInterface
+	// Only set if the connection is created by Dial.
+	owned *grpc.ClientConn
+}
+
+// NewClient creates a new client of the Admin service using the connection,
+// e.g. created by grpc.NewClient.
+func NewClient(conn grpc.ClientConnInterface) *Client {
+	return &Client{conn: conn}
+}
+
+// Dial creates a new client of the Admin service served on addr, e.g.
+// "localhost:9999", by default without TLS. The options are passed to
+// grpc.NewClient, e.g. grpc.WithPerRPCCredentials(TokenCredentials(token)).
+// The client must be closed once it's no longer used.
+func Dial(addr string, opts ...grpc.DialOption) (*Client, error) {
+	opts = append([]grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())}, opts...)
+	conn, err := grpc.NewClient(addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn, owned: conn}, nil
+}
+
+// Close closes the connection created by Dial, it's a no-op for clients
+// created by NewClient.
+func (c *Client) Close() error {
+	if c.owned == nil {
+		return nil
+	}
+	return c.owned.Close()
+}
+
+// TokenCredentials returns the credentials to call a server protected by
+// TokenAuth, to be passed to grpc.WithPerRPCCredentials. The token is also
+// send without TLS, so only use this without TLS on localhost.
+func TokenCredentials(token string) credentials.PerRPCCredentials {
+	return tokenCredentials(token)
+}
+
+type tokenCredentials string
+
+func (token tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(token)}, nil
+}
+
+func (token tokenCredentials) RequireTransportSecurity() bool {
+	return false
+}
+
+// Status returns the status of the logger.
+func (c *Client) Status(ctx context.Context) (Status, error) {
+	var status Status
+	response, err := c.call(ctx, "Status", nil)
+	if err == nil {
+		err = status.unmarshalProtobuf(response)
+	}
+	return status, err
+}
+
+// SetMinLevel sets the minimum EventType, e.g. "Debug", and returns the
+// previous minimum.
+func (c *Client) SetMinLevel(ctx context.Context, level string) (string, error) {
+	response, err := c.call(ctx, "SetMinLevel", protobufString(1, level))
+	if err != nil {
+		return "", err
+	}
+	return stringField(response, 1)
+}
+
+// Flush flushes all EventWriters.
+func (c *Client) Flush(ctx context.Context) error {
+	_, err := c.call(ctx, "Flush", nil)
+	return err
+}
+
+// Rotate rotates the files of all EventWriters.
+func (c *Client) Rotate(ctx context.Context) error {
+	_, err := c.call(ctx, "Rotate", nil)
+	return err
+}
+
+// call calls the method and returns the response message. Errors returned by
+// the server can be inspected using the status package of gRPC.
+func (c *Client) call(ctx context.Context, method string, request []byte) ([]byte, error) {
+	var response message
+	err := c.conn.Invoke(ctx, "/"+ServiceName+"/"+method, &message{buf: request},
+		&response, grpc.ForceCodec(codec{}))
+	return response.buf, err
+}
@@ -0,0 +1,164 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger/internal/protobuf"
+	"google.golang.org/grpc/encoding"
+	protoenc "google.golang.org/grpc/encoding/proto"
+)
+
+// message is an encoded message of the Admin service, the messages are
+// encoded and decoded by the methods, see Status.
+type message struct {
+	buf []byte
+}
+
+// codec is the gRPC codec of the Admin service, it passes the encoded messages
+// as is and uses the Protocol Buffers codec for all other messages, e.g. of
+// other services on the same server.
+type codec struct{}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	if msg, ok := v.(*message); ok {
+		return msg.buf, nil
+	}
+	return protoCodec().Marshal(v)
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	if msg, ok := v.(*message); ok {
+		msg.buf = append([]byte(nil), data...)
+		return nil
+	}
+	return protoCodec().Unmarshal(data, v)
+}
+
+func (codec) Name() string {
+	return protoenc.Name
+}
+
+// protoCodec returns the registered Protocol Buffers codec.
+func protoCodec() encoding.Codec {
+	c := encoding.GetCodec(protoenc.Name)
+	if c == nil {
+		panic(fmt.Sprintf("admin: no %s codec registered", protoenc.Name))
+	}
+	return c
+}
+
+// Status is the status of the logger, see StatusResponse in admin.proto.
+type Status struct {
+	MinLevel      string
+	QueueDepth    int
+	QueueCapacity int
+	Writers       []WriterStatus
+}
+
+// WriterStatus is the status of a single EventWriter, see WriterStatus in
+// admin.proto.
+type WriterStatus struct {
+	Type            string
+	Bad             bool
+	Writes          int
+	WriteLatencyP99 time.Duration
+	WriteLatencyMax time.Duration
+}
+
+func (status Status) appendProtobuf(buf []byte) []byte {
+	buf = protobuf.AppendStringField(buf, 1, status.MinLevel)
+	buf = protobuf.AppendVarintField(buf, 2, uint64(status.QueueDepth))
+	buf = protobuf.AppendVarintField(buf, 3, uint64(status.QueueCapacity))
+	for _, writer := range status.Writers {
+		buf = protobuf.AppendBytesField(buf, 4, writer.appendProtobuf(nil))
+	}
+	return buf
+}
+
+func (status *Status) unmarshalProtobuf(buf []byte) error {
+	return consumeFields(buf, func(field protobuf.Field) error {
+		switch field.Number {
+		case 1:
+			status.MinLevel = string(field.Bytes)
+		case 2:
+			status.QueueDepth = int(field.Varint)
+		case 3:
+			status.QueueCapacity = int(field.Varint)
+		case 4:
+			var writer WriterStatus
+			if err := writer.unmarshalProtobuf(field.Bytes); err != nil {
+				return err
+			}
+			status.Writers = append(status.Writers, writer)
+		}
+		return nil
+	})
+}
+
+func (status WriterStatus) appendProtobuf(buf []byte) []byte {
+	buf = protobuf.AppendStringField(buf, 1, status.Type)
+	if status.Bad {
+		buf = protobuf.AppendVarintField(buf, 2, 1)
+	}
+	buf = protobuf.AppendVarintField(buf, 3, uint64(status.Writes))
+	buf = protobuf.AppendVarintField(buf, 4, uint64(status.WriteLatencyP99))
+	buf = protobuf.AppendVarintField(buf, 5, uint64(status.WriteLatencyMax))
+	return buf
+}
+
+func (status *WriterStatus) unmarshalProtobuf(buf []byte) error {
+	return consumeFields(buf, func(field protobuf.Field) error {
+		switch field.Number {
+		case 1:
+			status.Type = string(field.Bytes)
+		case 2:
+			status.Bad = field.Varint != 0
+		case 3:
+			status.Writes = int(field.Varint)
+		case 4:
+			status.WriteLatencyP99 = time.Duration(field.Varint)
+		case 5:
+			status.WriteLatencyMax = time.Duration(field.Varint)
+		}
+		return nil
+	})
+}
+
+// protobufString returns a message with a single string field.
+func protobufString(number int, value string) []byte {
+	return protobuf.AppendStringField(nil, number, value)
+}
+
+// stringField returns the value of the string field with the given number,
+// used for the messages with a single string field.
+func stringField(buf []byte, number int) (string, error) {
+	var value string
+	err := consumeFields(buf, func(field protobuf.Field) error {
+		if field.Number == number {
+			value = string(field.Bytes)
+		}
+		return nil
+	})
+	return value, err
+}
+
+// consumeFields calls fn for each field in buf, unknown fields should be
+// ignored by fn.
+func consumeFields(buf []byte, fn func(protobuf.Field) error) error {
+	for len(buf) > 0 {
+		field, n, err := protobuf.ConsumeField(buf)
+		if err != nil {
+			return err
+		}
+		if err := fn(field); err != nil {
+			return err
+		}
+		buf = buf[n:]
+	}
+	return nil
+}
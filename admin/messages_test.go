@@ -0,0 +1,35 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package admin
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStatusProtobuf(t *testing.T) {
+	status := Status{
+		MinLevel:      "Info",
+		QueueDepth:    10,
+		QueueCapacity: 1024,
+		Writers: []WriterStatus{
+			{Type: "*logger.fileEventWriter", Writes: 100, WriteLatencyP99: time.Millisecond,
+				WriteLatencyMax: 2 * time.Millisecond},
+			{Type: "*syslog.EventWriter", Bad: true},
+		},
+	}
+
+	var got Status
+	if err := got.unmarshalProtobuf(status.appendProtobuf(nil)); err != nil {
+		t.Fatal("Unexpected error unmarshaling: " + err.Error())
+	} else if !reflect.DeepEqual(got, status) {
+		t.Fatalf("Expected %#v, but got %#v", status, got)
+	}
+
+	if err := got.unmarshalProtobuf([]byte{0x22, 0x10}); err == nil {
+		t.Fatal("Expected an error unmarshaling an invalid message")
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+// Package agent provides a log shipping agent. It tails log files written by
+// the logger package in the text format, e.g. by the EventWriter created by
+// logger.NewFileEventWriter, converts the lines back into events using
+// logger.Parser and forwards them to an EventWriter, for example one of the
+// network EventWriters:
+//
+//	ew, err := logstash.NewEventWriter(logger.InfoEvent, logstash.Config{Addr: addr}, handleError)
+//	if err != nil {
+//		panic(err)
+//	}
+//	a, err := agent.Start(agent.Config{Paths: []string{"/var/log/app.log"}}, ew)
+//	if err != nil {
+//		panic(err)
+//	}
+//	defer a.Stop()
+//
+// The files are polled for new lines. Renamed (rotated) and truncated files
+// are detected, after which the new file is read from the start.
+package agent
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+// Defaults used if the corresponding Config fields are not set.
+const (
+	DefaultPollInterval  = time.Second
+	DefaultRetryInterval = 5 * time.Second
+)
+
+// Config is the configuration of the Agent.
+type Config struct {
+	// Paths of the files to tail.
+	Paths []string
+
+	// TimestampFormat used to write the events, see logger.NewParser.
+	TimestampFormat logger.TimestampFormat
+
+	// Escaped must be true if the events are escaped, see
+	// logger.NewEscapedParser.
+	Escaped bool
+
+	// FromStart reads the files from the start, by default only lines written
+	// after the Agent is started are read. Files created after a rotation are
+	// always read from the start.
+	FromStart bool
+
+	// PollInterval is the interval at which the files are checked for new
+	// lines, defaults to DefaultPollInterval. An event is forwarded once the
+	// next event is written or once no new lines are written for a single
+	// interval, since lines can be continuations of the previous event.
+	PollInterval time.Duration
+
+	// RetryInterval is the time waited before writing an event again after the
+	// EventWriter returned an error, defaults to DefaultRetryInterval. The
+	// error is passed to the error handler of the EventWriter.
+	RetryInterval time.Duration
+}
+
+// Agent tails log files and forwards the events.
+type Agent struct {
+	config  Config
+	ew      logger.EventWriter
+	tailers []*tailer
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// Start starts tailing the files and forwarding the events to the
+// EventWriter. It returns an error if a file can't be opened.
+func Start(config Config, ew logger.EventWriter) (*Agent, error) {
+	if len(config.Paths) == 0 {
+		return nil, errors.New("agent: no paths to tail")
+	}
+	if config.PollInterval == 0 {
+		config.PollInterval = DefaultPollInterval
+	}
+	if config.RetryInterval == 0 {
+		config.RetryInterval = DefaultRetryInterval
+	}
+
+	a := &Agent{config: config, ew: ew, stop: make(chan struct{})}
+	for _, path := range config.Paths {
+		t, err := newTailer(path, config.TimestampFormat, config.Escaped, config.FromStart)
+		if err != nil {
+			a.closeTailers()
+			return nil, err
+		}
+		a.tailers = append(a.tailers, t)
+	}
+
+	a.wg.Add(1)
+	go a.run()
+	return a, nil
+}
+
+func (a *Agent) run() {
+	defer a.wg.Done()
+	ticker := time.NewTicker(a.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for _, t := range a.tailers {
+			if !a.forward(t.poll(false)) {
+				return
+			}
+		}
+
+		select {
+		case <-a.stop:
+			for _, t := range a.tailers {
+				if !a.forward(t.poll(true)) {
+					return
+				}
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// forward writes the events to the EventWriter, retrying failed writes. It
+// returns false if the Agent is stopped while retrying.
+func (a *Agent) forward(events []logger.Event, err error) bool {
+	if err != nil {
+		a.ew.HandleError(err)
+	}
+
+	for _, event := range events {
+		for {
+			err := a.ew.Write(event)
+			if err == nil {
+				break
+			}
+			a.ew.HandleError(err)
+
+			select {
+			case <-a.stop:
+				return false
+			case <-time.After(a.config.RetryInterval):
+			}
+		}
+	}
+	return true
+}
+
+// Stop stops the Agent, it forwards all lines read so far and closes the
+// files and the EventWriter. If the EventWriter keeps returning errors the
+// remaining events are dropped.
+func (a *Agent) Stop() error {
+	close(a.stop)
+	a.wg.Wait()
+	a.closeTailers()
+	return a.ew.Close()
+}
+
+func (a *Agent) closeTailers() {
+	for _, t := range a.tailers {
+		t.close()
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package agent
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+// eventWriter sends all events to a channel, the first write fails.
+type eventWriter struct {
+	events chan logger.Event
+	errors []error
+	failed bool
+	closed bool
+}
+
+func (ew *eventWriter) Write(event logger.Event) error {
+	if !ew.failed {
+		ew.failed = true
+		return errors.New("Write error")
+	}
+	ew.events <- event
+	return nil
+}
+
+func (ew *eventWriter) HandleError(err error) {
+	ew.errors = append(ew.errors, err)
+}
+
+func (ew *eventWriter) Close() error {
+	ew.closed = true
+	return nil
+}
+
+func (ew *eventWriter) expect(t *testing.T, messages ...string) {
+	for _, msg := range messages {
+		select {
+		case event := <-ew.events:
+			if event.Message != msg {
+				t.Fatalf("Expected event with message %q, but got %v", msg, event)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Timed out waiting for event with message %q", msg)
+		}
+	}
+}
+
+func appendFile(t *testing.T, path, content string) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		t.Fatal("Unexpected error opening file: " + err.Error())
+	}
+	defer f.Close()
+	if _, err := f.WriteString(content); err != nil {
+		t.Fatal("Unexpected error writing file: " + err.Error())
+	}
+}
+
+func TestAgent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logger-agent")
+	if err != nil {
+		t.Fatal("Unexpected error creating temp dir: " + err.Error())
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+	appendFile(t, path, "2015-09-01 14:22:36 [Info] app: Message 1\n"+
+		"2015-09-01 14:22:37 [Warn] app: Multi\nline message\n")
+
+	ew := eventWriter{events: make(chan logger.Event, 10)}
+	a, err := Start(Config{
+		Paths:         []string{path},
+		FromStart:     true,
+		PollInterval:  10 * time.Millisecond,
+		RetryInterval: time.Millisecond,
+	}, &ew)
+	if err != nil {
+		t.Fatal("Unexpected error starting agent: " + err.Error())
+	}
+
+	ew.expect(t, "Message 1", "Multi\nline message")
+
+	appendFile(t, path, "2015-09-01 14:22:38 [Error] app: Message 3\n")
+	ew.expect(t, "Message 3")
+
+	// Rotate the file.
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal("Unexpected error renaming file: " + err.Error())
+	}
+	appendFile(t, path+".1", "2015-09-01 14:22:39 [Info] app: Message 4\n")
+	appendFile(t, path, "2015-09-01 14:22:40 [Info] app: Message 5\n2015-09-01 14:22:41 [Info] app: Message 6")
+	ew.expect(t, "Message 4", "Message 5")
+
+	if err := a.Stop(); err != nil {
+		t.Fatal("Unexpected error stopping agent: " + err.Error())
+	}
+	ew.expect(t, "Message 6")
+
+	if !ew.closed {
+		t.Fatal("Expected the EventWriter to be closed")
+	}
+	if len(ew.errors) != 1 || ew.errors[0].Error() != "Write error" {
+		t.Fatalf("Expected the write error to be handled, but got %v", ew.errors)
+	}
+}
+
+func TestAgentFromEnd(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logger-agent")
+	if err != nil {
+		t.Fatal("Unexpected error creating temp dir: " + err.Error())
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+	appendFile(t, path, "2015-09-01 14:22:36 [Info] app: Old message\n")
+
+	ew := eventWriter{events: make(chan logger.Event, 10), failed: true}
+	a, err := Start(Config{Paths: []string{path}, PollInterval: 10 * time.Millisecond}, &ew)
+	if err != nil {
+		t.Fatal("Unexpected error starting agent: " + err.Error())
+	}
+
+	appendFile(t, path, "2015-09-01 14:22:37 [Info] app: New message\n")
+	ew.expect(t, "New message")
+	if err := a.Stop(); err != nil {
+		t.Fatal("Unexpected error stopping agent: " + err.Error())
+	}
+	if len(ew.events) != 0 {
+		t.Fatalf("Expected no more events, but got %d", len(ew.events))
+	}
+}
+
+func TestStartErrors(t *testing.T) {
+	if _, err := Start(Config{}, nil); err == nil {
+		t.Fatal("Expected an error without paths")
+	}
+	if _, err := Start(Config{Paths: []string{"/non-existing/app.log"}}, nil); !os.IsNotExist(err) {
+		t.Fatalf("Expected a not exist error, but got %v", err)
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package agent
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+// tailer tails a single file.
+type tailer struct {
+	path    string
+	format  logger.TimestampFormat
+	escaped bool
+
+	// File currently read, nil if the file doesn't exist (yet).
+	f *os.File
+	// Incomplete last line read.
+	partial []byte
+	// Lines of the last event, it's not parsed yet because the next lines
+	// could be continuations of it.
+	lines []string
+	buf   []byte
+}
+
+func newTailer(path string, format logger.TimestampFormat, escaped, fromStart bool) (*tailer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !fromStart {
+		if _, err := f.Seek(0, io.SeekEnd); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return &tailer{path: path, format: format, escaped: escaped, f: f, buf: make([]byte, 32*1024)}, nil
+}
+
+// poll reads the new lines from the file and returns the completed events. If
+// final is true all read lines are returned as events.
+func (t *tailer) poll(final bool) ([]logger.Event, error) {
+	var events []logger.Event
+	read, err := t.read(&events)
+	if err != nil {
+		return t.flush(events, final), err
+	}
+
+	rotated, err := t.rotated()
+	if err != nil {
+		return t.flush(events, final), err
+	} else if rotated {
+		// Read the remainder of the old file, before reading the new file from
+		// the start.
+		_, err := t.read(&events)
+		events = t.flush(events, true)
+		t.f.Close()
+		t.f = nil
+		if err != nil {
+			return events, err
+		}
+		if read, err = t.read(&events); err != nil {
+			return t.flush(events, final), err
+		}
+	}
+
+	// If no new lines are written the last event is considered complete.
+	if !read || final {
+		events = t.flush(events, final)
+	}
+	return events, nil
+}
+
+// read reads all new lines from the file, completed events are appended to
+// events. If the file isn't opened, e.g. after it was rotated, it's opened
+// first, if it exists. It returns whether or not new data was read.
+func (t *tailer) read(events *[]logger.Event) (bool, error) {
+	if t.f == nil {
+		f, err := os.Open(t.path)
+		if os.IsNotExist(err) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		t.f = f
+	}
+
+	var read bool
+	for {
+		n, err := t.f.Read(t.buf)
+		if n > 0 {
+			read = true
+			t.partial = append(t.partial, t.buf[:n]...)
+			t.addLines(events)
+		}
+		if err == io.EOF {
+			return read, nil
+		} else if err != nil {
+			return read, err
+		}
+	}
+}
+
+// addLines adds the complete lines in partial to lines, parsing the lines of
+// the previous event once a new event starts.
+func (t *tailer) addLines(events *[]logger.Event) {
+	for {
+		i := bytes.IndexByte(t.partial, '\n')
+		if i == -1 {
+			return
+		}
+		line := string(t.partial[:i])
+		t.partial = t.partial[i+1:]
+
+		if len(t.lines) != 0 && t.isEventStart(line) {
+			*events = append(*events, t.parse()...)
+		}
+		t.lines = append(t.lines, line)
+	}
+}
+
+// isEventStart returns true if the line isn't a continuation of the previous
+// event.
+func (t *tailer) isEventStart(line string) bool {
+	if t.escaped {
+		return true
+	}
+	parser := logger.NewParser(strings.NewReader(line), t.format)
+	if !parser.Scan() {
+		return true
+	}
+	parseErr, ok := parser.Event().Data.(*logger.ParseError)
+	return !ok || parseErr.Err != logger.ErrNoEvent
+}
+
+// parse parses the lines of the last event.
+func (t *tailer) parse() []logger.Event {
+	input := strings.NewReader(strings.Join(t.lines, "\n"))
+	t.lines = t.lines[:0]
+
+	var parser *logger.Parser
+	if t.escaped {
+		parser = logger.NewEscapedParser(input, t.format)
+	} else {
+		parser = logger.NewParser(input, t.format)
+	}
+	var events []logger.Event
+	for parser.Scan() {
+		events = append(events, parser.Event())
+	}
+	return events
+}
+
+// flush appends the last event to events. If partial is true an incomplete
+// last line is considered complete, e.g. when the file is no longer read.
+func (t *tailer) flush(events []logger.Event, partial bool) []logger.Event {
+	if partial && len(t.partial) != 0 {
+		t.partial = append(t.partial, '\n')
+		t.addLines(&events)
+	}
+	if len(t.lines) != 0 {
+		events = append(events, t.parse()...)
+	}
+	return events
+}
+
+// rotated returns true if the file at path is no longer the file being read.
+// If the file is truncated it's read again from the start.
+func (t *tailer) rotated() (bool, error) {
+	if t.f == nil {
+		return false, nil
+	}
+
+	info, err := os.Stat(t.path)
+	if os.IsNotExist(err) {
+		// Renamed, but the new file isn't created yet.
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	current, err := t.f.Stat()
+	if err != nil {
+		return false, err
+	}
+	if !os.SameFile(info, current) {
+		return true, nil
+	}
+
+	offset, err := t.f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return false, err
+	}
+	if info.Size() < offset {
+		t.partial = t.partial[:0]
+		_, err := t.f.Seek(0, io.SeekStart)
+		return false, err
+	}
+	return false, nil
+}
+
+func (t *tailer) close() {
+	if t.f != nil {
+		t.f.Close()
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+// Package alert provides an EventWriter that doesn't store events, but
+// triggers incidents in PagerDuty or Opsgenie for them. By default only Fatal
+// events trigger an incident, but repeated Error events can trigger one as
+// well, see Config. Use it next to another EventWriter that stores the events.
+package alert
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+	"github.com/Thomasdezeeuw/logger/internal/util"
+)
+
+// Provider is the incident management service used to trigger incidents.
+type Provider uint8
+
+const (
+	// PagerDuty uses the PagerDuty Events API v2, Config.Key must be the
+	// integration (routing) key.
+	PagerDuty Provider = iota
+
+	// Opsgenie uses the Opsgenie Alert API, Config.Key must be an API key.
+	Opsgenie
+)
+
+// Defaults used if the Config fields are not set.
+const (
+	DefaultPagerDutyEndpoint = "https://events.pagerduty.com/v2/enqueue"
+	DefaultOpsgenieEndpoint  = "https://api.opsgenie.com/v2/alerts"
+	DefaultSource            = "logger"
+	DefaultErrorWindow       = 5 * time.Minute
+	DefaultTimeout           = 10 * time.Second
+)
+
+// Config is the configuration for the EventWriter created by NewEventWriter.
+type Config struct {
+	// Provider to trigger incidents in, defaults to PagerDuty.
+	Provider Provider
+
+	// Key is the routing key (PagerDuty) or API key (Opsgenie).
+	Key string
+
+	// Endpoint of the API, defaults to DefaultPagerDutyEndpoint or
+	// DefaultOpsgenieEndpoint depending on the Provider.
+	Endpoint string
+
+	// Source of the incident, e.g. the hostname or application name.
+	// Defaults to DefaultSource.
+	Source string
+
+	// ErrorThreshold is the number of Error events with the same
+	// deduplication key, within ErrorWindow, that trigger an incident. If
+	// zero Error events never trigger an incident.
+	ErrorThreshold int
+
+	// ErrorWindow is the window in which ErrorThreshold events must occur,
+	// defaults to DefaultErrorWindow.
+	ErrorWindow time.Duration
+
+	// Timeout of a single request, defaults to DefaultTimeout.
+	Timeout time.Duration
+}
+
+// DedupKey returns the deduplication key for an event, derived from the
+// message and tags of the event. Events with the same deduplication key are
+// grouped into a single incident.
+func DedupKey(event logger.Event) string {
+	h := sha256.New()
+	io.WriteString(h, event.Message)
+	for _, tag := range event.Tags {
+		h.Write([]byte{0})
+		io.WriteString(h, tag)
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
+
+// errorCount is the number of Error events seen for a deduplication key,
+// since start.
+type errorCount struct {
+	start time.Time
+	n     int
+}
+
+type eventWriter struct {
+	config       Config
+	client       *http.Client
+	errors       map[string]*errorCount
+	errorHandler func(error)
+}
+
+func (ew *eventWriter) Write(event logger.Event) error {
+	key := DedupKey(event)
+	switch event.Type {
+	case logger.FatalEvent:
+	case logger.ErrorEvent:
+		// Write is never called concurrently, so we don't need to lock the map.
+		if ew.config.ErrorThreshold == 0 || !ew.countError(key, event.Timestamp) {
+			return nil
+		}
+	default:
+		return nil
+	}
+
+	body, err := ew.request(key, event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", ew.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if ew.config.Provider == Opsgenie {
+		req.Header.Set("Authorization", "GenieKey "+ew.config.Key)
+	}
+
+	resp, err := ew.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		respBody, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("alert: unexpected response: %s: %s", resp.Status, respBody)
+	}
+	delete(ew.errors, key)
+	return nil
+}
+
+// CountError counts an Error event and returns true if the threshold is
+// reached. The count is reset by Write once the incident is triggered.
+func (ew *eventWriter) countError(key string, t time.Time) bool {
+	// Remove expired counts, so the map doesn't keep growing.
+	for k, c := range ew.errors {
+		if t.Sub(c.start) > ew.config.ErrorWindow {
+			delete(ew.errors, k)
+		}
+	}
+
+	c, ok := ew.errors[key]
+	if !ok {
+		c = &errorCount{start: t}
+		ew.errors[key] = c
+	}
+	c.n++
+	return c.n >= ew.config.ErrorThreshold
+}
+
+// Request creates the request body for the provider.
+func (ew *eventWriter) request(key string, event logger.Event) ([]byte, error) {
+	details := map[string]string{}
+	if len(event.Tags) != 0 {
+		details["tags"] = event.Tags.String()
+	}
+	if event.Data != nil {
+		details["data"] = util.InterfaceToString(event.Data)
+	}
+
+	var request interface{}
+	switch ew.config.Provider {
+	case Opsgenie:
+		message := event.Message
+		// Opsgenie limits the message to 130 characters.
+		if len(message) > 130 {
+			message = message[:127] + "..."
+		}
+		priority := "P3"
+		if event.Type == logger.FatalEvent {
+			priority = "P1"
+		}
+		request = map[string]interface{}{
+			"message":     message,
+			"alias":       key,
+			"description": event.Message,
+			"tags":        event.Tags,
+			"priority":    priority,
+			"source":      ew.config.Source,
+			"details":     details,
+		}
+	default:
+		severity := "error"
+		if event.Type == logger.FatalEvent {
+			severity = "critical"
+		}
+		request = map[string]interface{}{
+			"routing_key":  ew.config.Key,
+			"event_action": "trigger",
+			"dedup_key":    key,
+			"payload": map[string]interface{}{
+				"summary":        event.Message,
+				"source":         ew.config.Source,
+				"severity":       severity,
+				"timestamp":      event.Timestamp.Format(time.RFC3339Nano),
+				"custom_details": details,
+			},
+		}
+	}
+	return json.Marshal(request)
+}
+
+func (ew *eventWriter) HandleError(err error) {
+	ew.errorHandler(err)
+}
+
+func (ew *eventWriter) Close() error {
+	return nil
+}
+
+// NewEventWriter creates a new EventWriter that triggers an incident for every
+// Fatal event and, optionally, repeated Error events, see Config. Incidents
+// are deduplicated using DedupKey.
+func NewEventWriter(config Config, errorHandler func(error)) logger.EventWriter {
+	if config.Endpoint == "" {
+		config.Endpoint = DefaultPagerDutyEndpoint
+		if config.Provider == Opsgenie {
+			config.Endpoint = DefaultOpsgenieEndpoint
+		}
+	}
+	if config.Source == "" {
+		config.Source = DefaultSource
+	}
+	if config.ErrorWindow == 0 {
+		config.ErrorWindow = DefaultErrorWindow
+	}
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+
+	return &eventWriter{
+		config:       config,
+		client:       &http.Client{Timeout: config.Timeout},
+		errors:       map[string]*errorCount{},
+		errorHandler: errorHandler,
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package alert
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+var t1 = time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC)
+
+func TestDedupKey(t *testing.T) {
+	event1 := logger.Event{Type: logger.ErrorEvent, Timestamp: t1, Tags: logger.Tags{"tag1"}, Message: "msg"}
+	event2 := logger.Event{Type: logger.FatalEvent, Tags: logger.Tags{"tag1"}, Message: "msg"}
+	event3 := logger.Event{Type: logger.FatalEvent, Tags: logger.Tags{"tag1", "tag2"}, Message: "msg"}
+	event4 := logger.Event{Type: logger.FatalEvent, Tags: logger.Tags{"tag1"}, Message: "msg2"}
+
+	key := DedupKey(event1)
+	if len(key) != 32 {
+		t.Fatalf("Expected the key to be 32 characters, but got %q", key)
+	} else if got := DedupKey(event2); got != key {
+		t.Fatalf("Expected the key to be %q, but got %q", key, got)
+	} else if got := DedupKey(event3); got == key {
+		t.Fatalf("Expected different tags to create a different key, but got %q", got)
+	} else if got := DedupKey(event4); got == key {
+		t.Fatalf("Expected a different message to create a different key, but got %q", got)
+	}
+}
+
+type request struct {
+	authorization string
+	body          map[string]interface{}
+}
+
+func newServer(t *testing.T, requests chan<- request, status *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var req map[string]interface{}
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Error("Unexpected error decoding request: " + err.Error())
+		}
+		w.WriteHeader(*status)
+		requests <- request{r.Header.Get("Authorization"), req}
+	}))
+}
+
+func TestEventWriterPagerDuty(t *testing.T) {
+	requests := make(chan request, 10)
+	status := http.StatusAccepted
+	server := newServer(t, requests, &status)
+	defer server.Close()
+
+	ew := NewEventWriter(Config{Key: "key", Endpoint: server.URL, Source: "host"}, func(error) {})
+	events := []logger.Event{
+		{Type: logger.InfoEvent, Timestamp: t1, Message: "Info message"},
+		{Type: logger.ErrorEvent, Timestamp: t1, Message: "Error message"},
+		{Type: logger.FatalEvent, Timestamp: t1, Tags: logger.Tags{"tag1"},
+			Message: "Fatal message", Data: "stack"},
+	}
+	for _, event := range events {
+		if err := ew.Write(event); err != nil {
+			t.Fatal("Unexpected error writing event: " + err.Error())
+		}
+	}
+
+	req := <-requests
+	if len(requests) != 0 {
+		t.Fatalf("Expected a single request, but got %d", len(requests)+1)
+	}
+	got, _ := json.Marshal(req.body)
+	expected := `{"dedup_key":"` + DedupKey(events[2]) + `","event_action":"trigger",` +
+		`"payload":{"custom_details":{"data":"stack","tags":"tag1"},"severity":"critical",` +
+		`"source":"host","summary":"Fatal message","timestamp":"2015-09-01T14:22:36Z"},` +
+		`"routing_key":"key"}`
+	if string(got) != expected {
+		t.Fatalf("Expected request:\n%s\nBut got:\n%s", expected, got)
+	}
+
+	status = http.StatusBadRequest
+	if err := ew.Write(events[2]); err == nil {
+		t.Fatal("Expected an error writing event, but got none")
+	}
+}
+
+func TestEventWriterOpsgenie(t *testing.T) {
+	requests := make(chan request, 10)
+	status := http.StatusAccepted
+	server := newServer(t, requests, &status)
+	defer server.Close()
+
+	config := Config{
+		Provider:       Opsgenie,
+		Key:            "key",
+		Endpoint:       server.URL,
+		ErrorThreshold: 2,
+		ErrorWindow:    time.Minute,
+	}
+	ew := NewEventWriter(config, func(error) {})
+
+	event := logger.Event{Type: logger.ErrorEvent, Timestamp: t1, Tags: logger.Tags{"tag1"}, Message: "Error message"}
+	for _, ts := range []time.Time{t1, t1.Add(2 * time.Minute)} {
+		event.Timestamp = ts
+		if err := ew.Write(event); err != nil {
+			t.Fatal("Unexpected error writing event: " + err.Error())
+		}
+	}
+	if len(requests) != 0 {
+		t.Fatal("Expected errors outside of the window to not trigger an incident")
+	}
+
+	// Failed request should be retried, i.e. not reset the count.
+	status = http.StatusInternalServerError
+	event.Timestamp = t1.Add(2*time.Minute + time.Second)
+	if err := ew.Write(event); err == nil {
+		t.Fatal("Expected an error writing event, but got none")
+	}
+	<-requests
+
+	status = http.StatusAccepted
+	if err := ew.Write(event); err != nil {
+		t.Fatal("Unexpected error writing event: " + err.Error())
+	}
+	req := <-requests
+	if req.authorization != "GenieKey key" {
+		t.Fatalf("Expected authorization %q, but got %q", "GenieKey key", req.authorization)
+	}
+	got, _ := json.Marshal(req.body)
+	expected := `{"alias":"` + DedupKey(event) + `","description":"Error message",` +
+		`"details":{"tags":"tag1"},"message":"Error message","priority":"P3",` +
+		`"source":"logger","tags":["tag1"]}`
+	if string(got) != expected {
+		t.Fatalf("Expected request:\n%s\nBut got:\n%s", expected, got)
+	}
+
+	// Count should be reset after the incident is triggered.
+	if err := ew.Write(event); err != nil {
+		t.Fatal("Unexpected error writing event: " + err.Error())
+	} else if len(requests) != 0 {
+		t.Fatal("Expected the error count to be reset")
+	}
+}
@@ -0,0 +1,456 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+// Package amqp provides an EventWriter that publishes events to an AMQP
+// exchange, e.g. in RabbitMQ. It implements the required parts of AMQP 0-9-1,
+// see https://www.rabbitmq.com/amqp-0-9-1-reference.html.
+package amqp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+// Defaults used if the Config fields are not set.
+const (
+	DefaultUsername    = "guest"
+	DefaultPassword    = "guest"
+	DefaultVirtualHost = "/"
+	DefaultTimeout     = 5 * time.Second
+)
+
+// Config is the configuration for the EventWriter created by NewEventWriter.
+type Config struct {
+	// Addr is the TCP address of the AMQP broker.
+	Addr string
+
+	// Username and Password are used to authenticate with the broker, default
+	// to DefaultUsername and DefaultPassword.
+	Username string
+	Password string
+
+	// VirtualHost to connect to, defaults to DefaultVirtualHost.
+	VirtualHost string
+
+	// Exchange the events are published to, defaults to the default exchange
+	// (an empty string).
+	Exchange string
+
+	// RoutingKey returns the routing key for an event, defaults to
+	// TypeRoutingKey.
+	RoutingKey func(logger.Event) string
+
+	// Confirm enables publisher confirms, each write waits until the broker
+	// confirms the event. If the broker rejects the event ErrNack is returned,
+	// which is passed to the error handler.
+	Confirm bool
+
+	// Persistent marks the events as persistent, so they survive a broker
+	// restart (if the queue is durable).
+	Persistent bool
+
+	// Timeout for connecting, writing and waiting for confirmations, defaults
+	// to DefaultTimeout.
+	Timeout time.Duration
+}
+
+// TypeRoutingKey uses the EventType, in lowercase, as routing key, e.g.
+// "error".
+func TypeRoutingKey(event logger.Event) string {
+	return strings.ToLower(event.Type.String())
+}
+
+// TagsRoutingKey uses the tags of the event joined by dots as routing key,
+// e.g. "tag1.tag2". This allows topic exchanges to route on the tags.
+func TagsRoutingKey(event logger.Event) string {
+	return strings.Join(event.Tags, ".")
+}
+
+// ErrNack is returned if the broker rejects an event, only used if publisher
+// confirms are enabled.
+var ErrNack = errors.New("amqp: event rejected by broker")
+
+// ErrUnexpectedFrame is returned if the broker returns an unexpected frame.
+var ErrUnexpectedFrame = errors.New("amqp: unexpected frame")
+
+// Frame types and the end of frame marker.
+const (
+	frameMethod    = 1
+	frameHeader    = 2
+	frameBody      = 3
+	frameHeartbeat = 8
+	frameEnd       = 0xce
+)
+
+// Class and method ids, combined into a single uint32.
+const (
+	connectionStart    = 10<<16 | 10
+	connectionStartOk  = 10<<16 | 11
+	connectionTune     = 10<<16 | 30
+	connectionTuneOk   = 10<<16 | 31
+	connectionOpen     = 10<<16 | 40
+	connectionOpenOk   = 10<<16 | 41
+	connectionClose    = 10<<16 | 50
+	connectionCloseOk  = 10<<16 | 51
+	channelOpen        = 20<<16 | 10
+	channelOpenOk      = 20<<16 | 11
+	basicPublish       = 60<<16 | 40
+	basicAck           = 60<<16 | 80
+	basicNack          = 60<<16 | 120
+	confirmSelect      = 85<<16 | 10
+	confirmSelectOk    = 85<<16 | 11
+	basicClass         = 60
+	minFrameSize       = 4096
+	maxFrameSize       = 131072
+	frameOverhead      = 8
+	propContentType    = 0x8000
+	propDeliveryMode   = 0x1000
+	persistentDelivery = 2
+)
+
+var protocolHeader = []byte("AMQP\x00\x00\x09\x01")
+
+type eventWriter struct {
+	config       Config
+	conn         net.Conn
+	r            *bufio.Reader
+	frameMax     uint32
+	deliveryTag  uint64
+	buf          []byte
+	errorHandler func(error)
+	minType      logger.EventType
+}
+
+func (ew *eventWriter) Write(event logger.Event) error {
+	if event.Type < ew.minType {
+		return nil
+	}
+
+	if ew.conn == nil {
+		if err := ew.connect(); err != nil {
+			return err
+		}
+	}
+
+	err := ew.publish(event)
+	if err != nil && err != ErrNack {
+		// A nack only rejects the event, any other error is an I/O or
+		// protocol error, so reconnect on the next write.
+		ew.conn.Close()
+		ew.conn = nil
+	}
+	return err
+}
+
+func (ew *eventWriter) connect() error {
+	conn, err := net.DialTimeout("tcp", ew.config.Addr, ew.config.Timeout)
+	if err != nil {
+		return err
+	}
+	ew.conn = conn
+	ew.r = bufio.NewReader(conn)
+	ew.deliveryTag = 0
+
+	if err := ew.handshake(); err != nil {
+		conn.Close()
+		ew.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (ew *eventWriter) handshake() error {
+	ew.conn.SetDeadline(time.Now().Add(ew.config.Timeout))
+	if _, err := ew.conn.Write(protocolHeader); err != nil {
+		return err
+	} else if _, err := ew.expectMethod(connectionStart); err != nil {
+		return err
+	}
+
+	var args []byte
+	args = appendUint32(args, 0) // No client properties.
+	args = appendShortString(args, "PLAIN")
+	args = appendLongString(args, "\x00"+ew.config.Username+"\x00"+ew.config.Password)
+	args = appendShortString(args, "en_US")
+	if err := ew.writeMethod(0, connectionStartOk, args); err != nil {
+		return err
+	}
+
+	if err := ew.tune(); err != nil {
+		return err
+	}
+
+	args = appendShortString(args[:0], ew.config.VirtualHost)
+	args = append(args, 0, 0) // Reserved.
+	if err := ew.call(0, connectionOpen, args, connectionOpenOk); err != nil {
+		return err
+	} else if err := ew.call(1, channelOpen, []byte{0}, channelOpenOk); err != nil {
+		return err
+	}
+
+	if ew.config.Confirm {
+		return ew.call(1, confirmSelect, []byte{0}, confirmSelectOk)
+	}
+	return nil
+}
+
+// tune negotiates the maximum frame size with the server.
+func (ew *eventWriter) tune() error {
+	tune, err := ew.expectMethod(connectionTune)
+	if err != nil {
+		return err
+	} else if len(tune) < 8 {
+		return ErrUnexpectedFrame
+	}
+	ew.frameMax = binary.BigEndian.Uint32(tune[2:6])
+	if ew.frameMax == 0 || ew.frameMax < minFrameSize {
+		ew.frameMax = minFrameSize
+	} else if ew.frameMax > maxFrameSize {
+		ew.frameMax = maxFrameSize
+	}
+
+	args := append([]byte(nil), tune[0:2]...) // Channel max.
+	args = appendUint32(args, ew.frameMax)
+	args = append(args, 0, 0) // No heartbeats.
+	return ew.writeMethod(0, connectionTuneOk, args)
+}
+
+// call writes the method and reads the expected response method.
+func (ew *eventWriter) call(channel uint16, method uint32, args []byte, expected uint32) error {
+	if err := ew.writeMethod(channel, method, args); err != nil {
+		return err
+	}
+	_, err := ew.expectMethod(expected)
+	return err
+}
+
+func (ew *eventWriter) publish(event logger.Event) error {
+	ew.conn.SetDeadline(time.Now().Add(ew.config.Timeout))
+
+	// Write is never called concurrently, so we can reuse the buffer.
+	body := event.AppendJSON(ew.buf[:0])
+	ew.buf = body
+
+	var args []byte
+	args = append(args, 0, 0) // Reserved.
+	args = appendShortString(args, ew.config.Exchange)
+	args = appendShortString(args, ew.config.RoutingKey(event))
+	args = append(args, 0) // Not mandatory or immediate.
+	if err := ew.writeMethod(1, basicPublish, args); err != nil {
+		return err
+	} else if err := ew.writeContent(body); err != nil {
+		return err
+	}
+
+	if !ew.config.Confirm {
+		return nil
+	}
+	return ew.readConfirm()
+}
+
+// writeContent writes the content header and body frames of a published
+// message.
+func (ew *eventWriter) writeContent(body []byte) error {
+	var header []byte
+	header = append(header, 0, basicClass, 0, 0) // Class id and weight.
+	header = appendUint64(header, uint64(len(body)))
+	if ew.config.Persistent {
+		header = append(header, (propContentType|propDeliveryMode)>>8, 0)
+		header = appendShortString(header, "application/json")
+		header = append(header, persistentDelivery)
+	} else {
+		header = append(header, propContentType>>8, 0)
+		header = appendShortString(header, "application/json")
+	}
+	if err := ew.writeFrame(frameHeader, 1, header); err != nil {
+		return err
+	}
+
+	maxBody := int(ew.frameMax) - frameOverhead
+	for len(body) > 0 {
+		n := len(body)
+		if n > maxBody {
+			n = maxBody
+		}
+		if err := ew.writeFrame(frameBody, 1, body[:n]); err != nil {
+			return err
+		}
+		body = body[n:]
+	}
+	return nil
+}
+
+// readConfirm reads the publisher confirm of the last published message.
+func (ew *eventWriter) readConfirm() error {
+	ew.deliveryTag++
+	method, args, err := ew.readMethod()
+	if err != nil {
+		return err
+	}
+	switch {
+	case len(args) < 8 || binary.BigEndian.Uint64(args) != ew.deliveryTag:
+		return ErrUnexpectedFrame
+	case method == basicAck:
+		return nil
+	case method == basicNack:
+		return ErrNack
+	}
+	return ErrUnexpectedFrame
+}
+
+func (ew *eventWriter) writeMethod(channel uint16, method uint32, args []byte) error {
+	payload := appendUint32(make([]byte, 0, 4+len(args)), method)
+	return ew.writeFrame(frameMethod, channel, append(payload, args...))
+}
+
+func (ew *eventWriter) writeFrame(frameType byte, channel uint16, payload []byte) error {
+	frame := make([]byte, 0, len(payload)+frameOverhead)
+	frame = append(frame, frameType, byte(channel>>8), byte(channel))
+	frame = appendUint32(frame, uint32(len(payload)))
+	frame = append(frame, payload...)
+	frame = append(frame, frameEnd)
+	_, err := ew.conn.Write(frame)
+	return err
+}
+
+// ReadMethod reads the next method frame, ignoring heartbeats. It returns the
+// method and its arguments.
+func (ew *eventWriter) readMethod() (uint32, []byte, error) {
+	for {
+		var header [7]byte
+		if _, err := io.ReadFull(ew.r, header[:]); err != nil {
+			return 0, nil, err
+		}
+
+		// Before the frame size is negotiated, see tune, it's limited to
+		// maxFrameSize.
+		frameMax := ew.frameMax
+		if frameMax == 0 {
+			frameMax = maxFrameSize
+		}
+		size := binary.BigEndian.Uint32(header[3:])
+		if size > frameMax {
+			return 0, nil, ErrUnexpectedFrame
+		}
+		payload := make([]byte, size+1)
+		if _, err := io.ReadFull(ew.r, payload); err != nil {
+			return 0, nil, err
+		} else if payload[size] != frameEnd {
+			return 0, nil, ErrUnexpectedFrame
+		}
+		payload = payload[:size]
+
+		switch header[0] {
+		case frameHeartbeat:
+			continue
+		case frameMethod:
+			if len(payload) < 4 {
+				return 0, nil, ErrUnexpectedFrame
+			}
+			return binary.BigEndian.Uint32(payload), payload[4:], nil
+		}
+		return 0, nil, ErrUnexpectedFrame
+	}
+}
+
+func (ew *eventWriter) expectMethod(expected uint32) ([]byte, error) {
+	method, args, err := ew.readMethod()
+	if err != nil {
+		return nil, err
+	} else if method == connectionClose {
+		return nil, closeError(args)
+	} else if method != expected {
+		return nil, ErrUnexpectedFrame
+	}
+	return args, nil
+}
+
+// CloseError converts the arguments of a Connection.Close method into an
+// error.
+func closeError(args []byte) error {
+	if len(args) < 3 || len(args) < 3+int(args[2]) {
+		return ErrUnexpectedFrame
+	}
+	code := binary.BigEndian.Uint16(args)
+	text := string(args[3 : 3+int(args[2])])
+	return fmt.Errorf("amqp: connection closed by broker: %d %s", code, text)
+}
+
+func (ew *eventWriter) HandleError(err error) {
+	ew.errorHandler(err)
+}
+
+func (ew *eventWriter) Close() error {
+	if ew.conn == nil {
+		return nil
+	}
+
+	ew.conn.SetDeadline(time.Now().Add(ew.config.Timeout))
+	var args []byte
+	args = append(args, 0x00, 200) // Reply code.
+	args = appendShortString(args, "")
+	args = append(args, 0, 0, 0, 0) // Class and method id.
+	if err := ew.writeMethod(0, connectionClose, args); err == nil {
+		ew.expectMethod(connectionCloseOk)
+	}
+	return ew.conn.Close()
+}
+
+func appendShortString(buf []byte, str string) []byte {
+	if len(str) > 255 {
+		str = str[:255]
+	}
+	buf = append(buf, byte(len(str)))
+	return append(buf, str...)
+}
+
+func appendLongString(buf []byte, str string) []byte {
+	buf = appendUint32(buf, uint32(len(str)))
+	return append(buf, str...)
+}
+
+func appendUint32(buf []byte, n uint32) []byte {
+	return append(buf, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
+
+func appendUint64(buf []byte, n uint64) []byte {
+	return appendUint32(appendUint32(buf, uint32(n>>32)), uint32(n))
+}
+
+// NewEventWriter creates a new EventWriter that publishes events as JSON, see
+// Event.MarshalJSON, to an AMQP exchange. If the connection fails it will
+// reconnect on the next write. MinType is the minimal EventType an event must
+// have to be logged. For example if minType is InfoEvent, then any events with
+// an EventType of DebugEvent will not be logged.
+func NewEventWriter(minType logger.EventType, config Config, errorHandler func(error)) (logger.EventWriter, error) {
+	if config.Username == "" {
+		config.Username = DefaultUsername
+	}
+	if config.Password == "" {
+		config.Password = DefaultPassword
+	}
+	if config.VirtualHost == "" {
+		config.VirtualHost = DefaultVirtualHost
+	}
+	if config.RoutingKey == nil {
+		config.RoutingKey = TypeRoutingKey
+	}
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+
+	ew := &eventWriter{config: config, errorHandler: errorHandler, minType: minType}
+	if err := ew.connect(); err != nil {
+		return nil, err
+	}
+	return ew, nil
+}
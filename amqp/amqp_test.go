@@ -0,0 +1,226 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package amqp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+var t1 = time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC)
+
+type published struct {
+	exchange, routingKey string
+	persistent           bool
+	body                 []byte
+}
+
+type testBroker struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func (b *testBroker) readFrame() (byte, []byte) {
+	var header [7]byte
+	if _, err := io.ReadFull(b.r, header[:]); err != nil {
+		b.t.Errorf("Unexpected error reading frame: %s", err)
+		return 0, nil
+	}
+	payload := make([]byte, binary.BigEndian.Uint32(header[3:])+1)
+	if _, err := io.ReadFull(b.r, payload); err != nil {
+		b.t.Errorf("Unexpected error reading frame: %s", err)
+		return 0, nil
+	}
+	return header[0], payload[:len(payload)-1]
+}
+
+func (b *testBroker) expectMethod(expected uint32) []byte {
+	frameType, payload := b.readFrame()
+	if frameType != frameMethod || len(payload) < 4 || binary.BigEndian.Uint32(payload) != expected {
+		b.t.Errorf("Expected method %x, but got frame %d: % x", expected, frameType, payload)
+		return nil
+	}
+	return payload[4:]
+}
+
+func (b *testBroker) writeMethod(channel uint16, method uint32, args []byte) {
+	payload := append(appendUint32(nil, method), args...)
+	frame := []byte{frameMethod, byte(channel >> 8), byte(channel)}
+	frame = appendUint32(frame, uint32(len(payload)))
+	frame = append(frame, payload...)
+	b.conn.Write(append(frame, frameEnd))
+}
+
+// handshake does the handshake of the connection and channel, returning false
+// if it fails.
+func (b *testBroker) handshake() bool {
+	header := make([]byte, len(protocolHeader))
+	if _, err := io.ReadFull(b.r, header); err != nil || !bytes.Equal(header, protocolHeader) {
+		b.t.Errorf("Unexpected protocol header: % x", header)
+		return false
+	}
+
+	b.writeMethod(0, connectionStart, []byte{0, 9, 0, 0, 0, 0, 0, 0, 0, 5, 'P', 'L', 'A', 'I', 'N', 0, 0, 0, 5, 'e', 'n', '_', 'U', 'S'})
+	if args := b.expectMethod(connectionStartOk); !bytes.Contains(args, []byte("\x00user\x00pass")) {
+		b.t.Errorf("Unexpected Connection.Start-Ok arguments: % x", args)
+		return false
+	}
+	b.writeMethod(0, connectionTune, []byte{0, 0, 0, 0, 0x10, 0, 0, 0})
+	b.expectMethod(connectionTuneOk)
+	if args := b.expectMethod(connectionOpen); !bytes.HasPrefix(args, []byte("\x05vhost")) {
+		b.t.Errorf("Unexpected Connection.Open arguments: % x", args)
+		return false
+	}
+	b.writeMethod(0, connectionOpenOk, []byte{0})
+	b.expectMethod(channelOpen)
+	b.writeMethod(1, channelOpenOk, []byte{0, 0, 0, 0})
+	b.expectMethod(confirmSelect)
+	b.writeMethod(1, confirmSelectOk, nil)
+	return true
+}
+
+// readPublished reads the content of the Basic.Publish method payload.
+func (b *testBroker) readPublished(payload []byte) published {
+	args := payload[6:]
+	exchange := string(args[1 : 1+args[0]])
+	args = args[1+args[0]:]
+	event := published{exchange: exchange, routingKey: string(args[1 : 1+args[0]])}
+
+	_, header := b.readFrame()
+	size := binary.BigEndian.Uint64(header[4:])
+	event.persistent = header[12]&(propDeliveryMode>>8) != 0
+	for uint64(len(event.body)) < size {
+		_, body := b.readFrame()
+		event.body = append(event.body, body...)
+	}
+	return event
+}
+
+// Broker accepts a single connection and responds as an AMQP broker would.
+// It acknowledges the first published event and rejects all others.
+func broker(t *testing.T, l net.Listener) <-chan published {
+	events := make(chan published, 4)
+	go func() {
+		defer close(events)
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		b := &testBroker{t, conn, bufio.NewReader(conn)}
+		if !b.handshake() {
+			return
+		}
+
+		for tag := uint64(1); ; tag++ {
+			frameType, payload := b.readFrame()
+			if frameType != frameMethod {
+				return
+			} else if method := binary.BigEndian.Uint32(payload); method == connectionClose {
+				b.writeMethod(0, connectionCloseOk, nil)
+				return
+			}
+
+			events <- b.readPublished(payload)
+
+			if tag == 1 {
+				b.writeMethod(1, basicAck, append(appendUint64(nil, tag), 0))
+			} else {
+				b.writeMethod(1, basicNack, append(appendUint64(nil, tag), 0))
+			}
+		}
+	}()
+	return events
+}
+
+func TestEventWriter(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Unexpected error listening: " + err.Error())
+	}
+	defer l.Close()
+	events := broker(t, l)
+
+	config := Config{Addr: l.Addr().String(), Username: "user", Password: "pass",
+		VirtualHost: "vhost", Exchange: "logs", Confirm: true, Persistent: true}
+	ew, err := NewEventWriter(logger.InfoEvent, config, func(error) {})
+	if err != nil {
+		t.Fatal("Unexpected error creating EventWriter: " + err.Error())
+	}
+	defer func() {
+		if err := ew.Close(); err != nil {
+			t.Fatal("Unexpected error closing EventWriter: " + err.Error())
+		}
+		// Wait for the broker to stop.
+		for range events {
+		}
+	}()
+
+	// Larger then a single frame.
+	msg := strings.Repeat("a", 5000)
+	event := logger.Event{Type: logger.ErrorEvent, Timestamp: t1, Tags: logger.Tags{"tag"}, Message: msg}
+	expected, _ := event.MarshalJSON()
+
+	ew.Write(logger.Event{Type: logger.DebugEvent, Message: "Never gets logged"})
+	if err := ew.Write(event); err != nil {
+		t.Fatal("Unexpected error writing event: " + err.Error())
+	}
+	checkPublished(t, <-events, expected)
+
+	// The broker only accepts a single connection, so a rejected event must
+	// not close the connection.
+	for i := 0; i < 2; i++ {
+		if err := ew.Write(event); err != ErrNack {
+			t.Fatalf("Expected ErrNack, but got %v", err)
+		}
+		<-events
+	}
+}
+
+func TestReadMethodFrameSize(t *testing.T) {
+	tests := []struct {
+		frameMax uint32
+		size     uint32
+	}{
+		{0, 0xFFFFFFFF},
+		{0, maxFrameSize + 1},
+		{minFrameSize, minFrameSize + 1},
+	}
+
+	for _, test := range tests {
+		frame := appendUint32([]byte{frameMethod, 0, 0}, test.size)
+		ew := eventWriter{r: bufio.NewReader(bytes.NewReader(frame)), frameMax: test.frameMax}
+		if _, _, err := ew.readMethod(); err != ErrUnexpectedFrame {
+			t.Fatalf("Expected ErrUnexpectedFrame for a frame of %d bytes with frame max %d, but got %v",
+				test.size, test.frameMax, err)
+		}
+	}
+}
+
+func checkPublished(t *testing.T, got published, expected []byte) {
+	if got.exchange != "logs" || got.routingKey != "error" || !got.persistent {
+		t.Fatalf("Unexpected published event: %s, %s, %t", got.exchange, got.routingKey, got.persistent)
+	} else if !bytes.Equal(got.body, expected) {
+		t.Fatalf("Expected body %s, but got %s", expected, got.body)
+	}
+}
+
+func TestRoutingKeys(t *testing.T) {
+	event := logger.Event{Type: logger.WarnEvent, Tags: logger.Tags{"tag1", "tag2"}}
+	if got := TypeRoutingKey(event); got != "warn" {
+		t.Fatalf("Expected TypeRoutingKey to return warn, but got %s", got)
+	} else if got := TagsRoutingKey(event); got != "tag1.tag2" {
+		t.Fatalf("Expected TagsRoutingKey to return tag1.tag2, but got %s", got)
+	}
+}
@@ -0,0 +1,196 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// RateDetector detects anomalies in the rate of events, see
+// SetAnomalyDetection.
+type RateDetector interface {
+	// Observe is called with the number of events in an interval, it returns
+	// the expected number of events and true if the number is an anomaly.
+	Observe(count float64) (baseline float64, anomaly bool)
+}
+
+// EWMADetector is a RateDetector that uses an exponentially weighted moving
+// average of the previous intervals as baseline. A count is an anomaly if it's
+// Factor times higher, or lower, than the baseline. The zero value is usable,
+// the zero fields are set to their default.
+type EWMADetector struct {
+	// Alpha is the weight of the last interval in the baseline, between 0
+	// and 1, defaults to 0.3.
+	Alpha float64
+
+	// Factor is how many times the count must deviate from the baseline to be
+	// an anomaly, defaults to 4.
+	Factor float64
+
+	// MinBaseline is the minimum baseline used in the detection, so a few
+	// events after a quiet period aren't an anomaly, but also no events
+	// after a quiet period, defaults to 1.
+	MinBaseline float64
+
+	// Warmup is the number of intervals observed before anomalies are
+	// detected, defaults to 5.
+	Warmup int
+
+	baseline float64
+	n        int
+}
+
+// Observe implements the RateDetector interface.
+func (d *EWMADetector) Observe(count float64) (float64, bool) {
+	if d.Alpha == 0 {
+		d.Alpha = 0.3
+	}
+	if d.Factor == 0 {
+		d.Factor = 4
+	}
+	if d.MinBaseline == 0 {
+		d.MinBaseline = 1
+	}
+	if d.Warmup == 0 {
+		d.Warmup = 5
+	}
+
+	baseline := d.baseline
+	if d.n == 0 {
+		baseline = count
+	}
+	d.baseline = d.Alpha*count + (1-d.Alpha)*baseline
+	d.n++
+	if d.n <= d.Warmup {
+		return baseline, false
+	}
+
+	// Storms, e.g. many Error events, and sudden silences.
+	min := baseline
+	if min < d.MinBaseline {
+		min = d.MinBaseline
+	}
+	anomaly := count > min*d.Factor || (baseline >= d.MinBaseline && count < baseline/d.Factor)
+	return baseline, anomaly
+}
+
+// AnomalyDetection is the configuration of the rate anomaly detection, see
+// SetAnomalyDetection.
+type AnomalyDetection struct {
+	// Interval in which the events are counted, e.g. a minute.
+	Interval time.Duration
+
+	// Key returns the key under which the event is counted, e.g. the type of
+	// the event and a tag, or an empty string to not count the event. The
+	// number of keys should be small. Defaults to the name of the EventType.
+	Key func(Event) string
+
+	// NewDetector returns a RateDetector for a new key, defaults to an
+	// EWMADetector with the default settings.
+	NewDetector func() RateDetector
+}
+
+// AnomalyTag is added to the warning events of the anomaly detection, see
+// SetAnomalyDetection.
+const AnomalyTag = "anomaly"
+
+var anomalyDetection AnomalyDetection
+
+// Stubbed for testing.
+var newAnomalyTicker = func(d time.Duration) (<-chan time.Time, func()) {
+	ticker := time.NewTicker(d)
+	return ticker.C, ticker.Stop
+}
+
+// SetAnomalyDetection enables the detection of anomalies in the rate of
+// events. Every interval the number of events per key is passed to the
+// RateDetector of the key, if it detects an anomaly a Warn event is logged,
+// with the tags "logger" and AnomalyTag. This catches both error storms and
+// sudden silences, e.g. when no more requests are logged. For example to
+// detect anomalies in the number of events per type, per minute:
+//
+//	logger.SetAnomalyDetection(logger.AnomalyDetection{Interval: time.Minute})
+//
+// An Interval of zero disables the detection.
+//
+// Note: this must be called before Start and is not safe for concurrent use.
+func SetAnomalyDetection(detection AnomalyDetection) {
+	if detection.Key == nil {
+		detection.Key = func(event Event) string { return event.Type.String() }
+	}
+	if detection.NewDetector == nil {
+		detection.NewDetector = func() RateDetector { return &EWMADetector{} }
+	}
+	anomalyDetection = detection
+}
+
+// anomalyMonitor counts the events per key, it's only used in the goroutine
+// that fans out the events.
+type anomalyMonitor struct {
+	detection AnomalyDetection
+	counts    map[string]float64
+	detectors map[string]RateDetector
+}
+
+func newAnomalyMonitor(detection AnomalyDetection) *anomalyMonitor {
+	if detection.Interval <= 0 {
+		return nil
+	}
+	return &anomalyMonitor{
+		detection: detection,
+		counts:    make(map[string]float64),
+		detectors: make(map[string]RateDetector),
+	}
+}
+
+// ticker returns the channel on which the intervals end, or nil if the
+// detection is disabled.
+func (m *anomalyMonitor) ticker() (<-chan time.Time, func()) {
+	if m == nil {
+		return nil, func() {}
+	}
+	return newAnomalyTicker(m.detection.Interval)
+}
+
+// count counts the event.
+func (m *anomalyMonitor) count(event Event) {
+	if m == nil {
+		return
+	}
+	if key := m.detection.Key(event); key != "" {
+		m.counts[key]++
+	}
+}
+
+// tick ends the current interval, it returns the warning events for the
+// detected anomalies, sorted by key.
+func (m *anomalyMonitor) tick(t time.Time) []Event {
+	for key := range m.counts {
+		if _, ok := m.detectors[key]; !ok {
+			m.detectors[key] = m.detection.NewDetector()
+		}
+	}
+
+	keys := make([]string, 0, len(m.detectors))
+	for key := range m.detectors {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var events []Event
+	for _, key := range keys {
+		count := m.counts[key]
+		baseline, anomaly := m.detectors[key].Observe(count)
+		if anomaly {
+			msg := fmt.Sprintf("Anomalous rate of %s events: %.0f in %s, expected %.1f",
+				key, count, m.detection.Interval, baseline)
+			events = append(events, Event{WarnEvent, t, Tags{"logger", AnomalyTag}, msg, nil, 0})
+		}
+		delete(m.counts, key)
+	}
+	return events
+}
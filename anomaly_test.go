@@ -0,0 +1,112 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestEWMADetector(t *testing.T) {
+	var d EWMADetector
+	for i := 0; i < 10; i++ {
+		if _, anomaly := d.Observe(10); anomaly {
+			t.Fatalf("Unexpected anomaly in interval %d", i)
+		}
+	}
+
+	tests := []struct {
+		count   float64
+		anomaly bool
+	}{
+		{12, false},
+		{100, true}, // Storm.
+		{10, false},
+		{0, true}, // Silence.
+	}
+
+	for _, test := range tests {
+		d := d // Same baseline.
+		baseline, anomaly := d.Observe(test.count)
+		if baseline != 10 {
+			t.Errorf("Expected the baseline to be 10, but got %f", baseline)
+		}
+		if anomaly != test.anomaly {
+			t.Errorf("Expected anomaly for count %f to be %t, but got %t", test.count, test.anomaly, anomaly)
+		}
+	}
+}
+
+func TestEWMADetectorQuiet(t *testing.T) {
+	d := EWMADetector{Warmup: 1}
+	d.Observe(0)
+	if _, anomaly := d.Observe(0); anomaly {
+		t.Fatal("Unexpected anomaly for no events after no events")
+	} else if _, anomaly := d.Observe(3); anomaly {
+		t.Fatal("Unexpected anomaly for a few events after no events")
+	} else if _, anomaly := d.Observe(10); !anomaly {
+		t.Fatal("Expected an anomaly for a storm after no events")
+	}
+}
+
+func TestEWMADetectorWarmup(t *testing.T) {
+	d := EWMADetector{Warmup: 2}
+	if _, anomaly := d.Observe(1); anomaly {
+		t.Fatal("Unexpected anomaly during the warmup")
+	} else if _, anomaly := d.Observe(100); anomaly {
+		t.Fatal("Unexpected anomaly during the warmup")
+	}
+}
+
+// alwaysAnomaly is a RateDetector that reports every count as anomaly.
+type alwaysAnomaly struct{}
+
+func (alwaysAnomaly) Observe(count float64) (float64, bool) { return 1, true }
+
+func TestAnomalyDetection(t *testing.T) {
+	defer reset()
+	ticks := make(chan time.Time)
+	oldNewAnomalyTicker := newAnomalyTicker
+	defer func() { newAnomalyTicker = oldNewAnomalyTicker }()
+	newAnomalyTicker = func(time.Duration) (<-chan time.Time, func()) {
+		return ticks, func() {}
+	}
+
+	SetAnomalyDetection(AnomalyDetection{
+		Interval:    time.Minute,
+		NewDetector: func() RateDetector { return alwaysAnomaly{} },
+	})
+	var ew eventWriter
+	Start(&ew)
+
+	Error(nil, errors.New("Error message"))
+	Error(nil, errors.New("Error message"))
+	Info(nil, "Info message")
+	// Make sure the events are dispatched before the interval ends.
+	for len(eventChannel) > 0 {
+		runtime.Gosched()
+	}
+	ticks <- t1
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	expected := []string{
+		"Anomalous rate of Error events: 2 in 1m0s, expected 1.0",
+		"Anomalous rate of Info events: 1 in 1m0s, expected 1.0",
+	}
+	if len(ew.events) != 5 {
+		t.Fatalf("Expected 5 events, but got %d: %v", len(ew.events), ew.events)
+	}
+	for i, msg := range expected {
+		got := ew.events[3+i]
+		if got.Type != WarnEvent || got.Message != msg || !got.Timestamp.Equal(t1) ||
+			len(got.Tags) != 2 || got.Tags[1] != AnomalyTag {
+			t.Errorf("Expected a warning %q, but got %v", msg, got)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+// BatchEventWriter is an extended EventWriter that can batch events. If
+// SupportsBatch returns true Write may buffer the event, rather then writing
+// it directly. The logger then calls Flush once no more events are waiting to
+// be written to the EventWriter, so events are never kept in the buffer while
+// the logger is idle. Errors returned by Flush are passed to the error
+// handler.
+//
+// EventWriters that don't implement this interface, or return false from
+// SupportsBatch, are expected to write each event in Write. Flush is still
+// called on them by the Flush function of the package, see Flusher.
+type BatchEventWriter interface {
+	EventWriter
+	Flusher
+
+	// SupportsBatch returns true if Write buffers events until Flush is
+	// called. It's called once, when the logger is started.
+	SupportsBatch() bool
+}
+
+// batchFlusher returns the Flusher of the EventWriter, if it batches events.
+func batchFlusher(ew EventWriter) Flusher {
+	if bew, ok := ew.(BatchEventWriter); ok && bew.SupportsBatch() {
+		return bew
+	}
+	return nil
+}
+
+// flushBatch flushes a batch of events written to the EventWriter.
+func flushBatch(ew EventWriter, flusher Flusher, state *writerState) {
+	state.startWrite()
+	err := flusher.Flush()
+	state.endWrite()
+	if err != nil {
+		state.setLastError(err)
+		ew.HandleError(err)
+	}
+}
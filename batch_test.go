@@ -0,0 +1,57 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import "testing"
+
+// batchEventWriter is an eventWriter that buffers events until flushed.
+type batchEventWriter struct {
+	eventWriter
+	buf     []Event
+	flushes int
+	batch   bool
+}
+
+func (ew *batchEventWriter) Write(event Event) error {
+	ew.buf = append(ew.buf, event)
+	return nil
+}
+
+func (ew *batchEventWriter) Flush() error {
+	ew.flushes++
+	ew.events = append(ew.events, ew.buf...)
+	ew.buf = ew.buf[:0]
+	return nil
+}
+
+func (ew *batchEventWriter) SupportsBatch() bool {
+	return ew.batch
+}
+
+func TestBatchEventWriter(t *testing.T) {
+	defer reset()
+	bew := batchEventWriter{batch: true}
+	nobatch := batchEventWriter{batch: false}
+	Start(&bew, &nobatch)
+
+	const n = 100
+	for i := 0; i < n; i++ {
+		Info(Tags{"batch"}, "Info message")
+	}
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if len(bew.events) != n || len(bew.buf) != 0 {
+		t.Fatalf("Expected all %d events to be flushed, but got %d", n, len(bew.events))
+	}
+	if bew.flushes == 0 || bew.flushes > n {
+		t.Fatalf("Expected between 1 and %d flushes, but got %d", n, bew.flushes)
+	}
+	if nobatch.flushes != 0 {
+		t.Fatalf("Expected an EventWriter not supporting batches not to be flushed, but got %d flushes",
+			nobatch.flushes)
+	}
+}
@@ -4,7 +4,12 @@
 
 package logger
 
-import "testing"
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
 
 // go test -run none -bench . -benchmem -benchtime 5s -timeout 15m
 
@@ -122,3 +127,107 @@ github.com/Thomasdezeeuw/logger.TestLog(0xc8200a0e10)
 	}
 	benchmarkResultStackTrace = newStackTrace
 }
+
+var benchmarkResultTags Tags
+
+func BenchmarkInternTags(b *testing.B) {
+	b.ReportAllocs()
+	var tags Tags
+	for n := 0; n < b.N; n++ {
+		tags = InternTags("tag1", "tag2", "tag3", "tag4")
+	}
+	benchmarkResultTags = tags
+}
+
+var benchmarkResultEventJSON []byte
+
+func BenchmarkEvent_AppendJSON(b *testing.B) {
+	b.ReportAllocs()
+	event := Event{InfoEvent, t1, tag4, "Message", "data", 0}
+	buf := make([]byte, 0, 512)
+	for n := 0; n < b.N; n++ {
+		buf = event.AppendJSON(buf[:0])
+	}
+	benchmarkResultEventJSON = buf
+}
+
+func BenchmarkJSONEventWriter(b *testing.B) {
+	b.ReportAllocs()
+	ew := NewJSONEventWriter(DebugEvent, ioutil.Discard, func(error) {})
+	event := Event{InfoEvent, t1, tag4, "Message", "data", 0}
+	for n := 0; n < b.N; n++ {
+		ew.Write(event)
+	}
+}
+
+func BenchmarkDebugfDisabled(b *testing.B) {
+	defer Reset()
+	SetMinEventType(InfoEvent)
+	for n := 0; n < b.N; n++ {
+		Debugf(nil, "Debug %s message %d", "formatted", n)
+	}
+}
+
+var benchmarkResultTag string
+
+func BenchmarkTag_Int(b *testing.B) {
+	var tag string
+	for n := 0; n < b.N; n++ {
+		tag = Tag("attempt", n)
+	}
+	benchmarkResultTag = tag
+}
+
+func BenchmarkInt(b *testing.B) {
+	var tag string
+	for n := 0; n < b.N; n++ {
+		tag = Int("attempt", n)
+	}
+	benchmarkResultTag = tag
+}
+
+func BenchmarkConsoleEventWriter(b *testing.B) {
+	b.ReportAllocs()
+	ew := &consoleEventWriter{w: ioutil.Discard, errW: ioutil.Discard}
+	event := Event{InfoEvent, t1, tag4, "Message", "data", 0}
+	for n := 0; n < b.N; n++ {
+		ew.Write(event)
+	}
+}
+
+func BenchmarkFileEventWriter(b *testing.B) {
+	b.ReportAllocs()
+	dir, err := ioutil.TempDir("", "logger-benchmark")
+	if err != nil {
+		b.Fatal("Unexpected error creating temporary directory: " + err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	ew, err := NewFileEventWriter(DebugEvent, filepath.Join(dir, "benchmark.log"))
+	if err != nil {
+		b.Fatal("Unexpected error creating file EventWriter: " + err.Error())
+	}
+	defer ew.Close()
+
+	event := Event{InfoEvent, t1, tag4, "Message", "data", 0}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		ew.Write(event)
+	}
+}
+
+func BenchmarkEncodeEvents(b *testing.B) {
+	b.ReportAllocs()
+	encodings := []Encoding{TextEncoding, JSONEncoding}
+	event := Event{InfoEvent, t1, tag4, "Message", "data", 0}
+	var encoded [numEncodings]*encodedBuffer
+	for n := 0; n < b.N; n++ {
+		encodeEvents(event, encodings, &encoded)
+		for _, buffer := range encoded {
+			if buffer != nil {
+				buffer.refs = 1
+				buffer.release()
+			}
+		}
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import "runtime/debug"
+
+// BuildInfo identifies the build of the binary that produced the logs. It can
+// be added to every event using SetMandatoryTags, or logged once at start up
+// using LogBuildInfo.
+type BuildInfo struct {
+	Version string // E.g. v1.2.3.
+	Commit  string // Version control revision.
+	Date    string // Build date, or date of the commit.
+}
+
+// Stubbed for testing.
+var readBuildInfo = debug.ReadBuildInfo
+
+// ReadBuildInfo returns the BuildInfo as embedded in the binary by the Go
+// toolchain, see runtime/debug.ReadBuildInfo. The version is the version of the
+// main module and the commit and date are the version control revision and
+// time. Missing values are left empty, values set by the user (e.g. using
+// -ldflags "-X main.version=v1.2.3") can be set in the returned BuildInfo.
+func ReadBuildInfo() BuildInfo {
+	var info BuildInfo
+	buildInfo, ok := readBuildInfo()
+	if !ok {
+		return info
+	}
+
+	if buildInfo.Main.Version != "(devel)" {
+		info.Version = buildInfo.Main.Version
+	}
+	for _, setting := range buildInfo.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			info.Commit = setting.Value
+		case "vcs.time":
+			info.Date = setting.Value
+		}
+	}
+	return info
+}
+
+// Tags returns the build info as key=value tags (see Tag), with the keys
+// version, commit and build_date. Empty values are skipped. To add the build
+// info to every event use:
+//
+//	logger.SetMandatoryTags(logger.ReadBuildInfo().Tags()...)
+func (info BuildInfo) Tags() Tags {
+	var tags Tags
+	for _, field := range info.fields() {
+		if field[1] != "" {
+			tags = append(tags, Tag(field[0], field[1]))
+		}
+	}
+	return tags
+}
+
+func (info BuildInfo) fields() [3][2]string {
+	return [3][2]string{
+		{"version", info.Version},
+		{"commit", info.Commit},
+		{"build_date", info.Date},
+	}
+}
+
+// LogBuildInfo logs an Info event, "Build info", with the build info as data
+// (type map[string]string, using the same keys as BuildInfo.Tags). Calling
+// this right after Start gives every log file a banner that identifies the
+// build that produced it.
+func LogBuildInfo(tags Tags, info BuildInfo) {
+	data := make(map[string]string, 3)
+	for _, field := range info.fields() {
+		if field[1] != "" {
+			data[field[0]] = field[1]
+		}
+	}
+	sendEvent(Event{InfoEvent, now(), tags, "Build info", data, 0})
+}
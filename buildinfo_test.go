@@ -0,0 +1,79 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"reflect"
+	"runtime/debug"
+	"testing"
+)
+
+func TestReadBuildInfo(t *testing.T) {
+	oldReadBuildInfo := readBuildInfo
+	defer func() { readBuildInfo = oldReadBuildInfo }()
+
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{
+			Main: debug.Module{Path: "example.com/app", Version: "v1.2.3"},
+			Settings: []debug.BuildSetting{
+				{Key: "vcs", Value: "git"},
+				{Key: "vcs.revision", Value: "abc123"},
+				{Key: "vcs.time", Value: "2015-09-01T14:22:36Z"},
+			},
+		}, true
+	}
+	expected := BuildInfo{"v1.2.3", "abc123", "2015-09-01T14:22:36Z"}
+	if got := ReadBuildInfo(); got != expected {
+		t.Fatalf("Expected build info %v, but got %v", expected, got)
+	}
+
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{Main: debug.Module{Version: "(devel)"}}, true
+	}
+	if got := ReadBuildInfo(); got != (BuildInfo{}) {
+		t.Fatalf("Expected an empty build info, but got %v", got)
+	}
+
+	readBuildInfo = func() (*debug.BuildInfo, bool) { return nil, false }
+	if got := ReadBuildInfo(); got != (BuildInfo{}) {
+		t.Fatalf("Expected an empty build info, but got %v", got)
+	}
+}
+
+func TestBuildInfoTags(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		info     BuildInfo
+		expected Tags
+	}{
+		{BuildInfo{}, nil},
+		{BuildInfo{Version: "v1.2.3"}, Tags{"version=v1.2.3"}},
+		{BuildInfo{"v1.2.3", "abc123", "2015-09-01"},
+			Tags{"version=v1.2.3", "commit=abc123", "build_date=2015-09-01"}},
+	}
+
+	for _, test := range tests {
+		if got := test.info.Tags(); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("Expected tags %v, but got %v", test.expected, got)
+		}
+	}
+}
+
+func TestLogBuildInfo(t *testing.T) {
+	defer reset()
+	var ew eventWriter
+	Start(&ew)
+	LogBuildInfo(Tags{"startup"}, BuildInfo{Version: "v1.2.3", Commit: "abc123"})
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	expected := Event{InfoEvent, ew.events[0].Timestamp, Tags{"startup"}, "Build info",
+		map[string]string{"version": "v1.2.3", "commit": "abc123"}, 1}
+	if got := ew.events[0]; !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected event %v, but got %v", expected, got)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger/internal/cbor"
+)
+
+// CBORFormatter formats events as CBOR (RFC 7049) maps, with the following
+// keys: "schema_version" (see SchemaVersion), "type", "timestamp", "tags",
+// "message" and "data". The timestamp is encoded as a tagged RFC 3339 string
+// (with nanoseconds). Unlike the text and JSON formats, data is not converted
+// into a string but encoded using the CBOR equivalent of its type, e.g. a
+// struct is encoded as a map.
+type CBORFormatter struct{}
+
+// Format appends the CBOR encoded event to buf.
+func (CBORFormatter) Format(buf []byte, event Event) ([]byte, error) {
+	n := 5
+	if event.Data != nil {
+		n++
+	}
+
+	buf = cbor.AppendMapHeader(buf, n)
+	buf = cbor.AppendString(buf, "schema_version")
+	buf = cbor.AppendUint(buf, SchemaVersion)
+	buf = cbor.AppendString(buf, "type")
+	buf = cbor.AppendString(buf, event.Type.String())
+	buf = cbor.AppendString(buf, "timestamp")
+	buf = cbor.AppendTime(buf, event.Timestamp.UTC())
+	buf = cbor.AppendString(buf, "tags")
+	buf = cbor.AppendArrayHeader(buf, len(event.Tags))
+	for _, tag := range event.Tags {
+		buf = cbor.AppendString(buf, tag)
+	}
+	buf = cbor.AppendString(buf, "message")
+	buf = cbor.AppendString(buf, event.Message)
+	if event.Data != nil {
+		buf = cbor.AppendString(buf, "data")
+		buf = cbor.AppendValue(buf, event.Data)
+	}
+	return buf, nil
+}
+
+// errInvalidCBOREvent is returned by Event.UnmarshalCBOR if the CBOR value is
+// not an event.
+var errInvalidCBOREvent = errors.New("logger: invalid CBOR event")
+
+// UnmarshalCBOR converts an event in the CBOR format, as created by
+// CBORFormatter, back into an Event. Newer versions of the format, see
+// SchemaVersion, only add keys, which are ignored.
+//
+// Data is decoded into the generic equivalent of its CBOR type, e.g. a struct
+// is decoded as map[string]interface{}, integers as uint64 or int64 and
+// floating point numbers as float64.
+//
+// Note: custom EventTypes are supported, but must created using NewEventType.
+func (event *Event) UnmarshalCBOR(data []byte) error {
+	value, n, err := cbor.ConsumeValue(data)
+	if err != nil {
+		return err
+	}
+	fields, ok := value.(map[string]interface{})
+	if !ok || n != len(data) {
+		return errInvalidCBOREvent
+	}
+
+	var e Event
+	if typ, ok := fields["type"].(string); !ok {
+		return errInvalidCBOREvent
+	} else if err := e.Type.UnmarshalText([]byte(typ)); err != nil {
+		return err
+	}
+	if e.Timestamp, ok = fields["timestamp"].(time.Time); !ok {
+		return errInvalidCBOREvent
+	}
+	tags, _ := fields["tags"].([]interface{})
+	if len(tags) != 0 {
+		e.Tags = make(Tags, len(tags))
+		for i, tag := range tags {
+			if e.Tags[i], ok = tag.(string); !ok {
+				return errInvalidCBOREvent
+			}
+		}
+	}
+	e.Message, _ = fields["message"].(string)
+	e.Data = fields["data"]
+	*event = e
+	return nil
+}
+
+// NewCBOREventWriter creates a new EventWriter that writes CBOR encoded
+// events, see CBORFormatter, to the given writer. MinType is the minimal
+// EventType an event must have to be logged. For example if minType is
+// InfoEvent, then any events with an EventType of DebugEvent will not be
+// logged.
+func NewCBOREventWriter(minType EventType, w io.Writer, errorHandler func(error)) EventWriter {
+	return NewFormatEventWriter(minType, w, CBORFormatter{}, errorHandler)
+}
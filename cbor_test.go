@@ -0,0 +1,64 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestCBOREventWriter(t *testing.T) {
+	var buf bytes.Buffer
+	ew := NewCBOREventWriter(InfoEvent, &buf, func(error) {})
+
+	event := Event{InfoEvent, now(), Tags{"tag"}, "Msg", user{1, "Thomas"}, 0}
+	if err := ew.Write(event); err != nil {
+		t.Fatal("Unexpected error writing to CBOREventWriter: " + err.Error())
+	}
+
+	// Must not be written.
+	event = Event{DebugEvent, now(), Tags{"tag"}, "Never gets logged", nil, 0}
+	if err := ew.Write(event); err != nil {
+		t.Fatal("Unexpected error writing to CBOREventWriter: " + err.Error())
+	}
+
+	if err := ew.Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	var expected []byte
+	expected = append(expected, 0xa6)
+	expected = append(expected, "\x6eschema_version\x01"...)
+	expected = append(expected, "\x64type\x64Info"...)
+	expected = append(expected, "\x69timestamp\xc0\x742015-09-01T14:22:36Z"...)
+	expected = append(expected, "\x64tags\x81\x63tag"...)
+	expected = append(expected, "\x67message\x63Msg"...)
+	expected = append(expected, "\x64data\xa2\x62ID\x01\x64Name\x66Thomas"...)
+
+	if got := buf.Bytes(); !bytes.Equal(got, expected) {
+		t.Fatalf("Expected buffer to contain:\n% x\nBut got:\n% x", expected, got)
+	}
+}
+
+func TestUnmarshalCBOR(t *testing.T) {
+	event := Event{InfoEvent, now(), Tags{"tag1", "tag2"}, "Msg", user{1, "Thomas"}, 0}
+	data, _ := CBORFormatter{}.Format(nil, event)
+
+	var got Event
+	if err := got.UnmarshalCBOR(data); err != nil {
+		t.Fatal("Unexpected error unmarshaling: " + err.Error())
+	}
+	event.Data = map[string]interface{}{"ID": uint64(1), "Name": "Thomas"}
+	if !reflect.DeepEqual(got, event) {
+		t.Fatalf("Expected %#v, but got %#v", event, got)
+	}
+
+	for _, data := range [][]byte{nil, data[:len(data)-1], {0x01}, {0xa1, 0x64, 't', 'y', 'p', 'e', 0x01}} {
+		if err := got.UnmarshalCBOR(data); err == nil {
+			t.Fatalf("Expected an error unmarshaling % x", data)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+// Command loggercat prints events written by the logger package, from files in
+// the text format or from the live-tail endpoints of the tail package.
+//
+// Usage:
+//
+//	loggercat [flags] source...
+//
+// A source is a file, "-" for standard in, or an http(s) URL of a Server-Sent
+// Events endpoint (see tail.Tail.SSE). Events can be filtered on their type,
+// tags or using a filter expression (see logger.CompileFilter). With -f files
+// are followed, printing events as they're written, like tail -f.
+//
+// With -i loggercat is interactive, reading commands from standard in, one
+// per line:
+//
+//	p          pause or resume the output, events are buffered while paused
+//	l type     only show events with at least the type, e.g. "l Warn"
+//	t [tag]    only show events with the tag, a glob pattern optionally negated
+//	           with '!', e.g. "svc.*" or "!debug", without a tag all events
+//	f [expr]   only show events matching the filter expression
+//	/[text]    only show events containing text, highlighted, "/" to clear
+//	q          quit
+//
+// Output is colorized by event type if standard out is a terminal, see -color.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+func main() {
+	follow := flag.Bool("f", false, "follow the files, printing events as they're written")
+	interactive := flag.Bool("i", false, "read commands from standard in")
+	level := flag.String("level", "Trace", "minimum event type to show")
+	tag := flag.String("tag", "", "only show events with a tag matching this glob pattern, '!' negates")
+	filter := flag.String("filter", "", "only show events matching the filter expression")
+	color := flag.String("color", "auto", "colorize the output: auto, always or never")
+	escaped := flag.Bool("escaped", false, "the files contain escaped events")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: loggercat [flags] source...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 || (*interactive && contains(flag.Args(), "-")) {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	v := newView(os.Stdout, useColor(*color))
+	for _, cmd := range []string{"l " + *level, "t " + *tag, "f " + *filter} {
+		if _, err := v.command(cmd); err != nil {
+			fatalf("%s", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	events := make(chan logger.Event, 1024)
+	for _, source := range flag.Args() {
+		wg.Add(1)
+		go func(source string) {
+			defer wg.Done()
+			if err := read(source, *follow, *escaped, events); err != nil {
+				fmt.Fprintf(os.Stderr, "loggercat: %s: %s\n", source, err)
+			}
+		}(source)
+	}
+	go func() {
+		wg.Wait()
+		close(events)
+	}()
+
+	quit := make(chan struct{})
+	if *interactive {
+		go func() {
+			commands(os.Stdin, v, os.Stderr)
+			close(quit)
+		}()
+	}
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			v.show(event)
+		case <-quit:
+			return
+		}
+	}
+}
+
+// useColor returns whether or not the output should be colorized.
+func useColor(mode string) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	case "auto":
+		if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+			return false
+		}
+		info, err := os.Stdout.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0
+	}
+	fatalf("invalid -color %q", mode)
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "loggercat: "+strings.TrimSuffix(format, "\n")+"\n", args...)
+	os.Exit(2)
+}
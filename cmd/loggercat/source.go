@@ -0,0 +1,124 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+	"github.com/Thomasdezeeuw/logger/agent"
+)
+
+// pollInterval is the interval at which followed files are checked.
+const pollInterval = 250 * time.Millisecond
+
+// read reads the events from the source and sends them to events.
+func read(source string, follow, escaped bool, events chan<- logger.Event) error {
+	switch {
+	case strings.HasPrefix(source, "http://"), strings.HasPrefix(source, "https://"):
+		return readSSE(source, events)
+	case source == "-":
+		return parse(os.Stdin, escaped, events)
+	case follow:
+		return followFile(source, escaped, events)
+	}
+
+	f, err := os.Open(source)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return parse(f, escaped, events)
+}
+
+// parse parses all events in r.
+func parse(r io.Reader, escaped bool, events chan<- logger.Event) error {
+	var parser *logger.Parser
+	if escaped {
+		parser = logger.NewEscapedParser(r, logger.TimestampFormat{})
+	} else {
+		parser = logger.NewParser(r, logger.TimestampFormat{})
+	}
+	for parser.Scan() {
+		events <- parser.Event()
+	}
+	return parser.Err()
+}
+
+// followFile reads the events in the file and keeps following it, it never
+// returns unless the file can't be opened.
+func followFile(path string, escaped bool, events chan<- logger.Event) error {
+	errs := make(chan error, 1)
+	ew := &followEventWriter{events, errs}
+	_, err := agent.Start(agent.Config{
+		Paths:        []string{path},
+		Escaped:      escaped,
+		FromStart:    true,
+		PollInterval: pollInterval,
+	}, ew)
+	if err != nil {
+		return err
+	}
+	for err := range errs {
+		fmt.Fprintf(os.Stderr, "loggercat: %s: %s\n", path, err)
+	}
+	return nil
+}
+
+// followEventWriter passes the events read by the agent to the channel.
+type followEventWriter struct {
+	events chan<- logger.Event
+	errors chan error
+}
+
+func (ew *followEventWriter) Write(event logger.Event) error {
+	ew.events <- event
+	return nil
+}
+
+func (ew *followEventWriter) HandleError(err error) {
+	ew.errors <- err
+}
+
+func (ew *followEventWriter) Close() error {
+	close(ew.errors)
+	return nil
+}
+
+// readSSE reads the events from a Server-Sent Events endpoint, until the
+// server closes the stream.
+func readSSE(url string, events chan<- logger.Event) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected response: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		data := strings.TrimPrefix(scanner.Text(), "data: ")
+		if len(data) == len(scanner.Text()) {
+			continue // Comments, ids and empty lines.
+		}
+		var event logger.Event
+		if err := event.UnmarshalJSON([]byte(data)); err != nil {
+			return err
+		}
+		events <- event
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,199 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+// maxPaused is the maximum number of events buffered while paused, older
+// events are dropped.
+const maxPaused = 10000
+
+// ANSI escape codes.
+const (
+	reset   = "\x1b[0m"
+	reverse = "\x1b[7m"
+)
+
+// colors of the EventTypes, other EventTypes aren't colorized.
+var colors = map[logger.EventType]string{
+	logger.TraceEvent:    "\x1b[90m",
+	logger.DebugEvent:    "\x1b[90m",
+	logger.NoticeEvent:   "\x1b[36m",
+	logger.WarnEvent:     "\x1b[33m",
+	logger.ErrorEvent:    "\x1b[31m",
+	logger.FatalEvent:    "\x1b[1;31m",
+	logger.SecurityEvent: "\x1b[35m",
+}
+
+// view prints the events that match the current filters.
+type view struct {
+	mu      sync.Mutex
+	w       io.Writer
+	color   bool
+	minType logger.EventType
+	tag     string
+	filter  *logger.Filter
+	search  string
+	paused  bool
+	pending []logger.Event
+	dropped int
+	buf     []byte
+}
+
+func newView(w io.Writer, color bool) *view {
+	return &view{w: w, color: color}
+}
+
+// show prints the event, if it matches the filters.
+func (v *view) show(event logger.Event) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.paused {
+		if len(v.pending) == maxPaused {
+			v.pending = v.pending[1:]
+			v.dropped++
+		}
+		v.pending = append(v.pending, event)
+		return
+	}
+	v.print(event)
+}
+
+// print prints the event, if it matches the filters. v.mu must be locked.
+func (v *view) print(event logger.Event) {
+	if !v.match(event) {
+		return
+	}
+
+	line := event.String()
+	v.buf = v.buf[:0]
+	color, ok := colors[event.Type]
+	if ok && v.color {
+		v.buf = append(v.buf, color...)
+	}
+	if v.search != "" && v.color {
+		// Highlight the search matches, restoring the color afterwards.
+		highlight := reverse + v.search + reset + color
+		line = strings.Replace(line, v.search, highlight, -1)
+	}
+	v.buf = append(v.buf, line...)
+	if ok && v.color {
+		v.buf = append(v.buf, reset...)
+	}
+	v.buf = append(v.buf, '\n')
+	v.w.Write(v.buf)
+}
+
+// match returns true if the event matches the filters. v.mu must be locked.
+func (v *view) match(event logger.Event) bool {
+	if event.Type < v.minType {
+		return false
+	}
+	if v.tag != "" && !logger.MatchTags(v.tag, event.Tags) {
+		return false
+	}
+	if v.filter != nil && !v.filter.Match(event) {
+		return false
+	}
+	if v.search != "" && !strings.Contains(event.String(), v.search) {
+		return false
+	}
+	return true
+}
+
+var errQuit = errors.New("quit")
+
+// command executes a single command, see the package documentation. It
+// returns a message to show to the user, if any, and errQuit if the user
+// quits.
+func (v *view) command(cmd string) (string, error) {
+	cmd = strings.TrimSpace(cmd)
+	name, arg := cmd, ""
+	if i := strings.IndexByte(cmd, ' '); i != -1 {
+		name, arg = cmd[:i], strings.TrimSpace(cmd[i+1:])
+	}
+	if strings.HasPrefix(cmd, "/") {
+		name, arg = "/", cmd[1:]
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	switch name {
+	case "":
+		return "", nil
+	case "p":
+		v.paused = !v.paused
+		if v.paused {
+			return "paused", nil
+		}
+		msg := fmt.Sprintf("resumed, %d events while paused", len(v.pending)+v.dropped)
+		if v.dropped != 0 {
+			msg += fmt.Sprintf(", %d dropped", v.dropped)
+		}
+		for _, event := range v.pending {
+			v.print(event)
+		}
+		v.pending, v.dropped = nil, 0
+		return msg, nil
+	case "l":
+		var eventType logger.EventType
+		if err := eventType.UnmarshalText([]byte(arg)); err != nil {
+			return "", err
+		}
+		v.minType = eventType
+		return "showing " + eventType.String() + " and up", nil
+	case "t":
+		v.tag = arg
+		if arg == "" {
+			return "showing all tags", nil
+		}
+		return "showing tag " + arg, nil
+	case "f":
+		if arg == "" {
+			v.filter = nil
+			return "filter cleared", nil
+		}
+		filter, err := logger.CompileFilter(arg)
+		if err != nil {
+			return "", err
+		}
+		v.filter = filter
+		return "filter " + filter.String(), nil
+	case "/":
+		v.search = arg
+		if arg == "" {
+			return "search cleared", nil
+		}
+		return "searching " + arg, nil
+	case "q":
+		return "", errQuit
+	}
+	return "", fmt.Errorf("unknown command %q, use p, l, t, f, / or q", name)
+}
+
+// commands reads commands from r, writing messages to w, until the user quits
+// or r is empty.
+func commands(r io.Reader, v *view, w io.Writer) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		msg, err := v.command(scanner.Text())
+		if err == errQuit {
+			return
+		} else if err != nil {
+			fmt.Fprintf(w, "loggercat: %s\n", err)
+		} else if msg != "" {
+			fmt.Fprintf(w, "loggercat: %s\n", msg)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+var t1 = time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC)
+
+var testEvents = []logger.Event{
+	{Type: logger.DebugEvent, Timestamp: t1, Tags: logger.Tags{"db"}, Message: "Debug message"},
+	{Type: logger.InfoEvent, Timestamp: t1, Tags: logger.Tags{"http"}, Message: "Info message"},
+	{Type: logger.ErrorEvent, Timestamp: t1, Tags: logger.Tags{"http"}, Message: "Error message"},
+}
+
+func TestViewCommands(t *testing.T) {
+	tests := []struct {
+		commands []string
+		expected string
+	}{
+		{nil, "2015-09-01 14:22:36 [Debug] db: Debug message\n" +
+			"2015-09-01 14:22:36 [Info] http: Info message\n" +
+			"2015-09-01 14:22:36 [Error] http: Error message\n"},
+		{[]string{"l Info"}, "2015-09-01 14:22:36 [Info] http: Info message\n" +
+			"2015-09-01 14:22:36 [Error] http: Error message\n"},
+		{[]string{"t db"}, "2015-09-01 14:22:36 [Debug] db: Debug message\n"},
+		{[]string{"t h*"}, "2015-09-01 14:22:36 [Info] http: Info message\n" +
+			"2015-09-01 14:22:36 [Error] http: Error message\n"},
+		{[]string{"t !http"}, "2015-09-01 14:22:36 [Debug] db: Debug message\n"},
+		{[]string{"t db", "t"}, "2015-09-01 14:22:36 [Debug] db: Debug message\n" +
+			"2015-09-01 14:22:36 [Info] http: Info message\n" +
+			"2015-09-01 14:22:36 [Error] http: Error message\n"},
+		{[]string{`f tags has "http" && type > Info`}, "2015-09-01 14:22:36 [Error] http: Error message\n"},
+		{[]string{"/Info"}, "2015-09-01 14:22:36 [Info] http: Info message\n"},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		v := newView(&buf, false)
+		for _, cmd := range test.commands {
+			if _, err := v.command(cmd); err != nil {
+				t.Fatalf("Unexpected error executing %q: %s", cmd, err)
+			}
+		}
+		for _, event := range testEvents {
+			v.show(event)
+		}
+		if got := buf.String(); got != test.expected {
+			t.Errorf("Expected output for commands %q to be:\n%s\nbut got:\n%s", test.commands, test.expected, got)
+		}
+	}
+}
+
+func TestViewPause(t *testing.T) {
+	var buf bytes.Buffer
+	v := newView(&buf, false)
+	if msg, _ := v.command("p"); msg != "paused" {
+		t.Fatalf("Expected to be paused, but got %q", msg)
+	}
+	for _, event := range testEvents {
+		v.show(event)
+	}
+	if buf.Len() != 0 {
+		t.Fatalf("Expected no output while paused, but got %q", buf.String())
+	}
+
+	if msg, _ := v.command("p"); msg != "resumed, 3 events while paused" {
+		t.Fatalf("Unexpected message resuming: %q", msg)
+	}
+	if got := strings.Count(buf.String(), "\n"); got != 3 {
+		t.Fatalf("Expected the 3 buffered events to be printed, but got %q", buf.String())
+	}
+}
+
+func TestViewColor(t *testing.T) {
+	var buf bytes.Buffer
+	v := newView(&buf, true)
+	v.command("/Error")
+	v.show(testEvents[2])
+
+	expected := "\x1b[31m2015-09-01 14:22:36 [\x1b[7mError\x1b[0m\x1b[31m] http: \x1b[7mError\x1b[0m\x1b[31m message\x1b[0m\n"
+	if got := buf.String(); got != expected {
+		t.Fatalf("Expected %q, but got %q", expected, got)
+	}
+}
+
+func TestViewCommandErrors(t *testing.T) {
+	v := newView(nil, false)
+	for _, cmd := range []string{"l Unknown", "f type ==", "x"} {
+		if _, err := v.command(cmd); err == nil {
+			t.Errorf("Expected an error for command %q", cmd)
+		}
+	}
+	if _, err := v.command("q"); err != errQuit {
+		t.Fatalf("Expected errQuit, but got %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+// Command ringdump dumps the events in a ring buffer file, written by the
+// EventWriter from the mmapring package, to standard out. This allows the most
+// recent events to be recovered after the process that wrote them was killed.
+//
+// Usage:
+//
+//	ringdump file...
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/Thomasdezeeuw/logger/mmapring"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "Usage: ringdump file...")
+		os.Exit(2)
+	}
+
+	for _, path := range os.Args[1:] {
+		if err := mmapring.Dump(path, os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "ringdump: %s: %s\n", path, err)
+			os.Exit(1)
+		}
+	}
+}
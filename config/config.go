@@ -0,0 +1,302 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+// Package config configures the EventWriters of the logger package using a JSON
+// configuration file. For example:
+//
+//	{
+//		"writers": [
+//			{"name": "console", "type": "console", "min_level": "Warn"},
+//			{
+//				"name": "file",
+//				"type": "file",
+//				"min_level": "Info",
+//				"path": "/var/log/app.log",
+//				"retention": {"max_age": "168h"}
+//			}
+//		],
+//		"routes": [
+//			{"match": "type == Security", "writers": ["file"], "final": true},
+//			{"writers": ["console", "file"]}
+//		]
+//	}
+//
+// The EventWriter created by New writes to all the configured EventWriters and
+// can be reconfigured while the logger is running, see EventWriter.Apply and
+// EventWriter.Watch.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+// The supported types of EventWriters.
+const (
+	ConsoleType = "console"
+	FileType    = "file"
+	TagFileType = "tagfile"
+)
+
+// Config is the configuration of the logger package.
+type Config struct {
+	Writers []Writer `json:"writers"`
+
+	// Routes determine to which writers an event is written. If no routes are
+	// configured all events are written to all writers. Otherwise events are
+	// written to the writers of all matching routes, in order, until a
+	// matching final route. Events that match no route are dropped.
+	Routes []Route `json:"routes"`
+}
+
+// Route routes matching events to writers.
+type Route struct {
+	// Match is the filter expression events must match, see
+	// logger.CompileFilter. If empty all events match.
+	Match string `json:"match"`
+
+	// Writers are the names of the writers the events are written to.
+	Writers []string `json:"writers"`
+
+	// Final stops the routing of matching events, the routes after it are not
+	// considered.
+	Final bool `json:"final"`
+
+	filter  *logger.Filter
+	writers []int // Indices into Config.Writers.
+}
+
+// Writer is the configuration of a single EventWriter.
+type Writer struct {
+	// Name is the unique name of the EventWriter, defaults to the type.
+	Name string `json:"name"`
+
+	// Type is the type of EventWriter, one of ConsoleType, FileType or
+	// TagFileType.
+	Type string `json:"type"`
+
+	// MinLevel is the minimum EventType an event must have to be written,
+	// defaults to TraceEvent, i.e. all events are written.
+	MinLevel logger.EventType `json:"min_level"`
+
+	// Path is the path to the file, for FileType, or the directory, for
+	// TagFileType.
+	Path string `json:"path"`
+
+	// Key is the tag key used to route events into files, only for TagFileType.
+	Key string `json:"key"`
+
+	// Escape enables escaping, see logger.WithEscaping.
+	Escape bool `json:"escape"`
+
+	// Retention of the log files, only for FileType, see logger.WithRetention.
+	Retention *Retention `json:"retention"`
+}
+
+// Retention is the configuration of logger.Retention.
+type Retention struct {
+	MaxBytes int64    `json:"max_bytes"`
+	MaxAge   Duration `json:"max_age"`
+	Pattern  string   `json:"pattern"`
+}
+
+// Duration is a time.Duration that is formatted as a string in JSON, e.g.
+// "1h30m", see time.ParseDuration.
+type Duration time.Duration
+
+// UnmarshalText parses the duration using time.ParseDuration.
+func (d *Duration) UnmarshalText(text []byte) error {
+	duration, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(duration)
+	return nil
+}
+
+// MarshalText formats the duration using time.Duration.String.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Load loads the configuration from the file at path, see Parse.
+func Load(path string) (*Config, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// Parse parses and validates the JSON configuration. Unknown fields are
+// returned as error.
+func Parse(r io.Reader) (*Config, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	var cfg Config
+	if err := decoder.Decode(&cfg); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// Validate validates the configuration and sets the defaults.
+func (cfg *Config) Validate() error {
+	if len(cfg.Writers) == 0 {
+		return errors.New("config: no writers configured")
+	}
+
+	names := make(map[string]bool, len(cfg.Writers))
+	for i := range cfg.Writers {
+		w := &cfg.Writers[i]
+		if w.Name == "" {
+			w.Name = w.Type
+		}
+		if names[w.Name] {
+			return fmt.Errorf("config: duplicate writer name %q", w.Name)
+		}
+		names[w.Name] = true
+
+		if err := w.validate(); err != nil {
+			return fmt.Errorf("config: writer %q: %s", w.Name, err.Error())
+		}
+	}
+
+	for i := range cfg.Routes {
+		if err := cfg.Routes[i].validate(cfg.Writers); err != nil {
+			return fmt.Errorf("config: route %d: %s", i+1, err.Error())
+		}
+	}
+	return nil
+}
+
+// validate validates the options of the writer's type.
+func (w *Writer) validate() error {
+	switch w.Type {
+	case ConsoleType:
+	case FileType, TagFileType:
+		if w.Path == "" {
+			return errors.New("missing path")
+		}
+	default:
+		return fmt.Errorf("unknown type %q", w.Type)
+	}
+
+	if w.Type == TagFileType && w.Key == "" {
+		return errors.New("missing key")
+	} else if w.Type != TagFileType && w.Key != "" {
+		return fmt.Errorf("key is only supported by the %s type", TagFileType)
+	} else if w.Type != FileType && w.Retention != nil {
+		return fmt.Errorf("retention is only supported by the %s type", FileType)
+	}
+	return nil
+}
+
+// validate validates the route and compiles the filter.
+func (route *Route) validate(writers []Writer) error {
+	if len(route.Writers) == 0 {
+		return errors.New("no writers")
+	}
+
+	route.filter = nil
+	if route.Match != "" {
+		filter, err := logger.CompileFilter(route.Match)
+		if err != nil {
+			return err
+		}
+		route.filter = filter
+	}
+
+	route.writers = route.writers[:0]
+outer:
+	for _, name := range route.Writers {
+		for i, w := range writers {
+			if w.Name == name {
+				route.writers = append(route.writers, i)
+				continue outer
+			}
+		}
+		return fmt.Errorf("unknown writer %q", name)
+	}
+	return nil
+}
+
+// EventWriters creates the configured EventWriters, in order. If any of the
+// EventWriters can't be created, the already created EventWriters are closed.
+func (cfg *Config) EventWriters() ([]logger.EventWriter, error) {
+	ews := make([]logger.EventWriter, 0, len(cfg.Writers))
+	for _, w := range cfg.Writers {
+		ew, err := w.eventWriter()
+		if err != nil {
+			closeAll(ews)
+			return nil, fmt.Errorf("config: writer %q: %s", w.Name, err.Error())
+		}
+		ews = append(ews, ew)
+	}
+	return ews, nil
+}
+
+func (w Writer) eventWriter() (logger.EventWriter, error) {
+	var ew logger.EventWriter
+	switch w.Type {
+	case ConsoleType:
+		ew = logger.NewConsoleEventWriter(w.MinLevel)
+	case FileType:
+		var err error
+		if ew, err = logger.NewFileEventWriter(w.MinLevel, w.Path); err != nil {
+			return nil, err
+		}
+		if w.Retention != nil {
+			_, err = logger.WithRetention(ew, logger.Retention{
+				MaxBytes: w.Retention.MaxBytes,
+				MaxAge:   time.Duration(w.Retention.MaxAge),
+				Pattern:  w.Retention.Pattern,
+			})
+			if err != nil {
+				ew.Close()
+				return nil, err
+			}
+		}
+	case TagFileType:
+		var err error
+		errorHandler := func(err error) {
+			fmt.Fprintf(os.Stderr, "config: writer %q: %s\n", w.Name, err.Error())
+		}
+		ew, err = logger.NewTagFileEventWriter(w.MinLevel, w.Path, w.Key, logger.DefaultMaxOpenFiles, errorHandler)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown type %q", w.Type)
+	}
+
+	if w.Escape {
+		if _, err := logger.WithEscaping(ew); err != nil {
+			ew.Close()
+			return nil, err
+		}
+	}
+	return ew, nil
+}
+
+// closeAll closes all EventWriters, returning the first error.
+func closeAll(ews []logger.EventWriter) error {
+	var err error
+	for _, ew := range ews {
+		if er := ew.Close(); er != nil && err == nil {
+			err = er
+		}
+	}
+	return err
+}
@@ -0,0 +1,133 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+func TestParse(t *testing.T) {
+	const input = `{
+		"writers": [
+			{"type": "console", "min_level": "Warn"},
+			{
+				"name": "app",
+				"type": "file",
+				"min_level": "Info",
+				"path": "app.log",
+				"escape": true,
+				"retention": {"max_bytes": 1024, "max_age": "24h"}
+			}
+		]
+	}`
+
+	cfg, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal("Unexpected error parsing config: " + err.Error())
+	}
+
+	expected := []Writer{
+		{Name: "console", Type: ConsoleType, MinLevel: logger.WarnEvent},
+		{Name: "app", Type: FileType, MinLevel: logger.InfoEvent, Path: "app.log",
+			Escape: true, Retention: &Retention{MaxBytes: 1024, MaxAge: Duration(24 * time.Hour)}},
+	}
+	if len(cfg.Writers) != len(expected) {
+		t.Fatalf("Expected %d writers, but got %d", len(expected), len(cfg.Writers))
+	}
+	for i, got := range cfg.Writers {
+		if !reflect.DeepEqual(got, expected[i]) {
+			t.Errorf("Expected writer %d to be %#v, but got %#v", i, expected[i], got)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"writers": []}`, "config: no writers configured"},
+		{`{"writers": [{"type": "console"}, {"type": "console"}]}`, `config: duplicate writer name "console"`},
+		{`{"writers": [{"type": "unknown"}]}`, `config: writer "unknown": unknown type "unknown"`},
+		{`{"writers": [{"type": "file"}]}`, `config: writer "file": missing path`},
+		{`{"writers": [{"type": "tagfile", "path": "logs"}]}`, `config: writer "tagfile": missing key`},
+		{`{"writers": [{"type": "console", "key": "tenant"}]}`, `config: writer "console": key is only supported by the tagfile type`},
+		{`{"writers": [{"type": "console", "retention": {}}]}`, `config: writer "console": retention is only supported by the file type`},
+		{`{"writers": [{"type": "console", "min_level": "Unknown"}]}`, `logger: unknown EventType: "Unknown"`},
+		{`{"writers": [{"type": "console", "color": true}]}`, `json: unknown field "color"`},
+		{`{"writers": [{"type": "console"}], "routes": [{"match": "type == Info"}]}`, "config: route 1: no writers"},
+		{`{"writers": [{"type": "console"}], "routes": [{"writers": ["file"]}]}`, `config: route 1: unknown writer "file"`},
+		{`{"writers": [{"type": "console"}], "routes": [{"match": "type ==", "writers": ["console"]}]}`,
+			"config: route 1: logger: invalid filter at offset 7: expected a value, but got end of expression"},
+	}
+
+	for _, test := range tests {
+		_, err := Parse(strings.NewReader(test.input))
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("Expected parsing %s to return error %q, but got %v", test.input, test.expected, err)
+		}
+	}
+}
+
+func TestEventWriters(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	cfg := &Config{Writers: []Writer{
+		{Type: FileType, MinLevel: logger.InfoEvent, Path: filepath.Join(dir, "app.log")},
+		{Type: TagFileType, Path: filepath.Join(dir, "tenants"), Key: "tenant"},
+	}}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal("Unexpected error validating config: " + err.Error())
+	}
+
+	ews, err := cfg.EventWriters()
+	if err != nil {
+		t.Fatal("Unexpected error creating EventWriters: " + err.Error())
+	} else if len(ews) != 2 {
+		t.Fatalf("Expected 2 EventWriters, but got %d", len(ews))
+	}
+	if err := closeAll(ews); err != nil {
+		t.Fatal("Unexpected error closing EventWriters: " + err.Error())
+	}
+
+	// Already created EventWriters are closed on error.
+	cfg.Writers = append(cfg.Writers, Writer{Name: "bad", Type: FileType,
+		Path: filepath.Join(dir, "missing", "app.log")})
+	if _, err := cfg.EventWriters(); err == nil || !strings.HasPrefix(err.Error(), `config: writer "bad": `) {
+		t.Fatalf("Expected an error creating the bad EventWriter, but got %v", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(path, []byte(`{"writers": [{"type": "console"}]}`), 0600); err != nil {
+		t.Fatal("Unexpected error writing config: " + err.Error())
+	}
+
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatal("Unexpected error loading config: " + err.Error())
+	} else if len(cfg.Writers) != 1 || cfg.Writers[0].Type != ConsoleType {
+		t.Fatalf("Unexpected config: %#v", cfg)
+	}
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "logger_config")
+	if err != nil {
+		t.Fatal("Unexpected error creating temporary directory: " + err.Error())
+	}
+	return dir
+}
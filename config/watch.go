@@ -0,0 +1,100 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"os"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+// DefaultWatchInterval is the interval used by Watch if no interval is given.
+const DefaultWatchInterval = 5 * time.Second
+
+var watchTags = logger.Tags{"logger", "config"}
+
+// Watch watches the configuration file at path, checking it every interval
+// (DefaultWatchInterval if zero) for changes in the modification time or size.
+// Once changed the configuration is loaded and applied, see Apply, which allows
+// changing the levels, adding or removing writers and the retention while the
+// application is running.
+//
+// Both successful reloads, as Info event, and failures, as Error event, are
+// logged with the tags "logger" and "config". After a failure the current
+// configuration remains in use.
+//
+// The returned function stops watching the file, it must be called before the
+// logger is closed.
+func (ew *EventWriter) Watch(path string, interval time.Duration) (stop func()) {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+
+	// Only changes after Watch is called are applied.
+	w := &watcher{ew: ew, path: path}
+	if info, err := os.Stat(path); err == nil {
+		w.modTime, w.size = info.ModTime(), info.Size()
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				w.check()
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// watcher holds the state of Watch.
+type watcher struct {
+	ew         *EventWriter
+	path       string
+	modTime    time.Time
+	size       int64
+	statFailed bool
+}
+
+// check applies the configuration if the file changed.
+func (w *watcher) check() {
+	// Only log the first of consecutive failures.
+	info, err := os.Stat(w.path)
+	if err != nil {
+		if !w.statFailed {
+			w.statFailed = true
+			logger.Error(watchTags, err)
+		}
+		return
+	}
+	w.statFailed = false
+	if info.ModTime().Equal(w.modTime) && info.Size() == w.size {
+		return
+	}
+	w.modTime, w.size = info.ModTime(), info.Size()
+
+	cfg, err := Load(w.path)
+	if err == nil {
+		err = w.ew.Apply(cfg)
+	}
+	if err != nil {
+		logger.Error(watchTags, err)
+		return
+	}
+	logger.Info(watchTags, "Reloaded configuration from "+w.path)
+}
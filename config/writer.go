@@ -0,0 +1,165 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"sync"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+// EventWriter is an EventWriter that writes to the EventWriters in the
+// configuration, according to the routes. The configuration can be changed
+// while the logger is running, using Apply or Watch.
+//
+// Write never returns an error, errors returned by the configured EventWriters
+// are passed to their own HandleError method.
+type EventWriter struct {
+	mu      sync.Mutex
+	cfg     *Config
+	writers []logger.EventWriter
+	// Writers selected by the routes, reused for every event.
+	selected []bool
+}
+
+// New creates a new EventWriter using the configuration.
+func New(cfg *Config) (*EventWriter, error) {
+	ew := &EventWriter{}
+	if err := ew.Apply(cfg); err != nil {
+		return nil, err
+	}
+	return ew, nil
+}
+
+// Open creates a new EventWriter using the configuration file at path, see
+// Load.
+func Open(path string) (*EventWriter, error) {
+	cfg, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return New(cfg)
+}
+
+// Write writes the event to the configured EventWriters selected by the
+// routes.
+func (ew *EventWriter) Write(event logger.Event) error {
+	ew.mu.Lock()
+	defer ew.mu.Unlock()
+
+	routes := ew.cfg.Routes
+	if len(routes) != 0 {
+		ew.selectWriters(routes, event)
+	}
+
+	for i, w := range ew.writers {
+		if len(routes) != 0 && !ew.selected[i] {
+			continue
+		}
+		if err := w.Write(event); err != nil {
+			w.HandleError(err)
+		}
+	}
+	return nil
+}
+
+// selectWriters sets the writers selected by the routes for the event in
+// ew.selected, ew.mu must be held.
+func (ew *EventWriter) selectWriters(routes []Route, event logger.Event) {
+	for i := range ew.selected {
+		ew.selected[i] = false
+	}
+	for _, route := range routes {
+		if route.filter != nil && !route.filter.Match(event) {
+			continue
+		}
+		for _, i := range route.writers {
+			ew.selected[i] = true
+		}
+		if route.Final {
+			return
+		}
+	}
+}
+
+// HandleError passes the error to all configured EventWriters.
+func (ew *EventWriter) HandleError(err error) {
+	ew.mu.Lock()
+	defer ew.mu.Unlock()
+	for _, w := range ew.writers {
+		w.HandleError(err)
+	}
+}
+
+// Flush flushes all configured EventWriters that implement logger.Flusher.
+func (ew *EventWriter) Flush() error {
+	ew.mu.Lock()
+	defer ew.mu.Unlock()
+	var err error
+	for _, w := range ew.writers {
+		if flusher, ok := w.(logger.Flusher); ok {
+			if er := flusher.Flush(); er != nil && err == nil {
+				err = er
+			}
+		}
+	}
+	return err
+}
+
+// Rotate rotates all configured EventWriters that implement logger.Rotator.
+func (ew *EventWriter) Rotate() error {
+	ew.mu.Lock()
+	defer ew.mu.Unlock()
+	var err error
+	for _, w := range ew.writers {
+		if rotator, ok := w.(logger.Rotator); ok {
+			if er := rotator.Rotate(); er != nil && err == nil {
+				err = er
+			}
+		}
+	}
+	return err
+}
+
+// Close closes all configured EventWriters.
+func (ew *EventWriter) Close() error {
+	ew.mu.Lock()
+	writers := ew.writers
+	ew.writers = nil
+	ew.mu.Unlock()
+	return closeAll(writers)
+}
+
+// Config returns the current configuration.
+func (ew *EventWriter) Config() *Config {
+	ew.mu.Lock()
+	defer ew.mu.Unlock()
+	return ew.cfg
+}
+
+// Apply validates the configuration and creates the configured EventWriters.
+// If that succeeds the EventWriters atomically replace the current ones, which
+// are closed after the swap. If the configuration is invalid, or any of the
+// EventWriters can't be created, the error is returned and the current
+// EventWriters remain in use, so a bad configuration never breaks logging.
+//
+// Apply is safe to call while the logger is running.
+func (ew *EventWriter) Apply(cfg *Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	writers, err := cfg.EventWriters()
+	if err != nil {
+		return err
+	}
+
+	ew.mu.Lock()
+	old := ew.writers
+	ew.cfg = cfg
+	ew.writers = writers
+	ew.selected = make([]bool, len(writers))
+	ew.mu.Unlock()
+	return closeAll(old)
+}
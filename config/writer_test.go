@@ -0,0 +1,173 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+func TestEventWriterApply(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path1, path2 := filepath.Join(dir, "1.log"), filepath.Join(dir, "2.log")
+
+	ew, err := New(&Config{Writers: []Writer{{Type: FileType, Path: path1}}})
+	if err != nil {
+		t.Fatal("Unexpected error creating EventWriter: " + err.Error())
+	}
+
+	event := logger.Event{Type: logger.InfoEvent, Message: "Info message"}
+	ew.Write(event)
+	if err := ew.Apply(&Config{Writers: []Writer{{Type: FileType, Path: path2}}}); err != nil {
+		t.Fatal("Unexpected error applying config: " + err.Error())
+	}
+
+	// A bad configuration is not applied.
+	if err := ew.Apply(&Config{}); err == nil {
+		t.Fatal("Expected an error applying an invalid config")
+	} else if ew.Config().Writers[0].Path != path2 {
+		t.Fatalf("Expected the config to remain unchanged, but got %#v", ew.Config())
+	}
+
+	ew.Write(event)
+	if err := ew.Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	for _, path := range []string{path1, path2} {
+		if got := readFile(t, path); strings.Count(got, "Info message") != 1 {
+			t.Errorf("Expected %s to contain the event once, but got %q", path, got)
+		}
+	}
+}
+
+func TestEventWriterRoutes(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := func(name string) string { return filepath.Join(dir, name+".log") }
+
+	input := `{
+		"writers": [
+			{"name": "app", "type": "file", "path": "` + path("app") + `"},
+			{"name": "audit", "type": "file", "path": "` + path("audit") + `"},
+			{"name": "errors", "type": "file", "path": "` + path("errors") + `"}
+		],
+		"routes": [
+			{"match": "type == Security", "writers": ["audit"], "final": true},
+			{"match": "type >= Error", "writers": ["errors"]},
+			{"match": "!(tags has \"debug\")", "writers": ["app"]}
+		]
+	}`
+	cfg, err := Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal("Unexpected error parsing config: " + err.Error())
+	}
+	ew, err := New(cfg)
+	if err != nil {
+		t.Fatal("Unexpected error creating EventWriter: " + err.Error())
+	}
+
+	ew.Write(logger.Event{Type: logger.SecurityEvent, Message: "Security message"})
+	ew.Write(logger.Event{Type: logger.ErrorEvent, Message: "Error message"})
+	ew.Write(logger.Event{Type: logger.InfoEvent, Message: "Info message"})
+	ew.Write(logger.Event{Type: logger.InfoEvent, Tags: logger.Tags{"debug"}, Message: "Dropped message"})
+	if err := ew.Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	expected := map[string][]string{
+		"app":    {"Error message", "Info message"},
+		"audit":  {"Security message"},
+		"errors": {"Error message"},
+	}
+	for name, messages := range expected {
+		got := readFile(t, path(name))
+		if lines := strings.Count(got, "\n"); lines != len(messages) {
+			t.Errorf("Expected %d events in %s, but got %q", len(messages), name, got)
+		}
+		for _, msg := range messages {
+			if !strings.Contains(got, msg) {
+				t.Errorf("Expected %s to contain %q, but got %q", name, msg, got)
+			}
+		}
+	}
+}
+
+func TestEventWriterWatch(t *testing.T) {
+	logger.Reset()
+	defer logger.Reset()
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path1, path2 := filepath.Join(dir, "1.log"), filepath.Join(dir, "2.log")
+	configPath := filepath.Join(dir, "config.json")
+	writeConfig(t, configPath, `{"writers": [{"type": "file", "path": "`+path1+`"}]}`)
+
+	ew, err := Open(configPath)
+	if err != nil {
+		t.Fatal("Unexpected error opening config: " + err.Error())
+	}
+	logger.Start(ew)
+	stop := ew.Watch(configPath, 10*time.Millisecond)
+
+	writeConfig(t, configPath, `{"writers": [{"type": "file", "path": "`+path2+`"}]}`)
+	waitFor(t, func() bool { return ew.Config().Writers[0].Path == path2 })
+
+	writeConfig(t, configPath, `{"writers": [{"type": "unknown"}]}`)
+	time.Sleep(50 * time.Millisecond)
+	stop()
+	if err := logger.Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	got := readFile(t, path2)
+	if !strings.Contains(got, "[Info] logger, config: Reloaded configuration from "+configPath) {
+		t.Errorf("Expected the reload to be logged, but got %q", got)
+	}
+	if !strings.Contains(got, `[Error] logger, config: config: writer "unknown": unknown type "unknown"`) {
+		t.Errorf("Expected the invalid config to be logged, but got %q", got)
+	}
+}
+
+// Modification time of the config file written by writeConfig.
+var configModTime = time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC)
+
+func writeConfig(t *testing.T, path, config string) {
+	if err := ioutil.WriteFile(path, []byte(config), 0600); err != nil {
+		t.Fatal("Unexpected error writing config: " + err.Error())
+	}
+	// Make sure the modification time changes, even on file systems with a low
+	// time resolution.
+	configModTime = configModTime.Add(time.Minute)
+	modTime := configModTime
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal("Unexpected error changing modification time: " + err.Error())
+	}
+}
+
+func waitFor(t *testing.T, fn func() bool) {
+	for i := 0; i < 500; i++ {
+		if fn() {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("Timed out waiting")
+}
+
+func readFile(t *testing.T, path string) string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal("Unexpected error reading file: " + err.Error())
+	}
+	return string(b)
+}
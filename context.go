@@ -0,0 +1,186 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"context"
+	"sync"
+)
+
+// ContextTagger extracts tags from a context, for example a request id or
+// user id, see RegisterContextTagger.
+type ContextTagger func(ctx context.Context) Tags
+
+var (
+	contextTaggersMu sync.RWMutex
+	contextTaggers   []ContextTagger
+)
+
+// RegisterContextTagger registers a ContextTagger, which is used by the Ctx
+// log operations (e.g. InfoCtx) and ContextTags to add tags from the context
+// to the event. This allows values such as a request id, user id or locale to
+// flow into the events automatically, for example:
+//
+//	type userKey struct{}
+//
+//	logger.RegisterContextTagger(func(ctx context.Context) logger.Tags {
+//		if user, ok := ctx.Value(userKey{}).(string); ok {
+//			return logger.Tags{logger.Tag("user", user)}
+//		}
+//		return nil
+//	})
+//
+// The ContextTaggers are called in the order they are registered.
+func RegisterContextTagger(tagger ContextTagger) {
+	contextTaggersMu.Lock()
+	contextTaggers = append(contextTaggers, tagger)
+	contextTaggersMu.Unlock()
+}
+
+type contextTagsKey struct{}
+
+// WithContextTags returns a copy of ctx with the tags added, these tags are
+// returned by ContextTags, after the tags of the parent context, and thus
+// added to events logged by the Ctx log operations.
+func WithContextTags(ctx context.Context, tags ...string) context.Context {
+	parent := contextTagsValue(ctx)
+	// Always allocate a new slice, so we don't modify the tags of the parent.
+	newTags := make(Tags, 0, len(parent)+len(tags))
+	newTags = append(newTags, parent...)
+	newTags = append(newTags, tags...)
+	return context.WithValue(ctx, contextTagsKey{}, newTags)
+}
+
+func contextTagsValue(ctx context.Context) Tags {
+	tags, _ := ctx.Value(contextTagsKey{}).(Tags)
+	return tags
+}
+
+// ContextTags returns the tags added to the context using WithContextTags,
+// followed by the tags returned by the registered ContextTaggers.
+func ContextTags(ctx context.Context) Tags {
+	tags := contextTagsValue(ctx)
+
+	contextTaggersMu.RLock()
+	defer contextTaggersMu.RUnlock()
+	if len(contextTaggers) == 0 {
+		return tags
+	}
+
+	// Don't modify the tags stored in the context.
+	tags = tags[:len(tags):len(tags)]
+	for _, tagger := range contextTaggers {
+		tags = append(tags, tagger(ctx)...)
+	}
+	return tags
+}
+
+// contextTags returns the given tags followed by the tags from the context.
+func contextTags(ctx context.Context, tags Tags) Tags {
+	ctxTags := ContextTags(ctx)
+	if len(ctxTags) == 0 {
+		return tags
+	}
+	newTags := make(Tags, 0, len(tags)+len(ctxTags))
+	newTags = append(newTags, tags...)
+	return append(newTags, ctxTags...)
+}
+
+// TraceCtx does the same as Trace, but adds the tags from the context, see
+// ContextTags.
+func TraceCtx(ctx context.Context, tags Tags, msg string) {
+	Trace(contextTags(ctx, tags), msg)
+}
+
+// TracefCtx does the same as Tracef, but adds the tags from the context, see
+// ContextTags.
+func TracefCtx(ctx context.Context, tags Tags, format string, v ...interface{}) {
+	Tracef(contextTags(ctx, tags), format, v...)
+}
+
+// DebugCtx does the same as Debug, but adds the tags from the context, see
+// ContextTags.
+func DebugCtx(ctx context.Context, tags Tags, msg string) {
+	Debug(contextTags(ctx, tags), msg)
+}
+
+// DebugfCtx does the same as Debugf, but adds the tags from the context, see
+// ContextTags.
+func DebugfCtx(ctx context.Context, tags Tags, format string, v ...interface{}) {
+	Debugf(contextTags(ctx, tags), format, v...)
+}
+
+// InfoCtx does the same as Info, but adds the tags from the context, see
+// ContextTags.
+func InfoCtx(ctx context.Context, tags Tags, msg string) {
+	Info(contextTags(ctx, tags), msg)
+}
+
+// InfofCtx does the same as Infof, but adds the tags from the context, see
+// ContextTags.
+func InfofCtx(ctx context.Context, tags Tags, format string, v ...interface{}) {
+	Infof(contextTags(ctx, tags), format, v...)
+}
+
+// NoticeCtx does the same as Notice, but adds the tags from the context, see
+// ContextTags.
+func NoticeCtx(ctx context.Context, tags Tags, msg string) {
+	Notice(contextTags(ctx, tags), msg)
+}
+
+// NoticefCtx does the same as Noticef, but adds the tags from the context, see
+// ContextTags.
+func NoticefCtx(ctx context.Context, tags Tags, format string, v ...interface{}) {
+	Noticef(contextTags(ctx, tags), format, v...)
+}
+
+// WarnCtx does the same as Warn, but adds the tags from the context, see
+// ContextTags.
+func WarnCtx(ctx context.Context, tags Tags, msg string) {
+	Warn(contextTags(ctx, tags), msg)
+}
+
+// WarnfCtx does the same as Warnf, but adds the tags from the context, see
+// ContextTags.
+func WarnfCtx(ctx context.Context, tags Tags, format string, v ...interface{}) {
+	Warnf(contextTags(ctx, tags), format, v...)
+}
+
+// ErrorCtx does the same as Error, but adds the tags from the context, see
+// ContextTags.
+func ErrorCtx(ctx context.Context, tags Tags, err error) {
+	Error(contextTags(ctx, tags), err)
+}
+
+// ErrorfCtx does the same as Errorf, but adds the tags from the context, see
+// ContextTags.
+func ErrorfCtx(ctx context.Context, tags Tags, format string, v ...interface{}) {
+	Errorf(contextTags(ctx, tags), format, v...)
+}
+
+// FatalCtx does the same as Fatal, but adds the tags from the context, see
+// ContextTags.
+func FatalCtx(ctx context.Context, tags Tags, recv interface{}) {
+	sendEvent(fatalEvent(contextTags(ctx, tags), recv, getStackTrace()))
+}
+
+// SecurityCtx does the same as Security, but adds the tags from the context,
+// see ContextTags.
+func SecurityCtx(ctx context.Context, tags Tags, msg string) {
+	Security(contextTags(ctx, tags), msg)
+}
+
+// SecurityfCtx does the same as Securityf, but adds the tags from the
+// context, see ContextTags.
+func SecurityfCtx(ctx context.Context, tags Tags, format string, v ...interface{}) {
+	Securityf(contextTags(ctx, tags), format, v...)
+}
+
+// LogCtx does the same as Log, but adds the tags from the context, see
+// ContextTags.
+func LogCtx(ctx context.Context, event Event) {
+	event.Tags = contextTags(ctx, event.Tags)
+	Log(event)
+}
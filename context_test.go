@@ -0,0 +1,139 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type userKey struct{}
+
+func userTagger(ctx context.Context) Tags {
+	if user, ok := ctx.Value(userKey{}).(string); ok {
+		return Tags{Tag("user", user)}
+	}
+	return nil
+}
+
+func TestContextTags(t *testing.T) {
+	defer reset()
+
+	ctx := context.Background()
+	if got := ContextTags(ctx); len(got) != 0 {
+		t.Fatalf("Expected no tags, but got %v", got)
+	}
+
+	parent := WithContextTags(ctx, "tag1")
+	child1 := WithContextTags(parent, "tag2")
+	child2 := WithContextTags(parent, "tag3")
+	if expected, got := (Tags{"tag1", "tag2"}), ContextTags(child1); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected tags %v, but got %v", expected, got)
+	} else if expected, got := (Tags{"tag1", "tag3"}), ContextTags(child2); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected tags %v, but got %v", expected, got)
+	}
+
+	RegisterContextTagger(userTagger)
+	ctx = context.WithValue(child1, userKey{}, "Thomas")
+	if expected, got := (Tags{"tag1", "tag2", "user=Thomas"}), ContextTags(ctx); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected tags %v, but got %v", expected, got)
+	} else if expected, got := (Tags{"tag1", "tag2"}), ContextTags(child1); !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected tags %v, but got %v", expected, got)
+	}
+}
+
+func TestCtxLogOperations(t *testing.T) {
+	defer reset()
+	var ew eventWriter
+	Start(&ew)
+
+	RegisterContextTagger(userTagger)
+	ctx := context.WithValue(WithContextTags(context.Background(), "request"), userKey{}, "Thomas")
+	tags := Tags{"tag"}
+
+	TraceCtx(ctx, tags, "Trace message")
+	DebugCtx(ctx, tags, "Debug message")
+	InfoCtx(ctx, tags, "Info message")
+	NoticeCtx(ctx, tags, "Notice message")
+	WarnCtx(ctx, tags, "Warn message")
+	ErrorCtx(ctx, tags, errors.New("Error message"))
+	SecurityCtx(ctx, tags, "Security message")
+	TracefCtx(ctx, tags, "%s message", "Tracef")
+	DebugfCtx(ctx, tags, "%s message", "Debugf")
+	InfofCtx(ctx, tags, "%s message", "Infof")
+	NoticefCtx(ctx, tags, "%s message", "Noticef")
+	WarnfCtx(ctx, tags, "%s message", "Warnf")
+	ErrorfCtx(ctx, tags, "%s message", "Errorf")
+	SecurityfCtx(ctx, tags, "%s message", "Securityf")
+	LogCtx(ctx, Event{Type: LogEvent, Tags: tags, Message: "Log message"})
+	InfoCtx(context.Background(), tags, "No context tags")
+
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	expected := []Event{
+		{Type: TraceEvent, Message: "Trace message"},
+		{Type: DebugEvent, Message: "Debug message"},
+		{Type: InfoEvent, Message: "Info message"},
+		{Type: NoticeEvent, Message: "Notice message"},
+		{Type: WarnEvent, Message: "Warn message"},
+		{Type: ErrorEvent, Message: "Error message"},
+		{Type: SecurityEvent, Message: "Security message"},
+		{Type: TraceEvent, Message: "Tracef message"},
+		{Type: DebugEvent, Message: "Debugf message"},
+		{Type: InfoEvent, Message: "Infof message"},
+		{Type: NoticeEvent, Message: "Noticef message"},
+		{Type: WarnEvent, Message: "Warnf message"},
+		{Type: ErrorEvent, Message: "Errorf message"},
+		{Type: SecurityEvent, Message: "Securityf message"},
+		{Type: LogEvent, Message: "Log message"},
+		{Type: InfoEvent, Message: "No context tags", Tags: tags},
+	}
+	if len(ew.events) != len(expected) {
+		t.Fatalf("Expected %d events, but got %d", len(expected), len(ew.events))
+	}
+	for i, event := range ew.events {
+		expectedEvent := expected[i]
+		expectedEvent.Timestamp = now()
+		expectedEvent.Seq = uint64(i + 1)
+		if expectedEvent.Tags == nil {
+			expectedEvent.Tags = Tags{"tag", "request", "user=Thomas"}
+		}
+		if !reflect.DeepEqual(event, expectedEvent) {
+			t.Errorf("Expected event #%d to be %v, but got %v", i, expectedEvent, event)
+		}
+	}
+}
+
+func TestFatalCtx(t *testing.T) {
+	defer reset()
+	var ew eventWriter
+	Start(&ew)
+
+	ctx := WithContextTags(context.Background(), "request")
+	FatalCtx(ctx, Tags{"tag"}, "Fatal message")
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if len(ew.events) != 1 {
+		t.Fatalf("Expected a single event, but got %v", ew.events)
+	}
+	event := ew.events[0]
+	if event.Type != FatalEvent || event.Message != "Fatal message" ||
+		!reflect.DeepEqual(event.Tags, Tags{"tag", "request"}) {
+		t.Fatalf("Unexpected event: %v", event)
+	}
+	// The stack trace starts at the caller of FatalCtx.
+	stackTrace, _ := event.Data.([]byte)
+	if bytes.Contains(stackTrace, []byte("logger.FatalCtx(")) ||
+		!bytes.Contains(stackTrace, []byte("TestFatalCtx")) {
+		t.Fatalf("Unexpected stack trace:\n%s", stackTrace)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"errors"
+	"math"
+	"sync"
+)
+
+// Flusher is implemented by EventWriters that buffer events, see Flush.
+type Flusher interface {
+	// Flush writes all buffered events to the underlying storage.
+	Flush() error
+}
+
+// Rotator is implemented by EventWriters that write to files, see Rotate.
+type Rotator interface {
+	// Rotate closes and reopens the file(s) written to, e.g. after they're
+	// moved by logrotate.
+	Rotate() error
+}
+
+// ErrNotRunning is returned by Flush and Rotate if the logger isn't started, or
+// already closed.
+var ErrNotRunning = errors.New("logger: not running")
+
+// controlEventType is the EventType of control events, which are never written
+// but carry a *controlRequest for all EventWriters. It's never returned by
+// NewEventType.
+const controlEventType EventType = math.MaxUint16
+
+// Control operations.
+const (
+	flushOp = iota
+	rotateOp
+)
+
+// controlRequest is a request for all EventWriters to perform an operation,
+// the request is send in order with the events so all events logged before
+// the request are written before the operation is performed.
+type controlRequest struct {
+	op  int
+	wg  sync.WaitGroup
+	mu  sync.Mutex
+	err error
+}
+
+// do performs the operation on the EventWriter, if supported.
+func (req *controlRequest) do(ew EventWriter, state *writerState) {
+	defer req.wg.Done()
+
+	var err error
+	state.startWrite()
+	switch req.op {
+	case flushOp:
+		if flusher, ok := ew.(Flusher); ok {
+			err = flusher.Flush()
+		}
+	case rotateOp:
+		if rotator, ok := ew.(Rotator); ok {
+			err = rotator.Rotate()
+		}
+	}
+	state.endWrite()
+
+	if err != nil {
+		req.mu.Lock()
+		if req.err == nil {
+			req.err = err
+		}
+		req.mu.Unlock()
+	}
+}
+
+// Flush flushes all EventWriters that implement Flusher, after all events
+// logged before the call are written. It blocks until all EventWriters are
+// flushed and returns the first error. EventWriters that are bad are skipped.
+//
+// Note: Flush must not be called concurrently with Close.
+func Flush() error {
+	flushLocalBuffers()
+	flushSpill()
+	return control(flushOp)
+}
+
+// Rotate rotates the files of all EventWriters that implement Rotator, after
+// all events logged before the call are written. It blocks until all files are
+// rotated and returns the first error. EventWriters that are bad are skipped.
+//
+// Note: Rotate must not be called concurrently with Close.
+func Rotate() error {
+	return control(rotateOp)
+}
+
+func control(op int) error {
+	if !started || closed {
+		return ErrNotRunning
+	}
+
+	req := &controlRequest{op: op}
+	req.wg.Add(len(eventWriters))
+	eventChannel <- Event{Type: controlEventType, Data: req}
+	req.wg.Wait()
+	return req.err
+}
@@ -0,0 +1,122 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// controlEventWriter counts the number of flushes and rotations, and the number
+// of events written at the time of the first flush.
+type controlEventWriter struct {
+	eventWriter
+	flushes, rotations int
+	flushedEvents      int
+	err                error
+}
+
+func (ew *controlEventWriter) Flush() error {
+	ew.flushes++
+	if ew.flushes == 1 {
+		ew.flushedEvents = len(ew.events)
+	}
+	return ew.err
+}
+
+func (ew *controlEventWriter) Rotate() error {
+	ew.rotations++
+	return nil
+}
+
+func TestFlushAndRotate(t *testing.T) {
+	defer reset()
+	if err := Flush(); err != ErrNotRunning {
+		t.Fatalf("Expected ErrNotRunning, but got %v", err)
+	}
+
+	var cew controlEventWriter
+	bad := badEventWriter{bad: make(chan struct{})}
+	var ew eventWriter
+	Start(&cew, &bad, &ew)
+
+	for i := 0; i < 10; i++ {
+		Info(Tags{"control"}, "Info message")
+	}
+	if err := Flush(); err != nil {
+		t.Fatal("Unexpected error flushing: " + err.Error())
+	}
+	cew.err = errors.New("flush error")
+	if err := Flush(); err != cew.err {
+		t.Fatalf("Expected the flush error, but got %v", err)
+	}
+	if err := Rotate(); err != nil {
+		t.Fatal("Unexpected error rotating: " + err.Error())
+	}
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	checkControlEventWriter(t, &cew)
+	// Control events are never written.
+	if len(ew.events) != 11 {
+		t.Fatalf("Expected 11 events, but got %v", ew.events)
+	}
+
+	if err := Flush(); err != ErrNotRunning {
+		t.Fatalf("Expected ErrNotRunning, but got %v", err)
+	}
+}
+
+func checkControlEventWriter(t *testing.T, cew *controlEventWriter) {
+	if cew.flushes != 2 || cew.rotations != 1 {
+		t.Fatalf("Expected 2 flushes and 1 rotation, but got %d and %d", cew.flushes, cew.rotations)
+	} else if cew.flushedEvents < 10 {
+		t.Fatalf("Expected all events to be written before flushing, but got %d", cew.flushedEvents)
+	}
+}
+
+func TestFileEventWriterRotate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logger_rotate")
+	if err != nil {
+		t.Fatal("Unexpected error creating temporary directory: " + err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "app.log")
+	ew, err := NewFileEventWriter(InfoEvent, path)
+	if err != nil {
+		t.Fatal("Unexpected error creating new file event writer: " + err.Error())
+	}
+
+	event := Event{Type: InfoEvent, Timestamp: now(), Message: "Info message"}
+	ew.Write(event)
+	if err := ew.(Flusher).Flush(); err != nil {
+		t.Fatal("Unexpected error flushing: " + err.Error())
+	}
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal("Unexpected error moving file: " + err.Error())
+	}
+	if err := ew.(Rotator).Rotate(); err != nil {
+		t.Fatal("Unexpected error rotating: " + err.Error())
+	}
+	ew.Write(event)
+	if err := ew.Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	for _, p := range []string{path + ".1", path} {
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			t.Fatal("Unexpected error reading file: " + err.Error())
+		} else if strings.Count(string(b), "Info message") != 1 {
+			t.Errorf("Expected %s to contain a single event, but got %q", p, b)
+		}
+	}
+}
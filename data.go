@@ -0,0 +1,32 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"reflect"
+
+	"github.com/Thomasdezeeuw/logger/internal/util"
+)
+
+// RegisterDataEncoder registers an encoder for Event.Data of type t. All
+// EventWriters that convert data to a string, e.g. the text and JSON writers,
+// use the encoder rather then falling back to fmt's %v formatting. This allows
+// domain types to be serialized in a controlled way, for example:
+//
+//	logger.RegisterDataEncoder(reflect.TypeOf(HTTPRequest{}), func(data interface{}) ([]byte, error) {
+//		req := data.(HTTPRequest)
+//		return []byte(req.Method + " " + req.URL), nil
+//	})
+//
+// If t is an interface type the encoder is used for all types that implement
+// the interface, unless an encoder for the concrete type is registered. If the
+// encoder returns an error the data is written as "%!(ENCODE_ERROR=<error>)".
+// EventWriters that handle specific data types, e.g. the fields in a
+// map[string]string, handle those types before using the registered encoders.
+//
+// Note: this must be called before Start and is not safe for concurrent use.
+func RegisterDataEncoder(t reflect.Type, encoder func(interface{}) ([]byte, error)) {
+	util.RegisterEncoder(t, encoder)
+}
@@ -0,0 +1,43 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger/internal/util"
+)
+
+type httpRequest struct {
+	Method string
+	URL    string
+}
+
+func TestRegisterDataEncoder(t *testing.T) {
+	defer util.ResetEncoders()
+	RegisterDataEncoder(reflect.TypeOf(httpRequest{}), func(data interface{}) ([]byte, error) {
+		req := data.(httpRequest)
+		return []byte(req.Method + " " + req.URL), nil
+	})
+
+	t1 := time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC)
+	event := Event{Type: InfoEvent, Timestamp: t1, Message: "Request", Data: httpRequest{"GET", "/"}}
+
+	if got, expected := event.String(), "2015-09-01 14:22:36 [Info] : Request, GET /"; got != expected {
+		t.Errorf("Expected %q, but got %q", expected, got)
+	}
+
+	var buf bytes.Buffer
+	ew := NewJSONEventWriter(InfoEvent, &buf, func(error) {})
+	if err := ew.Write(event); err != nil {
+		t.Fatal("Unexpected error writing event: " + err.Error())
+	}
+	if expected := `"data":"GET /"`; !bytes.Contains(buf.Bytes(), []byte(expected)) {
+		t.Errorf("Expected the JSON to contain %s, but got %s", expected, buf.String())
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/Thomasdezeeuw/logger/internal/util"
+)
+
+// DataPlaceholder replaces the content of Event.Data that exceeds the limits
+// set with SetDataLimits.
+const DataPlaceholder = "<truncated>"
+
+var (
+	maxDataDepth    int
+	maxDataElements int
+)
+
+// The maximum depth used if only the maximum number of elements is set, this
+// stops the limiting of cyclic data structures.
+const maxDataNesting = 64
+
+// SetDataLimits sets the maximum nesting depth of structured data, i.e. the
+// slices, arrays, maps and structs in Event.Data, and the maximum number of
+// elements of each of them. This prevents a pathological object, e.g. a deeply
+// nested tree, from producing an unbounded log line, e.g. when it's encoded
+// by CBORFormatter or converted into a string by the JSON EventWriters.
+//
+// Data that exceeds the limits is replaced by a copy, in which nested content
+// deeper then maxDepth is replaced by DataPlaceholder, slices and arrays are
+// converted into []interface{} and maps and structs (exported fields only)
+// into map[string]interface{}. Elements over maxElements are dropped and
+// DataPlaceholder is added as last element, or key of the map. For example
+// with a maxDepth of 1 the data []interface{}{1, []int{2, 3}} is replaced by
+// []interface{}{1, "<truncated>"}. Like with SetSizeLimits TruncatedTag is
+// added to the tags of the event. Values with a registered encoder, see
+// RegisterDataEncoder, errors and fmt.Stringers are not inspected.
+//
+// A limit of zero (the default) means no limit. If only maxElements is set
+// content nested deeper then 64 levels, e.g. in a cyclic data structure, is
+// still replaced. The data is limited before the size limits are applied.
+//
+// Note: this must be called before Start and is not safe for concurrent use.
+func SetDataLimits(maxDepth, maxElements int) {
+	maxDataDepth = maxDepth
+	maxDataElements = maxElements
+}
+
+// limitData returns a copy of the data, limited to maxDepth and maxElements,
+// see SetDataLimits. If the data doesn't exceed the limits it's returned as is
+// and false is returned.
+func limitData(data interface{}, maxDepth, maxElements int) (interface{}, bool) {
+	if maxDepth <= 0 && maxElements <= 0 {
+		return data, false
+	} else if maxDepth <= 0 {
+		maxDepth = maxDataNesting
+	}
+	l := dataLimiter{maxDepth, maxElements}
+	v := reflect.ValueOf(data)
+	if !l.exceeds(v, 0) {
+		return data, false
+	}
+	return l.limit(v, 0), true
+}
+
+type dataLimiter struct {
+	maxDepth, maxElements int
+}
+
+// exceeds returns true if the value, at the given depth, exceeds the limits.
+func (l dataLimiter) exceeds(v reflect.Value, depth int) bool {
+	v, ok := dataContainer(v)
+	if !ok {
+		return false
+	} else if l.maxDepth > 0 && depth >= l.maxDepth {
+		return true
+	}
+
+	elements := containerElements(v)
+	if l.maxElements > 0 && len(elements) > l.maxElements {
+		return true
+	}
+	for _, element := range elements {
+		if l.exceeds(element.value, depth+1) {
+			return true
+		}
+	}
+	return false
+}
+
+// limit returns the limited copy of the value, at the given depth.
+func (l dataLimiter) limit(v reflect.Value, depth int) interface{} {
+	v, ok := dataContainer(v)
+	if !ok {
+		return leafValue(v)
+	} else if l.maxDepth > 0 && depth >= l.maxDepth {
+		return DataPlaceholder
+	}
+
+	elements := containerElements(v)
+	truncated := l.maxElements > 0 && len(elements) > l.maxElements
+	if truncated {
+		elements = elements[:l.maxElements]
+	}
+
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		return l.limitSlice(elements, truncated, depth)
+	}
+
+	limited := make(map[string]interface{}, len(elements)+1)
+	for _, element := range elements {
+		limited[element.key] = l.limit(element.value, depth+1)
+	}
+	if truncated {
+		limited[DataPlaceholder] = DataPlaceholder
+	}
+	return limited
+}
+
+// leafValue returns the value that isn't a container, see dataContainer.
+func leafValue(v reflect.Value) interface{} {
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Interface()
+}
+
+// limitSlice returns the limited copy of the elements of a slice or array, at
+// the given depth.
+func (l dataLimiter) limitSlice(elements []dataElement, truncated bool, depth int) []interface{} {
+	limited := make([]interface{}, 0, len(elements)+1)
+	for _, element := range elements {
+		limited = append(limited, l.limit(element.value, depth+1))
+	}
+	if truncated {
+		limited = append(limited, DataPlaceholder)
+	}
+	return limited
+}
+
+// dataContainer returns the slice, array, map or struct the value holds,
+// following pointers and interfaces. It returns false if the value is not such
+// a container, or if it shouldn't be inspected, e.g. a []byte or a value with
+// a registered encoder.
+func dataContainer(v reflect.Value) (reflect.Value, bool) {
+	for v.IsValid() {
+		if v.CanInterface() && isDataLeaf(v) {
+			return v, false
+		}
+
+		switch v.Kind() {
+		case reflect.Ptr, reflect.Interface:
+			if v.IsNil() {
+				return v, false
+			}
+			v = v.Elem()
+		case reflect.Slice:
+			return v, !v.IsNil() && v.Type().Elem().Kind() != reflect.Uint8
+		case reflect.Array, reflect.Map, reflect.Struct:
+			return v, true
+		default:
+			return v, false
+		}
+	}
+	return v, false
+}
+
+// isDataLeaf returns true if the value is converted into a string as a whole.
+func isDataLeaf(v reflect.Value) bool {
+	if util.HasEncoder(v.Type()) {
+		return true
+	}
+	switch v.Interface().(type) {
+	case error, fmt.Stringer:
+		return true
+	}
+	return false
+}
+
+// dataElement is an element of a container, the key is only set for maps and
+// structs.
+type dataElement struct {
+	key   string
+	value reflect.Value
+}
+
+// containerElements returns the elements of the container. The entries of
+// maps are sorted by key, so the limited copy is deterministic, the fields of
+// structs are in order.
+func containerElements(v reflect.Value) []dataElement {
+	var elements []dataElement
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		elements = make([]dataElement, v.Len())
+		for i := range elements {
+			elements[i].value = v.Index(i)
+		}
+	case reflect.Map:
+		elements = make([]dataElement, 0, v.Len())
+		for _, key := range v.MapKeys() {
+			elements = append(elements, dataElement{fmt.Sprint(key.Interface()), v.MapIndex(key)})
+		}
+		sort.Slice(elements, func(i, j int) bool { return elements[i].key < elements[j].key })
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			if field := t.Field(i); field.PkgPath == "" {
+				elements = append(elements, dataElement{field.Name, v.Field(i)})
+			}
+		}
+	}
+	return elements
+}
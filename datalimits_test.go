@@ -0,0 +1,100 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type dataNode struct {
+	Name     string
+	Children []*dataNode
+}
+
+func TestLimitData(t *testing.T) {
+	t.Parallel()
+
+	err := errors.New("error")
+	tests := []struct {
+		data                  interface{}
+		maxDepth, maxElements int
+		expected              interface{}
+		limited               bool
+	}{
+		{nil, 1, 1, nil, false},
+		{123, 1, 1, 123, false},
+		{"string", 1, 1, "string", false},
+		{[]byte("bytes"), 1, 1, []byte("bytes"), false},
+		{err, 1, 1, err, false},
+		{[]int{1, 2}, 1, 2, []int{1, 2}, false},
+		{[]int{1, 2, 3}, 0, 0, []int{1, 2, 3}, false},
+		{[]int{1, 2, 3}, 0, 2, []interface{}{1, 2, DataPlaceholder}, true},
+		{[]interface{}{1, []int{2, 3}}, 1, 0, []interface{}{1, DataPlaceholder}, true},
+		{[2][]int{{1}, {2}}, 2, 0, [2][]int{{1}, {2}}, false},
+		{map[string]int{"c": 3, "a": 1, "b": 2}, 0, 2,
+			map[string]interface{}{"a": 1, "b": 2, DataPlaceholder: DataPlaceholder}, true},
+		{map[int][]int{1: {1, 2}}, 1, 0, map[string]interface{}{"1": DataPlaceholder}, true},
+		{&dataNode{Name: "root", Children: []*dataNode{{Name: "child"}}}, 2, 0,
+			map[string]interface{}{"Name": "root", "Children": []interface{}{DataPlaceholder}}, true},
+		{user{1, "Thomas"}, 0, 1, map[string]interface{}{"ID": 1, DataPlaceholder: DataPlaceholder}, true},
+	}
+
+	for _, test := range tests {
+		got, limited := limitData(test.data, test.maxDepth, test.maxElements)
+		if limited != test.limited || !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("Expected limitData(%#v, %d, %d) to return %#v, %v, but got %#v, %v",
+				test.data, test.maxDepth, test.maxElements, test.expected, test.limited, got, limited)
+		}
+	}
+}
+
+func TestLimitDataCycle(t *testing.T) {
+	t.Parallel()
+
+	// Without a maximum depth cyclic data is still limited.
+	node := &dataNode{Name: "node"}
+	node.Children = []*dataNode{node}
+	got, limited := limitData(node, 0, 10)
+	if !limited {
+		t.Fatal("Expected cyclic data to be limited")
+	}
+	for depth := 0; depth < maxDataNesting; depth += 2 {
+		children, ok := got.(map[string]interface{})["Children"].([]interface{})
+		if !ok {
+			t.Fatalf("Expected children at depth %d, but got %#v", depth, got)
+		}
+		got = children[0]
+	}
+	if got != DataPlaceholder {
+		t.Fatalf("Expected the placeholder at the maximum depth, but got %#v", got)
+	}
+}
+
+func TestSetDataLimits(t *testing.T) {
+	defer reset()
+	SetDataLimits(1, 2)
+
+	var ew eventWriter
+	Start(&ew)
+	Log(Event{Type: InfoEvent, Tags: Tags{"limits"}, Message: "Info message", Data: []interface{}{1, []int{2}, 3}})
+	Log(Event{Type: InfoEvent, Tags: Tags{"limits"}, Message: "Info message", Data: []int{1}})
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if len(ew.events) != 2 {
+		t.Fatalf("Expected 2 events, but got %v", ew.events)
+	}
+	expected := []interface{}{1, DataPlaceholder, DataPlaceholder}
+	if got := ew.events[0]; !reflect.DeepEqual(got.Data, expected) ||
+		!reflect.DeepEqual(got.Tags, Tags{"limits", TruncatedTag}) {
+		t.Fatalf("Expected the data to be limited to %v, but got %v", expected, got)
+	}
+	if got := ew.events[1]; !reflect.DeepEqual(got.Data, []int{1}) || len(got.Tags) != 1 {
+		t.Fatalf("Expected the data not to be limited, but got %v", got)
+	}
+}
@@ -0,0 +1,222 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"context"
+	"errors"
+)
+
+// Filtered returns an EventWriter that only writes the events for which filter
+// returns true to ew. A compiled filter expression can be used by passing its
+// Match method, e.g.:
+//
+//	filter := logger.MustCompileFilter(`tags has "http" && type >= Warn`)
+//	logger.Start(logger.Filtered(ew, filter.Match))
+//
+// Errors, Flush, Rotate and Close are passed to ew, as are SupportsBatch,
+// WriteContext and WriteEncoded if ew implements them.
+func Filtered(ew EventWriter, filter func(Event) bool) EventWriter {
+	return &filteredEventWriter{ew, filter}
+}
+
+type filteredEventWriter struct {
+	ew     EventWriter
+	filter func(Event) bool
+}
+
+func (ew *filteredEventWriter) Write(event Event) error {
+	if !ew.filter(event) {
+		return nil
+	}
+	return ew.ew.Write(event)
+}
+
+func (ew *filteredEventWriter) WriteContext(ctx context.Context, event Event) error {
+	if !ew.filter(event) {
+		return nil
+	}
+	return writeContext(ctx, ew.ew, event)
+}
+
+func (ew *filteredEventWriter) Encoding() Encoding {
+	return eventWriterEncoding(ew.ew)
+}
+
+func (ew *filteredEventWriter) WriteEncoded(event Event, encoded []byte) error {
+	if !ew.filter(event) {
+		return nil
+	}
+	return write(ew.ew, event, encoded)
+}
+
+func (ew *filteredEventWriter) HandleError(err error) { ew.ew.HandleError(err) }
+func (ew *filteredEventWriter) Flush() error          { return flushEventWriter(ew.ew) }
+func (ew *filteredEventWriter) SupportsBatch() bool   { return supportsBatch(ew.ew) }
+func (ew *filteredEventWriter) Rotate() error         { return rotateEventWriter(ew.ew) }
+func (ew *filteredEventWriter) Close() error          { return ew.ew.Close() }
+
+// Transformed returns an EventWriter that writes the events, as returned by
+// fn, to ew. Like an EventWriter fn may modify the tags of the event, and the
+// data if it's a byte slice, in place, but not other data.
+//
+// Errors, Flush, Rotate and Close are passed to ew, as are SupportsBatch and
+// WriteContext if ew implements them. Since the event is modified ew always
+// gets the event, rather then the encoded event, see EncodedEventWriter.
+func Transformed(ew EventWriter, fn func(Event) Event) EventWriter {
+	return &transformedEventWriter{ew, fn}
+}
+
+type transformedEventWriter struct {
+	ew EventWriter
+	fn func(Event) Event
+}
+
+func (ew *transformedEventWriter) Write(event Event) error {
+	return ew.ew.Write(ew.fn(event))
+}
+
+func (ew *transformedEventWriter) WriteContext(ctx context.Context, event Event) error {
+	return writeContext(ctx, ew.ew, ew.fn(event))
+}
+
+func (ew *transformedEventWriter) HandleError(err error) { ew.ew.HandleError(err) }
+func (ew *transformedEventWriter) Flush() error          { return flushEventWriter(ew.ew) }
+func (ew *transformedEventWriter) SupportsBatch() bool   { return supportsBatch(ew.ew) }
+func (ew *transformedEventWriter) Rotate() error         { return rotateEventWriter(ew.ew) }
+func (ew *transformedEventWriter) Close() error          { return ew.ew.Close() }
+
+// Tee returns an EventWriter that writes all events to all given EventWriters,
+// in order, so they can be used as a single EventWriter, e.g. with Filtered.
+// Like with Start every EventWriter gets its own copy of the event.
+//
+// If a write fails the error is passed to the EventWriter that returned it and
+// if the write is retried the event is only written to the EventWriters that
+// failed. If the returned EventWriter is considered bad ErrBadEventWriter is
+// passed to all EventWriters. Closing the returned EventWriter closes all
+// EventWriters and returns a *CloseError if any of them fail. The context of
+// WriteContext is passed to the EventWriters that implement
+// ContextEventWriter and if any of the EventWriters batches events all of them
+// are flushed once the logger is idle, see BatchEventWriter.
+func Tee(ews ...EventWriter) EventWriter {
+	return &teeEventWriter{ews: ews, errors: make([]error, len(ews)), copies: make([]Event, len(ews))}
+}
+
+type teeEventWriter struct {
+	ews []EventWriter
+	// Sequence number of the last event written and the errors returned by
+	// each EventWriter, used to only retry the failed writes.
+	seq    uint64
+	errors []error
+	copies []Event
+}
+
+func (ew *teeEventWriter) Write(event Event) error {
+	return ew.WriteContext(context.Background(), event)
+}
+
+func (ew *teeEventWriter) WriteContext(ctx context.Context, event Event) error {
+	retry := event.Seq != 0 && event.Seq == ew.seq
+	ew.seq = event.Seq
+
+	copyEvent(event, ew.copies)
+	var err error
+	for i, w := range ew.ews {
+		if retry && ew.errors[i] == nil {
+			continue
+		}
+		ew.errors[i] = writeContext(ctx, w, ew.copies[i])
+		if err == nil {
+			err = ew.errors[i]
+		}
+	}
+	return err
+}
+
+func (ew *teeEventWriter) SupportsBatch() bool {
+	for _, w := range ew.ews {
+		if supportsBatch(w) {
+			return true
+		}
+	}
+	return false
+}
+
+func (ew *teeEventWriter) HandleError(err error) {
+	bad := errors.Is(err, ErrBadEventWriter)
+	for i, w := range ew.ews {
+		if bad {
+			w.HandleError(err)
+		} else if ew.errors[i] != nil {
+			w.HandleError(ew.errors[i])
+		}
+	}
+}
+
+func (ew *teeEventWriter) Flush() error {
+	var err error
+	for _, w := range ew.ews {
+		if er := flushEventWriter(w); er != nil && err == nil {
+			err = er
+		}
+	}
+	return err
+}
+
+func (ew *teeEventWriter) Rotate() error {
+	var err error
+	for _, w := range ew.ews {
+		if er := rotateEventWriter(w); er != nil && err == nil {
+			err = er
+		}
+	}
+	return err
+}
+
+func (ew *teeEventWriter) Close() error {
+	var closeErr CloseError
+	for _, w := range ew.ews {
+		if err := w.Close(); err != nil {
+			closeErr.Writers = append(closeErr.Writers, w)
+			closeErr.Errors = append(closeErr.Errors, err)
+		}
+	}
+	if len(closeErr.Errors) != 0 {
+		return &closeErr
+	}
+	return nil
+}
+
+// flushEventWriter flushes the EventWriter, if it implements Flusher.
+func flushEventWriter(ew EventWriter) error {
+	if flusher, ok := ew.(Flusher); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+// writeContext writes the event to the EventWriter, using WriteContext if it
+// implements ContextEventWriter.
+func writeContext(ctx context.Context, ew EventWriter, event Event) error {
+	if cew, ok := ew.(ContextEventWriter); ok {
+		return cew.WriteContext(ctx, event)
+	}
+	return ew.Write(event)
+}
+
+// supportsBatch returns true if the EventWriter batches events, see
+// BatchEventWriter.
+func supportsBatch(ew EventWriter) bool {
+	bew, ok := ew.(BatchEventWriter)
+	return ok && bew.SupportsBatch()
+}
+
+// rotateEventWriter rotates the EventWriter, if it implements Rotator.
+func rotateEventWriter(ew EventWriter) error {
+	if rotator, ok := ew.(Rotator); ok {
+		return rotator.Rotate()
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFiltered(t *testing.T) {
+	defer reset()
+	var ew eventWriter
+	Start(Filtered(&ew, MustCompileFilter(`type >= Warn`).Match))
+
+	Info(Tags{"filter"}, "Info message")
+	Warn(Tags{"filter"}, "Warn message")
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if !ew.closed {
+		t.Fatal("Expected the EventWriter to be closed")
+	}
+	if len(ew.events) != 1 || ew.events[0].Message != "Warn message" {
+		t.Fatalf("Expected only the warning to be written, but got %v", ew.events)
+	}
+}
+
+func TestTransformed(t *testing.T) {
+	defer reset()
+	var ew, other eventWriter
+	Start(Transformed(&ew, func(event Event) Event {
+		event.Tags = append(Tags{"transformed"}, event.Tags...)
+		return event
+	}), &other)
+
+	Info(Tags{"transform"}, "Info message")
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if len(ew.events) != 1 || ew.events[0].Tags.String() != "transformed, transform" {
+		t.Fatalf("Expected the event to be transformed, but got %v", ew.events)
+	}
+	if len(other.events) != 1 || other.events[0].Tags.String() != "transform" {
+		t.Fatalf("Expected the event of other EventWriters not be modified, but got %v", other.events)
+	}
+}
+
+func TestTee(t *testing.T) {
+	defer reset()
+	var ew eventWriter
+	flaky := flakyEventWriter{failures: 2}
+	Start(Tee(&ew, &flaky))
+
+	Info(Tags{"tee"}, "Info message")
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if !ew.closed || !flaky.closed {
+		t.Fatal("Expected all EventWriters to be closed")
+	}
+	if len(ew.events) != 1 || len(ew.errors) != 0 {
+		t.Fatalf("Expected the event to be written once, but got %v", ew.events)
+	}
+	if len(flaky.events) != 1 || len(flaky.errors) != 2 {
+		t.Fatalf("Expected the event to be written after 2 failures, but got events %v and errors %v",
+			flaky.events, flaky.errors)
+	}
+}
+
+func TestDecoratorsForwarding(t *testing.T) {
+	all := func(Event) bool { return true }
+	same := func(event Event) Event { return event }
+	decorators := []struct {
+		name     string
+		decorate func(EventWriter) EventWriter
+	}{
+		{"Filtered", func(ew EventWriter) EventWriter { return Filtered(ew, all) }},
+		{"Transformed", func(ew EventWriter) EventWriter { return Transformed(ew, same) }},
+		{"Tee", func(ew EventWriter) EventWriter { return Tee(&eventWriter{}, ew) }},
+	}
+
+	for _, decorator := range decorators {
+		t.Run(decorator.name, func(t *testing.T) {
+			if batchFlusher(decorator.decorate(&batchEventWriter{batch: true})) == nil {
+				t.Fatal("Expected the decorated BatchEventWriter to support batches")
+			} else if batchFlusher(decorator.decorate(&batchEventWriter{})) != nil {
+				t.Fatal("Expected the decorated EventWriter not to support batches")
+			}
+
+			defer reset()
+			SetWriteTimeout(time.Second)
+			var ew slowEventWriter
+			ew.slow = true
+			Start(decorator.decorate(&ew))
+			Info(Tags{"decorator"}, "Info message")
+			if err := Close(); err != nil {
+				t.Fatal("Unexpected error closing: " + err.Error())
+			}
+			if !ew.hasDeadline || len(ew.events) != 1 {
+				t.Fatalf("Expected the event to be written with a deadline, but got %v", ew.events)
+			}
+		})
+	}
+}
+
+func TestFilteredEncoded(t *testing.T) {
+	defer reset()
+	ew := encodedEventWriter{encoding: JSONEncoding}
+	Start(Filtered(&ew, MustCompileFilter(`type >= Warn`).Match))
+
+	Info(Tags{"filter"}, "Info message")
+	Warn(Tags{"filter"}, "Warn message")
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if len(ew.encoded) != 1 || len(ew.events) != 1 || ew.events[0].Message != "Warn message" {
+		t.Fatalf("Expected only the encoded warning to be written, but got %v", ew.events)
+	}
+}
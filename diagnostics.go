@@ -0,0 +1,20 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+var diagnosticsWriter EventWriter
+
+// SetDiagnosticsEventWriter sets a dedicated EventWriter, e.g. one that writes
+// to standard error, for the logger's own operational events. These events,
+// such as an EventWriter panicking or being dropped after too many errors, are
+// tagged with "logger". By default they're written to all other EventWriters,
+// once set they're only written to ew and not mixed with the application's
+// events. The diagnostics EventWriter is closed by Close, after the other
+// EventWriters. Passing nil restores the default.
+//
+// Note: this must be called before Start and is not safe for concurrent use.
+func SetDiagnosticsEventWriter(ew EventWriter) {
+	diagnosticsWriter = ew
+}
@@ -0,0 +1,74 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"errors"
+	"testing"
+)
+
+// badEventWriter always fails to write, it signals bad once it's considered
+// bad.
+type badEventWriter struct {
+	eventWriter
+	bad chan struct{}
+}
+
+func (ew *badEventWriter) Write(event Event) error {
+	return errors.New("write error")
+}
+
+func (ew *badEventWriter) HandleError(err error) {
+	ew.eventWriter.HandleError(err)
+	if errors.Is(err, ErrBadEventWriter) {
+		close(ew.bad)
+	}
+}
+
+func TestDiagnosticsEventWriter(t *testing.T) {
+	defer reset()
+	var diag, ew eventWriter
+	bad := badEventWriter{bad: make(chan struct{})}
+	SetDiagnosticsEventWriter(&diag)
+	Start(&bad, &ew)
+
+	Info(Tags{"test"}, "message")
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if len(ew.events) != 1 || ew.events[0].Message != "message" {
+		t.Fatalf("Expected only the application event, but got %v", ew.events)
+	}
+
+	expected := "EventWriter *logger.badEventWriter is bad and is dropped"
+	if len(diag.events) != 1 {
+		t.Fatalf("Expected a single diagnostics event, but got %v", diag.events)
+	} else if event := diag.events[0]; event.Type != ErrorEvent ||
+		event.Message != expected || event.Tags.String() != "logger" {
+		t.Fatalf("Expected an Error event with message %q, but got %v", expected, event)
+	}
+	if !diag.closed {
+		t.Fatal("Expected the diagnostics EventWriter to be closed")
+	}
+}
+
+func TestBadEventWriterInternalEvent(t *testing.T) {
+	defer reset()
+	var ew eventWriter
+	bad := badEventWriter{bad: make(chan struct{})}
+	Start(&bad, &ew)
+
+	Info(Tags{"test"}, "message")
+	<-bad.bad
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	expected := "EventWriter *logger.badEventWriter is bad and is dropped"
+	if len(ew.events) != 2 || ew.events[1].Message != expected {
+		t.Fatalf("Expected the application event and an Error event with message %q, but got %v", expected, ew.events)
+	}
+}
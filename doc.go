@@ -12,14 +12,20 @@
 // logging is done asynchronously. The logger package can used simultaneously
 // from multiple goroutines.
 //
+// Before Start is called the log operations don't block, the first events are
+// buffered until Start is called and the others are dropped, see
+// SetPreStartBuffer. So libraries can use the logger package without requiring
+// the application to use it as well.
+//
 // Because the logger package is asynchronous Close must be called before the
 // program exits, this way logger will make sure all log event will be written.
-// After Close is called all calls to any log operation will panic. This is
-// because internally the logger package uses a channel to make the logging
-// asynchronous and sending to a closed channel will panic.
+// After Close is called all calls to any log operation are dropped, see
+// DroppedAfterClose. If the program must exit with a status code use Exit,
+// rather then os.Exit, which closes the logger and runs the cleanup functions
+// registered with AtExit before exiting.
 //
-// By default there are six different event types (from lower to higher): debug,
-// info, warn, error, fatal and thumb. But new event types can be created using
-// NewEventType. These can then be used in a custom EventWriter to extract data
-// from Event.Data.
+// By default there are nine different event types (from lower to higher):
+// trace, debug, info, notice, warn, error, fatal, security and thumb. But new
+// event types can be created using NewEventType. These can then be used in a
+// custom EventWriter to extract data from Event.Data.
 package logger
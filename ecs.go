@@ -0,0 +1,150 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"bytes"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger/internal/json"
+	"github.com/Thomasdezeeuw/logger/internal/util"
+)
+
+// ECSVersion is the version of the Elastic Common Schema used by
+// ECSFormatter.
+const ECSVersion = "1.12.0"
+
+// ECSFormatter formats events as JSON following the Elastic Common Schema
+// (ECS), see https://www.elastic.co/guide/en/ecs/current/index.html. This
+// allows events to be stored in Elasticsearch and used in Kibana without an
+// ingest pipeline. Each event is followed by a newline.
+//
+// The event is mapped to the following ECS fields:
+//
+//	Timestamp -> @timestamp
+//	Type      -> log.level (in lowercase)
+//	Tags      -> tags, key=value tags (see Tag) are added to labels.
+//	Message   -> message
+//	Data      -> labels, if data is a map or any other data type that isn't a
+//	             stack trace, the latter is stored in the "data" label.
+//	Data      -> error.stack_trace, if data is a stack trace, e.g. for Fatal
+//	             events.
+//	Seq       -> event.sequence, if not zero.
+type ECSFormatter struct{}
+
+// Format appends the ECS JSON formatted event to buf.
+func (ECSFormatter) Format(buf []byte, event Event) ([]byte, error) {
+	buf = append(buf, `{"@timestamp":"`...)
+	buf = event.Timestamp.UTC().AppendFormat(buf, time.RFC3339Nano)
+	buf = append(buf, `","log.level":`...)
+	buf = json.AppendString(buf, strings.ToLower(event.Type.String()))
+	buf = append(buf, `,"message":`...)
+	buf = json.AppendString(buf, event.Message)
+	buf = append(buf, `,"tags":`...)
+	buf, fields := appendPlainTagsJSON(buf, event.Tags, true)
+	if event.Seq != 0 {
+		buf = append(buf, `,"event.sequence":`...)
+		buf = strconv.AppendUint(buf, event.Seq, 10)
+	}
+
+	buf, labels := appendECSData(buf, event.Data)
+	if fields {
+		labels = addTagLabels(labels, event.Tags)
+	}
+	if labels != nil {
+		buf = appendECSLabels(buf, labels)
+	}
+
+	buf = append(buf, `,"ecs.version":"`+ECSVersion+`"}`...)
+	return append(buf, '\n'), nil
+}
+
+// appendECSData appends the data if it's a stack trace, otherwise it returns
+// the data as labels.
+func appendECSData(buf []byte, data interface{}) ([]byte, map[string]string) {
+	switch data := data.(type) {
+	case nil:
+	case map[string]string:
+		return buf, data
+	case map[string]interface{}:
+		labels := make(map[string]string, len(data))
+		for key, value := range data {
+			labels[key] = util.InterfaceToString(value)
+		}
+		return buf, labels
+	case []StackFrame:
+		buf = append(buf, `,"error.stack_trace":`...)
+		buf = json.AppendString(buf, stackFramesString(data))
+	case []byte:
+		if _, ok := parseStackTrace(data); ok {
+			buf = append(buf, `,"error.stack_trace":`...)
+			buf = json.AppendString(buf, string(data))
+			break
+		}
+		return buf, map[string]string{"data": string(data)}
+	default:
+		return buf, map[string]string{"data": util.InterfaceToString(data)}
+	}
+	return buf, nil
+}
+
+// addTagLabels returns the labels with the key=value tags added, the labels
+// take precedence.
+func addTagLabels(labels map[string]string, tags Tags) map[string]string {
+	tagLabels := make(map[string]string, len(labels)+len(tags))
+	for i := len(tags) - 1; i >= 0; i-- {
+		if key, value, ok := splitTag(tags[i]); ok {
+			tagLabels[key] = value
+		}
+	}
+	for key, value := range labels {
+		tagLabels[key] = value
+	}
+	return tagLabels
+}
+
+func appendECSLabels(buf []byte, labels map[string]string) []byte {
+	// Sort the keys to get a stable output.
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	buf = append(buf, `,"labels":{`...)
+	for i, key := range keys {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+		buf = json.AppendString(buf, key)
+		buf = append(buf, ':')
+		buf = json.AppendString(buf, labels[key])
+	}
+	return append(buf, '}')
+}
+
+func stackFramesString(frames []StackFrame) string {
+	var buf bytes.Buffer
+	for i, frame := range frames {
+		if i != 0 {
+			buf.WriteByte(newLine)
+		}
+		buf.WriteString(frame.String())
+	}
+	return buf.String()
+}
+
+// NewECSEventWriter creates a new EventWriter that writes Elastic Common
+// Schema JSON formatted events, see ECSFormatter, to the given writer.
+// MinType is the minimal EventType an event must have to be logged. For
+// example if minType is InfoEvent, then any events with an EventType of
+// DebugEvent will not be logged.
+func NewECSEventWriter(minType EventType, w io.Writer, errorHandler func(error)) EventWriter {
+	return NewFormatEventWriter(minType, w, ECSFormatter{}, errorHandler)
+}
@@ -0,0 +1,76 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestECSFormatter(t *testing.T) {
+	t.Parallel()
+
+	const prefix = `{"@timestamp":"2015-09-01T14:22:36Z",`
+	const suffix = `"ecs.version":"` + ECSVersion + `"}` + "\n"
+	stackTrace := "goroutine 1 [running]:\nmain.main()\n\t/main.go:10 +0x20\n"
+
+	tests := []struct {
+		event    Event
+		expected string
+	}{
+		{Event{InfoEvent, t1, Tags{"tag1", "tag2"}, "Message", nil, 0},
+			prefix + `"log.level":"info","message":"Message","tags":["tag1","tag2"],` + suffix},
+		{Event{WarnEvent, t1, Tags{}, "Message", map[string]interface{}{"user": 1, "a": "b"}, 0},
+			prefix + `"log.level":"warn","message":"Message","tags":[],` +
+				`"labels":{"a":"b","user":"1"},` + suffix},
+		{Event{ErrorEvent, t1, Tags{}, "Message", "data", 0},
+			prefix + `"log.level":"error","message":"Message","tags":[],` +
+				`"labels":{"data":"data"},` + suffix},
+		{Event{FatalEvent, t1, Tags{}, "Message", []byte(stackTrace), 0},
+			prefix + `"log.level":"fatal","message":"Message","tags":[],` +
+				`"error.stack_trace":"goroutine 1 [running]:\nmain.main()\n\t/main.go:10 +0x20\n",` + suffix},
+		{Event{FatalEvent, t1, Tags{}, "Message", []StackFrame{{"main.main", "/main.go", 10}}, 0},
+			prefix + `"log.level":"fatal","message":"Message","tags":[],` +
+				`"error.stack_trace":"main.main\n\t/main.go:10",` + suffix},
+		{Event{InfoEvent, t1, Tags{"tag1", Tag("user", 1), Tag("a", "c"), Tag("a", "d")}, "Message",
+			map[string]string{"a": "b"}, 0},
+			prefix + `"log.level":"info","message":"Message","tags":["tag1"],` +
+				`"labels":{"a":"b","user":"1"},` + suffix},
+		{Event{InfoEvent, t1, Tags{Tag("user", 1), Tag("user", 2)}, "Message", nil, 0},
+			prefix + `"log.level":"info","message":"Message","tags":[],` +
+				`"labels":{"user":"1"},` + suffix},
+		{Event{InfoEvent, t1, Tags{}, "Message", nil, 3},
+			prefix + `"log.level":"info","message":"Message","tags":[],"event.sequence":3,` + suffix},
+	}
+
+	for _, test := range tests {
+		got, err := ECSFormatter{}.Format(nil, test.event)
+		if err != nil {
+			t.Fatal("Unexpected error formatting event: " + err.Error())
+		} else if string(got) != test.expected {
+			t.Errorf("Expected ECSFormatter to return:\n%s\nBut got:\n%s", test.expected, got)
+		}
+
+		var decoded map[string]interface{}
+		if err := json.Unmarshal(got, &decoded); err != nil {
+			t.Errorf("Unexpected error unmarshaling %s: %s", got, err.Error())
+		}
+	}
+}
+
+func TestECSEventWriter(t *testing.T) {
+	var buf bytes.Buffer
+	ew := NewECSEventWriter(InfoEvent, &buf, func(error) {})
+
+	ew.Write(Event{DebugEvent, t1, Tags{}, "Never gets logged", nil, 0})
+	ew.Write(Event{InfoEvent, t1, Tags{}, "Message", nil, 0})
+
+	expected := `{"@timestamp":"2015-09-01T14:22:36Z","log.level":"info",` +
+		`"message":"Message","tags":[],"ecs.version":"` + ECSVersion + `"}` + "\n"
+	if got := buf.String(); got != expected {
+		t.Fatalf("Expected buffer to contain:\n%s\nBut got:\n%s", expected, got)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Encoding is an encoding of events, see EncodedEventWriter.
+type Encoding uint8
+
+// Encodings supported by EncodedEventWriter.
+const (
+	// TextEncoding is the format of Event.String, followed by a newline.
+	TextEncoding Encoding = iota + 1
+
+	// JSONEncoding is the format of Event.MarshalJSON, followed by a newline.
+	JSONEncoding
+
+	// ProtobufEncoding is the format of Event.MarshalProtobuf, it's not
+	// delimited.
+	ProtobufEncoding
+
+	// Number of Encodings, including the invalid zero Encoding.
+	numEncodings = iota + 1
+)
+
+// String returns the name of the Encoding.
+func (encoding Encoding) String() string {
+	switch encoding {
+	case TextEncoding:
+		return "text"
+	case JSONEncoding:
+		return "json"
+	case ProtobufEncoding:
+		return "protobuf"
+	}
+	return "unknown"
+}
+
+// EncodedEventWriter is an EventWriter that writes events in a single
+// Encoding, e.g. an EventWriter that writes JSON to the network. Rather then
+// calling Write the logger encodes every event once per Encoding, shared by
+// all EventWriters that prefer the same Encoding, and calls WriteEncoded. This
+// way multiple EventWriters don't have to encode the same event.
+type EncodedEventWriter interface {
+	EventWriter
+
+	// Encoding returns the preferred Encoding of the EventWriter, it's called
+	// once, when the logger is started.
+	Encoding() Encoding
+
+	// WriteEncoded is called instead of Write with the event and the encoded
+	// event. The encoded event is shared with the other EventWriters, and
+	// reused for other events once written, it must not be modified or
+	// retained after WriteEncoded returns.
+	WriteEncoded(event Event, encoded []byte) error
+}
+
+// queuedEvent is an event queued for a single EventWriter. Encoded is the
+// event in the Encoding of the EventWriter, or nil. Once written, or dropped,
+// done must be called to release the encoded event.
+type queuedEvent struct {
+	Event
+	encoded []byte
+	buffer  *encodedBuffer
+}
+
+// done releases the encoded event, if any.
+func (event queuedEvent) done() {
+	event.buffer.release()
+}
+
+// encodedBuffer is a pooled buffer that holds an encoded event, shared by all
+// EventWriters with the same Encoding. It's returned to the pool once all
+// EventWriters are done with it.
+type encodedBuffer struct {
+	buf []byte
+	// Number of EventWriters not yet done with the buffer, must be accessed
+	// atomically.
+	refs int32
+}
+
+// Buffers that grew larger then this are not returned to the pool, so a
+// single large event doesn't keep the memory in use.
+const maxPooledBufferSize = 64 * 1024
+
+var encodedBufferPool = sync.Pool{
+	New: func() interface{} { return &encodedBuffer{buf: make([]byte, 0, 512)} },
+}
+
+// release releases a reference to the buffer, returning it to the pool once
+// all references are released.
+func (buffer *encodedBuffer) release() {
+	if buffer == nil || atomic.AddInt32(&buffer.refs, -1) != 0 {
+		return
+	}
+	if cap(buffer.buf) <= maxPooledBufferSize {
+		buffer.buf = buffer.buf[:0]
+		encodedBufferPool.Put(buffer)
+	}
+}
+
+// eventWriterEncoding returns the Encoding of the EventWriter, or 0 if it isn't
+// an EncodedEventWriter.
+func eventWriterEncoding(ew EventWriter) Encoding {
+	if eew, ok := ew.(EncodedEventWriter); ok {
+		if encoding := eew.Encoding(); encoding > 0 && encoding < numEncodings {
+			return encoding
+		}
+	}
+	return 0
+}
+
+// encodeEvent encodes the event, appending it to buf.
+func encodeEvent(buf []byte, event Event, encoding Encoding) []byte {
+	switch encoding {
+	case TextEncoding:
+		return append(event.AppendText(buf, TimestampFormat{}), '\n')
+	case JSONEncoding:
+		return append(event.AppendJSON(buf), '\n')
+	case ProtobufEncoding:
+		return event.AppendProtobuf(buf)
+	}
+	return buf
+}
+
+// encodeEvents encodes the event once for each of the encodings, using pooled
+// buffers, the buffers are stored in encoded indexed by Encoding. The
+// references of the buffers must be set by the caller.
+func encodeEvents(event Event, encodings []Encoding, encoded *[numEncodings]*encodedBuffer) {
+	*encoded = [numEncodings]*encodedBuffer{}
+	for _, encoding := range encodings {
+		if encoding != 0 && encoded[encoding] == nil {
+			buffer := encodedBufferPool.Get().(*encodedBuffer)
+			buffer.buf = encodeEvent(buffer.buf[:0], event, encoding)
+			encoded[encoding] = buffer
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// encodedEventWriter is an eventWriter that records the encoded events.
+type encodedEventWriter struct {
+	eventWriter
+	encoding Encoding
+	encoded  [][]byte
+}
+
+func (ew *encodedEventWriter) Encoding() Encoding {
+	return ew.encoding
+}
+
+func (ew *encodedEventWriter) WriteEncoded(event Event, encoded []byte) error {
+	ew.encoded = append(ew.encoded, encoded)
+	return ew.Write(event)
+}
+
+func TestEncodedEventWriter(t *testing.T) {
+	defer reset()
+	json1 := encodedEventWriter{encoding: JSONEncoding}
+	json2 := encodedEventWriter{encoding: JSONEncoding}
+	text := encodedEventWriter{encoding: TextEncoding}
+	Start(&json1, &json2, &text)
+
+	event := Event{Type: InfoEvent, Timestamp: time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC),
+		Tags: Tags{"encoding"}, Message: "Info message"}
+	Log(event)
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	for _, ew := range []*encodedEventWriter{&json1, &json2, &text} {
+		if len(ew.encoded) != 1 || len(ew.events) != 1 {
+			t.Fatalf("Expected a single encoded event, but got %v", ew.events)
+		}
+	}
+
+	event.Seq = 1
+	expected := string(append(event.AppendJSON(nil), '\n'))
+	if got := string(json1.encoded[0]); got != expected {
+		t.Fatalf("Expected the event to be encoded as %q, but got %q", expected, got)
+	}
+	if &json1.encoded[0][0] != &json2.encoded[0][0] {
+		t.Fatal("Expected the encoded event to be shared between the EventWriters")
+	}
+
+	expected = "2015-09-01 14:22:36 [Info] encoding: Info message\n"
+	if got := string(text.encoded[0]); got != expected {
+		t.Fatalf("Expected the event to be encoded as %q, but got %q", expected, got)
+	}
+}
+
+// checkingEventWriter is an EncodedEventWriter that checks the encoded events
+// against the events.
+type checkingEventWriter struct {
+	eventWriter
+	encoding Encoding
+}
+
+func (ew *checkingEventWriter) Encoding() Encoding {
+	return ew.encoding
+}
+
+func (ew *checkingEventWriter) WriteEncoded(event Event, encoded []byte) error {
+	if expected := encodeEvent(nil, event, ew.encoding); string(encoded) != string(expected) {
+		ew.errors = append(ew.errors, fmt.Errorf("expected the encoded event %q, but got %q", expected, encoded))
+	}
+	return ew.Write(event)
+}
+
+func TestEncodedBufferReuse(t *testing.T) {
+	defer reset()
+	json1 := checkingEventWriter{encoding: JSONEncoding}
+	json2 := checkingEventWriter{encoding: JSONEncoding}
+	text := checkingEventWriter{encoding: TextEncoding}
+	Start(&json1, &json2, &text)
+
+	const n = 1000
+	for i := 0; i < n; i++ {
+		Info(Tags{"encoding"}, strings.Repeat("a", i%100))
+	}
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	for _, ew := range []*checkingEventWriter{&json1, &json2, &text} {
+		if len(ew.errors) != 0 {
+			t.Fatalf("Unexpected error(s): %v", ew.errors[0])
+		} else if len(ew.events) != n {
+			t.Fatalf("Expected %d events, but got %d", n, len(ew.events))
+		}
+	}
+}
+
+func TestEncodingString(t *testing.T) {
+	tests := []struct {
+		encoding Encoding
+		expected string
+	}{
+		{TextEncoding, "text"},
+		{JSONEncoding, "json"},
+		{ProtobufEncoding, "protobuf"},
+		{0, "unknown"},
+	}
+
+	for _, test := range tests {
+		if got := test.encoding.String(); got != test.expected {
+			t.Errorf("Expected Encoding %d to be %q, but got %q", test.encoding, test.expected, got)
+		}
+	}
+}
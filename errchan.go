@@ -0,0 +1,98 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"context"
+	"fmt"
+)
+
+// EventWriteCloser is an EventWriter without the error handler, see
+// WithErrorChannel.
+type EventWriteCloser interface {
+	Write(Event) error
+	Close() error
+}
+
+// WriterError is an error returned by an EventWriter, see WithErrorChannel.
+type WriterError struct {
+	Writer EventWriteCloser
+	Err    error
+}
+
+func (err WriterError) Error() string {
+	return fmt.Sprintf("EventWriter %T: %s", err.Writer, err.Err)
+}
+
+// Unwrap returns the error returned by the EventWriter.
+func (err WriterError) Unwrap() error {
+	return err.Err
+}
+
+// WithErrorChannel returns an EventWriter, to be passed to Start, that sends
+// the errors of ew to the returned channel, rather then requiring ew to
+// implement HandleError. This includes the *BadEventWriterError if ew is
+// considered bad. The channel is buffered with size errors, if it's full
+// errors are dropped so a slow reader never blocks the logger. The channel is
+// closed after ew is closed.
+//
+// Flush, SupportsBatch, Rotate and WriteContext are passed to ew, if it
+// implements them.
+func WithErrorChannel(ew EventWriteCloser, size int) (EventWriter, <-chan WriterError) {
+	errors := make(chan WriterError, size)
+	return &errorChannelEventWriter{ew, errors}, errors
+}
+
+type errorChannelEventWriter struct {
+	ew     EventWriteCloser
+	errors chan WriterError
+}
+
+func (ew *errorChannelEventWriter) Write(event Event) error {
+	return ew.ew.Write(event)
+}
+
+func (ew *errorChannelEventWriter) WriteContext(ctx context.Context, event Event) error {
+	if cew, ok := ew.ew.(interface {
+		WriteContext(context.Context, Event) error
+	}); ok {
+		return cew.WriteContext(ctx, event)
+	}
+	return ew.ew.Write(event)
+}
+
+func (ew *errorChannelEventWriter) HandleError(err error) {
+	select {
+	case ew.errors <- WriterError{ew.ew, err}:
+	default:
+	}
+}
+
+func (ew *errorChannelEventWriter) Flush() error {
+	if flusher, ok := ew.ew.(Flusher); ok {
+		return flusher.Flush()
+	}
+	return nil
+}
+
+func (ew *errorChannelEventWriter) SupportsBatch() bool {
+	bew, ok := ew.ew.(interface {
+		SupportsBatch() bool
+	})
+	return ok && bew.SupportsBatch()
+}
+
+func (ew *errorChannelEventWriter) Rotate() error {
+	if rotator, ok := ew.ew.(Rotator); ok {
+		return rotator.Rotate()
+	}
+	return nil
+}
+
+func (ew *errorChannelEventWriter) Close() error {
+	err := ew.ew.Close()
+	close(ew.errors)
+	return err
+}
@@ -0,0 +1,63 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"errors"
+	"testing"
+)
+
+// writeCloser is an EventWriteCloser that fails to write the first event.
+type writeCloser struct {
+	events []Event
+	failed bool
+	closed bool
+}
+
+func (ew *writeCloser) Write(event Event) error {
+	if !ew.failed {
+		ew.failed = true
+		return errors.New("Write error")
+	}
+	ew.events = append(ew.events, event)
+	return nil
+}
+
+func (ew *writeCloser) Close() error {
+	ew.closed = true
+	return nil
+}
+
+func TestWithErrorChannel(t *testing.T) {
+	defer reset()
+	var wc writeCloser
+	ew, errs := WithErrorChannel(&wc, 1)
+	Start(ew)
+
+	Info(Tags{"errors"}, "Info message")
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if !wc.closed {
+		t.Fatal("Expected the EventWriter to be closed")
+	}
+	if len(wc.events) != 1 || wc.events[0].Message != "Info message" {
+		t.Fatalf("Expected the event to be written, but got %v", wc.events)
+	}
+
+	var got []WriterError
+	for err := range errs {
+		got = append(got, err)
+	}
+	if len(got) != 1 || got[0].Writer != &wc || got[0].Err.Error() != "Write error" {
+		t.Fatalf("Expected a single write error, but got %v", got)
+	}
+
+	expected := "EventWriter *logger.writeCloser: Write error"
+	if msg := got[0].Error(); msg != expected {
+		t.Fatalf("Expected the error message to be %q, but got %q", expected, msg)
+	}
+}
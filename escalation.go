@@ -0,0 +1,129 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// EscalationPolicy escalates repeated Error events, see SetEscalationPolicy.
+type EscalationPolicy struct {
+	// Threshold is the number of Error events with the same fingerprint,
+	// within Window, after which an escalation event is logged. If zero
+	// Error events are never escalated.
+	Threshold int
+
+	// Window is the period in which Threshold events must occur.
+	Window time.Duration
+
+	// Type of the escalation event, defaults to FatalEvent if zero. This can
+	// be a custom EventType, e.g. one created with NewEventType("Alert").
+	Type EventType
+
+	// Fingerprint returns the fingerprint of an Error event, Error events
+	// with the same fingerprint are considered the same. Defaults to
+	// Fingerprint.
+	Fingerprint func(Event) string
+}
+
+// EscalationTag is added to the escalation events, see SetEscalationPolicy.
+const EscalationTag = "escalated"
+
+// maxEscalationFingerprints is the number of fingerprints after which the
+// fingerprints without Error events in the window are removed.
+const maxEscalationFingerprints = 1024
+
+var escalationPolicy EscalationPolicy
+
+// SetEscalationPolicy sets the policy used to escalate repeated Error events.
+// If Threshold Error events with the same fingerprint are logged within the
+// Window an escalation event is logged, e.g. a Fatal event, so paging rules
+// can depend on it. The escalation event has the tags of the last Error event
+// and EscalationTag, its message includes the number of Error events and the
+// message of the last one. After an escalation the count for the fingerprint
+// starts over.
+//
+// For example to log a Fatal event if the same error is logged 10 times
+// within a minute:
+//
+//	logger.SetEscalationPolicy(logger.EscalationPolicy{
+//		Threshold: 10,
+//		Window:    time.Minute,
+//	})
+//
+// Note: this must be called before Start and is not safe for concurrent use.
+func SetEscalationPolicy(policy EscalationPolicy) {
+	if policy.Type == 0 {
+		policy.Type = FatalEvent
+	}
+	if policy.Fingerprint == nil {
+		policy.Fingerprint = Fingerprint
+	}
+	escalationPolicy = policy
+}
+
+// Fingerprint returns the fingerprint of an event, derived from the message
+// and tags of the event.
+func Fingerprint(event Event) string {
+	return event.Message + "\x00" + strings.Join(event.Tags, "\x00")
+}
+
+// escalator counts the Error events per fingerprint, it's only used in the
+// goroutine that fans out the events.
+type escalator struct {
+	policy EscalationPolicy
+	// Times of the Error events within the window, per fingerprint.
+	times map[string][]time.Time
+}
+
+func newEscalator(policy EscalationPolicy) *escalator {
+	if policy.Threshold <= 0 {
+		return nil
+	}
+	return &escalator{policy: policy, times: make(map[string][]time.Time)}
+}
+
+// record records the event and returns the escalation event, if the event
+// triggers an escalation.
+func (e *escalator) record(event Event) (Event, bool) {
+	if e == nil || event.Type != ErrorEvent {
+		return Event{}, false
+	}
+
+	start := event.Timestamp.Add(-e.policy.Window)
+	if len(e.times) >= maxEscalationFingerprints {
+		for fingerprint, times := range e.times {
+			if !times[len(times)-1].After(start) {
+				delete(e.times, fingerprint)
+			}
+		}
+	}
+
+	// Drop the events outside of the window.
+	fingerprint := e.policy.Fingerprint(event)
+	times := e.times[fingerprint]
+	n := 0
+	for _, t := range times {
+		if t.After(start) {
+			times[n] = t
+			n++
+		}
+	}
+	times = append(times[:n], event.Timestamp)
+	if len(times) < e.policy.Threshold {
+		e.times[fingerprint] = times
+		return Event{}, false
+	}
+	delete(e.times, fingerprint)
+
+	tags := make(Tags, 0, len(event.Tags)+1)
+	tags = append(append(tags, event.Tags...), EscalationTag)
+	msg := fmt.Sprintf("Error logged %d times within %s: %s",
+		len(times), e.policy.Window, event.Message)
+	return Event{Type: e.policy.Type, Timestamp: event.Timestamp, Tags: tags,
+		Message: msg, Data: event.Data}, true
+}
@@ -0,0 +1,86 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEscalationPolicy(t *testing.T) {
+	defer reset()
+	SetEscalationPolicy(EscalationPolicy{Threshold: 3, Window: time.Minute})
+	var ew eventWriter
+	Start(&ew)
+
+	tags := Tags{"db"}
+	events := []Event{
+		{Type: ErrorEvent, Timestamp: t1, Tags: tags, Message: "timeout"},
+		{Type: ErrorEvent, Timestamp: t1.Add(10 * time.Second), Tags: tags, Message: "timeout"},
+		// Different fingerprints.
+		{Type: ErrorEvent, Timestamp: t1.Add(20 * time.Second), Tags: tags, Message: "other"},
+		{Type: ErrorEvent, Timestamp: t1.Add(20 * time.Second), Message: "timeout"},
+		{Type: WarnEvent, Timestamp: t1.Add(20 * time.Second), Tags: tags, Message: "timeout"},
+		// Third within the window.
+		{Type: ErrorEvent, Timestamp: t1.Add(30 * time.Second), Tags: tags, Message: "timeout", Data: 1},
+		// Count starts over after the escalation.
+		{Type: ErrorEvent, Timestamp: t1.Add(40 * time.Second), Tags: tags, Message: "timeout"},
+		{Type: ErrorEvent, Timestamp: t1.Add(50 * time.Second), Tags: tags, Message: "timeout"},
+		// First two outside of the window.
+		{Type: ErrorEvent, Timestamp: t1.Add(2 * time.Minute), Tags: tags, Message: "timeout"},
+	}
+	for _, event := range events {
+		Forward(event)
+	}
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	escalation := Event{Type: FatalEvent, Timestamp: t1.Add(30 * time.Second),
+		Tags: Tags{"db", EscalationTag}, Message: "Error logged 3 times within 1m0s: timeout",
+		Data: 1, Seq: 7}
+	if len(ew.events) != len(events)+1 {
+		t.Fatalf("Expected %d events, but got %d: %v", len(events)+1, len(ew.events), ew.events)
+	} else if got := ew.events[6]; !reflect.DeepEqual(got, escalation) {
+		t.Fatalf("Expected the escalation event %v, but got %v", escalation, got)
+	}
+}
+
+func TestEscalationPolicyCustom(t *testing.T) {
+	defer reset()
+	alertType := SecurityEvent
+	SetEscalationPolicy(EscalationPolicy{
+		Threshold:   2,
+		Window:      time.Minute,
+		Type:        alertType,
+		Fingerprint: func(event Event) string { return "all" },
+	})
+	var ew eventWriter
+	Start(&ew)
+
+	Forward(Event{Type: ErrorEvent, Timestamp: t1, Message: "Error 1"})
+	Forward(Event{Type: ErrorEvent, Timestamp: t1, Message: "Error 2"})
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if len(ew.events) != 3 {
+		t.Fatalf("Expected 3 events, but got %d: %v", len(ew.events), ew.events)
+	} else if got := ew.events[2]; got.Type != alertType || got.Message != "Error logged 2 times within 1m0s: Error 2" {
+		t.Fatalf("Expected an escalation event of type %s, but got %v", alertType, got)
+	}
+}
+
+func TestEscalatorPrune(t *testing.T) {
+	e := newEscalator(EscalationPolicy{Threshold: 2, Window: time.Minute, Fingerprint: Fingerprint})
+	for i := 0; i < maxEscalationFingerprints; i++ {
+		e.record(Event{Type: ErrorEvent, Timestamp: t1, Message: string(rune('a' + i))})
+	}
+	e.record(Event{Type: ErrorEvent, Timestamp: t1.Add(2 * time.Minute), Message: "new"})
+	if len(e.times) != 1 {
+		t.Fatalf("Expected the old fingerprints to be removed, but got %d fingerprints", len(e.times))
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Thomasdezeeuw/logger/internal/util"
+)
+
+const hexDigits = "0123456789abcdef"
+
+// AppendEscapedText does the same as Event.AppendText, but escapes newlines,
+// other control characters and backslashes in the tags, message and data. This
+// guarantees that every event is written on a single line, which is required
+// by log shippers that split on newlines, see WithEscaping.
+//
+// Newlines, carriage returns and tabs are escaped as \n, \r and \t, other
+// control characters as \xHH and backslashes as \\.
+func (event Event) AppendEscapedText(buf []byte, format TimestampFormat) []byte {
+	buf = format.Append(buf, event.Timestamp)
+	buf = append(buf, " ["...)
+	buf = append(buf, event.Type.String()...)
+	buf = append(buf, "] "...)
+	for i, tag := range event.Tags {
+		if i != 0 {
+			buf = append(buf, ", "...)
+		}
+		buf = appendEscaped(buf, tag)
+	}
+	buf = append(buf, ": "...)
+	buf = appendEscaped(buf, event.Message)
+	if event.Data != nil {
+		buf = append(buf, ", "...)
+		buf = appendEscaped(buf, util.InterfaceToString(event.Data))
+	}
+	return buf
+}
+
+// appendEscaped appends s to buf, escaping control characters and backslashes.
+func appendEscaped(buf []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\':
+			buf = append(buf, '\\', '\\')
+		case c == '\n':
+			buf = append(buf, '\\', 'n')
+		case c == '\r':
+			buf = append(buf, '\\', 'r')
+		case c == '\t':
+			buf = append(buf, '\\', 't')
+		case c < 0x20 || c == 0x7f:
+			buf = append(buf, '\\', 'x', hexDigits[c>>4], hexDigits[c&0xf])
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return buf
+}
+
+// unescape reverses appendEscaped. Invalid escape sequences are kept as is.
+func unescape(s string) string {
+	if strings.IndexByte(s, '\\') == -1 {
+		return s
+	}
+
+	buf := make([]byte, 0, len(s))
+	for i := 0; i < len(s); {
+		if c, n := unescapeSequence(s[i:]); n != 0 {
+			buf = append(buf, c)
+			i += n
+			continue
+		}
+		buf = append(buf, s[i])
+		i++
+	}
+	return string(buf)
+}
+
+// unescapeSequence returns the unescaped byte of the escape sequence at the
+// start of s and the length of the sequence, or 0 if s doesn't start with a
+// valid escape sequence.
+func unescapeSequence(s string) (byte, int) {
+	if len(s) < 2 || s[0] != '\\' {
+		return 0, 0
+	} else if c, ok := unescapedBytes[s[1]]; ok {
+		return c, 2
+	} else if s[1] == 'x' && len(s) >= 4 && isHex(s[2]) && isHex(s[3]) {
+		return unhex(s[2])<<4 | unhex(s[3]), 4
+	}
+	return 0, 0
+}
+
+// unescapedBytes maps the character after the backslash of the two character
+// escape sequences to the unescaped byte.
+var unescapedBytes = map[byte]byte{'\\': '\\', 'n': '\n', 'r': '\r', 't': '\t'}
+
+func isHex(c byte) bool {
+	return ('0' <= c && c <= '9') || ('a' <= c && c <= 'f')
+}
+
+func unhex(c byte) byte {
+	if c <= '9' {
+		return c - '0'
+	}
+	return c - 'a' + 10
+}
+
+// ErrEscapingUnsupported is returned by WithEscaping if the EventWriter doesn't
+// support escaping.
+var ErrEscapingUnsupported = errors.New("logger: EventWriter doesn't support escaping")
+
+// escapingSetter is implemented by EventWriters that support escaping, see
+// WithEscaping.
+type escapingSetter interface {
+	setEscaping()
+}
+
+// WithEscaping makes the given EventWriter escape newlines and other control
+// characters, see Event.AppendEscapedText, and returns it. This guarantees one
+// event per line. Supported are the EventWriters created by
+// NewFileEventWriter, NewTagFileEventWriter, NewConsoleEventWriter and
+// NewSplitConsoleEventWriter, for other EventWriters ErrEscapingUnsupported is
+// returned. Use NewEscapedParser to parse the written events.
+//
+// Note: this must be called before the EventWriter is passed to Start.
+func WithEscaping(ew EventWriter) (EventWriter, error) {
+	setter, ok := ew.(escapingSetter)
+	if !ok {
+		return nil, ErrEscapingUnsupported
+	}
+	setter.setEscaping()
+	return ew, nil
+}
@@ -0,0 +1,112 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEscaping(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", ""},
+		{"no escaping", "no escaping"},
+		{"multi\nline\r\n", `multi\nline\r\n`},
+		{"tab\tand\\backslash", `tab\tand\\backslash`},
+		{"null\x00 bell\a del\x7f", `null\x00 bell\x07 del\x7f`},
+		{"unicode ✓", "unicode ✓"},
+	}
+
+	for _, test := range tests {
+		got := string(appendEscaped(nil, test.input))
+		if got != test.expected {
+			t.Errorf("Expected %q to be escaped as %q, but got %q", test.input, test.expected, got)
+		}
+
+		if unescaped := unescape(got); unescaped != test.input {
+			t.Errorf("Expected %q to be unescaped as %q, but got %q", got, test.input, unescaped)
+		}
+	}
+
+	// Invalid escape sequences are kept.
+	for _, input := range []string{`\`, `\q`, `\x`, `\x0`, `\xzz`} {
+		if got := unescape(input); got != input {
+			t.Errorf("Expected invalid escape sequence %q to be kept, but got %q", input, got)
+		}
+	}
+}
+
+func TestWithEscaping(t *testing.T) {
+	var buf bytes.Buffer
+	cew := NewConsoleEventWriter(InfoEvent).(*consoleEventWriter)
+	cew.w = &buf
+	ew, err := WithEscaping(cew)
+	if err != nil {
+		t.Fatal("Unexpected error enabling escaping: " + err.Error())
+	}
+
+	t1 := time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC)
+	events := []Event{
+		{Type: InfoEvent, Timestamp: t1, Tags: Tags{"tag\n1"}, Message: "Multi\nline", Data: "da\tta"},
+		{Type: InfoEvent, Timestamp: t1, Tags: Tags{"tag"}, Message: `C:\path`},
+	}
+	for _, event := range events {
+		if err := ew.Write(event); err != nil {
+			t.Fatal("Unexpected error writing event: " + err.Error())
+		}
+	}
+
+	expected := "2015-09-01 14:22:36 [Info] tag\\n1: Multi\\nline, da\\tta\n" +
+		"2015-09-01 14:22:36 [Info] tag: C:\\\\path\n"
+	if got := buf.String(); got != expected {
+		t.Fatalf("Expected buffer to contain %q, but got %q", expected, got)
+	}
+
+	// The data is parsed as part of the message.
+	events[0].Message += ", da\tta"
+	events[0].Data = nil
+	parser := NewEscapedParser(&buf, TimestampFormat{})
+	for i := 0; parser.Scan(); i++ {
+		if got := parser.Event(); !reflect.DeepEqual(got, events[i]) {
+			t.Errorf("Expected event #%d to be %#v, but got %#v", i, events[i], got)
+		}
+	}
+	if err := parser.Err(); err != nil {
+		t.Fatal("Unexpected error parsing: " + err.Error())
+	}
+}
+
+func TestEscapedParserNoContinuations(t *testing.T) {
+	t.Parallel()
+
+	input := "2015-09-01 14:22:36 [Info] : Message\nnot an event\n"
+	parser := NewEscapedParser(bytes.NewBufferString(input), TimestampFormat{})
+
+	var got []Event
+	for parser.Scan() {
+		got = append(got, parser.Event())
+	}
+	if len(got) != 2 || got[0].Message != "Message" {
+		t.Fatalf("Expected 2 events, but got %v", got)
+	} else if parseErr, ok := got[1].Data.(*ParseError); !ok || parseErr.Raw != "not an event" {
+		t.Fatalf("Expected the second event to be a ParseError, but got %v", got[1])
+	}
+}
+
+func TestWithEscapingUnsupported(t *testing.T) {
+	ew, err := WithEscaping(NewECSEventWriter(InfoEvent, &bytes.Buffer{}, func(error) {}))
+	if err != ErrEscapingUnsupported {
+		t.Fatalf("Expected ErrEscapingUnsupported, but got %v", err)
+	} else if ew != nil {
+		t.Fatalf("Expected no EventWriter, but got %v", ew)
+	}
+}
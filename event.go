@@ -15,9 +15,12 @@ import (
 )
 
 // TimeFormat is used in Event.String() and Event.Bytes() to format the
-// timestamp.
+// timestamp, it's also the default layout of TimestampFormat.
 const TimeFormat = "2006-01-02 15:04:05"
 
+// TimeFormatNano is TimeFormat with nanosecond precision, see NanoTimestamp.
+const TimeFormatNano = "2006-01-02 15:04:05.000000000"
+
 // Event is created by a log operation. The timezone of the timestamp is always
 // the current timezone, recommend is to log time in the UTC timezone, by
 // calling Event.Timestamp.UTC(), Event.String and Event.Bytes does this by
@@ -27,12 +30,18 @@ const TimeFormat = "2006-01-02 15:04:05"
 // EventType.String or .Bytes), not it's numeral format. Because the numeral
 // value of an EventType might change, this happens when a new builtin EventType
 // gets added, or if the order of calls to NewEventType changes.
+//
+// Seq is a sequence number set when the event is passed to the EventWriters,
+// starting at 1 and increasing with each event. It can be used to reconstruct
+// the order of events if the timestamps are equal. It's zero for events not
+// created by a log operation.
 type Event struct {
 	Type      EventType
 	Timestamp time.Time
 	Tags      Tags
 	Message   string
 	Data      interface{}
+	Seq       uint64
 }
 
 // String formats an event in the following format:
@@ -44,37 +53,45 @@ type Event struct {
 // wil be:
 //	YYYY-MM-DD HH:MM:SS [TYPE] tag1, tag2: message
 func (event Event) String() string {
-	str := event.Timestamp.UTC().Format(TimeFormat)
-	str += " [" + event.Type.String() + "] "
-	str += event.Tags.String() + ": "
-	str += event.Message
-	if event.Data != nil {
-		str += ", " + util.InterfaceToString(event.Data)
-	}
-	return str
+	return string(event.AppendText(nil, TimestampFormat{}))
 }
 
 // Bytes does the same as Event.String(), but returns a byte slice.
 func (event Event) Bytes() []byte {
-	return []byte(event.String())
+	return event.AppendText(nil, TimestampFormat{})
+}
+
+// AppendText appends the event, in the format of Event.String, to the given
+// buffer and returns the extended buffer. The timestamp is formatted using
+// the given TimestampFormat.
+func (event Event) AppendText(buf []byte, format TimestampFormat) []byte {
+	buf = format.Append(buf, event.Timestamp)
+	buf = append(buf, " ["...)
+	buf = append(buf, event.Type.String()...)
+	buf = append(buf, "] "...)
+	buf = append(buf, event.Tags.String()...)
+	buf = append(buf, ": "...)
+	buf = append(buf, event.Message...)
+	if event.Data != nil {
+		buf = append(buf, ", "...)
+		buf = append(buf, util.InterfaceToString(event.Data)...)
+	}
+	return buf
 }
 
 // MarshalJSON coverts the event to a JSON formatted byte slice. It uses
-// time.RFC3339Nano to format the timestamp.
+// time.RFC3339Nano to format the timestamp. Key=value tags, see Tag, are
+// written as the "fields" object, rather then in the "tags" array. The first
+// field is "schema_version", see SchemaVersion.
 func (event Event) MarshalJSON() ([]byte, error) {
-	tagsJSON, err := event.Tags.MarshalJSON()
-	if err != nil {
-		return []byte{}, err
-	}
+	return event.AppendJSON(nil), nil
+}
 
-	str := fmt.Sprintf(`{"type": %q, "timestamp": %q, "tags": %s, "message": %q`,
-		event.Type.String(), event.Timestamp.UTC().Format(time.RFC3339Nano),
-		string(tagsJSON), event.Message)
-	if event.Data != nil {
-		str += fmt.Sprintf(`, "data": %q`, util.InterfaceToString(event.Data))
-	}
-	str += "}"
-	return []byte(str), nil
+// AppendJSON does the same as Event.MarshalJSON, but appends the JSON to the
+// given buffer and returns the extended buffer. If the buffer has enough
+// capacity this doesn't allocate.
+func (event Event) AppendJSON(buf []byte) []byte {
+	return appendEventJSON(buf, event, false, nil)
 }
 
 // EventType indicates what type a log operation has.
@@ -82,11 +99,14 @@ type EventType uint16
 
 // EventTypes available by default.
 const (
-	DebugEvent EventType = iota
+	TraceEvent EventType = iota // Extremely verbose diagnostics, below Debug.
+	DebugEvent
 	InfoEvent
+	NoticeEvent // Normal but significant events, like syslog's NOTICE.
 	WarnEvent
 	ErrorEvent
 	FatalEvent
+	SecurityEvent // Authentication, authorization and audit-adjacent events.
 	ThumbEvent
 	LogEvent // Used in relaying logs from the default log package.
 )
@@ -94,8 +114,8 @@ const (
 // Names and indices for use in EventType.String and Event.Bytes, can be
 // modified by NewEventType
 var (
-	eventTypeNames   = "DebugInfoWarnErrorFatalThumbLog"
-	eventTypeIndices = []int{0, 5, 9, 13, 18, 23, 28, 31}
+	eventTypeNames   = "TraceDebugInfoNoticeWarnErrorFatalSecurityThumbLog"
+	eventTypeIndices = []int{0, 5, 10, 14, 20, 24, 29, 34, 42, 47, 50}
 )
 
 // String returns the name of the event type. Custom event types are also
@@ -110,8 +130,11 @@ func (eventType EventType) String() string {
 	return eventTypeNames[startIndex:endIndex]
 }
 
+// isDefinedEventType returns false for unknown EventTypes and for reserved
+// EventTypes that are not yet created, see ReserveEventTypes.
 func isDefinedEventType(eventType EventType) bool {
-	return int(eventType) <= len(eventTypeIndices)-1
+	return int(eventType) < len(eventTypeIndices)-1 &&
+		eventTypeIndices[eventType] != eventTypeIndices[eventType+1]
 }
 
 // Bytes does the same as EventType.String(), but returns a byte slice.
@@ -125,9 +148,38 @@ func (eventType EventType) MarshalJSON() ([]byte, error) {
 	return []byte(qoutedEventType), nil
 }
 
-// ErrEventTypeUnknown gets returned by EventType.UnmarshalJSON and
-// EventType.UnmarshalText if the EventType is not known.
-var ErrEventTypeUnknown = errors.New("unkown EventType")
+// Errors used in EventTypeError.
+var (
+	// ErrEventTypeUnknown gets returned by EventType.UnmarshalJSON and
+	// EventType.UnmarshalText if the EventType is not known.
+	ErrEventTypeUnknown = errors.New("unknown EventType")
+
+	// ErrEventTypeEmpty, ErrEventTypeTaken and ErrTooManyEventTypes are used
+	// by NewEventType.
+	ErrEventTypeEmpty    = errors.New("EventType name can't be empty")
+	ErrEventTypeTaken    = errors.New("EventType name already taken")
+	ErrTooManyEventTypes = errors.New("can't have more then 65535 EventTypes")
+)
+
+// EventTypeError is returned, or used as panic value, by functions that
+// convert or create EventTypes. Use errors.Is to check the kind of error, e.g.
+// errors.Is(err, ErrEventTypeUnknown).
+type EventTypeError struct {
+	Name string // Name of the EventType.
+	Err  error  // One of the ErrEventType* errors.
+}
+
+func (err *EventTypeError) Error() string {
+	if err.Name == "" {
+		return "logger: " + err.Err.Error()
+	}
+	return fmt.Sprintf("logger: %s: %q", err.Err, err.Name)
+}
+
+// Unwrap returns the underlying error.
+func (err *EventTypeError) Unwrap() error {
+	return err.Err
+}
 
 // UnmarshalJSON converts a qouted string EventType (e.g. "Error") to an actual
 // typed EventTyped.
@@ -135,13 +187,13 @@ var ErrEventTypeUnknown = errors.New("unkown EventType")
 // Note: custom EventTypes are supported, but must created using NewEventType.
 func (eventType *EventType) UnmarshalJSON(rawType []byte) error {
 	if len(rawType) <= 2 {
-		return ErrEventTypeUnknown
+		return &EventTypeError{string(rawType), ErrEventTypeUnknown}
 	}
 
 	// Drop the qoutes.
 	rawText, err := strconv.Unquote(string(rawType))
 	if err != nil {
-		return ErrEventTypeUnknown
+		return &EventTypeError{string(rawType), ErrEventTypeUnknown}
 	}
 
 	return eventType.UnmarshalText([]byte(rawText))
@@ -153,12 +205,12 @@ func (eventType *EventType) UnmarshalJSON(rawType []byte) error {
 // Note: custom EventTypes are supported, but must created using NewEventType.
 func (eventType *EventType) UnmarshalText(rawType []byte) error {
 	if len(rawType) == 0 {
-		return ErrEventTypeUnknown
+		return &EventTypeError{"", ErrEventTypeUnknown}
 	}
 
 	t, ok := findEventType(string(rawType))
 	if !ok {
-		return ErrEventTypeUnknown
+		return &EventTypeError{string(rawType), ErrEventTypeUnknown}
 	}
 
 	*eventType = t
@@ -167,7 +219,8 @@ func (eventType *EventType) UnmarshalText(rawType []byte) error {
 
 // NewEventType creates a new fully supported custom EventType to be used in
 // logging. This function makes sure that all EventType functions (e.g
-// EventType.String) work correctly. The name can't be empty and must be unique.
+// EventType.String) work correctly. The name can't be empty and must be unique,
+// libraries should use NewNamespacedEventType to avoid collisions.
 //
 // Advised is to store an EventType using it's string format (using
 // EventType.String or .Bytes), not it's numeral format. Because the numeral
@@ -178,16 +231,16 @@ func (eventType *EventType) UnmarshalText(rawType []byte) error {
 // log.
 //
 // Note: The maximum number of custom log levels is 65528, if more are created
-// this function will panic.
+// this function will panic. All panics use an *EventTypeError as value.
 func NewEventType(name string) EventType {
 	if len(eventTypeIndices) >= math.MaxUint16 {
-		panic("logger: can't have more then 65535 EventTypes")
+		panic(&EventTypeError{name, ErrTooManyEventTypes})
 	} else if len(name) == 0 {
-		panic("logger: EventType name can't be empty")
+		panic(&EventTypeError{name, ErrEventTypeEmpty})
 	}
 
 	if _, ok := findEventType(name); ok {
-		panic("logger: EventType must be unique")
+		panic(&EventTypeError{name, ErrEventTypeTaken})
 	}
 
 	eventTypeNames += name
@@ -30,37 +30,37 @@ func TestEvent(t *testing.T) {
 		expected     string
 		expectedJSON string
 	}{
-		{Event{DebugEvent, now, Tags{"tag1", "tag2", "tag3"}, "Message6", 0},
+		{Event{DebugEvent, now, Tags{"tag1", "tag2", "tag3"}, "Message6", 0, 0},
 			tStr + " [Debug] tag1, tag2, tag3: Message6, 0",
-			`{"type": "Debug", "timestamp": "` + tStrNano + `", "tags": ["tag1", "tag2", "tag3"], ` +
+			`{"schema_version": 1, "type": "Debug", "timestamp": "` + tStrNano + `", "tags": ["tag1", "tag2", "tag3"], ` +
 				`"message": "Message6", "data": "0"}`},
-		{Event{InfoEvent, now, Tags{"tag1", "tag2"}, "Message4", []byte("data")},
+		{Event{InfoEvent, now, Tags{"tag1", "tag2"}, "Message4", []byte("data"), 0},
 			tStr + " [Info] tag1, tag2: Message4, data",
-			`{"type": "Info", "timestamp": "` + tStrNano + `", "tags": ["tag1", "tag2"], ` +
+			`{"schema_version": 1, "type": "Info", "timestamp": "` + tStrNano + `", "tags": ["tag1", "tag2"], ` +
 				`"message": "Message4", "data": "data"}`},
-		{Event{WarnEvent, now, Tags{"tag1"}, "Message3", &stringer{}},
+		{Event{WarnEvent, now, Tags{"tag1"}, "Message3", &stringer{}, 0},
 			tStr + " [Warn] tag1: Message3, data",
-			`{"type": "Warn", "timestamp": "` + tStrNano + `", "tags": ["tag1"], ` +
+			`{"schema_version": 1, "type": "Warn", "timestamp": "` + tStrNano + `", "tags": ["tag1"], ` +
 				`"message": "Message3", "data": "data"}`},
-		{Event{ErrorEvent, now, Tags{"tag1"}, "Message2", "data"},
+		{Event{ErrorEvent, now, Tags{"tag1"}, "Message2", "data", 0},
 			tStr + " [Error] tag1: Message2, data",
-			`{"type": "Error", "timestamp": "` + tStrNano + `", "tags": ["tag1"], ` +
+			`{"schema_version": 1, "type": "Error", "timestamp": "` + tStrNano + `", "tags": ["tag1"], ` +
 				`"message": "Message2", "data": "data"}`},
-		{Event{FatalEvent, now, Tags{}, "Message1", nil},
+		{Event{FatalEvent, now, Tags{}, "Message1", nil, 0},
 			tStr + " [Fatal] : Message1",
-			`{"type": "Fatal", "timestamp": "` + tStrNano + `", "tags": [], ` +
+			`{"schema_version": 1, "type": "Fatal", "timestamp": "` + tStrNano + `", "tags": [], ` +
 				`"message": "Message1"}`},
-		{Event{ThumbEvent, now, Tags{"tag1", "tag2", "tag3"}, "Message5", errors.New("error data")},
+		{Event{ThumbEvent, now, Tags{"tag1", "tag2", "tag3"}, "Message5", errors.New("error data"), 0},
 			tStr + " [Thumb] tag1, tag2, tag3: Message5, error data",
-			`{"type": "Thumb", "timestamp": "` + tStrNano + `", "tags": ["tag1", "tag2", "tag3"], ` +
+			`{"schema_version": 1, "type": "Thumb", "timestamp": "` + tStrNano + `", "tags": ["tag1", "tag2", "tag3"], ` +
 				`"message": "Message5", "data": "error data"}`},
-		{Event{NewEventType("My-event-type"), now, Tags{"tag1"}, "Message7", nil},
+		{Event{NewEventType("My-event-type"), now, Tags{"tag1"}, "Message7", nil, 0},
 			tStr + " [My-event-type] tag1: Message7",
-			`{"type": "My-event-type", "timestamp": "` + tStrNano + `", "tags": ["tag1"], ` +
+			`{"schema_version": 1, "type": "My-event-type", "timestamp": "` + tStrNano + `", "tags": ["tag1"], ` +
 				`"message": "Message7"}`},
-		{Event{NewEventType(`my-"event"-type`), now, Tags{`tag"1"`}, "Message7", `"`},
+		{Event{NewEventType(`my-"event"-type`), now, Tags{`tag"1"`}, "Message7", `"`, 0},
 			tStr + " [my-\"event\"-type] tag\"1\": Message7, \"",
-			`{"type": "my-\"event\"-type", "timestamp": "` + tStrNano + `", "tags": ["tag\"1\""], ` +
+			`{"schema_version": 1, "type": "my-\"event\"-type", "timestamp": "` + tStrNano + `", "tags": ["tag\"1\""], ` +
 				`"message": "Message7", "data": "\""}`},
 	}
 
@@ -123,12 +123,15 @@ type eventTypeTest struct {
 
 func getEventTypesTests() []eventTypeTest {
 	return []eventTypeTest{
+		{TraceEvent, "Trace", `"Trace"`},
 		{DebugEvent, "Debug", `"Debug"`},
 		{ThumbEvent, "Thumb", `"Thumb"`},
 		{InfoEvent, "Info", `"Info"`},
+		{NoticeEvent, "Notice", `"Notice"`},
 		{WarnEvent, "Warn", `"Warn"`},
 		{ErrorEvent, "Error", `"Error"`},
 		{FatalEvent, "Fatal", `"Fatal"`},
+		{SecurityEvent, "Security", `"Security"`},
 		{LogEvent, "Log", `"Log"`},
 		{EventType(255), "EventType(255)", `"EventType(255)"`},
 		{NewEventType("my-event-type"), "my-event-type", `"my-event-type"`},
@@ -176,7 +179,7 @@ func TestEventTypeUnmarshalling(t *testing.T) {
 			expectedError = ErrEventTypeUnknown
 		}
 
-		if err := gotEventType.UnmarshalText([]byte(test.Text)); err != expectedError {
+		if err := gotEventType.UnmarshalText([]byte(test.Text)); !errors.Is(err, expectedError) {
 			t.Fatalf("Expected EventType.UnmarshalText(%s) to return error %v, but got %v",
 				test.Text, expectedError, err)
 		} else if expectedError == nil && *gotEventType != test.EventType {
@@ -184,7 +187,7 @@ func TestEventTypeUnmarshalling(t *testing.T) {
 				test.EventType, gotEventType)
 		}
 
-		if err := gotEventType.UnmarshalJSON([]byte(test.JSON)); err != expectedError {
+		if err := gotEventType.UnmarshalJSON([]byte(test.JSON)); !errors.Is(err, expectedError) {
 			t.Fatalf("Expected EventType.UnmarshalJSON(%s) to return error %v, but got %v",
 				test.JSON, expectedError, err)
 		} else if expectedError == nil && *gotEventType != test.EventType {
@@ -194,6 +197,25 @@ func TestEventTypeUnmarshalling(t *testing.T) {
 	}
 }
 
+func TestEventTypeError(t *testing.T) {
+	var eventType EventType
+	err := eventType.UnmarshalText([]byte("Unknown"))
+
+	var typeErr *EventTypeError
+	if !errors.As(err, &typeErr) {
+		t.Fatalf("Expected error to be an *EventTypeError, but got %#v", err)
+	} else if typeErr.Name != "Unknown" {
+		t.Fatalf("Expected the name to be %q, but got %q", "Unknown", typeErr.Name)
+	} else if !errors.Is(err, ErrEventTypeUnknown) {
+		t.Fatalf("Expected error to be ErrEventTypeUnknown, but got %v", err)
+	}
+
+	expected := `logger: unknown EventType: "Unknown"`
+	if got := err.Error(); got != expected {
+		t.Fatalf("Expected error message to be %q, but got %q", expected, got)
+	}
+}
+
 func TestNewEventTypeWithEmptyName(t *testing.T) {
 	defer resetEventTypes()
 
@@ -204,7 +226,7 @@ func TestNewEventTypeWithEmptyName(t *testing.T) {
 
 func TestNewEventTypeNotUnique(t *testing.T) {
 	defer resetEventTypes()
-	defer expectPanic(t, "logger: EventType must be unique")
+	defer expectPanic(t, `logger: EventType name already taken: "my-event-type"`)
 	NewEventType("my-event-type")
 	NewEventType("my-event-type")
 }
@@ -228,13 +250,13 @@ func TestNewEventTypeLimit(t *testing.T) {
 			t.Fatal("Expected a panic after creating 65528 log levels, but didn't get one")
 		}
 
-		got, ok := recv.(string)
-		if !ok {
-			t.Fatalf("Expected the recoverd panic to be a string, but it's %v", recv)
+		err, ok := recv.(*EventTypeError)
+		if !ok || !errors.Is(err, ErrTooManyEventTypes) {
+			t.Fatalf("Expected the recoverd panic to be an *EventTypeError, but it's %v", recv)
 		}
 
-		expected := "logger: can't have more then 65535 EventTypes"
-		if got != expected {
+		expected := fmt.Sprintf("logger: can't have more then 65535 EventTypes: \"EventType-%d\"", maxCostumEventTypes+1)
+		if got := err.Error(); got != expected {
 			t.Fatalf("Expected the recoverd panic to be %s, but got %s", expected, got)
 		}
 	}()
@@ -253,4 +275,5 @@ var (
 func resetEventTypes() {
 	eventTypeNames = oldEventTypeNames
 	eventTypeIndices = oldEventTypeIndices
+	eventTypeRanges = nil
 }
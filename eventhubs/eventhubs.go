@@ -0,0 +1,324 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+// Package eventhubs provides an EventWriter that ships events, formatted as
+// JSON, to an Azure Event Hub using the HTTPS ingest (REST) API. Events are
+// send in batches.
+package eventhubs
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+// Defaults used if the Config fields are not set.
+const (
+	DefaultBatchSize     = 100
+	DefaultFlushInterval = time.Second
+	DefaultTimeout       = 10 * time.Second
+	DefaultTokenExpiry   = time.Hour
+)
+
+// Config is the configuration for the EventWriter created by NewEventWriter.
+type Config struct {
+	// ConnectionString as provided by the Azure portal, for example:
+	//
+	//	Endpoint=sb://namespace.servicebus.windows.net/;SharedAccessKeyName=name;SharedAccessKey=key;EntityPath=hub
+	//
+	// If set it overwrites the Namespace, EventHub, KeyName and Key fields.
+	ConnectionString string
+
+	// Namespace is the Event Hubs namespace, e.g. "namespace" for
+	// "namespace.servicebus.windows.net".
+	Namespace string
+
+	// EventHub is the name of the Event Hub.
+	EventHub string
+
+	// KeyName and Key of the shared access policy used to create the shared
+	// access signature.
+	KeyName string
+	Key     string
+
+	// Endpoint of the API, defaults to
+	// "https://<namespace>.servicebus.windows.net".
+	Endpoint string
+
+	// PartitionKey returns the partition key for an event, events with the
+	// same partition key are send to the same partition. Defaults to no
+	// partition key, letting Event Hubs distribute the events.
+	PartitionKey func(logger.Event) string
+
+	// BatchSize is the number of events send in a single request, defaults to
+	// DefaultBatchSize.
+	BatchSize int
+
+	// FlushInterval is the maximum time an event is buffered before being
+	// send, defaults to DefaultFlushInterval.
+	FlushInterval time.Duration
+
+	// Timeout of a single request, defaults to DefaultTimeout.
+	Timeout time.Duration
+
+	// TokenExpiry is the validity of the shared access signature, defaults to
+	// DefaultTokenExpiry. A new signature is created once half of it passed.
+	TokenExpiry time.Duration
+}
+
+// ErrInvalidConnectionString is returned by NewEventWriter if the connection
+// string can't be parsed.
+var ErrInvalidConnectionString = errors.New("eventhubs: invalid connection string")
+
+// parseConnectionString parses the connection string into config.
+func parseConnectionString(config *Config) error {
+	for _, part := range strings.Split(config.ConnectionString, ";") {
+		if part == "" {
+			continue
+		}
+		i := strings.IndexByte(part, '=')
+		if i == -1 {
+			return ErrInvalidConnectionString
+		}
+
+		if err := setConnectionStringPart(config, part[:i], part[i+1:]); err != nil {
+			return err
+		}
+	}
+
+	if config.Namespace == "" || config.EventHub == "" ||
+		config.KeyName == "" || config.Key == "" {
+		return ErrInvalidConnectionString
+	}
+	return nil
+}
+
+// setConnectionStringPart sets the key-value pair of a connection string in
+// config, unknown keys are ignored.
+func setConnectionStringPart(config *Config, key, value string) error {
+	switch key {
+	case "Endpoint":
+		u, err := url.Parse(value)
+		if err != nil || u.Host == "" {
+			return ErrInvalidConnectionString
+		}
+		config.Namespace = strings.TrimSuffix(u.Host, ".servicebus.windows.net")
+		if config.Endpoint == "" {
+			config.Endpoint = "https://" + u.Host
+		}
+	case "SharedAccessKeyName":
+		config.KeyName = value
+	case "SharedAccessKey":
+		config.Key = value
+	case "EntityPath":
+		config.EventHub = value
+	}
+	return nil
+}
+
+// sasToken creates a shared access signature for resource, valid until
+// expiry.
+func sasToken(resource, keyName, key string, expiry time.Time) string {
+	resource = url.QueryEscape(strings.ToLower(resource))
+	se := strconv.FormatInt(expiry.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(key))
+	io.WriteString(mac, resource+"\n"+se)
+	sig := base64.StdEncoding.EncodeToString(mac.Sum(nil))
+
+	return "SharedAccessSignature sr=" + resource + "&sig=" + url.QueryEscape(sig) +
+		"&se=" + se + "&skn=" + url.QueryEscape(keyName)
+}
+
+type brokerProperties struct {
+	PartitionKey string `json:",omitempty"`
+}
+
+type message struct {
+	Body             string
+	BrokerProperties *brokerProperties `json:",omitempty"`
+}
+
+type eventWriter struct {
+	config Config
+	client *http.Client
+	url    string
+	closed chan struct{}
+	done   chan struct{}
+
+	mu           sync.Mutex
+	messages     []message
+	token        string
+	tokenRenewAt time.Time
+
+	errorHandler func(error)
+	minType      logger.EventType
+}
+
+func (ew *eventWriter) Write(event logger.Event) error {
+	if event.Type < ew.minType {
+		return nil
+	}
+
+	data, err := event.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	msg := message{Body: string(data)}
+	if ew.config.PartitionKey != nil {
+		msg.BrokerProperties = &brokerProperties{PartitionKey: ew.config.PartitionKey(event)}
+	}
+
+	ew.mu.Lock()
+	defer ew.mu.Unlock()
+	ew.messages = append(ew.messages, msg)
+	if len(ew.messages) < ew.config.BatchSize {
+		return nil
+	}
+
+	if err := ew.flush(); err != nil {
+		// The event will be written again, so we need to remove it. The other
+		// messages remain buffered and are retried in the next request.
+		ew.messages = ew.messages[:len(ew.messages)-1]
+		return err
+	}
+	return nil
+}
+
+// Flush sends all buffered messages, ew.mu must be held.
+func (ew *eventWriter) flush() error {
+	if len(ew.messages) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(ew.messages)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", ew.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.microsoft.servicebus.json")
+	req.Header.Set("Authorization", ew.sasToken())
+
+	resp, err := ew.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		respBody, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("eventhubs: unexpected response: %s: %s", resp.Status, respBody)
+	}
+
+	ew.messages = ew.messages[:0]
+	return nil
+}
+
+// SasToken returns a (cached) shared access signature, ew.mu must be held.
+func (ew *eventWriter) sasToken() string {
+	t := now()
+	if ew.token == "" || !t.Before(ew.tokenRenewAt) {
+		resource := "https://" + ew.config.Namespace + ".servicebus.windows.net/" + ew.config.EventHub
+		ew.token = sasToken(resource, ew.config.KeyName, ew.config.Key, t.Add(ew.config.TokenExpiry))
+		ew.tokenRenewAt = t.Add(ew.config.TokenExpiry / 2)
+	}
+	return ew.token
+}
+
+// Stubbed for testing.
+var now = time.Now
+
+// FlushPeriodically flushes the messages every flush interval, until the
+// writer is closed.
+func (ew *eventWriter) flushPeriodically() {
+	ticker := time.NewTicker(ew.config.FlushInterval)
+	defer ticker.Stop()
+	defer close(ew.done)
+
+	for {
+		select {
+		case <-ticker.C:
+			ew.mu.Lock()
+			err := ew.flush()
+			ew.mu.Unlock()
+			if err != nil {
+				ew.errorHandler(err)
+			}
+		case <-ew.closed:
+			return
+		}
+	}
+}
+
+func (ew *eventWriter) HandleError(err error) {
+	ew.errorHandler(err)
+}
+
+// Close sends all buffered events.
+func (ew *eventWriter) Close() error {
+	close(ew.closed)
+	<-ew.done
+
+	ew.mu.Lock()
+	defer ew.mu.Unlock()
+	return ew.flush()
+}
+
+// NewEventWriter creates a new EventWriter that ships events, formatted as
+// JSON, to an Azure Event Hub. Events are buffered and send in batches, either
+// once the batch is full or after the flush interval, see Config. MinType is
+// the minimal EventType an event must have to be logged. For example if
+// minType is InfoEvent, then any events with an EventType of DebugEvent will
+// not be logged.
+func NewEventWriter(minType logger.EventType, config Config, errorHandler func(error)) (logger.EventWriter, error) {
+	if config.ConnectionString != "" {
+		if err := parseConnectionString(&config); err != nil {
+			return nil, err
+		}
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = "https://" + config.Namespace + ".servicebus.windows.net"
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultBatchSize
+	}
+	if config.FlushInterval == 0 {
+		config.FlushInterval = DefaultFlushInterval
+	}
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.TokenExpiry == 0 {
+		config.TokenExpiry = DefaultTokenExpiry
+	}
+
+	ew := &eventWriter{
+		config:       config,
+		client:       &http.Client{Timeout: config.Timeout},
+		url:          strings.TrimSuffix(config.Endpoint, "/") + "/" + config.EventHub + "/messages",
+		closed:       make(chan struct{}),
+		done:         make(chan struct{}),
+		errorHandler: errorHandler,
+		minType:      minType,
+	}
+	go ew.flushPeriodically()
+	return ew, nil
+}
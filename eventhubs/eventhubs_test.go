@@ -0,0 +1,138 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package eventhubs
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+var t1 = time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC)
+
+func TestParseConnectionString(t *testing.T) {
+	config := Config{ConnectionString: "Endpoint=sb://namespace.servicebus.windows.net/;" +
+		"SharedAccessKeyName=name;SharedAccessKey=key;EntityPath=hub"}
+	if err := parseConnectionString(&config); err != nil {
+		t.Fatal("Unexpected error parsing connection string: " + err.Error())
+	}
+
+	if config.Namespace != "namespace" || config.EventHub != "hub" ||
+		config.KeyName != "name" || config.Key != "key" ||
+		config.Endpoint != "https://namespace.servicebus.windows.net" {
+		t.Fatalf("Unexpected config after parsing connection string: %#v", config)
+	}
+
+	for _, input := range []string{"", "Endpoint", "Endpoint=sb://namespace/;EntityPath=hub"} {
+		config := Config{ConnectionString: input}
+		if err := parseConnectionString(&config); err != ErrInvalidConnectionString {
+			t.Fatalf("Expected error %v for %q, but got %v", ErrInvalidConnectionString, input, err)
+		}
+	}
+}
+
+func TestSasToken(t *testing.T) {
+	got := sasToken("https://namespace.servicebus.windows.net/hub", "name", "key", t1)
+	expected := "SharedAccessSignature sr=https%3A%2F%2Fnamespace.servicebus.windows.net%2Fhub" +
+		"&sig=f4zxLlvMKhhpDDD6S3y69tEK1mlQ0T1F0FvWSjNjhEY%3D&se=1441117356&skn=name"
+	if got != expected {
+		t.Fatalf("Expected token %q, but got %q", expected, got)
+	}
+}
+
+type request struct {
+	path, contentType, authorization string
+	messages                         []message
+}
+
+func TestEventWriter(t *testing.T) {
+	requests := make(chan request, 10)
+	status := http.StatusCreated
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		var messages []message
+		if err := json.Unmarshal(body, &messages); err != nil {
+			t.Error("Unexpected error decoding request: " + err.Error())
+		}
+		w.WriteHeader(status)
+		requests <- request{r.URL.Path, r.Header.Get("Content-Type"),
+			r.Header.Get("Authorization"), messages}
+	}))
+	defer server.Close()
+
+	oldNow := now
+	defer func() { now = oldNow }()
+	now = func() time.Time { return t1 }
+
+	config := Config{
+		Namespace:     "namespace",
+		EventHub:      "hub",
+		KeyName:       "name",
+		Key:           "key",
+		Endpoint:      server.URL,
+		PartitionKey:  func(event logger.Event) string { return event.Type.String() },
+		BatchSize:     2,
+		FlushInterval: time.Hour,
+	}
+	ew, err := NewEventWriter(logger.InfoEvent, config, func(error) {})
+	if err != nil {
+		t.Fatal("Unexpected error creating EventWriter: " + err.Error())
+	}
+
+	events := []logger.Event{
+		{Type: logger.DebugEvent, Timestamp: t1, Message: "Debug message"},
+		{Type: logger.InfoEvent, Timestamp: t1, Message: "Info message"},
+		{Type: logger.ErrorEvent, Timestamp: t1, Message: "Error message"},
+	}
+	for _, event := range events {
+		if err := ew.Write(event); err != nil {
+			t.Fatal("Unexpected error writing event: " + err.Error())
+		}
+	}
+
+	checkRequest(t, <-requests)
+
+	// Failed request.
+	status = http.StatusUnauthorized
+	ew.Write(logger.Event{Type: logger.InfoEvent, Timestamp: t1, Message: "Message 1"})
+	if err := ew.Write(logger.Event{Type: logger.InfoEvent, Timestamp: t1, Message: "Message 2"}); err == nil {
+		t.Fatal("Expected an error writing event, but got none")
+	}
+	<-requests
+
+	// Only the first message should be retried.
+	status = http.StatusCreated
+	if err := ew.Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+	req := <-requests
+	if len(req.messages) != 1 {
+		t.Fatalf("Expected 1 message to be retried, but got %d", len(req.messages))
+	}
+}
+
+func checkRequest(t *testing.T, req request) {
+	if req.path != "/hub/messages" {
+		t.Fatalf("Expected request path %q, but got %q", "/hub/messages", req.path)
+	} else if expected := "application/vnd.microsoft.servicebus.json"; req.contentType != expected {
+		t.Fatalf("Expected content type %q, but got %q", expected, req.contentType)
+	} else if expected := sasToken("https://namespace.servicebus.windows.net/hub", "name", "key",
+		t1.Add(DefaultTokenExpiry)); req.authorization != expected {
+		t.Fatalf("Expected authorization %q, but got %q", expected, req.authorization)
+	} else if len(req.messages) != 2 {
+		t.Fatalf("Expected 2 messages, but got %d", len(req.messages))
+	}
+	expected := `{"schema_version": 1, "type": "Info", "timestamp": "2015-09-01T14:22:36Z", "tags": [], "message": "Info message"}`
+	if got := req.messages[0].Body; got != expected {
+		t.Fatalf("Expected message body %q, but got %q", expected, got)
+	} else if got := req.messages[1].BrokerProperties; got == nil || got.PartitionKey != "Error" {
+		t.Fatalf("Expected partition key %q, but got %v", "Error", got)
+	}
+}
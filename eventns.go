@@ -0,0 +1,70 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalidNamespace is used by NewNamespacedEventType if the namespace is
+// empty or contains NamespaceSeparator.
+var ErrInvalidNamespace = errors.New("invalid EventType namespace")
+
+// NamespaceSeparator separates the namespace and name of an EventType, see
+// NewNamespacedEventType.
+const NamespaceSeparator = "/"
+
+// NewNamespacedEventType creates a new custom EventType, like NewEventType,
+// under the namespace, e.g. the name of a library. The full name of the
+// EventType, as returned by EventType.String, is the namespace and the name
+// separated by NamespaceSeparator, e.g. "mylib/cache-miss". Only the full name
+// must be unique, so two libraries can both create a "Request" EventType in
+// their own namespace. The namespace can't contain NamespaceSeparator.
+//
+// Note: THIS FUNCTION IS NOT SAFE FOR CONCURRENT USE, use it before starting to
+// log. Like NewEventType it panics with an *EventTypeError.
+func NewNamespacedEventType(namespace, name string) EventType {
+	if namespace == "" || strings.Contains(namespace, NamespaceSeparator) {
+		panic(&EventTypeError{namespace, ErrInvalidNamespace})
+	} else if name == "" {
+		panic(&EventTypeError{namespace + NamespaceSeparator, ErrEventTypeEmpty})
+	}
+	return NewEventType(namespace + NamespaceSeparator + name)
+}
+
+// LookupEventType returns the EventType with the name, which is the full name
+// for namespaced EventTypes, e.g. "mylib/cache-miss". It returns false if no
+// such EventType exists.
+func LookupEventType(name string) (EventType, bool) {
+	if name == "" {
+		return 0, false
+	}
+	return findEventType(name)
+}
+
+// Namespace returns the namespace of the EventType, or an empty string if the
+// EventType has no namespace, see NewNamespacedEventType.
+func (eventType EventType) Namespace() string {
+	namespace, _ := splitEventTypeName(eventType.String())
+	return namespace
+}
+
+// Name returns the name of the EventType without its namespace, see
+// NewNamespacedEventType.
+func (eventType EventType) Name() string {
+	_, name := splitEventTypeName(eventType.String())
+	return name
+}
+
+// splitEventTypeName splits the full name of an EventType into its namespace
+// and name.
+func splitEventTypeName(fullName string) (namespace, name string) {
+	i := strings.Index(fullName, NamespaceSeparator)
+	if i <= 0 {
+		return "", fullName
+	}
+	return fullName[:i], fullName[i+len(NamespaceSeparator):]
+}
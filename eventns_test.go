@@ -0,0 +1,91 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewNamespacedEventType(t *testing.T) {
+	defer resetEventTypes()
+
+	request1 := NewNamespacedEventType("lib1", "Request")
+	request2 := NewNamespacedEventType("lib2", "Request")
+	request := NewEventType("Request")
+	if request1 == request2 || request1 == request {
+		t.Fatal("Expected different EventTypes")
+	}
+
+	tests := []struct {
+		eventType                 EventType
+		fullName, namespace, name string
+	}{
+		{request1, "lib1/Request", "lib1", "Request"},
+		{request2, "lib2/Request", "lib2", "Request"},
+		{request, "Request", "", "Request"},
+		{ErrorEvent, "Error", "", "Error"},
+	}
+	for _, test := range tests {
+		if got := test.eventType.String(); got != test.fullName {
+			t.Errorf("Expected EventType.String() to return %q, but got %q", test.fullName, got)
+		}
+		if got := test.eventType.Namespace(); got != test.namespace {
+			t.Errorf("Expected EventType.Namespace() to return %q, but got %q", test.namespace, got)
+		}
+		if got := test.eventType.Name(); got != test.name {
+			t.Errorf("Expected EventType.Name() to return %q, but got %q", test.name, got)
+		}
+		checkLookupEventType(t, test.eventType, test.fullName)
+	}
+
+	if _, ok := LookupEventType("lib3/Request"); ok {
+		t.Fatal("Expected LookupEventType to return false for an unknown EventType")
+	} else if _, ok := LookupEventType(""); ok {
+		t.Fatal("Expected LookupEventType to return false for an empty name")
+	}
+}
+
+// checkLookupEventType checks that the full name of the EventType can be
+// looked up and unmarshaled.
+func checkLookupEventType(t *testing.T, eventType EventType, fullName string) {
+	if got, ok := LookupEventType(fullName); !ok || got != eventType {
+		t.Errorf("Expected LookupEventType(%q) to return %d, but got %d (%t)",
+			fullName, eventType, got, ok)
+	}
+
+	var got EventType
+	if err := got.UnmarshalText([]byte(fullName)); err != nil {
+		t.Errorf("Unexpected error unmarshaling %q: %s", fullName, err.Error())
+	} else if got != eventType {
+		t.Errorf("Expected to unmarshal %q as %d, but got %d", fullName, eventType, got)
+	}
+}
+
+func TestNewNamespacedEventTypeErrors(t *testing.T) {
+	tests := []struct {
+		namespace, name string
+		expected        error
+	}{
+		{"", "Request", ErrInvalidNamespace},
+		{"lib/sub", "Request", ErrInvalidNamespace},
+		{"lib", "", ErrEventTypeEmpty},
+		{"lib", "Request", ErrEventTypeTaken},
+	}
+
+	for _, test := range tests {
+		func() {
+			defer resetEventTypes()
+			NewNamespacedEventType("lib", "Request")
+			defer func() {
+				err, ok := recover().(error)
+				if !ok || !errors.Is(err, test.expected) {
+					t.Errorf("Expected a panic with error %q, but got %v", test.expected, err)
+				}
+			}()
+			NewNamespacedEventType(test.namespace, test.name)
+		}()
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// Errors used in EventTypeError by ReserveEventTypes and
+// EventTypeRange.NewEventType.
+var (
+	ErrNamespaceTaken    = errors.New("EventType namespace already reserved")
+	ErrEventTypeOutRange = errors.New("EventType outside of the reserved range")
+)
+
+// EventTypeRange is a range of EventTypes reserved by a library, see
+// ReserveEventTypes.
+type EventTypeRange struct {
+	Namespace string
+	First     EventType // First EventType in the range.
+	N         int       // Number of EventTypes in the range.
+}
+
+// Reserved EventTypeRanges, by namespace.
+var eventTypeRanges map[string]EventTypeRange
+
+// ReserveEventTypes reserves a range of n EventTypes for the namespace, e.g.
+// the import path of a library. The EventTypes in the range can only be
+// created using EventTypeRange.NewEventType, so they never collide with the
+// EventTypes created by the application, or other libraries, using
+// NewEventType. Within the range the EventTypes have a stable value, i.e.
+// First plus their offset, regardless of the order in which they're created.
+// For example:
+//
+//	var (
+//		eventTypes = logger.ReserveEventTypes("example.com/cache", 2)
+//		CacheHit   = eventTypes.NewEventType(0, "CacheHit")
+//		CacheMiss  = eventTypes.NewEventType(1, "CacheMiss")
+//	)
+//
+// The reserved EventTypes that are not yet created are not defined, e.g.
+// EventType.String returns "EventType(n)" for them.
+//
+// Note: THIS FUNCTION IS NOT SAFE FOR CONCURRENT USE, use it before starting to
+// log. Like NewEventType it panics with an *EventTypeError if the namespace is
+// already reserved, or if n is larger then the number of EventTypes left.
+func ReserveEventTypes(namespace string, n int) EventTypeRange {
+	if _, ok := eventTypeRanges[namespace]; ok {
+		panic(&EventTypeError{namespace, ErrNamespaceTaken})
+	} else if n < 0 || len(eventTypeIndices)-1+n > math.MaxUint16 {
+		panic(&EventTypeError{namespace, ErrTooManyEventTypes})
+	}
+
+	// Reserved EventTypes have an empty name, so they can't be found by
+	// findEventType.
+	r := EventTypeRange{namespace, EventType(len(eventTypeIndices) - 1), n}
+	end := len(eventTypeNames)
+	for i := 0; i < n; i++ {
+		eventTypeIndices = append(eventTypeIndices, end)
+	}
+
+	if eventTypeRanges == nil {
+		eventTypeRanges = make(map[string]EventTypeRange)
+	}
+	eventTypeRanges[namespace] = r
+	return r
+}
+
+// NewEventType creates the EventType at the offset in the range, like the
+// package level NewEventType. It panics with an *EventTypeError if the offset
+// is outside of the range, if the EventType at the offset is already created,
+// or if the name is empty or not unique.
+func (r EventTypeRange) NewEventType(offset int, name string) EventType {
+	if offset < 0 || offset >= r.N {
+		panic(&EventTypeError{fmt.Sprintf("%s(%d)", name, offset), ErrEventTypeOutRange})
+	} else if len(name) == 0 {
+		panic(&EventTypeError{name, ErrEventTypeEmpty})
+	}
+
+	eventType := r.First + EventType(offset)
+	start, end := eventTypeIndices[eventType], eventTypeIndices[eventType+1]
+	if _, ok := findEventType(name); ok || start != end {
+		panic(&EventTypeError{name, ErrEventTypeTaken})
+	}
+
+	// Insert the name, moving the names of all following EventTypes. The
+	// indices are copied as they may be shared, e.g. by tests.
+	eventTypeNames = eventTypeNames[:start] + name + eventTypeNames[start:]
+	indices := make([]int, len(eventTypeIndices))
+	copy(indices, eventTypeIndices)
+	for i := int(eventType) + 1; i < len(indices); i++ {
+		indices[i] += len(name)
+	}
+	eventTypeIndices = indices
+	return eventType
+}
@@ -0,0 +1,84 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestReserveEventTypes(t *testing.T) {
+	defer resetEventTypes()
+
+	r := ReserveEventTypes("example.com/cache", 3)
+	first := EventType(len(oldEventTypeIndices) - 1)
+	if r.Namespace != "example.com/cache" || r.First != first || r.N != 3 {
+		t.Fatalf("Unexpected EventTypeRange %v", r)
+	}
+
+	// Types created by the application come after the range.
+	appType := NewEventType("app-type")
+	if appType != first+3 {
+		t.Fatalf("Expected the application EventType to be %d, but got %d", first+3, appType)
+	}
+
+	// Created in a different order then the offsets.
+	miss := r.NewEventType(2, "CacheMiss")
+	hit := r.NewEventType(0, "CacheHit")
+	if hit != first || miss != first+2 {
+		t.Fatalf("Expected the EventTypes %d and %d, but got %d and %d", first, first+2, hit, miss)
+	}
+
+	tests := []struct {
+		eventType EventType
+		expected  string
+	}{
+		{hit, "CacheHit"},
+		{first + 1, fmt.Sprintf("EventType(%d)", first+1)},
+		{miss, "CacheMiss"},
+		{appType, "app-type"},
+		{ErrorEvent, "Error"},
+		{appType + 1, fmt.Sprintf("EventType(%d)", appType+1)},
+	}
+	for _, test := range tests {
+		if got := test.eventType.String(); got != test.expected {
+			t.Errorf("Expected EventType %d to be %q, but got %q", test.eventType, test.expected, got)
+		}
+	}
+
+	for _, eventType := range []EventType{hit, miss, appType} {
+		checkLookupEventType(t, eventType, eventType.String())
+	}
+}
+
+func TestReserveEventTypesErrors(t *testing.T) {
+	tests := []struct {
+		fn       func(r EventTypeRange)
+		expected error
+	}{
+		{func(r EventTypeRange) { ReserveEventTypes("lib", 1) }, ErrNamespaceTaken},
+		{func(r EventTypeRange) { r.NewEventType(2, "Type") }, ErrEventTypeOutRange},
+		{func(r EventTypeRange) { r.NewEventType(-1, "Type") }, ErrEventTypeOutRange},
+		{func(r EventTypeRange) { r.NewEventType(0, "") }, ErrEventTypeEmpty},
+		{func(r EventTypeRange) { r.NewEventType(0, "Error") }, ErrEventTypeTaken},
+		{func(r EventTypeRange) { r.NewEventType(0, "Type"); r.NewEventType(0, "Other") }, ErrEventTypeTaken},
+		{func(r EventTypeRange) { r.NewEventType(0, "Type"); r.NewEventType(1, "Type") }, ErrEventTypeTaken},
+	}
+
+	for _, test := range tests {
+		func() {
+			defer resetEventTypes()
+			r := ReserveEventTypes("lib", 2)
+			defer func() {
+				err, ok := recover().(error)
+				if !ok || !errors.Is(err, test.expected) {
+					t.Errorf("Expected a panic with error %q, but got %v", test.expected, err)
+				}
+			}()
+			test.fn(r)
+		}()
+	}
+}
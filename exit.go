@@ -0,0 +1,58 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"os"
+	"sync"
+)
+
+var (
+	exitMu    sync.Mutex
+	exitFuncs []func()
+)
+
+// Stubbed for testing.
+var osExit = os.Exit
+
+// AtExit registers a cleanup function to be called by Exit, after the logger
+// is closed. The functions are called in the order they are registered.
+func AtExit(fn func()) {
+	exitMu.Lock()
+	exitFuncs = append(exitFuncs, fn)
+	exitMu.Unlock()
+}
+
+// Exit closes the logger, making sure all events are written by the
+// EventWriters, calls all functions registered with AtExit and then exits the
+// application by calling os.Exit with the given code.
+//
+// Calling os.Exit directly doesn't run any deferred functions, so a deferred
+// call to Close never happens and any buffered events are lost, Exit prevents
+// that. If the logger is not started, or already closed, Exit only calls the
+// registered functions before exiting.
+func Exit(code int) {
+	if started && !closed {
+		// There is nothing left to report the error to.
+		Close()
+	}
+
+	exitMu.Lock()
+	fns := exitFuncs
+	exitFuncs = nil
+	exitMu.Unlock()
+
+	for _, fn := range fns {
+		fn()
+	}
+	osExit(code)
+}
+
+// ExitFunc returns a function with the same signature as os.Exit that calls
+// Exit. This can be used to replace os.Exit in code that must exit, e.g.
+// grpclogger, without losing any buffered events.
+func ExitFunc() func(code int) {
+	return Exit
+}
@@ -0,0 +1,73 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExit(t *testing.T) {
+	defer reset()
+	oldOSExit := osExit
+	defer func() { osExit = oldOSExit }()
+
+	var calls []string
+	var exitCode int
+	osExit = func(code int) {
+		calls = append(calls, "exit")
+		exitCode = code
+	}
+
+	var ew eventWriter
+	Start(&ew)
+
+	AtExit(func() {
+		if !ew.closed {
+			t.Error("Expected the EventWriter to be closed before calling the exit functions")
+		}
+		calls = append(calls, "first")
+	})
+	AtExit(func() { calls = append(calls, "second") })
+
+	Info(Tags{"exit"}, "Exit message")
+	ExitFunc()(2)
+
+	if len(ew.events) != 1 || ew.events[0].Message != "Exit message" {
+		t.Errorf("Expected the event to be written before exiting, but got %v", ew.events)
+	}
+	if expected := []string{"first", "second", "exit"}; !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected the calls to be %v, but got %v", expected, calls)
+	}
+	if exitCode != 2 {
+		t.Errorf("Expected exit code 2, but got %d", exitCode)
+	}
+}
+
+func TestExitClosed(t *testing.T) {
+	defer reset()
+	oldOSExit := osExit
+	defer func() { osExit = oldOSExit }()
+
+	var exitCode = -1
+	osExit = func(code int) { exitCode = code }
+
+	// Not started.
+	Exit(1)
+	if exitCode != 1 {
+		t.Fatalf("Expected exit code 1, but got %d", exitCode)
+	}
+
+	// Already closed.
+	var ew eventWriter
+	Start(&ew)
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+	Exit(3)
+	if exitCode != 3 {
+		t.Fatalf("Expected exit code 3, but got %d", exitCode)
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ErrSlowEventWriter is passed to the error handler of the primary EventWriter
+// of a Failover once it's considered slow, see SlowWriterDetection.
+var ErrSlowEventWriter = errors.New("EventWriter is slow, writes exceed the write timeout, events are written to the failover")
+
+// SlowWriterDetection is the configuration of the detection of slow
+// EventWriters, see Failover.
+type SlowWriterDetection struct {
+	// Timeout is the maximum duration of a single Write. Writes of
+	// EventWriters that implement ContextEventWriter are cancelled once it's
+	// exceeded. Defaults to the write timeout, see SetWriteTimeout. If both
+	// are zero slow EventWriters are not detected.
+	Timeout time.Duration
+
+	// Threshold is the number of consecutive Writes exceeding Timeout after
+	// which the EventWriter is considered slow, defaults to 3.
+	Threshold int
+
+	// Cooldown is the time after which a slow EventWriter is tried again,
+	// defaults to 10 seconds. If the Write completes within Timeout the
+	// EventWriter is no longer considered slow.
+	Cooldown time.Duration
+}
+
+// Failover returns an EventWriter that writes the events to primary, unless
+// primary is slow, in which case the events are written to failover. This way
+// a degrading remote sink, e.g. a log collector over the network, doesn't hold
+// up the events, e.g. by writing them to a local file instead:
+//
+//	remote := newRemoteEventWriter()
+//	file, err := logger.NewFileEventWriter(logger.InfoEvent, "/var/log/app/spill.log")
+//	if err != nil {
+//		panic(err)
+//	}
+//	logger.Start(logger.Failover(remote, file, logger.SlowWriterDetection{
+//		Timeout: 100 * time.Millisecond,
+//	}))
+//
+// Events for which the Write to primary times out, i.e. WriteContext returns
+// context.DeadlineExceeded, are written to failover so they're not lost, the
+// error is passed to primary. Once primary is considered slow
+// ErrSlowEventWriter is passed to its error handler and WriterStats.Slow is
+// true, until primary recovers.
+//
+// Other errors are passed to the EventWriter that returned it, ErrBadEventWriter
+// is passed to both. Flush, Rotate and Close are passed to both EventWriters,
+// Close returns a *CloseError if any of them fail.
+func Failover(primary, failover EventWriter, config SlowWriterDetection) EventWriter {
+	if config.Threshold <= 0 {
+		config.Threshold = 3
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = 10 * time.Second
+	}
+	return &failoverEventWriter{primary: primary, failover: failover, config: config}
+}
+
+type failoverEventWriter struct {
+	primary, failover EventWriter
+	config            SlowWriterDetection
+
+	// Number of consecutive slow writes to primary and the time at which
+	// primary is tried again, if it's slow.
+	slowWrites int
+	retryAt    time.Time
+
+	// 1 if primary is slow, 0 otherwise. Must be accessed atomically, it's
+	// read by the statistics.
+	slow uint32
+
+	// Last EventWriter written to, used to pass errors returned by it back to
+	// it. Write is never called concurrently so we don't need to lock it.
+	last EventWriter
+}
+
+func (ew *failoverEventWriter) Write(event Event) error {
+	if ew.isSlow() && time.Now().Before(ew.retryAt) {
+		ew.last = ew.failover
+		return ew.failover.Write(event)
+	}
+
+	timeout := ew.config.Timeout
+	if timeout <= 0 {
+		timeout = writeTimeout
+	}
+	ew.last = ew.primary
+	if timeout <= 0 {
+		return ew.primary.Write(event)
+	}
+
+	start := time.Now()
+	err := writeTimeoutContext(ew.primary, event, timeout)
+	timedOut := errors.Is(err, context.DeadlineExceeded)
+	if !timedOut && time.Since(start) <= timeout {
+		ew.slowWrites = 0
+		atomic.StoreUint32(&ew.slow, 0)
+		return err
+	}
+
+	ew.slowWrites++
+	if ew.slowWrites >= ew.config.Threshold {
+		if !ew.isSlow() {
+			atomic.StoreUint32(&ew.slow, 1)
+			ew.primary.HandleError(ErrSlowEventWriter)
+		}
+		ew.retryAt = time.Now().Add(ew.config.Cooldown)
+	}
+	if !timedOut {
+		return err
+	}
+
+	// The event isn't written, write it to the failover instead.
+	ew.primary.HandleError(err)
+	ew.last = ew.failover
+	return ew.failover.Write(event)
+}
+
+// writeTimeoutContext writes the event to the EventWriter, cancelling the
+// write after timeout if the EventWriter implements ContextEventWriter.
+func writeTimeoutContext(ew EventWriter, event Event, timeout time.Duration) error {
+	cew, ok := ew.(ContextEventWriter)
+	if !ok {
+		return ew.Write(event)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return cew.WriteContext(ctx, event)
+}
+
+// isSlow returns true if the primary EventWriter is considered slow.
+func (ew *failoverEventWriter) isSlow() bool {
+	return atomic.LoadUint32(&ew.slow) == 1
+}
+
+func (ew *failoverEventWriter) HandleError(err error) {
+	if !errors.Is(err, ErrBadEventWriter) && ew.last != nil {
+		ew.last.HandleError(err)
+		return
+	}
+	ew.primary.HandleError(err)
+	ew.failover.HandleError(err)
+}
+
+func (ew *failoverEventWriter) Flush() error {
+	err := flushEventWriter(ew.primary)
+	if er := flushEventWriter(ew.failover); er != nil && err == nil {
+		err = er
+	}
+	return err
+}
+
+func (ew *failoverEventWriter) Rotate() error {
+	err := rotateEventWriter(ew.primary)
+	if er := rotateEventWriter(ew.failover); er != nil && err == nil {
+		err = er
+	}
+	return err
+}
+
+func (ew *failoverEventWriter) Close() error {
+	var closeErr CloseError
+	for _, w := range []EventWriter{ew.primary, ew.failover} {
+		if err := w.Close(); err != nil {
+			closeErr.Writers = append(closeErr.Writers, w)
+			closeErr.Errors = append(closeErr.Errors, err)
+		}
+	}
+	if len(closeErr.Errors) != 0 {
+		return &closeErr
+	}
+	return nil
+}
+
+// isSlowEventWriter returns true if the EventWriter is a Failover with a slow
+// primary EventWriter.
+func isSlowEventWriter(ew EventWriter) bool {
+	few, ok := ew.(*failoverEventWriter)
+	return ok && few.isSlow()
+}
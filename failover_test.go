@@ -0,0 +1,121 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// stallingEventWriter is an eventWriter that blocks all writes until the
+// context is done, while stalling.
+type stallingEventWriter struct {
+	eventWriter
+	stalling bool
+}
+
+func (ew *stallingEventWriter) WriteContext(ctx context.Context, event Event) error {
+	if ew.stalling {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return ew.Write(event)
+}
+
+func TestFailover(t *testing.T) {
+	var primary stallingEventWriter
+	var failover eventWriter
+	ew := Failover(&primary, &failover, SlowWriterDetection{
+		Timeout:   time.Millisecond,
+		Threshold: 2,
+		Cooldown:  50 * time.Millisecond,
+	})
+	few := ew.(*failoverEventWriter)
+
+	write := func(msg string) {
+		if err := ew.Write(Event{Type: InfoEvent, Message: msg}); err != nil {
+			t.Fatalf("Unexpected error writing %q: %s", msg, err.Error())
+		}
+	}
+
+	write("1")
+	primary.stalling = true
+	write("2")
+	if few.isSlow() {
+		t.Fatal("Expected the primary not to be slow after a single slow write")
+	}
+	write("3")
+	if !few.isSlow() {
+		t.Fatal("Expected the primary to be slow")
+	}
+
+	// While slow the primary isn't tried, so the writes don't block.
+	primary.stalling = false
+	write("4")
+
+	// After the cooldown the primary is tried again.
+	time.Sleep(60 * time.Millisecond)
+	write("5")
+	if few.isSlow() {
+		t.Fatal("Expected the primary to have recovered")
+	}
+
+	if got, expected := messages(primary.events), []string{"1", "5"}; !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected the primary to write %v, but got %v", expected, got)
+	}
+	if got, expected := messages(failover.events), []string{"2", "3", "4"}; !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected the failover to write %v, but got %v", expected, got)
+	}
+
+	expected := []error{context.DeadlineExceeded, ErrSlowEventWriter, context.DeadlineExceeded}
+	if !reflect.DeepEqual(primary.errors, expected) {
+		t.Fatalf("Expected the primary to get errors %v, but got %v", expected, primary.errors)
+	}
+}
+
+func TestFailoverNoTimeout(t *testing.T) {
+	defer reset()
+	var primary stallingEventWriter
+	var failover eventWriter
+	ew := Failover(&primary, &failover, SlowWriterDetection{})
+	if err := ew.Write(Event{Type: InfoEvent, Message: "1"}); err != nil {
+		t.Fatal("Unexpected error writing: " + err.Error())
+	}
+
+	if len(primary.events) != 1 || len(failover.events) != 0 {
+		t.Fatalf("Expected the event to be written to the primary, but got %v and %v",
+			primary.events, failover.events)
+	}
+}
+
+func TestFailoverStats(t *testing.T) {
+	defer reset()
+	SetWriteTimeout(time.Millisecond)
+	primary := stallingEventWriter{stalling: true}
+	var failover eventWriter
+	ew := Failover(&primary, &failover, SlowWriterDetection{Threshold: 1})
+	Start(ew)
+	Info(nil, "Info message")
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if stats := ReadStats(); !stats.Writers[0].Slow {
+		t.Fatalf("Expected the EventWriter to be slow, but got %v", stats.Writers[0])
+	} else if len(failover.events) != 1 || !failover.closed || !primary.closed {
+		t.Fatalf("Expected the event to be written to the failover and both to be closed, got %v", failover.events)
+	}
+}
+
+// messages returns the messages of the events.
+func messages(events []Event) []string {
+	msgs := make([]string, len(events))
+	for i, event := range events {
+		msgs[i] = event.Message
+	}
+	return msgs
+}
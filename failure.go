@@ -0,0 +1,101 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"reflect"
+	"time"
+)
+
+// FailureThreshold determines when an EventWriter is considered bad, see
+// SetFailureThreshold.
+type FailureThreshold struct {
+	// MaxErrors is the number of write errors after which the EventWriter is
+	// considered bad.
+	MaxErrors int
+
+	// Window is the period in which MaxErrors errors must occur. If zero the
+	// errors must be consecutive, i.e. a successful write resets the count.
+	//
+	// A single event is tried at most MaxErrors times, independent of the
+	// window. If the EventWriter isn't considered bad by then the event is
+	// dropped, see WriterStats.Dropped, and the next event is written.
+	Window time.Duration
+}
+
+// DefaultFailureThreshold is the FailureThreshold used for EventWriters that
+// don't have one set, an EventWriter is considered bad after 5 consecutive
+// write errors.
+var DefaultFailureThreshold = FailureThreshold{MaxErrors: maxNWriteErrors}
+
+var failureThresholds map[EventWriter]FailureThreshold
+
+// SetFailureThreshold sets the FailureThreshold for the EventWriter, this can
+// be used to be more lenient with e.g. flaky network EventWriters, or more
+// strict with local files. The EventWriter must be comparable, e.g. a pointer,
+// and the same value as is passed to Start.
+//
+// Note: this must be called before Start and is not safe for concurrent use.
+func SetFailureThreshold(ew EventWriter, threshold FailureThreshold) {
+	if failureThresholds == nil {
+		failureThresholds = make(map[EventWriter]FailureThreshold)
+	}
+	failureThresholds[ew] = threshold
+}
+
+// failureThreshold returns the FailureThreshold for the EventWriter.
+func failureThreshold(ew EventWriter) FailureThreshold {
+	// EventWriters that aren't comparable, e.g. a WriterFunc, can't have a
+	// FailureThreshold set and would panic as map key.
+	if !reflect.TypeOf(ew).Comparable() {
+		return DefaultFailureThreshold
+	}
+	if threshold, ok := failureThresholds[ew]; ok {
+		return threshold
+	}
+	return DefaultFailureThreshold
+}
+
+// failureCounter counts the write errors of a single EventWriter.
+type failureCounter struct {
+	threshold FailureThreshold
+	count     int
+	// Times of the errors within the window, only used if the window is set.
+	times []time.Time
+}
+
+// errors returns the number of counted errors.
+func (counter *failureCounter) errors() int {
+	if counter.threshold.Window <= 0 {
+		return counter.count
+	}
+	return len(counter.times)
+}
+
+// succeed records a successful write.
+func (counter *failureCounter) succeed() {
+	counter.count = 0
+}
+
+// fail records a write error at time t, it returns true if the EventWriter is
+// now considered bad.
+func (counter *failureCounter) fail(t time.Time) bool {
+	if counter.threshold.Window <= 0 {
+		counter.count++
+		return counter.count >= counter.threshold.MaxErrors
+	}
+
+	// Drop the errors outside of the window.
+	start := t.Add(-counter.threshold.Window)
+	n := 0
+	for _, errTime := range counter.times {
+		if errTime.After(start) {
+			counter.times[n] = errTime
+			n++
+		}
+	}
+	counter.times = append(counter.times[:n], t)
+	return len(counter.times) >= counter.threshold.MaxErrors
+}
@@ -0,0 +1,104 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// flakyEventWriter fails the first failures writes.
+type flakyEventWriter struct {
+	eventWriter
+	failures int
+}
+
+func (ew *flakyEventWriter) Write(event Event) error {
+	if ew.failures > 0 {
+		ew.failures--
+		return errors.New("flaky")
+	}
+	return ew.eventWriter.Write(event)
+}
+
+func TestSetFailureThreshold(t *testing.T) {
+	defer reset()
+	lenient := flakyEventWriter{failures: 10}
+	strict := flakyEventWriter{failures: 2}
+	SetFailureThreshold(&lenient, FailureThreshold{MaxErrors: 20})
+	SetFailureThreshold(&strict, FailureThreshold{MaxErrors: 2})
+	Start(&lenient, &strict)
+
+	Info(Tags{"failure"}, "Info message")
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if len(lenient.errors) != 10 || len(lenient.events) != 1 {
+		t.Fatalf("Expected the lenient EventWriter to write the event after 10 errors, but got %v and %v",
+			lenient.errors, lenient.events)
+	}
+	if len(strict.errors) != 3 || !errors.Is(strict.errors[2], ErrBadEventWriter) || len(strict.events) != 0 {
+		t.Fatalf("Expected the strict EventWriter to be bad after 2 errors, but got %v and %v",
+			strict.errors, strict.events)
+	}
+}
+
+func TestFailureCounter(t *testing.T) {
+	t0 := time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC)
+
+	t.Run("consecutive", func(t *testing.T) {
+		counter := failureCounter{threshold: FailureThreshold{MaxErrors: 3}}
+		if counter.fail(t0) || counter.fail(t0) {
+			t.Fatal("Expected the EventWriter not to be bad after 2 errors")
+		}
+		counter.succeed()
+		if counter.fail(t0) || counter.fail(t0) {
+			t.Fatal("Expected a successful write to reset the count")
+		}
+		if !counter.fail(t0) {
+			t.Fatal("Expected the EventWriter to be bad after 3 consecutive errors")
+		}
+	})
+
+	t.Run("window", func(t *testing.T) {
+		counter := failureCounter{threshold: FailureThreshold{MaxErrors: 3, Window: time.Minute}}
+		if counter.fail(t0) || counter.fail(t0.Add(30*time.Second)) {
+			t.Fatal("Expected the EventWriter not to be bad after 2 errors")
+		}
+		// The first error is outside of the window.
+		counter.succeed()
+		if counter.fail(t0.Add(70 * time.Second)) {
+			t.Fatal("Expected errors outside of the window to be dropped")
+		}
+		if !counter.fail(t0.Add(80 * time.Second)) {
+			t.Fatal("Expected the EventWriter to be bad after 3 errors in the window")
+		}
+	})
+}
+
+func TestFailureThresholdWindowDropsEvent(t *testing.T) {
+	defer reset()
+	// The errors never fall in the window, so the EventWriter is never bad,
+	// but the event must not be retried forever.
+	ew := flakyEventWriter{failures: 4}
+	SetFailureThreshold(&ew, FailureThreshold{MaxErrors: 3, Window: time.Nanosecond})
+	Start(&ew)
+
+	Info(Tags{"failure"}, "Info message1")
+	Info(Tags{"failure"}, "Info message2")
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if len(ew.errors) != 4 || len(ew.events) != 1 || ew.events[0].Message != "Info message2" {
+		t.Fatalf("Expected the first event to be dropped after 3 errors, but got %v and %v",
+			ew.errors, ew.events)
+	}
+	if stats := ReadStats(); stats.Writers[0].Dropped != 1 || stats.Writers[0].Bad {
+		t.Fatalf("Expected a single dropped event, but got %v", stats.Writers[0])
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+// copyEvent fills copies with copies of the event. The copies don't share the
+// tags, or the data if it's a byte slice, with each other or with the event,
+// so an EventWriter modifying them can't corrupt the event seen by the other
+// EventWriters, or by the caller of the log operation. All copies share a
+// single allocation for the tags and data, with their capacity limited so
+// appending to them doesn't overwrite another copy.
+func copyEvent(event Event, copies []Event) {
+	var tags Tags
+	if event.Tags != nil {
+		tags = make(Tags, len(event.Tags)*len(copies))
+	}
+	data, isBytes := event.Data.([]byte)
+	var datas []byte
+	if isBytes && data != nil {
+		datas = make([]byte, len(data)*len(copies))
+	}
+
+	for i := range copies {
+		c := event
+		if event.Tags != nil {
+			n := len(event.Tags)
+			c.Tags, tags = tags[:n:n], tags[n:]
+			copy(c.Tags, event.Tags)
+		}
+		if datas != nil {
+			n := len(data)
+			var d []byte
+			d, datas = datas[:n:n], datas[n:]
+			copy(d, data)
+			c.Data = d
+		}
+		copies[i] = c
+	}
+}
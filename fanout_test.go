@@ -0,0 +1,92 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"reflect"
+	"testing"
+)
+
+// mutatingEventWriter is an eventWriter that modifies the tags and data of the
+// events written to it, after recording them.
+type mutatingEventWriter struct {
+	eventWriter
+}
+
+func (ew *mutatingEventWriter) Write(event Event) error {
+	tags := append(Tags(nil), event.Tags...)
+	var data []byte
+	if b, ok := event.Data.([]byte); ok {
+		data = append([]byte(nil), b...)
+		for i := range b {
+			b[i] = 'x'
+		}
+	}
+	for i := range event.Tags {
+		event.Tags[i] = "mutated"
+	}
+	event.Tags = append(event.Tags, "appended")
+
+	event.Tags = tags
+	if data != nil {
+		event.Data = data
+	}
+	return ew.eventWriter.Write(event)
+}
+
+func TestCopyEvent(t *testing.T) {
+	event := Event{Type: InfoEvent, Tags: Tags{"a", "b"}, Message: "msg", Data: []byte("data")}
+	copies := make([]Event, 3)
+	copyEvent(event, copies)
+
+	for i, c := range copies {
+		if !reflect.DeepEqual(c, event) {
+			t.Fatalf("Expected copy #%d to be %v, but got %v", i, event, c)
+		}
+		if &c.Tags[0] == &event.Tags[0] || cap(c.Tags) != len(c.Tags) {
+			t.Fatalf("Expected copy #%d to have its own tags", i)
+		}
+		if data := c.Data.([]byte); &data[0] == &event.Data.([]byte)[0] || cap(data) != len(data) {
+			t.Fatalf("Expected copy #%d to have its own data", i)
+		}
+	}
+
+	event = Event{Type: InfoEvent, Message: "msg"}
+	copyEvent(event, copies)
+	for i, c := range copies {
+		if c.Tags != nil || c.Data != nil {
+			t.Fatalf("Expected copy #%d to not have tags or data, but got %v", i, c)
+		}
+	}
+}
+
+func TestFanOutCopies(t *testing.T) {
+	defer reset()
+	var ews [4]mutatingEventWriter
+	Start(&ews[0], &ews[1], Tee(&ews[2], &ews[3]))
+
+	tags := Tags{"fan", "out"}
+	const n = 100
+	for i := 0; i < n; i++ {
+		Log(Event{Type: InfoEvent, Timestamp: now(), Tags: tags, Message: "Info message", Data: []byte("data")})
+	}
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if tags[0] != "fan" || tags[1] != "out" {
+		t.Fatalf("Expected the tags of the caller not to be modified, but got %v", tags)
+	}
+	for i, ew := range ews {
+		if len(ew.events) != n {
+			t.Fatalf("Expected EventWriter #%d to get %d events, but got %d", i, n, len(ew.events))
+		}
+		for _, event := range ew.events {
+			if event.Tags.String() != "fan, out" || string(event.Data.([]byte)) != "data" {
+				t.Fatalf("Expected EventWriter #%d to get unmodified events, but got %v", i, event)
+			}
+		}
+	}
+}
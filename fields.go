@@ -0,0 +1,68 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"strconv"
+	"time"
+)
+
+// The functions below create typed key=value tags, like Tag, but without
+// converting the value to an empty interface first, which avoids the boxing of
+// the value and a type switch (or fmt's reflection) per tag. The only
+// allocation is the tag itself, for example:
+//
+//	tags := logger.Tags{"myFn", logger.Int("attempt", n), logger.Str("user", id)}
+//
+// Like all tags they should only be used for values with a low cardinality,
+// errors and other details belong in the message or data of the event. Unlike
+// Tag they don't use the encoders registered with RegisterDataEncoder.
+
+// Str creates a key=value tag with a string value.
+func Str(key, value string) string {
+	return key + "=" + value
+}
+
+// Int creates a key=value tag with an integer value.
+func Int(key string, value int) string {
+	return Int64(key, int64(value))
+}
+
+// Int64 creates a key=value tag with an integer value.
+func Int64(key string, value int64) string {
+	var buf [64]byte
+	return string(strconv.AppendInt(appendKey(buf[:0], key), value, 10))
+}
+
+// Uint64 creates a key=value tag with an unsigned integer value.
+func Uint64(key string, value uint64) string {
+	var buf [64]byte
+	return string(strconv.AppendUint(appendKey(buf[:0], key), value, 10))
+}
+
+// Float64 creates a key=value tag with a floating point value, formatted like
+// fmt's %v.
+func Float64(key string, value float64) string {
+	var buf [64]byte
+	return string(strconv.AppendFloat(appendKey(buf[:0], key), value, 'g', -1, 64))
+}
+
+// appendKey appends the key of a key=value tag to buf.
+func appendKey(buf []byte, key string) []byte {
+	return append(append(buf, key...), '=')
+}
+
+// Bool creates a key=value tag with a boolean value, "true" or "false".
+func Bool(key string, value bool) string {
+	if value {
+		return key + "=true"
+	}
+	return key + "=false"
+}
+
+// Duration creates a key=value tag with a duration value, e.g. "1.5s".
+func Duration(key string, value time.Duration) string {
+	return key + "=" + value.String()
+}
@@ -0,0 +1,51 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFields(t *testing.T) {
+	tests := []struct {
+		got, expected string
+	}{
+		{Str("user", "thomas"), Tag("user", "thomas")},
+		{Int("n", 5), Tag("n", 5)},
+		{Int("n", -5), Tag("n", -5)},
+		{Int64("n", math.MinInt64), Tag("n", int64(math.MinInt64))},
+		{Uint64("n", math.MaxUint64), Tag("n", uint64(math.MaxUint64))},
+		{Float64("f", 1.5), Tag("f", 1.5)},
+		{Float64("f", 1e21), Tag("f", 1e21)},
+		{Float64("f", math.NaN()), Tag("f", math.NaN())},
+		{Bool("b", true), Tag("b", true)},
+		{Bool("b", false), Tag("b", false)},
+		{Duration("d", 1500*time.Millisecond), Tag("d", 1500*time.Millisecond)},
+	}
+
+	for _, test := range tests {
+		if test.got != test.expected {
+			t.Errorf("Expected the tag %q, but got %q", test.expected, test.got)
+		}
+	}
+
+	// The only allocation should be the tag itself.
+	var tag string
+	allocs := testing.AllocsPerRun(100, func() {
+		tag = Int64("attempt", math.MaxInt64)
+	})
+	if allocs != 1 {
+		t.Fatalf("Expected a single allocation, but got %v", allocs)
+	} else if expected := Tag("attempt", int64(math.MaxInt64)); tag != expected {
+		t.Fatalf("Expected the tag %q, but got %q", expected, tag)
+	}
+
+	tags := Tags{Int("attempt", 3), Str("user", "thomas")}
+	if got := tags.Get("attempt"); got != "3" {
+		t.Fatalf("Expected the attempt tag to be 3, but got %q", got)
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// FileNameData is the data available in file name templates, see
+// NewFileEventWriter.
+type FileNameData struct {
+	Date string // Current date in UTC, in the format 2006-01-02.
+	Time string // Current time in UTC, in the format 150405.
+	Host string // Host name, see os.Hostname.
+	PID  int    // Process id.
+}
+
+// Stubbed for testing.
+var (
+	hostname = os.Hostname
+	getpid   = os.Getpid
+)
+
+// expandFileName executes the path as a template with FileNameData as data,
+// if it contains a template action.
+func expandFileName(path string) (string, error) {
+	if !strings.Contains(path, "{{") {
+		return path, nil
+	}
+
+	tmpl, err := template.New("file name").Option("missingkey=error").Parse(path)
+	if err != nil {
+		return "", err
+	}
+
+	host, err := hostname()
+	if err != nil {
+		return "", err
+	}
+
+	t := now().UTC()
+	data := FileNameData{
+		Date: t.Format("2006-01-02"),
+		Time: t.Format("150405"),
+		Host: host,
+		PID:  getpid(),
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
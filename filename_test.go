@@ -0,0 +1,72 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExpandFileName(t *testing.T) {
+	oldHostname, oldGetpid := hostname, getpid
+	defer func() { hostname, getpid = oldHostname, oldGetpid }()
+	hostname = func() (string, error) { return "host1", nil }
+	getpid = func() int { return 123 }
+
+	tests := []struct {
+		path     string
+		expected string
+	}{
+		{"/var/log/app.log", "/var/log/app.log"},
+		{"/var/log/app-{{.Date}}-{{.Host}}.log", "/var/log/app-2015-09-01-host1.log"},
+		{"app-{{.Date}}T{{.Time}}-{{.PID}}.log", "app-2015-09-01T142236-123.log"},
+	}
+
+	for _, test := range tests {
+		got, err := expandFileName(test.path)
+		if err != nil {
+			t.Fatal("Unexpected error expanding file name: " + err.Error())
+		} else if got != test.expected {
+			t.Errorf("Expected expandFileName(%q) to return %q, but got %q", test.path, test.expected, got)
+		}
+	}
+
+	for _, invalid := range []string{"app-{{.Date", "app-{{.Unknown}}.log"} {
+		if _, err := expandFileName(invalid); err == nil {
+			t.Errorf("Expected an error expanding %q", invalid)
+		}
+	}
+
+	hostname = func() (string, error) { return "", errors.New("no host") }
+	if _, err := expandFileName("{{.Host}}.log"); err == nil || err.Error() != "no host" {
+		t.Errorf("Expected the host name error, but got %v", err)
+	}
+}
+
+func TestFileEventWriterTemplate(t *testing.T) {
+	oldHostname := hostname
+	defer func() { hostname = oldHostname }()
+	hostname = func() (string, error) { return "host1", nil }
+
+	dir, err := ioutil.TempDir("", "logger_filename")
+	if err != nil {
+		t.Fatal("Unexpected error creating temporary directory: " + err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	ew, err := NewFileEventWriter(InfoEvent, filepath.Join(dir, "app-{{.Date}}-{{.Host}}.log"))
+	if err != nil {
+		t.Fatal("Unexpected error creating new file event writer: " + err.Error())
+	} else if err := ew.Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "app-2015-09-01-host1.log")); err != nil {
+		t.Fatal("Expected the file to be created: " + err.Error())
+	}
+}
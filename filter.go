@@ -0,0 +1,345 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Thomasdezeeuw/logger/internal/util"
+)
+
+// Filter is a compiled filter expression, see CompileFilter. A Filter is safe
+// for concurrent use.
+type Filter struct {
+	expr  string
+	match func(Event) bool
+}
+
+// FilterError is returned by CompileFilter if the expression is invalid.
+type FilterError struct {
+	Expr   string
+	Offset int // Byte offset in Expr of the error.
+	Msg    string
+}
+
+func (err *FilterError) Error() string {
+	return fmt.Sprintf("logger: invalid filter at offset %d: %s", err.Offset, err.Msg)
+}
+
+// CompileFilter compiles a filter expression. For example the following
+// expression matches Warn, and higher, events tagged with "db" that have
+// "timeout" in the message:
+//
+//	type >= Warn && tags has "db" && msg ~ "timeout"
+//
+// An expression consists of comparisons, which can be combined using &&
+// (and), || (or) and ! (not), and grouped using parentheses. Supported are:
+//
+//	type == Info  // Compares the EventType, supports ==, !=, <, <=, > and >=.
+//	tags has "db" // True if one of the tags is "db", a glob pattern, see MatchTags.
+//	msg ~ "regex" // Matches the message using a regular expression, !~ negates.
+//	msg == "text" // Compares the message, supports == and !=.
+//	data ~ "42"   // Same as msg, but for the data converted into a string.
+//
+// Values are double quoted strings, using Go's escaping rules. EventTypes may
+// also be written without quotes.
+func CompileFilter(expr string) (*Filter, error) {
+	p := filterParser{expr: expr}
+	p.next()
+	match, err := p.parseOr()
+	if err == nil && p.tok.kind != tokEOF {
+		err = p.errorf("unexpected %s", p.tok)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Filter{expr: expr, match: match}, nil
+}
+
+// MustCompileFilter is like CompileFilter, but panics on error.
+func MustCompileFilter(expr string) *Filter {
+	filter, err := CompileFilter(expr)
+	if err != nil {
+		panic(err.Error())
+	}
+	return filter
+}
+
+// Match returns true if the event matches the filter.
+func (filter *Filter) Match(event Event) bool {
+	return filter.match(event)
+}
+
+// String returns the filter expression.
+func (filter *Filter) String() string {
+	return filter.expr
+}
+
+// Kinds of tokens in a filter expression.
+const (
+	tokEOF = iota
+	tokIdent
+	tokString
+	tokOp
+	tokInvalid
+)
+
+type filterToken struct {
+	kind   int
+	value  string
+	offset int
+}
+
+func (tok filterToken) String() string {
+	switch tok.kind {
+	case tokEOF:
+		return "end of expression"
+	case tokString:
+		return strconv.Quote(tok.value)
+	default:
+		return "'" + tok.value + "'"
+	}
+}
+
+// filterParser is a recursive descent parser of filter expressions.
+type filterParser struct {
+	expr   string
+	offset int
+	tok    filterToken
+}
+
+// Operators, longest first.
+var filterOps = []string{"&&", "||", "==", "!=", "<=", ">=", "!~", "<", ">", "~", "!", "(", ")"}
+
+// next scans the next token into p.tok.
+func (p *filterParser) next() {
+	for p.offset < len(p.expr) && isSpaceByte(p.expr[p.offset]) {
+		p.offset++
+	}
+
+	start := p.offset
+	switch {
+	case start >= len(p.expr):
+		p.tok = filterToken{tokEOF, "", start}
+	case p.expr[start] == '"':
+		p.scanString(start)
+	case isIdentByte(p.expr[start]):
+		end := start
+		for end < len(p.expr) && isIdentByte(p.expr[end]) {
+			end++
+		}
+		p.tok = filterToken{tokIdent, p.expr[start:end], start}
+		p.offset = end
+	default:
+		p.scanOp(start)
+	}
+}
+
+// scanString scans the quoted string starting at start.
+func (p *filterParser) scanString(start int) {
+	end := start + 1
+	for end < len(p.expr) && p.expr[end] != '"' {
+		if p.expr[end] == '\\' {
+			end++
+		}
+		end++
+	}
+	if end >= len(p.expr) {
+		p.tok = filterToken{tokInvalid, "unterminated string", start}
+		p.offset = len(p.expr)
+		return
+	}
+	value, err := strconv.Unquote(p.expr[start : end+1])
+	if err != nil {
+		p.tok = filterToken{tokInvalid, "invalid string", start}
+	} else {
+		p.tok = filterToken{tokString, value, start}
+	}
+	p.offset = end + 1
+}
+
+// scanOp scans the operator starting at start.
+func (p *filterParser) scanOp(start int) {
+	for _, op := range filterOps {
+		if strings.HasPrefix(p.expr[start:], op) {
+			p.tok = filterToken{tokOp, op, start}
+			p.offset += len(op)
+			return
+		}
+	}
+	c := p.expr[start]
+	p.tok = filterToken{tokInvalid, "unexpected character " + strconv.QuoteRune(rune(c)), start}
+	p.offset++
+}
+
+func isSpaceByte(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isIdentByte(c byte) bool {
+	return ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') ||
+		('0' <= c && c <= '9') || c == '_'
+}
+
+func (p *filterParser) errorf(format string, args ...interface{}) error {
+	if p.tok.kind == tokInvalid {
+		return &FilterError{p.expr, p.tok.offset, p.tok.value}
+	}
+	return &FilterError{p.expr, p.tok.offset, fmt.Sprintf(format, args...)}
+}
+
+func (p *filterParser) isOp(op string) bool {
+	return p.tok.kind == tokOp && p.tok.value == op
+}
+
+func (p *filterParser) parseOr() (func(Event) bool, error) {
+	left, err := p.parseAnd()
+	for err == nil && p.isOp("||") {
+		p.next()
+		var right func(Event) bool
+		if right, err = p.parseAnd(); err == nil {
+			l := left
+			left = func(event Event) bool { return l(event) || right(event) }
+		}
+	}
+	return left, err
+}
+
+func (p *filterParser) parseAnd() (func(Event) bool, error) {
+	left, err := p.parseUnary()
+	for err == nil && p.isOp("&&") {
+		p.next()
+		var right func(Event) bool
+		if right, err = p.parseUnary(); err == nil {
+			l := left
+			left = func(event Event) bool { return l(event) && right(event) }
+		}
+	}
+	return left, err
+}
+
+func (p *filterParser) parseUnary() (func(Event) bool, error) {
+	if p.isOp("!") {
+		p.next()
+		match, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return func(event Event) bool { return !match(event) }, nil
+	} else if p.isOp("(") {
+		p.next()
+		match, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		} else if !p.isOp(")") {
+			return nil, p.errorf("expected ')', but got %s", p.tok)
+		}
+		p.next()
+		return match, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (func(Event) bool, error) {
+	field, op, value, err := p.scanComparison()
+	if err != nil {
+		return nil, err
+	}
+
+	switch field.value {
+	case "type":
+		return p.compileType(op, value)
+	case "tags":
+		return p.compileTags(op, value)
+	case "msg":
+		return p.compileString(op, value, func(event Event) string { return event.Message })
+	case "data":
+		return p.compileString(op, value, func(event Event) string {
+			if event.Data == nil {
+				return ""
+			}
+			return util.InterfaceToString(event.Data)
+		})
+	}
+	return nil, &FilterError{p.expr, field.offset, "unknown field " + strconv.Quote(field.value)}
+}
+
+// scanComparison scans the field, operator and value of a comparison.
+func (p *filterParser) scanComparison() (field, op, value filterToken, err error) {
+	if p.tok.kind != tokIdent {
+		return field, op, value, p.errorf("expected a field, but got %s", p.tok)
+	}
+	field = p.tok
+	p.next()
+	op = p.tok
+	if op.kind != tokOp && !(op.kind == tokIdent && op.value == "has") {
+		return field, op, value, p.errorf("expected an operator, but got %s", p.tok)
+	}
+	p.next()
+	value = p.tok
+	if value.kind != tokString && value.kind != tokIdent {
+		return field, op, value, p.errorf("expected a value, but got %s", p.tok)
+	}
+	p.next()
+	return field, op, value, nil
+}
+
+func (p *filterParser) compileTags(op, value filterToken) (func(Event) bool, error) {
+	if op.value != "has" {
+		return nil, &FilterError{p.expr, op.offset, fmt.Sprintf("operator %s not supported for tags", op)}
+	} else if value.kind != tokString {
+		return nil, &FilterError{p.expr, value.offset, "expected a string, but got " + value.String()}
+	}
+	pattern := value.value
+	return func(event Event) bool { return MatchTags(pattern, event.Tags) }, nil
+}
+
+func (p *filterParser) compileType(op, value filterToken) (func(Event) bool, error) {
+	t, ok := findEventType(value.value)
+	if !ok {
+		return nil, &FilterError{p.expr, value.offset, "unknown EventType " + strconv.Quote(value.value)}
+	}
+
+	switch op.value {
+	case "==":
+		return func(event Event) bool { return event.Type == t }, nil
+	case "!=":
+		return func(event Event) bool { return event.Type != t }, nil
+	case "<":
+		return func(event Event) bool { return event.Type < t }, nil
+	case "<=":
+		return func(event Event) bool { return event.Type <= t }, nil
+	case ">":
+		return func(event Event) bool { return event.Type > t }, nil
+	case ">=":
+		return func(event Event) bool { return event.Type >= t }, nil
+	}
+	return nil, &FilterError{p.expr, op.offset, fmt.Sprintf("operator %s not supported for type", op)}
+}
+
+func (p *filterParser) compileString(op, value filterToken, get func(Event) string) (func(Event) bool, error) {
+	if value.kind != tokString {
+		return nil, &FilterError{p.expr, value.offset, "expected a string, but got " + value.String()}
+	}
+
+	s := value.value
+	switch op.value {
+	case "==":
+		return func(event Event) bool { return get(event) == s }, nil
+	case "!=":
+		return func(event Event) bool { return get(event) != s }, nil
+	case "~", "!~":
+		re, err := regexp.Compile(s)
+		if err != nil {
+			return nil, &FilterError{p.expr, value.offset, err.Error()}
+		}
+		negate := op.value == "!~"
+		return func(event Event) bool { return re.MatchString(get(event)) != negate }, nil
+	}
+	return nil, &FilterError{p.expr, op.offset, fmt.Sprintf("operator %s not supported for strings", op)}
+}
@@ -0,0 +1,89 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import "testing"
+
+func TestFilter(t *testing.T) {
+	events := []Event{
+		{Type: WarnEvent, Tags: Tags{"db"}, Message: "query timeout"},
+		{Type: InfoEvent, Tags: Tags{"db", "user"}, Message: "query done"},
+		{Type: ErrorEvent, Tags: Tags{"http"}, Message: "request timeout", Data: 42},
+	}
+
+	tests := []struct {
+		expr     string
+		expected []bool
+	}{
+		{`type >= Warn && tags has "db" && msg ~ "timeout"`, []bool{true, false, false}},
+		{`type == Info`, []bool{false, true, false}},
+		{`type != "Info"`, []bool{true, false, true}},
+		{`type < Warn`, []bool{false, true, false}},
+		{`type <= Warn`, []bool{true, true, false}},
+		{`type > Warn`, []bool{false, false, true}},
+		{`tags has "user" || tags has "http"`, []bool{false, true, true}},
+		{`!(tags has "db")`, []bool{false, false, true}},
+		{`tags has "!db"`, []bool{false, false, true}},
+		{`tags has "u*"`, []bool{false, true, false}},
+		{`tags has "?ttp" || tags has "us?r"`, []bool{false, true, true}},
+		{`msg == "query done"`, []bool{false, true, false}},
+		{`msg != "query done"`, []bool{true, false, true}},
+		{`msg !~ "^query"`, []bool{false, false, true}},
+		{`data ~ "^42$"`, []bool{false, false, true}},
+		{`type == Info || type == Error && msg ~ "request"`, []bool{false, true, true}},
+		{`(type == Info || type == Error) && msg ~ "query"`, []bool{false, true, false}},
+	}
+
+	for _, test := range tests {
+		filter, err := CompileFilter(test.expr)
+		if err != nil {
+			t.Errorf("Unexpected error compiling %q: %s", test.expr, err.Error())
+			continue
+		} else if filter.String() != test.expr {
+			t.Errorf("Expected String to return %q, but got %q", test.expr, filter.String())
+		}
+
+		for i, event := range events {
+			if got := filter.Match(event); got != test.expected[i] {
+				t.Errorf("Expected %q to return %t for event %d, but got %t",
+					test.expr, test.expected[i], i, got)
+			}
+		}
+	}
+}
+
+func TestFilterErrors(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{``, "logger: invalid filter at offset 0: expected a field, but got end of expression"},
+		{`type ==`, "logger: invalid filter at offset 7: expected a value, but got end of expression"},
+		{`type Info`, "logger: invalid filter at offset 5: expected an operator, but got 'Info'"},
+		{`type == Unknown`, `logger: invalid filter at offset 8: unknown EventType "Unknown"`},
+		{`type ~ Info`, "logger: invalid filter at offset 5: operator '~' not supported for type"},
+		{`tags == "db"`, "logger: invalid filter at offset 5: operator '==' not supported for tags"},
+		{`tags has db`, "logger: invalid filter at offset 9: expected a string, but got 'db'"},
+		{`msg < "a"`, "logger: invalid filter at offset 4: operator '<' not supported for strings"},
+		{`msg ~ "("`, "logger: invalid filter at offset 6: error parsing regexp: missing closing ): `(`"},
+		{`level == Info`, `logger: invalid filter at offset 0: unknown field "level"`},
+		{`msg == "open`, "logger: invalid filter at offset 7: unterminated string"},
+		{`(type == Info`, "logger: invalid filter at offset 13: expected ')', but got end of expression"},
+		{`type == Info)`, "logger: invalid filter at offset 12: unexpected ')'"},
+		{`type == Info & msg == ""`, "logger: invalid filter at offset 13: unexpected character '&'"},
+	}
+
+	for _, test := range tests {
+		_, err := CompileFilter(test.expr)
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("Expected compiling %q to return error %q, but got %v", test.expr, test.expected, err)
+		}
+	}
+}
+
+func TestMustCompileFilter(t *testing.T) {
+	defer expectPanic(t, "logger: invalid filter at offset 0: expected a field, but got end of expression")
+	MustCompileFilter("")
+}
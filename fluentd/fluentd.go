@@ -0,0 +1,228 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+// Package fluentd provides an EventWriter that ships events to fluentd or
+// fluent-bit using the forward protocol, see
+// https://github.com/fluent/fluentd/wiki/Forward-Protocol-Specification-v1.
+package fluentd
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+	"github.com/Thomasdezeeuw/logger/internal/msgpack"
+)
+
+// Defaults used if the Config fields are not set.
+const (
+	DefaultAddr       = "127.0.0.1:24224"
+	DefaultTag        = "logger"
+	DefaultTimeout    = 5 * time.Second
+	eventTimeExt      = 0
+	chunkIDSize       = 16
+	optionChunkKey    = "chunk"
+	responseAckKey    = "ack"
+	maxResponseFields = 16
+)
+
+// Config is the configuration for the EventWriter created by NewEventWriter.
+type Config struct {
+	// Addr is the TCP address of the fluentd forward input, defaults to
+	// DefaultAddr.
+	Addr string
+
+	// Tag is the fluentd tag used for all events, defaults to DefaultTag.
+	Tag string
+
+	// RequireAck makes the EventWriter wait for fluentd to acknowledge each
+	// event, if the acknowledgement isn't received within Timeout the write is
+	// considered failed.
+	RequireAck bool
+
+	// Timeout for connecting, writing and waiting for an acknowledgement,
+	// defaults to DefaultTimeout.
+	Timeout time.Duration
+}
+
+// ErrInvalidAck is returned if fluentd responds with an acknowledgement for
+// another chunk.
+var ErrInvalidAck = errors.New("fluentd: invalid acknowledgement")
+
+type eventWriter struct {
+	config       Config
+	conn         net.Conn
+	r            *bufio.Reader
+	buf          []byte
+	errorHandler func(error)
+	minType      logger.EventType
+}
+
+func (ew *eventWriter) Write(event logger.Event) error {
+	if event.Type < ew.minType {
+		return nil
+	}
+
+	if ew.conn == nil {
+		if err := ew.connect(); err != nil {
+			return err
+		}
+	}
+
+	var chunk string
+	if ew.config.RequireAck {
+		var err error
+		if chunk, err = newChunkID(); err != nil {
+			return err
+		}
+	}
+
+	// Write is never called concurrently, so we can reuse the buffer.
+	ew.buf = appendMessage(ew.buf[:0], ew.config.Tag, event, chunk)
+
+	deadline := time.Now().Add(ew.config.Timeout)
+	ew.conn.SetDeadline(deadline)
+	if _, err := ew.conn.Write(ew.buf); err != nil {
+		ew.disconnect()
+		return err
+	}
+
+	if ew.config.RequireAck {
+		if err := ew.readAck(chunk); err != nil {
+			ew.disconnect()
+			return err
+		}
+	}
+	return nil
+}
+
+func (ew *eventWriter) connect() error {
+	conn, err := net.DialTimeout("tcp", ew.config.Addr, ew.config.Timeout)
+	if err != nil {
+		return err
+	}
+	ew.conn = conn
+	ew.r = bufio.NewReader(conn)
+	return nil
+}
+
+// Disconnect closes the connection, the next call to Write will reconnect.
+func (ew *eventWriter) disconnect() {
+	ew.conn.Close()
+	ew.conn = nil
+	ew.r = nil
+}
+
+// ReadAck reads the response from fluentd, which must be a map containing the
+// "ack" key with the chunk id as value.
+func (ew *eventWriter) readAck(chunk string) error {
+	n, err := msgpack.ReadMapHeader(ew.r)
+	if err != nil {
+		return err
+	} else if n > maxResponseFields {
+		return ErrInvalidAck
+	}
+
+	var ack string
+	for i := 0; i < n; i++ {
+		key, err := msgpack.ReadString(ew.r)
+		if err != nil {
+			return err
+		}
+		value, err := msgpack.ReadString(ew.r)
+		if err != nil {
+			return err
+		}
+		if key == responseAckKey {
+			ack = value
+		}
+	}
+
+	if ack != chunk {
+		return ErrInvalidAck
+	}
+	return nil
+}
+
+func (ew *eventWriter) HandleError(err error) {
+	ew.errorHandler(err)
+}
+
+func (ew *eventWriter) Close() error {
+	if ew.conn == nil {
+		return nil
+	}
+	return ew.conn.Close()
+}
+
+// AppendMessage appends the event in the forward protocol's message mode to
+// buf: [tag, time, record, option]. If chunk is not empty the option contains
+// the chunk id, requesting an acknowledgement.
+func appendMessage(buf []byte, tag string, event logger.Event, chunk string) []byte {
+	n := 3
+	if chunk != "" {
+		n++
+	}
+
+	buf = msgpack.AppendArrayHeader(buf, n)
+	buf = msgpack.AppendString(buf, tag)
+	buf = appendEventTime(buf, event.Timestamp)
+	// The record is the event in the MessagePack format, see
+	// logger.MsgpackFormatter, which never returns an error.
+	buf, _ = logger.MsgpackFormatter{}.Format(buf, event)
+	if chunk != "" {
+		buf = msgpack.AppendMapHeader(buf, 1)
+		buf = msgpack.AppendString(buf, optionChunkKey)
+		buf = msgpack.AppendString(buf, chunk)
+	}
+	return buf
+}
+
+// AppendEventTime appends the time using the EventTime extension type, which
+// supports nanosecond precision.
+func appendEventTime(buf []byte, t time.Time) []byte {
+	sec, nsec := uint32(t.Unix()), uint32(t.Nanosecond())
+	return msgpack.AppendExt(buf, eventTimeExt, []byte{
+		byte(sec >> 24), byte(sec >> 16), byte(sec >> 8), byte(sec),
+		byte(nsec >> 24), byte(nsec >> 16), byte(nsec >> 8), byte(nsec),
+	})
+}
+
+// Stubbed for testing.
+var newChunkID = func() (string, error) {
+	var id [chunkIDSize]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(id[:]), nil
+}
+
+// NewEventWriter creates a new EventWriter that ships events to fluentd using
+// the forward protocol. Each event is send as a single message with the event
+// in the MessagePack format as record, see logger.MsgpackFormatter. If the
+// connection fails it will reconnect on the next write. MinType is the minimal
+// EventType an event must have to be logged. For example if minType is
+// InfoEvent, then any events with an EventType of DebugEvent will not be
+// logged.
+func NewEventWriter(minType logger.EventType, config Config, errorHandler func(error)) (logger.EventWriter, error) {
+	if config.Addr == "" {
+		config.Addr = DefaultAddr
+	}
+	if config.Tag == "" {
+		config.Tag = DefaultTag
+	}
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+
+	ew := &eventWriter{config: config, errorHandler: errorHandler, minType: minType}
+	if err := ew.connect(); err != nil {
+		return nil, err
+	}
+	return ew, nil
+}
@@ -0,0 +1,109 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package fluentd
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+	"github.com/Thomasdezeeuw/logger/internal/msgpack"
+)
+
+var t1 = time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC)
+
+func init() {
+	newChunkID = func() (string, error) {
+		return "chunk1", nil
+	}
+}
+
+func TestAppendMessage(t *testing.T) {
+	event := logger.Event{Type: logger.InfoEvent, Timestamp: t1,
+		Tags: logger.Tags{"tag"}, Message: "Msg", Data: 1}
+
+	var expected []byte
+	expected = append(expected, 0x94, 0xa3, 'a', 'p', 'p')
+	expected = append(expected, 0xd7, 0x00, 0x55, 0xe5, 0xb4, 0xac, 0, 0, 0, 0)
+	expected = append(expected, 0x86, 0xae)
+	expected = append(expected, "schema_version"...)
+	expected = append(expected, logger.SchemaVersion)
+	expected = append(expected, 0xa4, 't', 'y', 'p', 'e', 0xa4, 'I', 'n', 'f', 'o')
+	expected = append(expected, 0xa9, 't', 'i', 'm', 'e', 's', 't', 'a', 'm', 'p')
+	expected = msgpack.AppendTime(expected, t1)
+	expected = append(expected, 0xa4, 't', 'a', 'g', 's', 0x91, 0xa3, 't', 'a', 'g')
+	expected = append(expected, 0xa7, 'm', 'e', 's', 's', 'a', 'g', 'e', 0xa3, 'M', 's', 'g')
+	expected = append(expected, 0xa4, 'd', 'a', 't', 'a', 0xa1, '1')
+	expected = append(expected, 0x81, 0xa5, 'c', 'h', 'u', 'n', 'k', 0xa3, 'i', 'd', '1')
+
+	if got := appendMessage(nil, "app", event, "id1"); !bytes.Equal(got, expected) {
+		t.Fatalf("Expected appendMessage to return:\n% x\nBut got:\n% x", expected, got)
+	}
+}
+
+// server accepts a single connection, reads a message of size for every
+// acknowledged chunk id and sends it on the returned channel.
+func server(l net.Listener, size int, acks ...string) <-chan []byte {
+	received := make(chan []byte, len(acks))
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for _, chunk := range acks {
+			buf := make([]byte, size)
+			if _, err := io.ReadFull(conn, buf); err != nil {
+				return
+			}
+			received <- buf
+
+			var ack []byte
+			ack = msgpack.AppendMapHeader(ack, 1)
+			ack = msgpack.AppendString(ack, "ack")
+			ack = msgpack.AppendString(ack, chunk)
+			conn.Write(ack)
+		}
+	}()
+	return received
+}
+
+func TestEventWriter(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Unexpected error listening: " + err.Error())
+	}
+	defer l.Close()
+
+	event := logger.Event{Type: logger.InfoEvent, Timestamp: t1, Tags: logger.Tags{}, Message: "Msg"}
+	expected := appendMessage(nil, "app", event, "chunk1")
+	received := server(l, len(expected), "chunk1", "other-chunk")
+
+	config := Config{Addr: l.Addr().String(), Tag: "app", RequireAck: true}
+	ew, err := NewEventWriter(logger.InfoEvent, config, func(error) {})
+	if err != nil {
+		t.Fatal("Unexpected error creating EventWriter: " + err.Error())
+	}
+
+	if err := ew.Write(logger.Event{Type: logger.DebugEvent, Message: "Never gets logged"}); err != nil {
+		t.Fatal("Unexpected error writing event: " + err.Error())
+	} else if err := ew.Write(event); err != nil {
+		t.Fatal("Unexpected error writing event: " + err.Error())
+	} else if got := <-received; !bytes.Equal(got, expected) {
+		t.Fatalf("Expected fluentd to receive:\n% x\nBut got:\n% x", expected, got)
+	}
+
+	if err := ew.Write(event); err != ErrInvalidAck {
+		t.Fatalf("Expected an invalid acknowledgement error, but got %v", err)
+	}
+
+	if err := ew.Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+}
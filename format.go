@@ -0,0 +1,69 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import "io"
+
+// Formatter formats events, for example as JSON or a binary format. It's used
+// by the EventWriter created with NewFormatEventWriter.
+type Formatter interface {
+	// Format appends the formatted event to buf and returns the extended
+	// buffer.
+	Format(buf []byte, event Event) ([]byte, error)
+}
+
+type formatEventWriter struct {
+	w            io.Writer
+	formatter    Formatter
+	buf          []byte
+	errorHandler func(error)
+	minType      EventType
+}
+
+func (ew *formatEventWriter) Write(event Event) error {
+	if event.Type < ew.minType {
+		return nil
+	}
+
+	// Write is never called concurrently, so we can reuse the buffer.
+	buf, err := ew.formatter.Format(ew.buf[:0], event)
+	if err != nil {
+		return err
+	}
+	ew.buf = buf
+	_, err = ew.w.Write(buf)
+	return err
+}
+
+// timestampFormatter is implemented by Formatters that support a custom
+// timestamp format, see WithTimestampFormat.
+type timestampFormatter interface {
+	withTimestampFormat(format TimestampFormat) Formatter
+}
+
+func (ew *formatEventWriter) setTimestampFormat(format TimestampFormat) bool {
+	formatter, ok := ew.formatter.(timestampFormatter)
+	if ok {
+		ew.formatter = formatter.withTimestampFormat(format)
+	}
+	return ok
+}
+
+func (ew *formatEventWriter) HandleError(err error) {
+	ew.errorHandler(err)
+}
+
+func (ew *formatEventWriter) Close() error {
+	return nil
+}
+
+// NewFormatEventWriter creates a new EventWriter that formats events using
+// the given Formatter and writes them to the given writer. MinType is the
+// minimal EventType an event must have to be logged. For example if minType
+// is InfoEvent, then any events with an EventType of DebugEvent will not be
+// logged.
+func NewFormatEventWriter(minType EventType, w io.Writer, formatter Formatter, errorHandler func(error)) EventWriter {
+	return &formatEventWriter{w, formatter, nil, errorHandler, minType}
+}
@@ -0,0 +1,136 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package frame
+
+import (
+	"crypto/tls"
+	"net"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+// DefaultTimeout is used if Config.Timeout is not set.
+const DefaultTimeout = 5 * time.Second
+
+// Config is the configuration for the EventWriter created by NewEventWriter.
+type Config struct {
+	// Network and Addr are the address of the server, see net.Dial. Network
+	// defaults to "tcp".
+	Network string
+	Addr    string
+
+	// TLSConfig, if not nil, is used to create a TLS connection.
+	TLSConfig *tls.Config
+
+	// Payload type of the frames, defaults to Protobuf.
+	Payload Payload
+
+	// Timeout for connecting and writing, defaults to DefaultTimeout.
+	Timeout time.Duration
+}
+
+type eventWriter struct {
+	config       Config
+	conn         net.Conn
+	buf          []byte
+	errorHandler func(error)
+	minType      logger.EventType
+}
+
+func (ew *eventWriter) Write(event logger.Event) error {
+	return ew.WriteEncoded(event, nil)
+}
+
+// Encoding returns logger.ProtobufEncoding if the payload type is Protobuf,
+// so the logger encodes the event once for all EventWriters that use it.
+func (ew *eventWriter) Encoding() logger.Encoding {
+	if ew.config.Payload == Protobuf {
+		return logger.ProtobufEncoding
+	}
+	return 0
+}
+
+func (ew *eventWriter) WriteEncoded(event logger.Event, encoded []byte) error {
+	if event.Type < ew.minType {
+		return nil
+	}
+
+	if ew.conn == nil {
+		if err := ew.connect(); err != nil {
+			return err
+		}
+	}
+
+	// Write is never called concurrently, so we can reuse the buffer.
+	var err error
+	if encoded != nil && ew.config.Payload == Protobuf {
+		ew.buf, err = appendEncoded(ew.buf[:0], encoded, Protobuf)
+	} else {
+		ew.buf, err = AppendFrame(ew.buf[:0], event, ew.config.Payload)
+	}
+	if err != nil {
+		return err
+	}
+
+	ew.conn.SetWriteDeadline(time.Now().Add(ew.config.Timeout))
+	if _, err := ew.conn.Write(ew.buf); err != nil {
+		// Reconnect on the next write.
+		ew.conn.Close()
+		ew.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (ew *eventWriter) connect() error {
+	dialer := &net.Dialer{Timeout: ew.config.Timeout}
+	var conn net.Conn
+	var err error
+	if ew.config.TLSConfig != nil {
+		conn, err = tls.DialWithDialer(dialer, ew.config.Network, ew.config.Addr, ew.config.TLSConfig)
+	} else {
+		conn, err = dialer.Dial(ew.config.Network, ew.config.Addr)
+	}
+	if err != nil {
+		return err
+	}
+	ew.conn = conn
+	return nil
+}
+
+func (ew *eventWriter) HandleError(err error) {
+	ew.errorHandler(err)
+}
+
+func (ew *eventWriter) Close() error {
+	if ew.conn == nil {
+		return nil
+	}
+	return ew.conn.Close()
+}
+
+// NewEventWriter creates a new EventWriter that sends every event in a frame
+// to the server, e.g. one created with Serve. If the connection fails it will
+// reconnect on the next write. MinType is the minimal EventType an event must
+// have to be logged. For example if minType is InfoEvent, then any events with
+// an EventType of DebugEvent will not be logged.
+func NewEventWriter(minType logger.EventType, config Config, errorHandler func(error)) (logger.EventWriter, error) {
+	if config.Network == "" {
+		config.Network = "tcp"
+	}
+	if config.Payload == 0 {
+		config.Payload = Protobuf
+	}
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+
+	ew := &eventWriter{config: config, errorHandler: errorHandler, minType: minType}
+	if err := ew.connect(); err != nil {
+		return nil, err
+	}
+	return ew, nil
+}
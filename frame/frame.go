@@ -0,0 +1,180 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+// Package frame provides a framed binary protocol to ship events between Go
+// processes, an EventWriter that sends events using it and a server that
+// receives them.
+//
+// Every event is send in a frame of a 10 byte header followed by the payload.
+// The header contains the magic "LOGF", the version of the protocol (currently
+// 1), the Payload type and the length of the payload as a big endian uint32.
+// The payload is the event in the Protocol Buffers format (see
+// logger.Event.MarshalProtobuf) or the CBOR format (see logger.CBORFormatter).
+package frame
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+const (
+	magic = "LOGF"
+
+	// Version is the version of the protocol written by the Writer.
+	Version = 1
+
+	// HeaderSize is the size of the header of a frame.
+	HeaderSize = 10
+
+	// MaxPayloadSize is the maximum size of the payload of a frame, larger
+	// frames are rejected by the Reader.
+	MaxPayloadSize = 16 * 1024 * 1024
+)
+
+// Payload is the type of the payload of a frame.
+type Payload uint8
+
+// Payload types.
+const (
+	Protobuf Payload = iota + 1
+	CBOR
+)
+
+// String returns the name of the Payload type.
+func (payload Payload) String() string {
+	switch payload {
+	case Protobuf:
+		return "protobuf"
+	case CBOR:
+		return "cbor"
+	}
+	return fmt.Sprintf("Payload(%d)", payload)
+}
+
+// Errors returned by the Reader.
+var (
+	ErrInvalidMagic        = errors.New("frame: invalid magic")
+	ErrUnsupportedVersion  = errors.New("frame: unsupported version")
+	ErrPayloadTooBig       = errors.New("frame: payload too big")
+	ErrUnsupportedEncoding = errors.New("frame: unsupported payload encoding")
+)
+
+// AppendFrame appends the event, framed with the payload type, to buf and
+// returns the extended buffer.
+func AppendFrame(buf []byte, event logger.Event, payload Payload) ([]byte, error) {
+	start := len(buf)
+	buf = append(buf, magic...)
+	buf = append(buf, Version, byte(payload), 0, 0, 0, 0)
+
+	var err error
+	switch payload {
+	case Protobuf:
+		buf = event.AppendProtobuf(buf)
+	case CBOR:
+		buf, err = logger.CBORFormatter{}.Format(buf, event)
+	default:
+		err = ErrUnsupportedEncoding
+	}
+	if err != nil {
+		return buf[:start], err
+	}
+	return appendLength(buf, start)
+}
+
+// appendEncoded appends a frame containing the already encoded payload.
+func appendEncoded(buf, encoded []byte, payload Payload) ([]byte, error) {
+	start := len(buf)
+	buf = append(buf, magic...)
+	buf = append(buf, Version, byte(payload), 0, 0, 0, 0)
+	buf = append(buf, encoded...)
+	return appendLength(buf, start)
+}
+
+// appendLength sets the length in the header of the frame starting at start.
+func appendLength(buf []byte, start int) ([]byte, error) {
+	length := len(buf) - start - HeaderSize
+	if length > MaxPayloadSize {
+		return buf[:start], ErrPayloadTooBig
+	}
+	binary.BigEndian.PutUint32(buf[start+6:], uint32(length))
+	return buf, nil
+}
+
+// Frame is a single frame.
+type Frame struct {
+	Version uint8
+	Payload Payload
+	Data    []byte
+}
+
+// Event decodes the event in the frame, see logger.Event.UnmarshalProtobuf and
+// logger.Event.UnmarshalCBOR. For unknown payloads ErrUnsupportedEncoding is
+// returned.
+func (frame Frame) Event() (logger.Event, error) {
+	var event logger.Event
+	var err error
+	switch frame.Payload {
+	case Protobuf:
+		err = event.UnmarshalProtobuf(frame.Data)
+	case CBOR:
+		err = event.UnmarshalCBOR(frame.Data)
+	default:
+		err = ErrUnsupportedEncoding
+	}
+	return event, err
+}
+
+// Reader reads frames.
+type Reader struct {
+	r      *bufio.Reader
+	header [HeaderSize]byte
+}
+
+// NewReader creates a new Reader reading from r.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// ReadFrame reads a single frame. It returns io.EOF if no more frames are
+// available, or io.ErrUnexpectedEOF if r ends in the middle of a frame.
+func (r *Reader) ReadFrame() (Frame, error) {
+	if _, err := io.ReadFull(r.r, r.header[:]); err != nil {
+		return Frame{}, err
+	}
+	if string(r.header[:4]) != magic {
+		return Frame{}, ErrInvalidMagic
+	}
+	frame := Frame{Version: r.header[4], Payload: Payload(r.header[5])}
+	if frame.Version != Version {
+		return Frame{}, ErrUnsupportedVersion
+	}
+	length := binary.BigEndian.Uint32(r.header[6:])
+	if length > MaxPayloadSize {
+		return Frame{}, ErrPayloadTooBig
+	}
+
+	frame.Data = make([]byte, length)
+	if _, err := io.ReadFull(r.r, frame.Data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return Frame{}, err
+	}
+	return frame, nil
+}
+
+// ReadEvent reads a single frame and decodes the event in it, see ReadFrame
+// and Frame.Event.
+func (r *Reader) ReadEvent() (logger.Event, error) {
+	frame, err := r.ReadFrame()
+	if err != nil {
+		return logger.Event{}, err
+	}
+	return frame.Event()
+}
@@ -0,0 +1,109 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package frame
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+var testEvent = logger.Event{
+	Type:      logger.InfoEvent,
+	Timestamp: time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC),
+	Tags:      logger.Tags{"frame", "test"},
+	Message:   "Info message",
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	buf, err := AppendFrame(nil, testEvent, Protobuf)
+	if err != nil {
+		t.Fatal("Unexpected error framing event: " + err.Error())
+	}
+	buf, err = AppendFrame(buf, testEvent, CBOR)
+	if err != nil {
+		t.Fatal("Unexpected error framing event: " + err.Error())
+	}
+
+	r := NewReader(bytes.NewReader(buf))
+	event, err := r.ReadEvent()
+	if err != nil {
+		t.Fatal("Unexpected error reading event: " + err.Error())
+	} else if !reflect.DeepEqual(event, testEvent) {
+		t.Fatalf("Expected event %v, but got %v", testEvent, event)
+	}
+
+	frame, err := r.ReadFrame()
+	if err != nil {
+		t.Fatal("Unexpected error reading frame: " + err.Error())
+	}
+	checkCBORFrame(t, frame)
+
+	if _, err := r.ReadFrame(); err != io.EOF {
+		t.Fatalf("Expected io.EOF, but got %v", err)
+	}
+}
+
+func checkCBORFrame(t *testing.T, frame Frame) {
+	expected, _ := logger.CBORFormatter{}.Format(nil, testEvent)
+	if frame.Version != Version || frame.Payload != CBOR || !bytes.Equal(frame.Data, expected) {
+		t.Fatalf("Expected a CBOR frame, but got %v", frame)
+	}
+	if event, err := frame.Event(); err != nil {
+		t.Fatal("Unexpected error decoding CBOR event: " + err.Error())
+	} else if !reflect.DeepEqual(event, testEvent) {
+		t.Fatalf("Expected event %v, but got %v", testEvent, event)
+	}
+	frame.Payload = 0
+	if _, err := frame.Event(); err != ErrUnsupportedEncoding {
+		t.Fatalf("Expected error %v, but got %v", ErrUnsupportedEncoding, err)
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	valid, _ := AppendFrame(nil, testEvent, Protobuf)
+	badVersion := append([]byte(nil), valid...)
+	badVersion[4] = 2
+	tooBig := append([]byte(nil), valid[:HeaderSize]...)
+	tooBig[6] = 0xff
+
+	tests := []struct {
+		input    []byte
+		expected error
+	}{
+		{[]byte("LOGX\x01\x01\x00\x00\x00\x00"), ErrInvalidMagic},
+		{badVersion, ErrUnsupportedVersion},
+		{tooBig, ErrPayloadTooBig},
+		{valid[:5], io.ErrUnexpectedEOF},
+		{valid[:len(valid)-1], io.ErrUnexpectedEOF},
+	}
+
+	for _, test := range tests {
+		if _, err := NewReader(bytes.NewReader(test.input)).ReadFrame(); err != test.expected {
+			t.Errorf("Expected error %v for input %q, but got %v", test.expected, test.input, err)
+		}
+	}
+}
+
+func TestPayloadString(t *testing.T) {
+	tests := []struct {
+		payload  Payload
+		expected string
+	}{
+		{Protobuf, "protobuf"},
+		{CBOR, "cbor"},
+		{0, "Payload(0)"},
+	}
+
+	for _, test := range tests {
+		if got := test.payload.String(); got != test.expected {
+			t.Errorf("Expected Payload %d to be %q, but got %q", test.payload, test.expected, got)
+		}
+	}
+}
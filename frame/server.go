@@ -0,0 +1,78 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package frame
+
+import (
+	"io"
+	"net"
+	"sync"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+// Serve accepts connections on the listener and reads the frames send on them,
+// e.g. by the EventWriter created by NewEventWriter. Every received event is
+// passed to fn, which is called concurrently for different connections. If a
+// frame can't be read, or its event can't be decoded, the connection is
+// closed and the error is passed to errorHandler, if not nil.
+//
+// Serve blocks until the listener is closed, after which it closes all
+// connections and returns the error returned by the listener. Once Serve
+// returns fn is no longer called.
+func Serve(l net.Listener, fn func(logger.Event), errorHandler func(error)) error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	conns := make(map[net.Conn]struct{})
+	defer func() {
+		mu.Lock()
+		for conn := range conns {
+			conn.Close()
+			delete(conns, conn)
+		}
+		mu.Unlock()
+		wg.Wait()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		conns[conn] = struct{}{}
+		mu.Unlock()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := serveConn(conn, fn)
+
+			mu.Lock()
+			_, open := conns[conn]
+			delete(conns, conn)
+			mu.Unlock()
+			conn.Close()
+			// Errors caused by closing the connection in Serve are expected.
+			if err != nil && open && errorHandler != nil {
+				errorHandler(err)
+			}
+		}()
+	}
+}
+
+// serveConn reads all frames from the connection. It returns nil once the
+// client closes the connection.
+func serveConn(conn net.Conn, fn func(logger.Event)) error {
+	r := NewReader(conn)
+	for {
+		event, err := r.ReadEvent()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		fn(event)
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package frame
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+func TestEventWriterAndServe(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Unexpected error listening: " + err.Error())
+	}
+
+	events := make(chan logger.Event, 2)
+	done := make(chan error)
+	go func() {
+		done <- Serve(l, func(event logger.Event) {
+			events <- event
+		}, func(err error) {
+			t.Errorf("Unexpected server error: %s", err)
+		})
+	}()
+
+	ew, err := NewEventWriter(logger.InfoEvent, Config{Addr: l.Addr().String()}, func(err error) {
+		t.Errorf("Unexpected write error: %s", err)
+	})
+	if err != nil {
+		t.Fatal("Unexpected error creating EventWriter: " + err.Error())
+	}
+
+	debug := testEvent
+	debug.Type = logger.DebugEvent
+	for _, event := range []logger.Event{debug, testEvent} {
+		if err := ew.Write(event); err != nil {
+			t.Fatal("Unexpected error writing: " + err.Error())
+		}
+	}
+
+	if got := <-events; !reflect.DeepEqual(got, testEvent) {
+		t.Fatalf("Expected event %v, but got %v", testEvent, got)
+	}
+
+	if err := ew.Close(); err != nil {
+		t.Fatal("Unexpected error closing EventWriter: " + err.Error())
+	}
+	l.Close()
+	if err := <-done; err == nil {
+		t.Fatal("Expected Serve to return the error of the closed listener")
+	}
+	if len(events) != 0 {
+		t.Fatalf("Expected no more events, but got %d", len(events))
+	}
+}
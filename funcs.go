@@ -0,0 +1,51 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+// WriterFunc is an EventWriter that calls the function to write the events,
+// so simple EventWriters can be defined inline. HandleError ignores all errors,
+// use WithErrorChannel to receive them, and Close does nothing, see
+// WithCloser.
+//
+// Note: a WriterFunc isn't comparable so it can't be passed to
+// SetFailureThreshold.
+type WriterFunc func(Event) error
+
+// Write calls fn(event).
+func (fn WriterFunc) Write(event Event) error {
+	return fn(event)
+}
+
+// HandleError does nothing.
+func (fn WriterFunc) HandleError(err error) {}
+
+// Close does nothing.
+func (fn WriterFunc) Close() error {
+	return nil
+}
+
+// WithCloser returns an EventWriter that calls fn to write the events and close
+// once it's closed.
+func (fn WriterFunc) WithCloser(close CloserFunc) EventWriter {
+	return &funcEventWriter{fn, close}
+}
+
+// CloserFunc is called once an EventWriter is closed, see
+// WriterFunc.WithCloser.
+type CloserFunc func() error
+
+// Close calls fn().
+func (fn CloserFunc) Close() error {
+	return fn()
+}
+
+type funcEventWriter struct {
+	WriterFunc
+	CloserFunc
+}
+
+func (ew *funcEventWriter) Close() error {
+	return ew.CloserFunc()
+}
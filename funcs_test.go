@@ -0,0 +1,51 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWriterFunc(t *testing.T) {
+	defer reset()
+	var events []Event
+	Start(WriterFunc(func(event Event) error {
+		events = append(events, event)
+		return nil
+	}))
+
+	Info(Tags{"func"}, "Info message")
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if len(events) != 1 || events[0].Message != "Info message" {
+		t.Fatalf("Expected the event to be written, but got %v", events)
+	}
+}
+
+func TestWriterFuncWithCloser(t *testing.T) {
+	defer reset()
+	var writes int
+	closeErr := errors.New("Close error")
+	ew := WriterFunc(func(event Event) error {
+		writes++
+		return nil
+	}).WithCloser(func() error {
+		return closeErr
+	})
+	SetFailureThreshold(ew, FailureThreshold{MaxErrors: 1})
+	Start(ew)
+
+	Info(Tags{"func"}, "Info message")
+	if err := Close(); !errors.Is(err, closeErr) {
+		t.Fatalf("Expected the closing error to be %v, but got %v", closeErr, err)
+	}
+
+	if writes != 1 {
+		t.Fatalf("Expected 1 write, but got %d", writes)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package grpclogger
+
+import (
+	"context"
+
+	"github.com/Thomasdezeeuw/logger"
+	"github.com/Thomasdezeeuw/logger/id"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// Keys of the tags added to the context of each call.
+const (
+	RequestIDKey = "request_id"
+	MethodKey    = "method"
+)
+
+// Stubbed for testing.
+var newRequestID = id.New
+
+// UnaryServerInterceptor returns a gRPC server interceptor that adds the
+// request id and the full method of the call as tags to the context, see
+// logger.WithContextTags. These tags, and the tags of the registered
+// logger.ContextTaggers, are included in the events logged by the Ctx log
+// operations, e.g. logger.InfoCtx. The request id is read from the
+// id.MetadataKey metadata, or generated if not present, and send back in the
+// header of the response. It's also added to the context, see id.FromContext.
+// For example:
+//
+//	server := grpc.NewServer(
+//		grpc.ChainUnaryInterceptor(grpclogger.UnaryServerInterceptor()),
+//		grpc.ChainStreamInterceptor(grpclogger.StreamServerInterceptor()),
+//	)
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, requestID := contextWithTags(ctx, info.FullMethod)
+		grpc.SetHeader(ctx, metadata.Pairs(id.MetadataKey, requestID))
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a gRPC server interceptor that does the
+// same as UnaryServerInterceptor, but for streaming calls.
+func StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, requestID := contextWithTags(ss.Context(), info.FullMethod)
+		ss.SetHeader(metadata.Pairs(id.MetadataKey, requestID))
+		return handler(srv, &serverStream{ss, ctx})
+	}
+}
+
+// contextWithTags returns a copy of ctx with the request id and method tags,
+// and the request id.
+func contextWithTags(ctx context.Context, method string) (context.Context, string) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	requestID := id.FromMetadata(md)
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+	ctx = logger.WithContextTags(ctx, logger.Tag(RequestIDKey, requestID),
+		logger.Tag(MethodKey, method))
+	return id.WithContext(ctx, requestID), requestID
+}
+
+// serverStream overwrites the context of a grpc.ServerStream.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (ss *serverStream) Context() context.Context {
+	return ss.ctx
+}
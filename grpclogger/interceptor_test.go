@@ -0,0 +1,83 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package grpclogger
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/Thomasdezeeuw/logger"
+	"github.com/Thomasdezeeuw/logger/id"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// testServerStream is a grpc.ServerStream that records the header.
+type testServerStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	header metadata.MD
+}
+
+func (ss *testServerStream) Context() context.Context {
+	return ss.ctx
+}
+
+func (ss *testServerStream) SetHeader(md metadata.MD) error {
+	ss.header = md
+	return nil
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	oldNewRequestID := newRequestID
+	defer func() { newRequestID = oldNewRequestID }()
+	newRequestID = func() string { return "new-id" }
+
+	tests := []struct {
+		md       metadata.MD
+		expected string
+	}{
+		{nil, "new-id"},
+		{metadata.Pairs(id.MetadataKey, "abc"), "abc"},
+	}
+
+	interceptor := UnaryServerInterceptor()
+	info := &grpc.UnaryServerInfo{FullMethod: "/service/Method"}
+	for _, test := range tests {
+		ctx := metadata.NewIncomingContext(context.Background(), test.md)
+		var got logger.Tags
+		var requestID string
+		interceptor(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			got, requestID = logger.ContextTags(ctx), id.FromContext(ctx)
+			return nil, nil
+		})
+
+		expected := logger.Tags{"request_id=" + test.expected, "method=/service/Method"}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("Expected the context tags %v, but got %v", expected, got)
+		} else if requestID != test.expected {
+			t.Errorf("Expected the request id %q, but got %q", test.expected, requestID)
+		}
+	}
+}
+
+func TestStreamServerInterceptor(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(id.MetadataKey, "abc"))
+	ss := &testServerStream{ctx: ctx}
+	info := &grpc.StreamServerInfo{FullMethod: "/service/Stream"}
+	var got logger.Tags
+	StreamServerInterceptor()(nil, ss, info, func(srv interface{}, ss grpc.ServerStream) error {
+		got = logger.ContextTags(ss.Context())
+		return nil
+	})
+
+	expected := logger.Tags{"request_id=abc", "method=/service/Stream"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected the context tags %v, but got %v", expected, got)
+	} else if header := ss.header.Get(id.MetadataKey); len(header) != 1 || header[0] != "abc" {
+		t.Fatalf("Expected the request id to be send in the header, but got %v", ss.header)
+	}
+}
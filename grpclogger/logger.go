@@ -5,12 +5,14 @@
 // Package grpclogger creates a logger interface to be used in grpc logger
 // package (google.golang.org/grpc/grpclog). For more information on grpc see
 // http://www.grpc.io, for grpc-go see https://github.com/grpc/grpc-go.
+//
+// It also provides server interceptors that add the request id and method of
+// each call to its context, see UnaryServerInterceptor.
 package grpclogger
 
 import (
 	"errors"
 	"fmt"
-	"os"
 
 	"github.com/Thomasdezeeuw/logger"
 	"github.com/Thomasdezeeuw/logger/internal/util"
@@ -51,12 +53,14 @@ func (log *log) Println(args ...interface{}) {
 }
 
 // Stubbed for testing.
-var osExit = os.Exit
+var osExit = logger.ExitFunc()
 
 // Hate to do this, but it is what the default log package does.
 // Stubbed for testing.
 var exit = func(closeFn func()) {
-	closeFn()
+	if closeFn != nil {
+		closeFn()
+	}
 	osExit(1)
 }
 
@@ -73,7 +77,9 @@ var exit = func(closeFn func()) {
 // A third point is that a call to Fatal* in the builtin log package calls to
 // os.Exit, which closes the application immediately without running deffered
 // statements. To combat that we accept a close function which runs before the
-// call to os.Exit. In this function logger.Close must be called by the user.
+// application exits, closeFn may be nil. The exit is done using logger.Exit,
+// which closes the logger (if not already closed by closeFn) and runs the
+// functions registered with logger.AtExit.
 func CreateLogger(tags logger.Tags, closeFn func()) grpclog.Logger {
 	return &log{tags, closeFn}
 }
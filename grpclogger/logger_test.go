@@ -49,8 +49,9 @@ func TestGrpcLogger(t *testing.T) {
 	tags := logger.Tags{"TestGrpcLogger"}
 	logTime := time.Now()
 
-	grpclog.SetLogger(CreateLogger(tags, closeFn))
-	expectedEvents := callGrpcLogger(tags)
+	// Since grpc-go 1.x grpclog.Fatal calls os.Exit after the logger returns,
+	// so the logger is called directly.
+	expectedEvents := callGrpcLogger(CreateLogger(tags, closeFn), tags)
 
 	if err := logger.Close(); err != nil {
 		t.Fatal("Unexpected error closing logger: " + err.Error())
@@ -63,6 +64,7 @@ func TestGrpcLogger(t *testing.T) {
 	for i, event := range ew.events {
 		expected, got := expectedEvents[i], event
 		expected.Timestamp = logTime
+		expected.Seq = uint64(i + 1)
 
 		if err := compareEvents(i, expected, got); err != nil {
 			t.Error(err)
@@ -75,14 +77,14 @@ func TestGrpcLogger(t *testing.T) {
 	}
 }
 
-// Make calls to the grpclog package and returns the expected events.
-func callGrpcLogger(tags logger.Tags) (expected []logger.Event) {
-	grpclog.Print("Error message")
-	grpclog.Printf("Error %s message", "formatted")
-	grpclog.Println("Error message")
-	grpclog.Fatal("Fatal message")
-	grpclog.Fatalf("Fatal %s message", "formatted")
-	grpclog.Fatalln("Fatal message")
+// Make calls to the grpclog Logger and returns the expected events.
+func callGrpcLogger(log grpclog.Logger, tags logger.Tags) (expected []logger.Event) {
+	log.Print("Error message")
+	log.Printf("Error %s message", "formatted")
+	log.Println("Error message")
+	log.Fatal("Fatal message")
+	log.Fatalf("Fatal %s message", "formatted")
+	log.Fatalln("Fatal message")
 
 	return []logger.Event{
 		{Type: logger.ErrorEvent, Tags: tags, Message: "Error message"},
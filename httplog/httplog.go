@@ -0,0 +1,216 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+// Package httplog provides an HTTP middleware that logs each request and makes
+// a request-scoped Logger available to the handlers, for example:
+//
+//	func handler(w http.ResponseWriter, r *http.Request) {
+//		log := httplog.From(r.Context())
+//		log.Info("Handling request")
+//	}
+//
+//	http.ListenAndServe(":8080", httplog.Handler(http.HandlerFunc(handler)))
+//
+// All events logged with the Logger include the request id, method and path
+// as key=value tags, see logger.Tag. The same tags are added to the context
+// of the request, so they're also included by logger.InfoCtx etc.
+package httplog
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+	"github.com/Thomasdezeeuw/logger/id"
+)
+
+// RequestIDHeader is the header used to read the request id from the request,
+// if not present (or invalid) a new id is generated, see id.FromRequest. The
+// request id is also set in the response using this header.
+const RequestIDHeader = id.Header
+
+// Keys of the tags added to each request.
+const (
+	RequestIDKey = "request_id"
+	MethodKey    = "method"
+	PathKey      = "path"
+)
+
+type loggerKey struct{}
+
+// Handler returns an http.Handler that adds a request-scoped Logger to the
+// context of the request, which can be retrieved using From, before calling
+// next. The request id is also added to the context, see id.FromContext.
+// After next returns the request is logged, with the status code and
+// duration, as an Info event or an Error event if the status code is 500 or
+// higher.
+func Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := now()
+		requestID := id.FromRequest(r)
+		if requestID == "" {
+			requestID = newRequestID()
+		}
+		id.Inject(w.Header(), requestID)
+
+		tags := logger.Tags{
+			logger.Tag(RequestIDKey, requestID),
+			logger.Tag(MethodKey, r.Method),
+			logger.Tag(PathKey, r.URL.Path),
+		}
+		log := Logger{tags}
+		ctx := logger.WithContextTags(r.Context(), tags...)
+		ctx = context.WithValue(ctx, loggerKey{}, log)
+		ctx = id.WithContext(ctx, requestID)
+
+		rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rw, r.WithContext(ctx))
+
+		msg := fmt.Sprintf("%s %s %d %s", r.Method, r.URL.Path, rw.status, now().Sub(start))
+		if rw.status >= http.StatusInternalServerError {
+			log.Error(errorString(msg))
+		} else {
+			log.Info(msg)
+		}
+	})
+}
+
+// Stubbed for testing.
+var (
+	now          = time.Now
+	newRequestID = id.New
+)
+
+type errorString string
+
+func (err errorString) Error() string {
+	return string(err)
+}
+
+// responseWriter records the status code of the response.
+type responseWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush implements http.Flusher, if the underlying ResponseWriter does.
+func (w *responseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker, if the underlying ResponseWriter does, e.g.
+// to support WebSockets. The request is logged with the status code 101
+// (Switching Protocols).
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("httplog: %T doesn't support hijacking", w.ResponseWriter)
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err == nil && !w.wroteHeader {
+		w.status = http.StatusSwitchingProtocols
+		w.wroteHeader = true
+	}
+	return conn, rw, err
+}
+
+// Unwrap returns the underlying ResponseWriter, used by
+// http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// From returns the request-scoped Logger from the context, as added by
+// Handler. If the context doesn't contain a Logger, a Logger with the tags
+// from the context (see logger.ContextTags) is returned.
+func From(ctx context.Context) Logger {
+	if log, ok := ctx.Value(loggerKey{}).(Logger); ok {
+		return log
+	}
+	return Logger{logger.ContextTags(ctx)}
+}
+
+// Logger is a request-scoped logger, it adds its tags to all events it logs.
+type Logger struct {
+	tags logger.Tags
+}
+
+// Tags returns the tags of the Logger, the returned tags must not be modified.
+func (log Logger) Tags() logger.Tags {
+	return log.tags
+}
+
+// With returns a new Logger with the given tags added.
+func (log Logger) With(tags ...string) Logger {
+	return Logger{log.withTags(tags)}
+}
+
+// withTags returns the tags of the Logger followed by tags, it never modifies
+// the tags of the Logger.
+func (log Logger) withTags(tags logger.Tags) logger.Tags {
+	newTags := make(logger.Tags, 0, len(log.tags)+len(tags))
+	newTags = append(newTags, log.tags...)
+	return append(newTags, tags...)
+}
+
+// Trace logs a trace message, see logger.Trace.
+func (log Logger) Trace(msg string, tags ...string) {
+	logger.Trace(log.withTags(tags), msg)
+}
+
+// Debug logs a debug message, see logger.Debug.
+func (log Logger) Debug(msg string, tags ...string) {
+	logger.Debug(log.withTags(tags), msg)
+}
+
+// Info logs an informational message, see logger.Info.
+func (log Logger) Info(msg string, tags ...string) {
+	logger.Info(log.withTags(tags), msg)
+}
+
+// Notice logs a notice message, see logger.Notice.
+func (log Logger) Notice(msg string, tags ...string) {
+	logger.Notice(log.withTags(tags), msg)
+}
+
+// Warn logs a warning message, see logger.Warn.
+func (log Logger) Warn(msg string, tags ...string) {
+	logger.Warn(log.withTags(tags), msg)
+}
+
+// Error logs an error message, see logger.Error.
+func (log Logger) Error(err error, tags ...string) {
+	logger.Error(log.withTags(tags), err)
+}
+
+// Security logs a security message, see logger.Security.
+func (log Logger) Security(msg string, tags ...string) {
+	logger.Security(log.withTags(tags), msg)
+}
+
+// Log logs a custom created event, see logger.Log.
+func (log Logger) Log(event logger.Event) {
+	event.Tags = log.withTags(event.Tags)
+	logger.Log(event)
+}
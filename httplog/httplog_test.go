@@ -0,0 +1,163 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package httplog
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+	"github.com/Thomasdezeeuw/logger/id"
+)
+
+// EventWriter that collects the events.
+type eventWriter struct {
+	events []logger.Event
+}
+
+func (ew *eventWriter) Write(event logger.Event) error {
+	ew.events = append(ew.events, event)
+	return nil
+}
+
+func (ew *eventWriter) HandleError(err error) {}
+func (ew *eventWriter) Close() error          { return nil }
+
+func TestHandler(t *testing.T) {
+	t1 := time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC)
+	times := []time.Time{t1, t1.Add(10 * time.Millisecond), t1, t1.Add(time.Second)}
+	now = func() time.Time {
+		t := times[0]
+		times = times[1:]
+		return t
+	}
+	newRequestID = func() string { return "generated" }
+
+	var ew eventWriter
+	logger.Start(&ew)
+
+	handler := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got, expected := id.FromContext(r.Context()), r.Header.Get(RequestIDHeader); expected != "" && got != expected {
+			t.Errorf("Expected the request id in the context to be %q, but got %q", expected, got)
+		}
+		log := From(r.Context())
+		log.Info("Handler message", "tag")
+		logger.WarnCtx(r.Context(), logger.Tags{"ctx"}, "Context message")
+		if r.URL.Path == "/error" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		w.Write([]byte("Hello"))
+	}))
+
+	req := httptest.NewRequest("GET", "/path", nil)
+	req.Header.Set(RequestIDHeader, "abc")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if got := rec.Header().Get(RequestIDHeader); got != "abc" {
+		t.Fatalf("Expected the request id header to be %q, but got %q", "abc", got)
+	}
+
+	req = httptest.NewRequest("POST", "/error", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if got := rec.Header().Get(RequestIDHeader); got != "generated" {
+		t.Fatalf("Expected the request id header to be %q, but got %q", "generated", got)
+	}
+
+	if err := logger.Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	tags1 := logger.Tags{"request_id=abc", "method=GET", "path=/path"}
+	tags2 := logger.Tags{"request_id=generated", "method=POST", "path=/error"}
+	expected := []logger.Event{
+		{Type: logger.InfoEvent, Tags: append(tags1, "tag"), Message: "Handler message"},
+		{Type: logger.WarnEvent, Tags: append(logger.Tags{"ctx"}, tags1...), Message: "Context message"},
+		{Type: logger.InfoEvent, Tags: tags1, Message: "GET /path 200 10ms"},
+		{Type: logger.InfoEvent, Tags: append(tags2, "tag"), Message: "Handler message"},
+		{Type: logger.WarnEvent, Tags: append(logger.Tags{"ctx"}, tags2...), Message: "Context message"},
+		{Type: logger.ErrorEvent, Tags: tags2, Message: "POST /error 500 1s"},
+	}
+	if len(ew.events) != len(expected) {
+		t.Fatalf("Expected %d events, but got %d: %v", len(expected), len(ew.events), ew.events)
+	}
+	for i, event := range ew.events {
+		event.Timestamp = time.Time{}
+		event.Seq = 0
+		if !reflect.DeepEqual(event, expected[i]) {
+			t.Errorf("Expected event #%d to be %v, but got %v", i, expected[i], event)
+		}
+	}
+}
+
+func TestHandlerHijack(t *testing.T) {
+	now = time.Now
+	logger.Reset()
+	defer logger.Reset()
+	var ew eventWriter
+	logger.Start(&ew)
+
+	done := make(chan struct{})
+	handler := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The ResponseController must be able to reach the underlying
+		// ResponseWriter.
+		rc := http.NewResponseController(w)
+		if err := rc.SetWriteDeadline(time.Now().Add(time.Second)); err != nil {
+			t.Errorf("Unexpected error setting the write deadline: %s", err)
+		}
+		conn, rw, err := rc.Hijack()
+		if err != nil {
+			t.Errorf("Unexpected error hijacking: %s", err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nHijacked")
+		rw.Flush()
+	}))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r)
+		close(done)
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/hijack")
+	if err != nil {
+		t.Fatal("Unexpected error requesting: " + err.Error())
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "Hijacked" {
+		t.Fatalf("Expected the hijacked response, but got %q", body)
+	}
+
+	<-done
+	if err := logger.Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+	if len(ew.events) != 1 || !strings.HasPrefix(ew.events[0].Message, "GET /hijack 101 ") {
+		t.Fatalf("Expected the hijacked request to be logged, but got %v", ew.events)
+	}
+}
+
+func TestFrom(t *testing.T) {
+	ctx := logger.WithContextTags(context.Background(), "tag1")
+	log := From(ctx)
+	if expected := (logger.Tags{"tag1"}); !reflect.DeepEqual(log.Tags(), expected) {
+		t.Fatalf("Expected tags %v, but got %v", expected, log.Tags())
+	}
+
+	child := log.With("tag2")
+	if expected := (logger.Tags{"tag1", "tag2"}); !reflect.DeepEqual(child.Tags(), expected) {
+		t.Fatalf("Expected tags %v, but got %v", expected, child.Tags())
+	} else if expected := (logger.Tags{"tag1"}); !reflect.DeepEqual(log.Tags(), expected) {
+		t.Fatalf("Expected the parent tags to be unchanged, but got %v", log.Tags())
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+// Package id provides request (or correlation) id utilities, used by the HTTP
+// and gRPC integrations, see the httplog and grpclogger packages. It can
+// generate ULIDs and version 7 UUIDs, both of which sort by creation time, and
+// extract and inject request ids from HTTP headers, gRPC metadata and
+// contexts.
+package id
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Header is the HTTP header used to transfer request ids.
+const Header = "X-Request-ID"
+
+// MetadataKey is the gRPC metadata key used to transfer request ids, gRPC
+// metadata keys are lowercase.
+const MetadataKey = "x-request-id"
+
+// MaxLength is the maximum length of a request id accepted by FromRequest.
+const MaxLength = 128
+
+// Stubbed for testing.
+var (
+	now              = time.Now
+	random io.Reader = rand.Reader
+)
+
+// crockford is the Crockford base32 alphabet used by ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID returns a new ULID, see https://github.com/ulid/spec. It consists
+// of a 48 bit timestamp in milliseconds followed by 80 random bits, encoded as
+// 26 characters using Crockford's base32.
+func NewULID() string {
+	var b [16]byte
+	putMillis(b[:6], now())
+	io.ReadFull(random, b[6:])
+
+	// Encode the 128 bits as 26 characters of 5 bits each, the first
+	// character only contains 3 bits.
+	var dst [26]byte
+	var bits uint
+	var acc uint32
+	n := len(dst) - 1
+	for i := len(b) - 1; i >= 0; i-- {
+		acc |= uint32(b[i]) << bits
+		bits += 8
+		for bits >= 5 && n >= 0 {
+			dst[n] = crockford[acc&0x1F]
+			acc >>= 5
+			bits -= 5
+			n--
+		}
+	}
+	if n >= 0 {
+		dst[n] = crockford[acc&0x1F]
+	}
+	return string(dst[:])
+}
+
+// NewUUIDv7 returns a new version 7 UUID, see RFC 9562. It consists of a 48 bit
+// timestamp in milliseconds followed by random bits (and the version and
+// variant bits), formatted as xxxxxxxx-xxxx-7xxx-xxxx-xxxxxxxxxxxx.
+func NewUUIDv7() string {
+	var b [16]byte
+	putMillis(b[:6], now())
+	io.ReadFull(random, b[6:])
+	b[6] = b[6]&0x0F | 0x70 // Version 7.
+	b[8] = b[8]&0x3F | 0x80 // Variant 10.
+
+	var dst [36]byte
+	hex.Encode(dst[0:8], b[0:4])
+	dst[8] = '-'
+	hex.Encode(dst[9:13], b[4:6])
+	dst[13] = '-'
+	hex.Encode(dst[14:18], b[6:8])
+	dst[18] = '-'
+	hex.Encode(dst[19:23], b[8:10])
+	dst[23] = '-'
+	hex.Encode(dst[24:], b[10:])
+	return string(dst[:])
+}
+
+// putMillis puts the Unix timestamp in milliseconds as 48 bit big endian
+// integer in b.
+func putMillis(b []byte, t time.Time) {
+	ms := uint64(t.UnixNano() / int64(time.Millisecond))
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+}
+
+// New returns a new request id, it's a ULID. See NewULID.
+func New() string {
+	return NewULID()
+}
+
+// FromRequest returns the request id from the Header of the request. It
+// returns an empty string if the header is not set, or if the request id is
+// invalid: longer then MaxLength or containing characters other then
+// printable ASCII. This prevents clients from injecting arbitrary data into
+// the logs.
+func FromRequest(r *http.Request) string {
+	id := r.Header.Get(Header)
+	if !valid(id) {
+		return ""
+	}
+	return id
+}
+
+// FromMetadata returns the request id from the MetadataKey of the gRPC
+// metadata, e.g. metadata.MD. Like FromRequest it returns an empty string if
+// the request id is not set or invalid.
+func FromMetadata(md map[string][]string) string {
+	values := md[MetadataKey]
+	if len(values) == 0 || !valid(values[0]) {
+		return ""
+	}
+	return values[0]
+}
+
+func valid(id string) bool {
+	if len(id) == 0 || len(id) > MaxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// FromRequestOrNew returns the request id from the request, see FromRequest,
+// or a new request id if the request doesn't have a valid one.
+func FromRequestOrNew(r *http.Request) string {
+	if id := FromRequest(r); id != "" {
+		return id
+	}
+	return New()
+}
+
+// Inject sets the request id as Header in the given header, e.g. of an
+// outgoing request or a response.
+func Inject(header http.Header, id string) {
+	header.Set(Header, id)
+}
+
+type contextKey struct{}
+
+// WithContext returns a copy of ctx with the request id.
+func WithContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request id from the context, or an empty string if
+// the context doesn't have one.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
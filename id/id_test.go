@@ -0,0 +1,120 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package id
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var t1 = time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC)
+
+func stub() func() {
+	oldNow, oldRandom := now, random
+	now = func() time.Time { return t1 }
+	random = bytes.NewReader([]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9})
+	return func() { now, random = oldNow, oldRandom }
+}
+
+func TestNewULID(t *testing.T) {
+	defer stub()()
+	if got, expected := NewULID(), "019Y4MKFZ0000G40R40M30E209"; got != expected {
+		t.Fatalf("Expected ULID %q, but got %q", expected, got)
+	}
+}
+
+func TestNewUUIDv7(t *testing.T) {
+	defer stub()()
+	if got, expected := NewUUIDv7(), "014f8949-bfe0-7001-8203-040506070809"; got != expected {
+		t.Fatalf("Expected UUID %q, but got %q", expected, got)
+	}
+}
+
+func TestNewULIDSorts(t *testing.T) {
+	id1 := New()
+	time.Sleep(2 * time.Millisecond)
+	id2 := New()
+	if len(id1) != 26 || len(id2) != 26 {
+		t.Fatalf("Expected ULIDs to be 26 characters, but got %q and %q", id1, id2)
+	} else if id1 >= id2 {
+		t.Fatalf("Expected %q to sort before %q", id1, id2)
+	}
+}
+
+func TestFromRequest(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"", ""},
+		{"abc-123", "abc-123"},
+		{"with space", ""},
+		{"new\nline", ""},
+		{strings.Repeat("a", MaxLength), strings.Repeat("a", MaxLength)},
+		{strings.Repeat("a", MaxLength+1), ""},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		if test.header != "" {
+			r.Header.Set(Header, test.header)
+		}
+
+		if got := FromRequest(r); got != test.expected {
+			t.Fatalf("Expected FromRequest to return %q for %q, but got %q",
+				test.expected, test.header, got)
+		}
+
+		got := FromRequestOrNew(r)
+		if test.expected != "" && got != test.expected {
+			t.Fatalf("Expected FromRequestOrNew to return %q, but got %q", test.expected, got)
+		} else if test.expected == "" && len(got) != 26 {
+			t.Fatalf("Expected FromRequestOrNew to return a new id, but got %q", got)
+		}
+	}
+}
+
+func TestFromMetadata(t *testing.T) {
+	tests := []struct {
+		md       map[string][]string
+		expected string
+	}{
+		{nil, ""},
+		{map[string][]string{MetadataKey: {}}, ""},
+		{map[string][]string{MetadataKey: {"abc-123", "other"}}, "abc-123"},
+		{map[string][]string{MetadataKey: {"new\nline"}}, ""},
+	}
+
+	for _, test := range tests {
+		if got := FromMetadata(test.md); got != test.expected {
+			t.Errorf("Expected FromMetadata(%v) to return %q, but got %q", test.md, test.expected, got)
+		}
+	}
+}
+
+func TestInject(t *testing.T) {
+	header := http.Header{}
+	Inject(header, "abc")
+	if got := header.Get("X-Request-Id"); got != "abc" {
+		t.Fatalf("Expected the header to be %q, but got %q", "abc", got)
+	}
+}
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	if got := FromContext(ctx); got != "" {
+		t.Fatalf("Expected no request id, but got %q", got)
+	}
+
+	ctx = WithContext(ctx, "abc")
+	if got := FromContext(ctx); got != "abc" {
+		t.Fatalf("Expected the request id to be %q, but got %q", "abc", got)
+	}
+}
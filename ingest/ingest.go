@@ -0,0 +1,405 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+// Package ingest provides a server that receives events from other processes
+// and writes them to local EventWriters, turning the logger package into a
+// small central log collector.
+//
+// The server accepts events on a single address in two ways. Connections
+// starting with the frame magic "LOGF" are read as a stream of frames, see the
+// frame package and its EventWriter. All other connections are served as HTTP,
+// on which events can be POSTed in the framed format, with the
+// "application/x-logger-frame" content type, or in the JSON format of
+// logger.Event.MarshalJSON, with one or more events per request body.
+package ingest
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+	"github.com/Thomasdezeeuw/logger/frame"
+)
+
+// FrameContentType is the HTTP content type of a request body containing
+// frames.
+const FrameContentType = "application/x-logger-frame"
+
+// Defaults of the limits of the Server.
+const (
+	DefaultReadTimeout = 30 * time.Second
+	DefaultIdleTimeout = 5 * time.Minute
+	DefaultMaxBodySize = 10 * 1024 * 1024
+)
+
+// Size of the queue of received events, see Server.write.
+const queueSize = 1024
+
+// Stubbed for testing.
+var sniffTimeout = 10 * time.Second
+
+// Server writes received events to the EventWriters. It implements
+// http.Handler. The limits must be set before serving any connections.
+type Server struct {
+	// ErrorHandler, if not nil, is called with the errors of reading framed
+	// connections, e.g. an invalid frame. Errors of HTTP requests are returned
+	// in the response.
+	ErrorHandler func(error)
+
+	// ReadTimeout is the maximum duration to read the headers and body of
+	// an HTTP request, defaults to DefaultReadTimeout.
+	ReadTimeout time.Duration
+
+	// IdleTimeout is the maximum duration a framed connection, or an HTTP
+	// connection between requests, may be idle before it's closed. Defaults to
+	// DefaultIdleTimeout.
+	IdleTimeout time.Duration
+
+	// MaxBodySize is the maximum size of an HTTP request body in bytes,
+	// larger requests fail with a 413 Request Entity Too Large response.
+	// Defaults to DefaultMaxBodySize.
+	MaxBodySize int64
+
+	ews    []logger.EventWriter
+	events chan logger.Event
+	closed chan struct{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+// New creates a new Server that writes all received events to the
+// EventWriters. The EventWriters are written to by a single goroutine, in the
+// same way the logger writes to them, but errors are only passed to the error
+// handler, the events aren't written again. The Server must be closed to stop
+// this goroutine, see Close.
+func New(ews ...logger.EventWriter) *Server {
+	s := &Server{
+		ews:    ews,
+		events: make(chan logger.Event, queueSize),
+		closed: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go s.writeEvents()
+	return s
+}
+
+// write queues the event to be written to the EventWriters, so connections
+// don't wait on each other writing events. If the queue is full it blocks
+// until there is room, slowing down the sender. Events received after the
+// Server is closed are dropped.
+func (s *Server) write(event logger.Event) {
+	select {
+	case s.events <- event:
+	case <-s.closed:
+	}
+}
+
+// writeEvents writes the queued events to all EventWriters, until the Server
+// is closed.
+func (s *Server) writeEvents() {
+	defer close(s.done)
+	for {
+		select {
+		case event := <-s.events:
+			s.writeEvent(event)
+		case <-s.closed:
+			// Write the events queued before closing.
+			for {
+				select {
+				case event := <-s.events:
+					s.writeEvent(event)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (s *Server) writeEvent(event logger.Event) {
+	for _, ew := range s.ews {
+		if err := ew.Write(event); err != nil {
+			ew.HandleError(err)
+		}
+	}
+}
+
+// Close stops writing events, after writing the events already received. It
+// doesn't close the EventWriters nor the connections, see Serve.
+func (s *Server) Close() error {
+	s.once.Do(func() { close(s.closed) })
+	<-s.done
+	return nil
+}
+
+func (s *Server) readTimeout() time.Duration {
+	if s.ReadTimeout <= 0 {
+		return DefaultReadTimeout
+	}
+	return s.ReadTimeout
+}
+
+func (s *Server) idleTimeout() time.Duration {
+	if s.IdleTimeout <= 0 {
+		return DefaultIdleTimeout
+	}
+	return s.IdleTimeout
+}
+
+func (s *Server) maxBodySize() int64 {
+	if s.MaxBodySize <= 0 {
+		return DefaultMaxBodySize
+	}
+	return s.MaxBodySize
+}
+
+// ServeHTTP reads the events in the request body, only the POST method is
+// allowed. If the body contains an invalid event a 400 Bad Request response
+// is returned, the events before it are written. The same applies to bodies
+// larger then the maximum body size, with a 413 Request Entity Too Large
+// response.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, s.maxBodySize())
+	var err error
+	if r.Header.Get("Content-Type") == FrameContentType {
+		err = s.readFrames(body)
+	} else {
+		err = s.readJSON(body)
+	}
+	if err != nil {
+		code := http.StatusBadRequest
+		if isMaxBytesError(err) {
+			code = http.StatusRequestEntityTooLarge
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// isMaxBytesError returns true if the error is returned by a reader created by
+// http.MaxBytesReader.
+func isMaxBytesError(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
+
+// readFrames reads frames until r is empty.
+func (s *Server) readFrames(r io.Reader) error {
+	fr := frame.NewReader(r)
+	for {
+		event, err := fr.ReadEvent()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		s.write(event)
+	}
+}
+
+// readJSON reads JSON events until r is empty.
+func (s *Server) readJSON(r io.Reader) error {
+	dec := json.NewDecoder(r)
+	for {
+		var event logger.Event
+		if err := dec.Decode(&event); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		s.write(event)
+	}
+}
+
+// Serve accepts connections on the listener, see the package documentation.
+// It blocks until the listener is closed, after which it closes all
+// connections and returns the error returned by the listener.
+//
+// Framed connections are closed once idle for longer then the idle timeout,
+// HTTP connections are limited by the read and idle timeout.
+func (s *Server) Serve(l net.Listener) error {
+	httpListener := &connListener{addr: l.Addr(), conns: make(chan net.Conn), done: make(chan struct{})}
+	srv := &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: s.readTimeout(),
+		ReadTimeout:       s.readTimeout(),
+		IdleTimeout:       s.idleTimeout(),
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		srv.Serve(httpListener)
+	}()
+
+	var mu sync.Mutex
+	var closed bool
+	frameConns := make(map[net.Conn]struct{})
+	defer func() {
+		close(httpListener.done)
+		srv.Close()
+		mu.Lock()
+		closed = true
+		for conn := range frameConns {
+			conn.Close()
+			delete(frameConns, conn)
+		}
+		mu.Unlock()
+		wg.Wait()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conn, isFrame := sniff(conn)
+			if conn == nil {
+				return
+			} else if !isFrame {
+				httpListener.send(conn)
+				return
+			}
+
+			mu.Lock()
+			if closed {
+				mu.Unlock()
+				conn.Close()
+				return
+			}
+			frameConns[conn] = struct{}{}
+			mu.Unlock()
+
+			err := s.readFrames(&idleConn{conn, s.idleTimeout()})
+			mu.Lock()
+			_, open := frameConns[conn]
+			delete(frameConns, conn)
+			mu.Unlock()
+			conn.Close()
+			// Errors caused by closing the connection in Serve are expected.
+			if err != nil && open && s.ErrorHandler != nil {
+				s.ErrorHandler(err)
+			}
+		}()
+	}
+}
+
+// sniff returns true if the connection starts with the frame magic. The
+// returned connection still includes the read bytes. If nothing can be read
+// the connection is closed and nil is returned.
+func sniff(conn net.Conn) (net.Conn, bool) {
+	br := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(sniffTimeout))
+	b, err := br.Peek(4)
+	conn.SetReadDeadline(time.Time{})
+	if err != nil && len(b) == 0 {
+		conn.Close()
+		return nil, false
+	}
+	return &bufferedConn{conn, br}, string(b) == "LOGF"
+}
+
+// idleConn is a net.Conn that fails reading once no data is received within
+// the timeout.
+type idleConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (conn *idleConn) Read(b []byte) (int, error) {
+	conn.SetReadDeadline(time.Now().Add(conn.timeout))
+	return conn.Conn.Read(b)
+}
+
+// bufferedConn is a net.Conn that reads from a bufio.Reader.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (conn *bufferedConn) Read(b []byte) (int, error) {
+	return conn.r.Read(b)
+}
+
+// connListener is a net.Listener that returns the connections send to it.
+type connListener struct {
+	addr  net.Addr
+	conns chan net.Conn
+	done  chan struct{}
+}
+
+func (l *connListener) send(conn net.Conn) {
+	select {
+	case l.conns <- conn:
+	case <-l.done:
+		conn.Close()
+	}
+}
+
+func (l *connListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *connListener) Close() error {
+	return nil
+}
+
+func (l *connListener) Addr() net.Addr {
+	return l.addr
+}
+
+// ListenAndServe listens on the TCP address and writes all received events to
+// the EventWriters, see Server. It blocks until an error occurs.
+func ListenAndServe(addr string, ews ...logger.EventWriter) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return serve(l, ews)
+}
+
+// serve serves the listener using a new Server, closing it once done.
+func serve(l net.Listener, ews []logger.EventWriter) error {
+	s := New(ews...)
+	defer s.Close()
+	return s.Serve(l)
+}
+
+// ListenAndServeTLS does the same as ListenAndServe, but only accepts TLS
+// connections, using the certificate and key in the files.
+func ListenAndServeTLS(addr, certFile, keyFile string, ews ...logger.EventWriter) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	l = tls.NewListener(l, &tls.Config{Certificates: []tls.Certificate{cert}})
+	return serve(l, ews)
+}
@@ -0,0 +1,200 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package ingest
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+	"github.com/Thomasdezeeuw/logger/frame"
+)
+
+var testEvent = logger.Event{
+	Type:      logger.InfoEvent,
+	Timestamp: time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC),
+	Tags:      logger.Tags{"ingest", logger.Tag("host", "web-1")},
+	Message:   "Info message",
+	Data:      "data",
+}
+
+// eventWriter sends all events to a channel.
+type eventWriter struct {
+	events chan logger.Event
+}
+
+func (ew eventWriter) Write(event logger.Event) error {
+	ew.events <- event
+	return nil
+}
+
+func (ew eventWriter) HandleError(err error) {}
+func (ew eventWriter) Close() error          { return nil }
+
+func expectEvent(t *testing.T, events <-chan logger.Event, expected logger.Event) {
+	select {
+	case got := <-events:
+		if !reflect.DeepEqual(got, expected) {
+			t.Fatalf("Expected event %#v, but got %#v", expected, got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("Timed out waiting for event %v", expected)
+	}
+}
+
+func TestServeHTTP(t *testing.T) {
+	ew := eventWriter{make(chan logger.Event, 10)}
+	s := New(ew)
+	defer s.Close()
+
+	body, _ := testEvent.MarshalJSON()
+	body = append(body, '\n')
+	body = append(body, body...)
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected status %d, but got %d: %s", http.StatusNoContent, rec.Code, rec.Body)
+	}
+	expectEvent(t, ew.events, testEvent)
+	expectEvent(t, ew.events, testEvent)
+
+	body, _ = frame.AppendFrame(nil, testEvent, frame.Protobuf)
+	req = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", FrameContentType)
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("Expected status %d, but got %d: %s", http.StatusNoContent, rec.Code, rec.Body)
+	}
+	expectEvent(t, ew.events, testEvent)
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"type": "Info"}`))
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, but got %d", http.StatusBadRequest, rec.Code)
+	}
+
+	s.MaxBodySize = int64(len(body)) - 1
+	req = httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", FrameContentType)
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("Expected status %d, but got %d", http.StatusRequestEntityTooLarge, rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("Expected status %d, but got %d", http.StatusMethodNotAllowed, rec.Code)
+	}
+	if len(ew.events) != 0 {
+		t.Fatalf("Expected no more events, but got %d", len(ew.events))
+	}
+}
+
+func TestServe(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Unexpected error listening: " + err.Error())
+	}
+	ew := eventWriter{make(chan logger.Event, 10)}
+	s := New(ew)
+	defer s.Close()
+	s.ErrorHandler = func(err error) {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	done := make(chan error)
+	go func() {
+		done <- s.Serve(l)
+	}()
+
+	// Framed TCP connection.
+	fw, err := frame.NewEventWriter(logger.DebugEvent, frame.Config{Addr: l.Addr().String()}, nil)
+	if err != nil {
+		t.Fatal("Unexpected error creating EventWriter: " + err.Error())
+	}
+	if err := fw.Write(testEvent); err != nil {
+		t.Fatal("Unexpected error writing: " + err.Error())
+	}
+	expectEvent(t, ew.events, testEvent)
+	defer fw.Close()
+
+	// HTTP on the same address.
+	body, _ := testEvent.MarshalJSON()
+	resp, err := http.Post("http://"+l.Addr().String()+"/", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal("Unexpected error posting: " + err.Error())
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("Expected status %d, but got %d", http.StatusNoContent, resp.StatusCode)
+	}
+	expectEvent(t, ew.events, testEvent)
+
+	l.Close()
+	if err := <-done; err == nil {
+		t.Fatal("Expected Serve to return the error of the closed listener")
+	}
+}
+
+func TestServeIdleTimeout(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Unexpected error listening: " + err.Error())
+	}
+	defer l.Close()
+	ew := eventWriter{make(chan logger.Event, 10)}
+	s := New(ew)
+	defer s.Close()
+	s.IdleTimeout = 50 * time.Millisecond
+	errs := make(chan error, 1)
+	s.ErrorHandler = func(err error) { errs <- err }
+	go s.Serve(l)
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal("Unexpected error dialing: " + err.Error())
+	}
+	defer conn.Close()
+	body, _ := frame.AppendFrame(nil, testEvent, frame.Protobuf)
+	if _, err := conn.Write(body); err != nil {
+		t.Fatal("Unexpected error writing: " + err.Error())
+	}
+	expectEvent(t, ew.events, testEvent)
+
+	select {
+	case err := <-errs:
+		if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+			t.Fatalf("Expected a timeout error, but got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the idle connection to be closed")
+	}
+}
+
+func TestServerClose(t *testing.T) {
+	ew := eventWriter{make(chan logger.Event, 10)}
+	s := New(ew)
+	s.write(testEvent)
+	if err := s.Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+	// Events received before closing are written, later events are dropped.
+	expectEvent(t, ew.events, testEvent)
+	s.write(testEvent)
+	if len(ew.events) != 0 {
+		t.Fatalf("Expected no more events, but got %d", len(ew.events))
+	}
+}
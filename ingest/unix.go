@@ -0,0 +1,61 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package ingest
+
+import (
+	"errors"
+	"net"
+	"os"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+// ListenUnix listens on the Unix socket at path. If the path is a socket that
+// isn't used by another process, e.g. left behind after a crash, it's removed
+// first. The socket is removed once the listener is closed.
+func ListenUnix(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, errors.New("ingest: socket " + path + " is already in use")
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+// ListenAndServeUnix listens on the Unix socket at path, see ListenUnix, and
+// writes all received events to the EventWriters, see Server. This allows
+// processes on the same host to send their events to a single process, e.g.
+// using the EventWriter of the frame package with "unix" as network. To write
+// the events to the logger of the receiving process pass Pipeline() as
+// EventWriter. It blocks until an error occurs.
+func ListenAndServeUnix(path string, ews ...logger.EventWriter) error {
+	l, err := ListenUnix(path)
+	if err != nil {
+		return err
+	}
+	return serve(l, ews)
+}
+
+// Pipeline returns an EventWriter that logs the events using logger.Forward,
+// so received events are written to the EventWriters the logger is started
+// with, in order with the events logged by the process itself. Closing it does
+// nothing.
+func Pipeline() logger.EventWriter {
+	return pipelineEventWriter{}
+}
+
+type pipelineEventWriter struct{}
+
+func (pipelineEventWriter) Write(event logger.Event) error {
+	logger.Forward(event)
+	return nil
+}
+
+func (pipelineEventWriter) HandleError(err error) {}
+func (pipelineEventWriter) Close() error          { return nil }
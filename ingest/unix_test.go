@@ -0,0 +1,66 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package ingest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Thomasdezeeuw/logger"
+	"github.com/Thomasdezeeuw/logger/frame"
+)
+
+func TestListenUnix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logger-ingest")
+	if err != nil {
+		t.Fatal("Unexpected error creating temp dir: " + err.Error())
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "logger.sock")
+
+	l, err := ListenUnix(path)
+	if err != nil {
+		t.Fatal("Unexpected error listening: " + err.Error())
+	}
+	if _, err := ListenUnix(path); err == nil {
+		t.Fatal("Expected an error listening on a socket in use")
+	}
+
+	defer logger.Reset()
+	ew := eventWriter{make(chan logger.Event, 10)}
+	logger.Start(ew)
+
+	s := New(Pipeline())
+	defer s.Close()
+	done := make(chan error)
+	go func() {
+		done <- s.Serve(l)
+	}()
+
+	fw, err := frame.NewEventWriter(logger.DebugEvent, frame.Config{Network: "unix", Addr: path}, nil)
+	if err != nil {
+		t.Fatal("Unexpected error creating EventWriter: " + err.Error())
+	}
+	defer fw.Close()
+	event := testEvent
+	event.Seq = 100
+	if err := fw.Write(event); err != nil {
+		t.Fatal("Unexpected error writing: " + err.Error())
+	}
+
+	expected := testEvent
+	expected.Seq = 1
+	expectEvent(t, ew.events, expected)
+
+	l.Close()
+	if err := <-done; err == nil {
+		t.Fatal("Expected Serve to return the error of the closed listener")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("Expected the socket to be removed, but got %v", err)
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+// Package cbor provides functions to append CBOR (RFC 7049) encoded values to
+// a byte slice, and to decode them again.
+package cbor
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger/internal/util"
+)
+
+// Major types, already shifted into the top three bits.
+const (
+	majorUint   byte = 0 << 5
+	majorNegInt byte = 1 << 5
+	majorBytes  byte = 2 << 5
+	majorString byte = 3 << 5
+	majorArray  byte = 4 << 5
+	majorMap    byte = 5 << 5
+	majorTag    byte = 6 << 5
+	majorSimple byte = 7 << 5
+)
+
+// Maximum depth AppendValue will go into nested values, deeper values are
+// converted into a string.
+const maxDepth = 32
+
+// AppendNil appends a null value to buf.
+func AppendNil(buf []byte) []byte {
+	return append(buf, majorSimple|22)
+}
+
+// AppendBool appends a boolean to buf.
+func AppendBool(buf []byte, b bool) []byte {
+	if b {
+		return append(buf, majorSimple|21)
+	}
+	return append(buf, majorSimple|20)
+}
+
+// AppendInt appends a signed integer to buf.
+func AppendInt(buf []byte, n int64) []byte {
+	if n >= 0 {
+		return appendHeader(buf, majorUint, uint64(n))
+	}
+	return appendHeader(buf, majorNegInt, uint64(-(n + 1)))
+}
+
+// AppendUint appends an unsigned integer to buf.
+func AppendUint(buf []byte, n uint64) []byte {
+	return appendHeader(buf, majorUint, n)
+}
+
+// AppendFloat appends a 64 bit floating point number to buf.
+func AppendFloat(buf []byte, f float64) []byte {
+	n := math.Float64bits(f)
+	return append(buf, majorSimple|27, byte(n>>56), byte(n>>48), byte(n>>40),
+		byte(n>>32), byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
+
+// AppendString appends an UTF-8 text string to buf.
+func AppendString(buf []byte, str string) []byte {
+	buf = appendHeader(buf, majorString, uint64(len(str)))
+	return append(buf, str...)
+}
+
+// AppendBytes appends a byte string to buf.
+func AppendBytes(buf []byte, b []byte) []byte {
+	buf = appendHeader(buf, majorBytes, uint64(len(b)))
+	return append(buf, b...)
+}
+
+// AppendArrayHeader appends the header of an array with n elements to buf, the
+// elements must be appended after it.
+func AppendArrayHeader(buf []byte, n int) []byte {
+	return appendHeader(buf, majorArray, uint64(n))
+}
+
+// AppendMapHeader appends the header of a map with n key-value pairs to buf,
+// the keys and values must be appended after it.
+func AppendMapHeader(buf []byte, n int) []byte {
+	return appendHeader(buf, majorMap, uint64(n))
+}
+
+// AppendTime appends a timestamp as a tagged (tag 0) RFC 3339 string, with
+// nanosecond precision, to buf.
+func AppendTime(buf []byte, t time.Time) []byte {
+	buf = appendHeader(buf, majorTag, 0)
+	return AppendString(buf, t.Format(time.RFC3339Nano))
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// AppendValue appends an arbitrary value to buf. Booleans, numbers, strings,
+// byte slices, slices, arrays, maps, structs (exported fields only), pointers
+// and time.Time are encoded as their CBOR equivalents. Errors, fmt.Stringers
+// and all other values are encoded as a string.
+func AppendValue(buf []byte, value interface{}) []byte {
+	return appendValue(buf, reflect.ValueOf(value), 0)
+}
+
+func appendValue(buf []byte, v reflect.Value, depth int) []byte {
+	if !v.IsValid() {
+		return AppendNil(buf)
+	} else if depth > maxDepth {
+		return AppendString(buf, "<max depth reached>")
+	}
+
+	if v.Type() == timeType {
+		return AppendTime(buf, v.Interface().(time.Time))
+	} else if v.CanInterface() {
+		if encoded, ok := util.Encode(v.Interface()); ok {
+			return AppendString(buf, encoded)
+		}
+
+		switch value := v.Interface().(type) {
+		case error:
+			return AppendString(buf, value.Error())
+		case fmt.Stringer:
+			return AppendString(buf, value.String())
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return AppendBool(buf, v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return AppendInt(buf, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+		reflect.Uint64, reflect.Uintptr:
+		return AppendUint(buf, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return AppendFloat(buf, v.Float())
+	case reflect.String:
+		return AppendString(buf, v.String())
+	case reflect.Slice:
+		if v.IsNil() {
+			return AppendNil(buf)
+		} else if v.Type().Elem().Kind() == reflect.Uint8 {
+			return AppendBytes(buf, v.Bytes())
+		}
+		fallthrough
+	case reflect.Array:
+		buf = AppendArrayHeader(buf, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			buf = appendValue(buf, v.Index(i), depth+1)
+		}
+		return buf
+	case reflect.Map:
+		if v.IsNil() {
+			return AppendNil(buf)
+		}
+		buf = AppendMapHeader(buf, v.Len())
+		for _, key := range v.MapKeys() {
+			buf = appendValue(buf, key, depth+1)
+			buf = appendValue(buf, v.MapIndex(key), depth+1)
+		}
+		return buf
+	case reflect.Struct:
+		return appendStruct(buf, v, depth)
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return AppendNil(buf)
+		}
+		return appendValue(buf, v.Elem(), depth+1)
+	}
+
+	if v.CanInterface() {
+		return AppendString(buf, fmt.Sprintf("%v", v.Interface()))
+	}
+	return AppendString(buf, v.String())
+}
+
+func appendStruct(buf []byte, v reflect.Value, depth int) []byte {
+	t := v.Type()
+	var n int
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).PkgPath == "" {
+			n++
+		}
+	}
+
+	buf = AppendMapHeader(buf, n)
+	for i := 0; i < t.NumField(); i++ {
+		if field := t.Field(i); field.PkgPath == "" {
+			buf = AppendString(buf, field.Name)
+			buf = appendValue(buf, v.Field(i), depth+1)
+		}
+	}
+	return buf
+}
+
+// AppendHeader appends the header, major type and argument, using the
+// smallest possible representation.
+func appendHeader(buf []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(buf, major|byte(n))
+	case n <= math.MaxUint8:
+		return append(buf, major|24, byte(n))
+	case n <= math.MaxUint16:
+		return append(buf, major|25, byte(n>>8), byte(n))
+	case n <= math.MaxUint32:
+		return append(buf, major|26, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+	}
+	return append(buf, major|27, byte(n>>56), byte(n>>48), byte(n>>40),
+		byte(n>>32), byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
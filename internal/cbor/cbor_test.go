@@ -0,0 +1,58 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package cbor
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+type user struct {
+	ID      int
+	private string
+}
+
+func TestAppend(t *testing.T) {
+	t1 := time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC)
+	tests := []struct {
+		got      []byte
+		expected []byte
+	}{
+		// Examples from appendix A of RFC 7049.
+		{AppendNil(nil), []byte{0xf6}},
+		{AppendBool(nil, true), []byte{0xf5}},
+		{AppendBool(nil, false), []byte{0xf4}},
+		{AppendInt(nil, 10), []byte{0x0a}},
+		{AppendInt(nil, 100), []byte{0x18, 0x64}},
+		{AppendInt(nil, 1000), []byte{0x19, 0x03, 0xe8}},
+		{AppendInt(nil, -1), []byte{0x20}},
+		{AppendInt(nil, -1000), []byte{0x39, 0x03, 0xe7}},
+		{AppendUint(nil, 1000000), []byte{0x1a, 0x00, 0x0f, 0x42, 0x40}},
+		{AppendUint(nil, math.MaxUint64), []byte{0x1b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{AppendFloat(nil, 1.1), []byte{0xfb, 0x3f, 0xf1, 0x99, 0x99, 0x99, 0x99, 0x99, 0x9a}},
+		{AppendString(nil, "IETF"), []byte{0x64, 0x49, 0x45, 0x54, 0x46}},
+		{AppendBytes(nil, []byte{1, 2, 3, 4}), []byte{0x44, 0x01, 0x02, 0x03, 0x04}},
+		{AppendArrayHeader(nil, 3), []byte{0x83}},
+		{AppendMapHeader(nil, 2), []byte{0xa2}},
+		{AppendTime(nil, t1), append([]byte{0xc0, 0x74}, "2015-09-01T14:22:36Z"...)},
+
+		{AppendValue(nil, nil), []byte{0xf6}},
+		{AppendValue(nil, []interface{}{1, "a"}), []byte{0x82, 0x01, 0x61, 'a'}},
+		{AppendValue(nil, []byte{1}), []byte{0x41, 0x01}},
+		{AppendValue(nil, map[string]int{"a": 1}), []byte{0xa1, 0x61, 'a', 0x01}},
+		{AppendValue(nil, &user{1, "private"}), []byte{0xa1, 0x62, 'I', 'D', 0x01}},
+		{AppendValue(nil, errors.New("err")), []byte{0x63, 'e', 'r', 'r'}},
+		{AppendValue(nil, (*user)(nil)), []byte{0xf6}},
+	}
+
+	for i, test := range tests {
+		if !bytes.Equal(test.got, test.expected) {
+			t.Errorf("Expected test #%d to return % x, but got % x", i, test.expected, test.got)
+		}
+	}
+}
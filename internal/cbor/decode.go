@@ -0,0 +1,203 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package cbor
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Errors returned by ConsumeValue.
+var (
+	ErrTruncated   = errors.New("cbor: truncated value")
+	ErrUnsupported = errors.New("cbor: unsupported value")
+	ErrTooDeep     = errors.New("cbor: value nested too deeply")
+)
+
+// Maximum depth of nested values ConsumeValue decodes, deeper values return
+// ErrTooDeep. This is deeper then AppendValue goes, so all values it appends
+// can be decoded.
+const maxDecodeDepth = 2 * maxDepth
+
+// ConsumeValue decodes a single value from buf, returning the value and the
+// number of bytes consumed. Values are decoded as: unsigned integers as
+// uint64, negative integers as int64, floating point numbers as float64, byte
+// strings as []byte, text strings as string, arrays as []interface{}, maps as
+// map[string]interface{} (with non-string keys formatted using fmt.Sprint),
+// tagged (tag 0) timestamps as time.Time and null and undefined as nil. Other
+// tags are ignored, i.e. the tagged value is returned. Indefinite length values
+// are not supported.
+func ConsumeValue(buf []byte) (interface{}, int, error) {
+	return consumeValue(buf, 0)
+}
+
+func consumeValue(buf []byte, depth int) (interface{}, int, error) {
+	if depth > maxDecodeDepth {
+		return nil, 0, ErrTooDeep
+	}
+	major, arg, n, err := consumeHeader(buf)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch major {
+	case majorUint:
+		return arg, n, nil
+	case majorNegInt:
+		if arg > math.MaxInt64 {
+			return nil, 0, ErrUnsupported
+		}
+		return -int64(arg) - 1, n, nil
+	case majorBytes, majorString:
+		if arg > uint64(len(buf)-n) {
+			return nil, 0, ErrTruncated
+		}
+		end := n + int(arg)
+		if major == majorString {
+			return string(buf[n:end]), end, nil
+		}
+		return append([]byte(nil), buf[n:end]...), end, nil
+	case majorArray:
+		return consumeArray(buf, n, arg, depth)
+	case majorMap:
+		return consumeMap(buf, n, arg, depth)
+	case majorTag:
+		value, m, err := consumeValue(buf[n:], depth+1)
+		if err != nil {
+			return nil, 0, err
+		}
+		if str, ok := value.(string); ok && arg == 0 {
+			t, err := time.Parse(time.RFC3339Nano, str)
+			if err != nil {
+				return nil, 0, err
+			}
+			value = t
+		}
+		return value, n + m, nil
+	}
+	return consumeSimple(buf, n, arg)
+}
+
+// consumeArray decodes the n elements of an array, starting at offset.
+func consumeArray(buf []byte, offset int, n uint64, depth int) (interface{}, int, error) {
+	// Every element is at least a single byte, checking this before
+	// allocating prevents a small value from allocating a large array.
+	if n > uint64(len(buf)-offset) {
+		return nil, 0, ErrTruncated
+	}
+	values := make([]interface{}, n)
+	for i := range values {
+		value, m, err := consumeValue(buf[offset:], depth+1)
+		if err != nil {
+			return nil, 0, err
+		}
+		values[i] = value
+		offset += m
+	}
+	return values, offset, nil
+}
+
+// consumeMap decodes the n key-value pairs of a map, starting at offset.
+func consumeMap(buf []byte, offset int, n uint64, depth int) (interface{}, int, error) {
+	if n > uint64(len(buf)-offset)/2 {
+		return nil, 0, ErrTruncated
+	}
+	values := make(map[string]interface{}, n)
+	for i := uint64(0); i < n; i++ {
+		key, m, err := consumeValue(buf[offset:], depth+1)
+		if err != nil {
+			return nil, 0, err
+		}
+		offset += m
+		value, m, err := consumeValue(buf[offset:], depth+1)
+		if err != nil {
+			return nil, 0, err
+		}
+		offset += m
+
+		if str, ok := key.(string); ok {
+			values[str] = value
+		} else {
+			values[fmt.Sprint(key)] = value
+		}
+	}
+	return values, offset, nil
+}
+
+// consumeSimple decodes a simple value or floating point number, the header of
+// which is n bytes.
+func consumeSimple(buf []byte, n int, arg uint64) (interface{}, int, error) {
+	switch buf[0] & 0x1f {
+	case 20:
+		return false, n, nil
+	case 21:
+		return true, n, nil
+	case 22, 23:
+		return nil, n, nil
+	case 25:
+		return halfToFloat(uint16(arg)), n, nil
+	case 26:
+		return float64(math.Float32frombits(uint32(arg))), n, nil
+	case 27:
+		return math.Float64frombits(arg), n, nil
+	}
+	return nil, 0, ErrUnsupported
+}
+
+// consumeHeader decodes the header of a value, returning the major type, its
+// argument and the size of the header.
+func consumeHeader(buf []byte) (byte, uint64, int, error) {
+	if len(buf) == 0 {
+		return 0, 0, 0, ErrTruncated
+	}
+	major, info := buf[0]&0xe0, buf[0]&0x1f
+	switch {
+	case info < 24:
+		return major, uint64(info), 1, nil
+	case info > 27:
+		return 0, 0, 0, ErrUnsupported
+	}
+	size := 1 << (info - 24)
+	if len(buf) < 1+size {
+		return 0, 0, 0, ErrTruncated
+	}
+	var arg uint64
+	switch size {
+	case 1:
+		arg = uint64(buf[1])
+	case 2:
+		arg = uint64(binary.BigEndian.Uint16(buf[1:]))
+	case 4:
+		arg = uint64(binary.BigEndian.Uint32(buf[1:]))
+	case 8:
+		arg = binary.BigEndian.Uint64(buf[1:])
+	}
+	return major, arg, 1 + size, nil
+}
+
+// halfToFloat converts a half precision floating point number.
+func halfToFloat(half uint16) float64 {
+	exp, mant := int(half>>10)&0x1f, float64(half&0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 31:
+		if mant == 0 {
+			f = math.Inf(1)
+		} else {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if half&0x8000 != 0 {
+		return -f
+	}
+	return f
+}
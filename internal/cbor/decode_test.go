@@ -0,0 +1,69 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package cbor
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestConsumeValue(t *testing.T) {
+	t1 := time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC)
+	tests := []struct {
+		input    []byte
+		expected interface{}
+	}{
+		{AppendNil(nil), nil},
+		{AppendBool(nil, true), true},
+		{AppendInt(nil, 1000), uint64(1000)},
+		{AppendInt(nil, -1000), int64(-1000)},
+		{AppendUint(nil, math.MaxUint64), uint64(math.MaxUint64)},
+		{AppendFloat(nil, 1.1), 1.1},
+		{[]byte{0xf9, 0x3c, 0x00}, 1.0},
+		{[]byte{0xfa, 0x47, 0xc3, 0x50, 0x00}, 100000.0},
+		{AppendString(nil, "IETF"), "IETF"},
+		{AppendBytes(nil, []byte{1, 2}), []byte{1, 2}},
+		{AppendTime(nil, t1), t1},
+		{AppendValue(nil, []interface{}{1, "a"}), []interface{}{uint64(1), "a"}},
+		{AppendValue(nil, map[int]string{1: "a"}), map[string]interface{}{"1": "a"}},
+	}
+
+	for i, test := range tests {
+		got, n, err := ConsumeValue(test.input)
+		if err != nil {
+			t.Errorf("Unexpected error decoding test #%d: %s", i, err)
+		} else if n != len(test.input) || !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("Expected test #%d to return %#v (%d bytes), but got %#v (%d bytes)",
+				i, test.expected, len(test.input), got, n)
+		}
+	}
+}
+
+func TestConsumeValueErrors(t *testing.T) {
+	deep := make([]byte, maxDecodeDepth+2)
+	for i := range deep {
+		deep[i] = 0x81
+	}
+	tests := []struct {
+		input    []byte
+		expected error
+	}{
+		{nil, ErrTruncated},
+		{[]byte{0x19, 0x03}, ErrTruncated},
+		{[]byte{0x64, 'a'}, ErrTruncated},
+		// An array claiming to have a large number of elements.
+		{[]byte{0x9a, 0xff, 0xff, 0xff, 0xff}, ErrTruncated},
+		{[]byte{0x9f}, ErrUnsupported},
+		{deep, ErrTooDeep},
+	}
+
+	for i, test := range tests {
+		if _, _, err := ConsumeValue(test.input); err != test.expected {
+			t.Errorf("Expected test #%d to return error %v, but got %v", i, test.expected, err)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAppendString(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"", `""`},
+		{"message", `"message"`},
+		{`"qouted"`, `"\"qouted\""`},
+		{`back\slash`, `"back\\slash"`},
+		{"new\nline\r\n", `"new\nline\r\n"`},
+		{"tab\t", `"tab\t"`},
+		{"control\x00\x1f", `"control\u0000\u001f"`},
+		{"unicode é ☺", `"unicode é ☺"`},
+		{"invalid \xff utf-8", `"invalid \ufffd utf-8"`},
+		{"separators \u2028\u2029", `"separators \u2028\u2029"`},
+	}
+
+	for _, test := range tests {
+		got := string(AppendString(nil, test.input))
+		if got != test.expected {
+			t.Errorf("Expected AppendString(%q) to return %s, but got %s",
+				test.input, test.expected, got)
+		}
+
+		var decoded string
+		if err := json.Unmarshal([]byte(got), &decoded); err != nil {
+			t.Errorf("Unexpected error unmarshaling %s: %s", got, err.Error())
+		}
+	}
+}
@@ -0,0 +1,251 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+// Package msgpack provides functions to append MessagePack encoded values to a
+// byte slice, and to read a limited set of values. See
+// https://github.com/msgpack/msgpack/blob/master/spec.md for the
+// specification.
+package msgpack
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"math"
+	"time"
+)
+
+// AppendNil appends a nil value to buf.
+func AppendNil(buf []byte) []byte {
+	return append(buf, 0xc0)
+}
+
+// AppendBool appends a boolean to buf.
+func AppendBool(buf []byte, b bool) []byte {
+	if b {
+		return append(buf, 0xc3)
+	}
+	return append(buf, 0xc2)
+}
+
+// AppendInt appends a signed integer to buf, using the smallest possible
+// representation.
+func AppendInt(buf []byte, n int64) []byte {
+	if n >= 0 {
+		return AppendUint(buf, uint64(n))
+	}
+
+	switch {
+	case n >= -32:
+		return append(buf, byte(n))
+	case n >= math.MinInt8:
+		return append(buf, 0xd0, byte(n))
+	case n >= math.MinInt16:
+		return appendUint16(append(buf, 0xd1), uint16(n))
+	case n >= math.MinInt32:
+		return appendUint32(append(buf, 0xd2), uint32(n))
+	}
+	return appendUint64(append(buf, 0xd3), uint64(n))
+}
+
+// AppendUint appends an unsigned integer to buf, using the smallest possible
+// representation.
+func AppendUint(buf []byte, n uint64) []byte {
+	switch {
+	case n <= math.MaxInt8:
+		return append(buf, byte(n))
+	case n <= math.MaxUint8:
+		return append(buf, 0xcc, byte(n))
+	case n <= math.MaxUint16:
+		return appendUint16(append(buf, 0xcd), uint16(n))
+	case n <= math.MaxUint32:
+		return appendUint32(append(buf, 0xce), uint32(n))
+	}
+	return appendUint64(append(buf, 0xcf), n)
+}
+
+// AppendFloat appends a 64 bit floating point number to buf.
+func AppendFloat(buf []byte, f float64) []byte {
+	return appendUint64(append(buf, 0xcb), math.Float64bits(f))
+}
+
+// AppendString appends a string to buf.
+func AppendString(buf []byte, str string) []byte {
+	n := len(str)
+	switch {
+	case n <= 31:
+		buf = append(buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		buf = append(buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		buf = appendUint16(append(buf, 0xda), uint16(n))
+	default:
+		buf = appendUint32(append(buf, 0xdb), uint32(n))
+	}
+	return append(buf, str...)
+}
+
+// AppendBytes appends a binary value to buf.
+func AppendBytes(buf []byte, b []byte) []byte {
+	n := len(b)
+	switch {
+	case n <= math.MaxUint8:
+		buf = append(buf, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		buf = appendUint16(append(buf, 0xc5), uint16(n))
+	default:
+		buf = appendUint32(append(buf, 0xc6), uint32(n))
+	}
+	return append(buf, b...)
+}
+
+// AppendArrayHeader appends the header of an array with n elements to buf, the
+// elements must be appended after it.
+func AppendArrayHeader(buf []byte, n int) []byte {
+	switch {
+	case n <= 15:
+		return append(buf, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		return appendUint16(append(buf, 0xdc), uint16(n))
+	}
+	return appendUint32(append(buf, 0xdd), uint32(n))
+}
+
+// AppendMapHeader appends the header of a map with n key-value pairs to buf,
+// the keys and values must be appended after it.
+func AppendMapHeader(buf []byte, n int) []byte {
+	switch {
+	case n <= 15:
+		return append(buf, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		return appendUint16(append(buf, 0xde), uint16(n))
+	}
+	return appendUint32(append(buf, 0xdf), uint32(n))
+}
+
+// AppendExt appends an extension type with the given data to buf.
+func AppendExt(buf []byte, extType int8, data []byte) []byte {
+	n := len(data)
+	switch n {
+	case 1:
+		buf = append(buf, 0xd4)
+	case 2:
+		buf = append(buf, 0xd5)
+	case 4:
+		buf = append(buf, 0xd6)
+	case 8:
+		buf = append(buf, 0xd7)
+	case 16:
+		buf = append(buf, 0xd8)
+	default:
+		switch {
+		case n <= math.MaxUint8:
+			buf = append(buf, 0xc7, byte(n))
+		case n <= math.MaxUint16:
+			buf = appendUint16(append(buf, 0xc8), uint16(n))
+		default:
+			buf = appendUint32(append(buf, 0xc9), uint32(n))
+		}
+	}
+	buf = append(buf, byte(extType))
+	return append(buf, data...)
+}
+
+// AppendTime appends a timestamp, using the timestamp extension type, to buf.
+// The 96 bit format is always used to support all possible times with
+// nanosecond precision.
+func AppendTime(buf []byte, t time.Time) []byte {
+	buf = append(buf, 0xc7, 12, 0xff) // -1 as extension type.
+	buf = appendUint32(buf, uint32(t.Nanosecond()))
+	return appendUint64(buf, uint64(t.Unix()))
+}
+
+func appendUint16(buf []byte, n uint16) []byte {
+	return append(buf, byte(n>>8), byte(n))
+}
+
+func appendUint32(buf []byte, n uint32) []byte {
+	return append(buf, byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
+
+func appendUint64(buf []byte, n uint64) []byte {
+	return append(buf, byte(n>>56), byte(n>>48), byte(n>>40), byte(n>>32),
+		byte(n>>24), byte(n>>16), byte(n>>8), byte(n))
+}
+
+// ErrUnexpectedType is returned by the Read functions if the next value is of
+// another type.
+var ErrUnexpectedType = errors.New("msgpack: unexpected type")
+
+// ErrTooLarge is returned by ReadString if the string is larger then
+// MaxStringSize.
+var ErrTooLarge = errors.New("msgpack: string too large")
+
+// MaxStringSize is the maximum size of a string read by ReadString, in bytes.
+// This prevents a corrupt or malicious length from allocating gigabytes.
+const MaxStringSize = 1024 * 1024
+
+// ReadMapHeader reads the header of a map from r, returning the number of
+// key-value pairs in the map.
+func ReadMapHeader(r *bufio.Reader) (int, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case b&0xf0 == 0x80:
+		return int(b & 0x0f), nil
+	case b == 0xde:
+		n, err := readUint(r, 2)
+		return int(n), err
+	case b == 0xdf:
+		n, err := readUint(r, 4)
+		return int(n), err
+	}
+	return 0, ErrUnexpectedType
+}
+
+// ReadString reads a string of at most MaxStringSize bytes from r.
+func ReadString(r *bufio.Reader) (string, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+
+	var n uint64
+	switch {
+	case b&0xe0 == 0xa0:
+		n = uint64(b & 0x1f)
+	case b == 0xd9:
+		n, err = readUint(r, 1)
+	case b == 0xda:
+		n, err = readUint(r, 2)
+	case b == 0xdb:
+		n, err = readUint(r, 4)
+	default:
+		return "", ErrUnexpectedType
+	}
+	if err != nil {
+		return "", err
+	} else if n > MaxStringSize {
+		return "", ErrTooLarge
+	}
+
+	buf := make([]byte, n)
+	_, err = io.ReadFull(r, buf)
+	return string(buf), err
+}
+
+func readUint(r *bufio.Reader, size int) (uint64, error) {
+	var n uint64
+	for i := 0; i < size; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		n = n<<8 | uint64(b)
+	}
+	return n, nil
+}
@@ -0,0 +1,84 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package msgpack
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppend(t *testing.T) {
+	tests := []struct {
+		got      []byte
+		expected []byte
+	}{
+		{AppendNil(nil), []byte{0xc0}},
+		{AppendBool(nil, true), []byte{0xc3}},
+		{AppendBool(nil, false), []byte{0xc2}},
+		{AppendInt(nil, 1), []byte{0x01}},
+		{AppendInt(nil, -1), []byte{0xff}},
+		{AppendInt(nil, -33), []byte{0xd0, 0xdf}},
+		{AppendInt(nil, -200), []byte{0xd1, 0xff, 0x38}},
+		{AppendInt(nil, math.MinInt32), []byte{0xd2, 0x80, 0, 0, 0}},
+		{AppendInt(nil, math.MinInt64), []byte{0xd3, 0x80, 0, 0, 0, 0, 0, 0, 0}},
+		{AppendUint(nil, 200), []byte{0xcc, 200}},
+		{AppendUint(nil, 256), []byte{0xcd, 1, 0}},
+		{AppendUint(nil, math.MaxUint32), []byte{0xce, 0xff, 0xff, 0xff, 0xff}},
+		{AppendUint(nil, math.MaxUint32+1), []byte{0xcf, 0, 0, 0, 1, 0, 0, 0, 0}},
+		{AppendFloat(nil, 1.0), []byte{0xcb, 0x3f, 0xf0, 0, 0, 0, 0, 0, 0}},
+		{AppendString(nil, "abc"), []byte{0xa3, 'a', 'b', 'c'}},
+		{AppendString(nil, strings.Repeat("a", 32))[:2], []byte{0xd9, 32}},
+		{AppendString(nil, strings.Repeat("a", 256))[:3], []byte{0xda, 1, 0}},
+		{AppendBytes(nil, []byte{1, 2}), []byte{0xc4, 2, 1, 2}},
+		{AppendArrayHeader(nil, 2), []byte{0x92}},
+		{AppendArrayHeader(nil, 16), []byte{0xdc, 0, 16}},
+		{AppendMapHeader(nil, 2), []byte{0x82}},
+		{AppendMapHeader(nil, 16), []byte{0xde, 0, 16}},
+		{AppendExt(nil, 0, []byte{1, 2, 3, 4, 5, 6, 7, 8}), []byte{0xd7, 0, 1, 2, 3, 4, 5, 6, 7, 8}},
+		{AppendExt(nil, 1, []byte{1, 2, 3}), []byte{0xc7, 3, 1, 1, 2, 3}},
+		{AppendTime(nil, time.Unix(1, 2)), []byte{0xc7, 12, 0xff, 0, 0, 0, 2, 0, 0, 0, 0, 0, 0, 0, 1}},
+	}
+
+	for i, test := range tests {
+		if !bytes.Equal(test.got, test.expected) {
+			t.Errorf("Expected test #%d to return % x, but got % x", i, test.expected, test.got)
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	long := strings.Repeat("a", 300)
+	var buf []byte
+	buf = AppendMapHeader(buf, 2)
+	buf = AppendString(buf, "ack")
+	buf = AppendString(buf, long)
+	buf = AppendMapHeader(buf, 20)
+	buf = AppendInt(buf, 1)
+
+	r := bufio.NewReader(bytes.NewReader(buf))
+	if n, err := ReadMapHeader(r); err != nil || n != 2 {
+		t.Fatalf("Expected ReadMapHeader to return 2, <nil>, but got %d, %v", n, err)
+	} else if str, err := ReadString(r); err != nil || str != "ack" {
+		t.Fatalf("Expected ReadString to return ack, <nil>, but got %s, %v", str, err)
+	} else if str, err := ReadString(r); err != nil || str != long {
+		t.Fatalf("Expected ReadString to return a long string, but got %s, %v", str, err)
+	} else if n, err := ReadMapHeader(r); err != nil || n != 20 {
+		t.Fatalf("Expected ReadMapHeader to return 20, <nil>, but got %d, %v", n, err)
+	} else if _, err := ReadString(r); err != ErrUnexpectedType {
+		t.Fatalf("Expected ReadString to return ErrUnexpectedType, but got %v", err)
+	}
+}
+
+func TestReadStringTooLarge(t *testing.T) {
+	// Only the header of a string of 4 GiB.
+	r := bufio.NewReader(bytes.NewReader([]byte{0xdb, 0xff, 0xff, 0xff, 0xff}))
+	if _, err := ReadString(r); err != ErrTooLarge {
+		t.Fatalf("Expected ReadString to return ErrTooLarge, but got %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+// Package protobuf provides functions to encode and decode the Protocol Buffers
+// wire format. See https://developers.google.com/protocol-buffers/docs/encoding
+// for the specification.
+package protobuf
+
+import "errors"
+
+// Wire types.
+const (
+	WireVarint = 0
+	WireBytes  = 2
+)
+
+// ErrInvalid is returned when decoding invalid data.
+var ErrInvalid = errors.New("protobuf: invalid data")
+
+// AppendVarint appends n as a varint to buf.
+func AppendVarint(buf []byte, n uint64) []byte {
+	for n >= 0x80 {
+		buf = append(buf, byte(n)|0x80)
+		n >>= 7
+	}
+	return append(buf, byte(n))
+}
+
+// AppendKey appends the key (field number and wire type) of a field to buf.
+func AppendKey(buf []byte, field, wireType int) []byte {
+	return AppendVarint(buf, uint64(field)<<3|uint64(wireType))
+}
+
+// AppendVarintField appends a varint field to buf, if n is not zero.
+func AppendVarintField(buf []byte, field int, n uint64) []byte {
+	if n == 0 {
+		return buf
+	}
+	buf = AppendKey(buf, field, WireVarint)
+	return AppendVarint(buf, n)
+}
+
+// AppendStringField appends a string field to buf, if str is not empty.
+func AppendStringField(buf []byte, field int, str string) []byte {
+	if str == "" {
+		return buf
+	}
+	buf = AppendKey(buf, field, WireBytes)
+	buf = AppendVarint(buf, uint64(len(str)))
+	return append(buf, str...)
+}
+
+// AppendBytesField appends a length delimited field, e.g. an embedded message,
+// to buf.
+func AppendBytesField(buf []byte, field int, b []byte) []byte {
+	buf = AppendKey(buf, field, WireBytes)
+	buf = AppendVarint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
+
+// ConsumeVarint decodes a varint from buf, it returns the value and the number
+// of bytes read.
+func ConsumeVarint(buf []byte) (uint64, int, error) {
+	var n uint64
+	for i := 0; i < len(buf) && i < 10; i++ {
+		b := buf[i]
+		n |= uint64(b&0x7f) << (7 * uint(i))
+		if b < 0x80 {
+			return n, i + 1, nil
+		}
+	}
+	return 0, 0, ErrInvalid
+}
+
+// Field is a single decoded field.
+type Field struct {
+	Number   int
+	WireType int
+	Varint   uint64 // Set if WireType is WireVarint.
+	Bytes    []byte // Set if WireType is WireBytes.
+}
+
+// ConsumeField decodes a single field from buf, it returns the field and the
+// number of bytes read. Only varint and length delimited fields are
+// supported.
+func ConsumeField(buf []byte) (Field, int, error) {
+	key, n, err := ConsumeVarint(buf)
+	if err != nil {
+		return Field{}, 0, err
+	}
+
+	field := Field{Number: int(key >> 3), WireType: int(key & 7)}
+	value, m, err := ConsumeVarint(buf[n:])
+	if err != nil {
+		return Field{}, 0, err
+	}
+	n += m
+
+	switch field.WireType {
+	case WireVarint:
+		field.Varint = value
+	case WireBytes:
+		if value > uint64(len(buf)-n) {
+			return Field{}, 0, ErrInvalid
+		}
+		field.Bytes = buf[n : n+int(value)]
+		n += int(value)
+	default:
+		return Field{}, 0, ErrInvalid
+	}
+	return field, n, nil
+}
@@ -0,0 +1,68 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package protobuf
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestAppend(t *testing.T) {
+	tests := []struct {
+		got      []byte
+		expected []byte
+	}{
+		{AppendVarint(nil, 1), []byte{0x01}},
+		{AppendVarint(nil, 300), []byte{0xac, 0x02}},
+		{AppendKey(nil, 1, WireVarint), []byte{0x08}},
+		{AppendVarintField(nil, 1, 150), []byte{0x08, 0x96, 0x01}},
+		{AppendVarintField(nil, 1, 0), nil},
+		{AppendStringField(nil, 2, "testing"), []byte{0x12, 0x07, 't', 'e', 's', 't', 'i', 'n', 'g'}},
+		{AppendStringField(nil, 2, ""), nil},
+		{AppendBytesField(nil, 3, []byte{0x08, 0x96, 0x01}), []byte{0x1a, 0x03, 0x08, 0x96, 0x01}},
+	}
+
+	for i, test := range tests {
+		if !bytes.Equal(test.got, test.expected) {
+			t.Errorf("Expected test #%d to return % x, but got % x", i, test.expected, test.got)
+		}
+	}
+}
+
+func TestConsume(t *testing.T) {
+	for _, n := range []uint64{0, 1, 127, 128, 300, math.MaxUint64} {
+		buf := AppendVarint(nil, n)
+		got, read, err := ConsumeVarint(buf)
+		if err != nil || got != n || read != len(buf) {
+			t.Errorf("Expected ConsumeVarint(% x) to return %d, %d, <nil>, but got %d, %d, %v",
+				buf, n, len(buf), got, read, err)
+		}
+	}
+
+	buf := AppendVarintField(nil, 1, 150)
+	buf = AppendStringField(buf, 2, "testing")
+	expected := []Field{
+		{Number: 1, WireType: WireVarint, Varint: 150},
+		{Number: 2, WireType: WireBytes, Bytes: []byte("testing")},
+	}
+	for _, want := range expected {
+		field, n, err := ConsumeField(buf)
+		if err != nil {
+			t.Fatal("Unexpected error consuming field: " + err.Error())
+		} else if !reflect.DeepEqual(field, want) {
+			t.Fatalf("Expected field %v, but got %v", want, field)
+		}
+		buf = buf[n:]
+	}
+
+	invalid := [][]byte{{0x80}, {0x12, 0x05, 'a'}, {0x0b, 0x01}}
+	for _, buf := range invalid {
+		if _, _, err := ConsumeField(buf); err != ErrInvalid {
+			t.Errorf("Expected ConsumeField(% x) to return ErrInvalid, but got %v", buf, err)
+		}
+	}
+}
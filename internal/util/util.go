@@ -4,10 +4,94 @@
 
 package util
 
-import "fmt"
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
 
-// InterfaceToString converts a interface{} variable to a string.
+// Encoder encodes a value of a registered type, see RegisterEncoder.
+type Encoder func(interface{}) ([]byte, error)
+
+var (
+	encoders          map[reflect.Type]Encoder
+	interfaceTypes    []reflect.Type
+	interfaceEncoders []Encoder
+)
+
+// RegisterEncoder registers an encoder for values of type t. If t is an
+// interface type the encoder is used for all values that implement it, unless
+// an encoder for the concrete type is registered.
+func RegisterEncoder(t reflect.Type, encoder Encoder) {
+	if t.Kind() == reflect.Interface {
+		interfaceTypes = append(interfaceTypes, t)
+		interfaceEncoders = append(interfaceEncoders, encoder)
+		return
+	}
+
+	if encoders == nil {
+		encoders = map[reflect.Type]Encoder{}
+	}
+	encoders[t] = encoder
+}
+
+// ResetEncoders removes all registered encoders.
+func ResetEncoders() {
+	encoders = nil
+	interfaceTypes = nil
+	interfaceEncoders = nil
+}
+
+// HasEncoder returns true if an encoder is registered for values of type t.
+func HasEncoder(t reflect.Type) bool {
+	if _, ok := encoders[t]; ok {
+		return true
+	}
+	for _, interfaceType := range interfaceTypes {
+		if t.Implements(interfaceType) {
+			return true
+		}
+	}
+	return false
+}
+
+// Encode encodes the value using the registered encoder for its type, it
+// returns false if no encoder is registered. If the encoder returns an error
+// the encoded value describes the error, like fmt does for bad verbs.
+func Encode(value interface{}) (string, bool) {
+	if len(encoders) == 0 && len(interfaceTypes) == 0 || value == nil {
+		return "", false
+	}
+
+	t := reflect.TypeOf(value)
+	encoder, ok := encoders[t]
+	if !ok {
+		for i, interfaceType := range interfaceTypes {
+			if t.Implements(interfaceType) {
+				encoder, ok = interfaceEncoders[i], true
+				break
+			}
+		}
+		if !ok {
+			return "", false
+		}
+	}
+
+	encoded, err := encoder(value)
+	if err != nil {
+		return "%!(ENCODE_ERROR=" + err.Error() + ")", true
+	}
+	return string(encoded), true
+}
+
+// InterfaceToString converts a interface{} variable to a string. If an encoder
+// is registered for the type of the value it's used, see RegisterEncoder.
 func InterfaceToString(value interface{}) string {
+	if str, ok := Encode(value); ok {
+		return str
+	}
+
 	switch v := value.(type) {
 	case string:
 		return v
@@ -19,6 +103,37 @@ func InterfaceToString(value interface{}) string {
 		return v.Error()
 	case []interface{}:
 		return InterfacesToString(v)
+
+	// Fast paths for the most common types, the output is the same as fmt's
+	// %v, but without using reflection.
+	case int:
+		return strconv.Itoa(v)
+	case int8:
+		return strconv.FormatInt(int64(v), 10)
+	case int16:
+		return strconv.FormatInt(int64(v), 10)
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case []string:
+		return "[" + strings.Join(v, " ") + "]"
 	}
 	return fmt.Sprintf("%v", value)
 }
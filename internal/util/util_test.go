@@ -6,6 +6,9 @@ package util
 
 import (
 	"errors"
+	"fmt"
+	"math"
+	"reflect"
 	"testing"
 )
 
@@ -38,6 +41,43 @@ func TestInterfaceToString(t *testing.T) {
 	}
 }
 
+func TestInterfaceToStringFastPaths(t *testing.T) {
+	values := []interface{}{
+		-123, int8(-8), int16(-16), int32(-32), int64(math.MinInt64),
+		uint(123), uint8(8), uint16(16), uint32(32), uint64(math.MaxUint64),
+		float32(1.1), float64(1.1), 1e21, 1e-7, math.NaN(), math.Inf(1), math.Inf(-1),
+		true, false,
+		[]string{}, []string{"a"}, []string{"a", "b c"},
+	}
+
+	for _, value := range values {
+		// Must be the same as the output of fmt.
+		expected := fmt.Sprintf("%v", value)
+		if got := InterfaceToString(value); got != expected {
+			t.Errorf("Expected InterfaceToString(%#v) to return %s, but got %s",
+				value, expected, got)
+		}
+	}
+}
+
+var benchmarkResultString string
+
+func BenchmarkInterfaceToStringInt(b *testing.B) {
+	var str string
+	for n := 0; n < b.N; n++ {
+		str = InterfaceToString(n)
+	}
+	benchmarkResultString = str
+}
+
+func BenchmarkInterfaceToStringFloat64(b *testing.B) {
+	var str string
+	for n := 0; n < b.N; n++ {
+		str = InterfaceToString(float64(n) / 3)
+	}
+	benchmarkResultString = str
+}
+
 func TestInterfacesToString(t *testing.T) {
 	tests := []struct {
 		values   []interface{}
@@ -57,3 +97,53 @@ func TestInterfacesToString(t *testing.T) {
 		}
 	}
 }
+
+type point struct{ x, y int }
+
+type shape interface {
+	Area() int
+}
+
+type square int
+
+func (s square) Area() int { return int(s) * int(s) }
+
+func TestRegisterEncoder(t *testing.T) {
+	defer ResetEncoders()
+
+	RegisterEncoder(reflect.TypeOf(point{}), func(v interface{}) ([]byte, error) {
+		p := v.(point)
+		return []byte(fmt.Sprintf("(%d, %d)", p.x, p.y)), nil
+	})
+	RegisterEncoder(reflect.TypeOf((*shape)(nil)).Elem(), func(v interface{}) ([]byte, error) {
+		return []byte(fmt.Sprintf("area=%d", v.(shape).Area())), nil
+	})
+	RegisterEncoder(reflect.TypeOf(stringer(0)), func(interface{}) ([]byte, error) {
+		return nil, errors.New("bad stringer")
+	})
+
+	tests := []struct {
+		value    interface{}
+		expected string
+	}{
+		{point{1, 2}, "(1, 2)"},
+		{square(3), "area=9"},
+		{stringer(123), "%!(ENCODE_ERROR=bad stringer)"},
+		{"string", "string"},
+		{nil, "<nil>"},
+	}
+
+	for _, test := range tests {
+		got := InterfaceToString(test.value)
+		if got != test.expected {
+			t.Fatalf("Expected InterfaceToString(%#v) to return %s, but got %s",
+				test.value, test.expected, got)
+		}
+	}
+
+	if !HasEncoder(reflect.TypeOf(point{})) || !HasEncoder(reflect.TypeOf(square(1))) {
+		t.Fatal("Expected encoders to be registered for point and square")
+	} else if HasEncoder(reflect.TypeOf("")) {
+		t.Fatal("Expected no encoder to be registered for string")
+	}
+}
@@ -0,0 +1,149 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger/internal/json"
+	"github.com/Thomasdezeeuw/logger/internal/util"
+)
+
+// AppendTagsJSON appends the tags as a JSON array to buf. If compact is false
+// a space is added after each comma.
+func appendTagsJSON(buf []byte, tags Tags, compact bool) []byte {
+	buf = append(buf, '[')
+	for i, tag := range tags {
+		if i != 0 {
+			buf = appendJSONSeparator(buf, ',', compact)
+		}
+		buf = json.AppendString(buf, tag)
+	}
+	return append(buf, ']')
+}
+
+// appendEventTagsJSON appends the "tags" field with all tags that are not
+// key=value tags, followed by the "fields" field with the key=value tags as
+// object, if any. If a key is used multiple times only the first tag is
+// used, like Tags.Get.
+func appendEventTagsJSON(buf []byte, tags Tags, compact bool, names *jsonFieldNames) []byte {
+	buf = append(buf, names[jsonFieldTags]...)
+	buf = appendJSONSeparator(buf, ':', compact)
+	buf, fields := appendPlainTagsJSON(buf, tags, compact)
+	if !fields {
+		return buf
+	}
+
+	buf = appendJSONSeparator(buf, ',', compact)
+	buf = append(buf, names[jsonFieldFields]...)
+	buf = appendJSONSeparator(buf, ':', compact)
+	return appendTagFieldsJSON(buf, tags, compact)
+}
+
+// appendPlainTagsJSON appends all tags that are not key=value tags as a JSON
+// array to buf. It also returns whether or not tags contains key=value tags.
+func appendPlainTagsJSON(buf []byte, tags Tags, compact bool) ([]byte, bool) {
+	buf = append(buf, '[')
+	n, fields := 0, false
+	for _, tag := range tags {
+		if _, _, ok := splitTag(tag); ok {
+			fields = true
+			continue
+		}
+		if n != 0 {
+			buf = appendJSONSeparator(buf, ',', compact)
+		}
+		buf = json.AppendString(buf, tag)
+		n++
+	}
+	return append(buf, ']'), fields
+}
+
+// appendTagFieldsJSON appends the key=value tags as a JSON object to buf.
+func appendTagFieldsJSON(buf []byte, tags Tags, compact bool) []byte {
+	buf = append(buf, '{')
+	n := 0
+	for i, tag := range tags {
+		key, value, ok := splitTag(tag)
+		if !ok || isDuplicateTagKey(tags[:i], key) {
+			continue
+		}
+		if n != 0 {
+			buf = appendJSONSeparator(buf, ',', compact)
+		}
+		buf = json.AppendString(buf, key)
+		buf = appendJSONSeparator(buf, ':', compact)
+		buf = json.AppendString(buf, value)
+		n++
+	}
+	return append(buf, '}')
+}
+
+// isDuplicateTagKey returns true if tags contains a key=value tag with the
+// given key.
+func isDuplicateTagKey(tags Tags, key string) bool {
+	for _, tag := range tags {
+		if k, _, ok := splitTag(tag); ok && k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// AppendEventJSON appends the event as a JSON object to buf, using names as
+// field names, or the default names if nil. If compact is false a space is
+// added after each comma and colon.
+func appendEventJSON(buf []byte, event Event, compact bool, names *jsonFieldNames) []byte {
+	if names == nil {
+		names = &defaultJSONFieldNames
+	}
+	buf = append(buf, '{')
+	buf = append(buf, names[jsonFieldSchemaVersion]...)
+	buf = appendJSONSeparator(buf, ':', compact)
+	buf = strconv.AppendUint(buf, SchemaVersion, 10)
+	buf = appendJSONSeparator(buf, ',', compact)
+
+	buf = append(buf, names[jsonFieldType]...)
+	buf = appendJSONSeparator(buf, ':', compact)
+	buf = json.AppendString(buf, event.Type.String())
+	buf = appendJSONSeparator(buf, ',', compact)
+
+	buf = append(buf, names[jsonFieldTimestamp]...)
+	buf = appendJSONSeparator(buf, ':', compact)
+	buf = append(buf, '"')
+	buf = event.Timestamp.UTC().AppendFormat(buf, time.RFC3339Nano)
+	buf = append(buf, '"')
+	buf = appendJSONSeparator(buf, ',', compact)
+
+	if event.Seq != 0 {
+		buf = append(buf, names[jsonFieldSeq]...)
+		buf = appendJSONSeparator(buf, ':', compact)
+		buf = strconv.AppendUint(buf, event.Seq, 10)
+		buf = appendJSONSeparator(buf, ',', compact)
+	}
+
+	buf = appendEventTagsJSON(buf, event.Tags, compact, names)
+	buf = appendJSONSeparator(buf, ',', compact)
+
+	buf = append(buf, names[jsonFieldMessage]...)
+	buf = appendJSONSeparator(buf, ':', compact)
+	buf = json.AppendString(buf, event.Message)
+
+	if event.Data != nil {
+		buf = appendJSONSeparator(buf, ',', compact)
+		buf = append(buf, names[jsonFieldData]...)
+		buf = appendJSONSeparator(buf, ':', compact)
+		buf = json.AppendString(buf, util.InterfaceToString(event.Data))
+	}
+	return append(buf, '}')
+}
+
+func appendJSONSeparator(buf []byte, sep byte, compact bool) []byte {
+	if compact {
+		return append(buf, sep)
+	}
+	return append(buf, sep, ' ')
+}
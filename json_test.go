@@ -0,0 +1,56 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEventJSONCompact(t *testing.T) {
+	t.Parallel()
+
+	event := Event{InfoEvent, t1, Tags{"tag1", "tag2"}, "Message", "data", 0}
+	expected := `{"schema_version":1,"type":"Info","timestamp":"2015-09-01T14:22:36Z",` +
+		`"tags":["tag1","tag2"],"message":"Message","data":"data"}`
+
+	if got := string(appendEventJSON(nil, event, true, nil)); got != expected {
+		t.Fatalf("Expected appendEventJSON to return %s, but got %s", expected, got)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(expected), &decoded); err != nil {
+		t.Fatal("Unexpected error unmarshaling event: " + err.Error())
+	}
+}
+
+func TestEventJSONTagFields(t *testing.T) {
+	t.Parallel()
+
+	tags := Tags{"tag1", Tag("user", 123), "=value", Tag("path", "/\"home\""), Tag("user", 456)}
+	event := Event{InfoEvent, t1, tags, "Message", nil, 0}
+	expected := `{"schema_version":1,"type":"Info","timestamp":"2015-09-01T14:22:36Z",` +
+		`"tags":["tag1","=value"],"fields":{"user":"123","path":"/\"home\""},"message":"Message"}`
+
+	if got := string(appendEventJSON(nil, event, true, nil)); got != expected {
+		t.Fatalf("Expected appendEventJSON to return %s, but got %s", expected, got)
+	}
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal([]byte(expected), &decoded); err != nil {
+		t.Fatal("Unexpected error unmarshaling event: " + err.Error())
+	}
+}
+
+func TestEventJSONSeq(t *testing.T) {
+	t.Parallel()
+
+	event := Event{InfoEvent, t1, Tags{"tag1"}, "Message", nil, 12}
+	expected := `{"schema_version": 1, "type": "Info", "timestamp": "2015-09-01T14:22:36Z", "seq": 12, ` +
+		`"tags": ["tag1"], "message": "Message"}`
+	if got := string(event.AppendJSON(nil)); got != expected {
+		t.Fatalf("Expected AppendJSON to return %s, but got %s", expected, got)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// jsonEvent is the JSON format of an event, see Event.MarshalJSON.
+type jsonEvent struct {
+	SchemaVersion uint64 `json:"schema_version"`
+
+	Type      *EventType `json:"type"`
+	Timestamp *time.Time `json:"timestamp"`
+	Seq       uint64     `json:"seq"`
+	Tags      Tags       `json:"tags"`
+	Fields    jsonFields `json:"fields"`
+	Message   *string    `json:"message"`
+	Data      *string    `json:"data"`
+}
+
+// jsonFields are the key=value tags of an event, in the order of the JSON
+// object.
+type jsonFields Tags
+
+func (fields *jsonFields) UnmarshalJSON(b []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	if tok, err := dec.Token(); err != nil {
+		return err
+	} else if tok != json.Delim('{') {
+		return errors.New("logger: fields must be an object")
+	}
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		var value string
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		*fields = append(*fields, Tag(key.(string), value))
+	}
+	return nil
+}
+
+// jsonDecoders decode events in the JSON format, indexed by the schema
+// version, see SchemaVersion.
+var jsonDecoders = [SchemaVersion + 1]func(*jsonEvent) (Event, error){
+	0: decodeJSONEvent, // Before the version was added, same as version 1.
+	1: decodeJSONEvent,
+}
+
+// UnmarshalJSON converts an event in the JSON format, as created by
+// Event.MarshalJSON, back into an event. The fields are added as key=value
+// tags after the other tags, see Tag. The data is always a string, since the
+// JSON format converts data into a string. The event is decoded based on its
+// "schema_version" field, see SchemaVersion.
+func (event *Event) UnmarshalJSON(b []byte) error {
+	var e jsonEvent
+	if err := json.Unmarshal(b, &e); err != nil {
+		return err
+	}
+
+	decoded, err := jsonDecoders[decodeVersion(e.SchemaVersion)](&e)
+	if err != nil {
+		return err
+	}
+	*event = decoded
+	return nil
+}
+
+// decodeJSONEvent decodes an event in version 1 of the JSON format.
+func decodeJSONEvent(e *jsonEvent) (Event, error) {
+	switch {
+	case e.Type == nil:
+		return Event{}, errors.New("logger: missing event type")
+	case e.Timestamp == nil:
+		return Event{}, errors.New("logger: missing event timestamp")
+	case e.Message == nil:
+		return Event{}, errors.New("logger: missing event message")
+	}
+
+	tags := e.Tags
+	if len(e.Fields) != 0 {
+		tags = append(tags, e.Fields...)
+	}
+	event := Event{
+		Type:      *e.Type,
+		Timestamp: e.Timestamp.UTC(),
+		Tags:      tags,
+		Message:   *e.Message,
+		Seq:       e.Seq,
+	}
+	if e.Data != nil {
+		event.Data = *e.Data
+	}
+	return event, nil
+}
@@ -0,0 +1,52 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEventUnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []Event{
+		{InfoEvent, t1, Tags{"tag1", "tag2"}, "Message", "data", 0},
+		{ErrorEvent, t1, Tags{"tag1", Tag("user", 123), Tag("path", "/\"home\"")}, "Message", nil, 12},
+		{WarnEvent, t1, Tags{}, "Multi\nline", nil, 1},
+	}
+
+	for _, expected := range tests {
+		b, _ := expected.MarshalJSON()
+		var got Event
+		if err := got.UnmarshalJSON(b); err != nil {
+			t.Errorf("Unexpected error unmarshaling %s: %s", b, err)
+		} else if !reflect.DeepEqual(got, expected) {
+			t.Errorf("Expected event %#v, but got %#v", expected, got)
+		}
+	}
+}
+
+func TestEventUnmarshalJSONErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"timestamp": "2015-09-01T14:22:36Z", "tags": [], "message": ""}`, "logger: missing event type"},
+		{`{"type": "Info", "tags": [], "message": ""}`, "logger: missing event timestamp"},
+		{`{"type": "Info", "timestamp": "2015-09-01T14:22:36Z", "tags": []}`, "logger: missing event message"},
+		{`{"type": "Unknown", "timestamp": "2015-09-01T14:22:36Z", "message": ""}`, `logger: unknown EventType: "Unknown"`},
+		{`{"type": "Info", "timestamp": "2015-09-01T14:22:36Z", "fields": [], "message": ""}`, "logger: fields must be an object"},
+	}
+
+	for _, test := range tests {
+		var event Event
+		if err := event.UnmarshalJSON([]byte(test.input)); err == nil || err.Error() != test.expected {
+			t.Errorf("Expected error %q for input %s, but got %v", test.expected, test.input, err)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Thomasdezeeuw/logger/internal/json"
+)
+
+// Fields of the JSON format, indices into jsonFieldNames.
+const (
+	jsonFieldSchemaVersion = iota
+	jsonFieldType
+	jsonFieldTimestamp
+	jsonFieldSeq
+	jsonFieldTags
+	jsonFieldFields
+	jsonFieldMessage
+	jsonFieldData
+
+	numJSONFields
+)
+
+// jsonFieldNames are the quoted names of the fields in the JSON format.
+type jsonFieldNames [numJSONFields]string
+
+// The default names of the fields, as written by Event.MarshalJSON.
+var (
+	jsonFieldDefaults = [numJSONFields]string{
+		"schema_version", "type", "timestamp", "seq", "tags", "fields", "message", "data",
+	}
+	defaultJSONFieldNames = newJSONFieldNames(jsonFieldDefaults)
+)
+
+func newJSONFieldNames(names [numJSONFields]string) jsonFieldNames {
+	var quoted jsonFieldNames
+	for i, name := range names {
+		quoted[i] = string(json.AppendString(nil, name))
+	}
+	return quoted
+}
+
+// jsonFieldNamesSetter is implemented by EventWriters that support renaming
+// the JSON fields, see WithJSONFieldNames.
+type jsonFieldNamesSetter interface {
+	setJSONFieldNames(names *jsonFieldNames) bool
+}
+
+// ErrRenameUnsupported is returned by WithJSONFieldNames if the EventWriter
+// doesn't support renaming the JSON fields.
+var ErrRenameUnsupported = errors.New("logger: EventWriter doesn't support renaming JSON fields")
+
+// WithJSONFieldNames renames the fields written by the given EventWriter and
+// returns it, so the output matches the schema expected downstream. Names maps
+// the default name of a field, as written by Event.MarshalJSON, to the new
+// name, e.g.:
+//
+//	ew := logger.NewJSONEventWriter(logger.InfoEvent, os.Stdout, handleError)
+//	ew, err := logger.WithJSONFieldNames(ew, map[string]string{
+//		"timestamp": "@timestamp",
+//		"type":      "level",
+//	})
+//
+// Fields not in names keep their default name. Supported are the EventWriters
+// created by NewJSONEventWriter and NewJSONFileEventWriter, for other
+// EventWriters ErrRenameUnsupported is returned. It also returns an error if
+// names contains an unknown field, or if two fields would get the same name,
+// in which case the EventWriter is left unchanged. Note that the renamed
+// output can't be read by Event.UnmarshalJSON.
+//
+// Note: this must be called before the EventWriter is passed to Start.
+func WithJSONFieldNames(ew EventWriter, names map[string]string) (EventWriter, error) {
+	setter, ok := ew.(jsonFieldNamesSetter)
+	if !ok {
+		return nil, ErrRenameUnsupported
+	}
+
+	renamed := jsonFieldDefaults
+	for from, to := range names {
+		i := jsonFieldIndex(from)
+		if i == -1 {
+			return nil, fmt.Errorf("logger: unknown JSON field %q", from)
+		}
+		renamed[i] = to
+	}
+	for i, name := range renamed {
+		for _, other := range renamed[:i] {
+			if name == other {
+				return nil, fmt.Errorf("logger: duplicate JSON field name %q", name)
+			}
+		}
+	}
+
+	quoted := newJSONFieldNames(renamed)
+	if !setter.setJSONFieldNames(&quoted) {
+		return nil, ErrRenameUnsupported
+	}
+	return ew, nil
+}
+
+// jsonFieldIndex returns the index of the field with the default name, or -1
+// if there is no such field.
+func jsonFieldIndex(name string) int {
+	for i, defaultName := range jsonFieldDefaults {
+		if name == defaultName {
+			return i
+		}
+	}
+	return -1
+}
+
+func (ew *jsonEventWriter) setJSONFieldNames(names *jsonFieldNames) bool {
+	ew.names = names
+	return true
+}
+
+func (ew *fileEventWriter) setJSONFieldNames(names *jsonFieldNames) bool {
+	if !ew.json {
+		return false
+	}
+	ew.jsonNames = names
+	return true
+}
@@ -0,0 +1,101 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestWithJSONFieldNames(t *testing.T) {
+	var buf bytes.Buffer
+	ew, err := WithJSONFieldNames(NewJSONEventWriter(InfoEvent, &buf, nil), map[string]string{
+		"timestamp": "@timestamp",
+		"type":      "level",
+		"fields":    "labels",
+		"data":      "da\"ta",
+	})
+	if err != nil {
+		t.Fatal("Unexpected error renaming fields: " + err.Error())
+	}
+
+	event := Event{
+		Type:      InfoEvent,
+		Timestamp: now(),
+		Tags:      Tags{"tag1", "user=123"},
+		Message:   "Message",
+		Data:      "data",
+		Seq:       2,
+	}
+	if err := ew.Write(event); err != nil {
+		t.Fatal("Unexpected error writing: " + err.Error())
+	}
+
+	expected := `{"schema_version":1,"level":"Info","@timestamp":"2015-09-01T14:22:36Z","seq":2,` +
+		`"tags":["tag1"],"labels":{"user":"123"},"message":"Message","da\"ta":"data"}` + "\n"
+	if got := buf.String(); got != expected {
+		t.Fatalf("Expected buffer to contain:\n%s\nBut got:\n%s", expected, got)
+	}
+}
+
+func TestWithJSONFieldNamesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	ew, err := NewJSONFileEventWriter(InfoEvent, path)
+	if err != nil {
+		t.Fatal("Unexpected error creating new JSON file event writer: " + err.Error())
+	}
+	if ew, err = WithJSONFieldNames(ew, map[string]string{"message": "msg"}); err != nil {
+		t.Fatal("Unexpected error renaming fields: " + err.Error())
+	}
+	if err := ew.Write(Event{Type: InfoEvent, Timestamp: now(), Tags: Tags{}, Message: "Message"}); err != nil {
+		t.Fatal("Unexpected error writing: " + err.Error())
+	}
+	if err := ew.Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal("Unexpected error reading file: " + err.Error())
+	}
+	expected := `{"schema_version":1,"type":"Info","timestamp":"2015-09-01T14:22:36Z","tags":[],"msg":"Message"}` + "\n"
+	if got := string(b); got != expected {
+		t.Fatalf("Expected file to contain:\n%s\nBut got:\n%s", expected, got)
+	}
+}
+
+func TestWithJSONFieldNamesErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	textFile, err := NewFileEventWriter(InfoEvent, path)
+	if err != nil {
+		t.Fatal("Unexpected error creating new file event writer: " + err.Error())
+	}
+	defer textFile.Close()
+
+	tests := []struct {
+		ew       EventWriter
+		names    map[string]string
+		expected string
+	}{
+		{NewConsoleEventWriter(InfoEvent), nil, ErrRenameUnsupported.Error()},
+		{textFile, nil, ErrRenameUnsupported.Error()},
+		{NewJSONEventWriter(InfoEvent, &bytes.Buffer{}, nil), map[string]string{"level": "type"},
+			`logger: unknown JSON field "level"`},
+		{NewJSONEventWriter(InfoEvent, &bytes.Buffer{}, nil), map[string]string{"type": "message"},
+			`logger: duplicate JSON field name "message"`},
+	}
+
+	for _, test := range tests {
+		ew, err := WithJSONFieldNames(test.ew, test.names)
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("Expected WithJSONFieldNames(%T, %v) to return error %q, but got %v",
+				test.ew, test.names, test.expected, err)
+		} else if ew != nil {
+			t.Errorf("Expected no EventWriter on error, but got %v", ew)
+		}
+	}
+}
@@ -0,0 +1,422 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+// Package kinesis provides an EventWriter that ships events, formatted as
+// JSON, to an AWS Kinesis data stream or a Kinesis Firehose delivery stream.
+// Events are send in batches using the PutRecords (or PutRecordBatch for
+// Firehose) API, in the background so writing never waits on the API. Every
+// event is send as a single record, records are not aggregated in the format
+// of the Kinesis Producer Library.
+package kinesis
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+// Defaults used if the Config fields are not set.
+const (
+	DefaultBatchSize     = 100
+	DefaultBufferSize    = 10 * MaxBatchSize
+	DefaultFlushInterval = time.Second
+	DefaultTimeout       = 10 * time.Second
+
+	// MaxBatchSize is the maximum number of records in a single request.
+	MaxBatchSize = 500
+)
+
+// Limits of the APIs, records larger then the maximum record size are
+// dropped and requests are split to stay within the maximum request size.
+const (
+	MaxRecordSize          = 1024 * 1024
+	MaxRequestSize         = 5 * 1024 * 1024
+	MaxFirehoseRecordSize  = 1000 * 1024
+	MaxFirehoseRequestSize = 4 * 1024 * 1024
+)
+
+// Config is the configuration for the EventWriter created by NewEventWriter.
+type Config struct {
+	// Stream is the name of the data stream or delivery stream.
+	Stream string
+
+	// Firehose indicates that Stream is a Firehose delivery stream, rather
+	// than a Kinesis data stream.
+	Firehose bool
+
+	// Region of the stream, defaults to the AWS_REGION environment variable.
+	Region string
+
+	// AccessKeyID, SecretAccessKey and SessionToken are the credentials used
+	// to sign the requests, default to the AWS_ACCESS_KEY_ID,
+	// AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN environment variables.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// Endpoint of the API, defaults to the endpoint of the service in the
+	// region, e.g. "https://kinesis.us-east-1.amazonaws.com".
+	Endpoint string
+
+	// PartitionKey returns the partition key for an event, only used for data
+	// streams. Defaults to TagsPartitionKey.
+	PartitionKey func(logger.Event) string
+
+	// BatchSize is the number of events send in a single request, at most
+	// MaxBatchSize. Defaults to DefaultBatchSize.
+	BatchSize int
+
+	// BufferSize is the maximum number of records buffered, e.g. while the
+	// stream is unavailable. Once full the oldest records are dropped, which
+	// is reported to the error handler as a *DroppedError. Defaults to
+	// DefaultBufferSize.
+	BufferSize int
+
+	// FlushInterval is the maximum time an event is buffered before being
+	// send, defaults to DefaultFlushInterval.
+	FlushInterval time.Duration
+
+	// Timeout of a single request, defaults to DefaultTimeout.
+	Timeout time.Duration
+}
+
+// TagsPartitionKey uses the tags of the event as partition key, keeping
+// events with the same tags in order. If the event has no tags the EventType
+// is used.
+func TagsPartitionKey(event logger.Event) string {
+	if len(event.Tags) == 0 {
+		return event.Type.String()
+	}
+	return event.Tags.String()
+}
+
+// ErrFailedRecords is returned if not all records in a request were accepted,
+// the failed records will be retried in the next request.
+var ErrFailedRecords = errors.New("kinesis: not all records were accepted")
+
+// ErrRecordTooLarge is passed to the error handler if an event is larger then
+// the maximum record size, the event is dropped.
+var ErrRecordTooLarge = errors.New("kinesis: event larger then the maximum record size")
+
+// DroppedError is passed to the error handler if records are dropped because
+// the buffer is full, see Config.BufferSize.
+type DroppedError struct {
+	Records int
+}
+
+func (err *DroppedError) Error() string {
+	return fmt.Sprintf("kinesis: buffer full, dropped %d records", err.Records)
+}
+
+type record struct {
+	Data         []byte
+	PartitionKey string `json:",omitempty"`
+}
+
+// size returns the size of the record as counted by the API limits.
+func (r record) size() int {
+	return len(r.Data) + len(r.PartitionKey)
+}
+
+type eventWriter struct {
+	config         Config
+	client         *http.Client
+	service        string
+	target         string
+	maxRecordSize  int
+	maxRequestSize int
+	// Signals flushPeriodically that a batch is full.
+	full   chan struct{}
+	closed chan struct{}
+	done   chan struct{}
+	once   sync.Once
+
+	// Mutex protecting the buffered records and the number of records
+	// dropped since it was last reported.
+	mu      sync.Mutex
+	records []record
+	dropped int
+
+	// Mutex serialising the calls to the error handler, which is called from
+	// both the goroutine calling Write and flushPeriodically.
+	errorMu      sync.Mutex
+	errorHandler func(error)
+	minType      logger.EventType
+}
+
+func (ew *eventWriter) Write(event logger.Event) error {
+	if event.Type < ew.minType {
+		return nil
+	}
+
+	data, err := event.MarshalJSON()
+	if err != nil {
+		return err
+	}
+	r := record{Data: append(data, '\n')}
+	if !ew.config.Firehose {
+		r.PartitionKey = ew.config.PartitionKey(event)
+	}
+	if r.size() > ew.maxRecordSize {
+		// Writing the event again won't make it any smaller, so don't return
+		// the error.
+		ew.HandleError(ErrRecordTooLarge)
+		return nil
+	}
+
+	ew.mu.Lock()
+	ew.records = append(ew.records, r)
+	ew.limitBuffer()
+	full := len(ew.records) >= ew.config.BatchSize
+	ew.mu.Unlock()
+
+	if full {
+		select {
+		case ew.full <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// limitBuffer drops the oldest records if the buffer holds more then the
+// buffer size, ew.mu must be held.
+func (ew *eventWriter) limitBuffer() {
+	if n := len(ew.records) - ew.config.BufferSize; n > 0 {
+		ew.records = append(ew.records[:0], ew.records[n:]...)
+		ew.dropped += n
+	}
+}
+
+// Flush sends the buffered records, if all is false only full batches are
+// send. Records that failed are kept for the next request.
+func (ew *eventWriter) flush(all bool) error {
+	for {
+		ew.mu.Lock()
+		batch := ew.nextBatch(all)
+		dropped := ew.dropped
+		ew.dropped = 0
+		ew.mu.Unlock()
+
+		if dropped != 0 {
+			ew.HandleError(&DroppedError{dropped})
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+
+		failed, err := ew.putRecords(batch)
+		if err != nil {
+			failed = batch
+		}
+		if len(failed) != 0 {
+			// Retry the failed records first, to keep them in order.
+			ew.mu.Lock()
+			ew.records = append(failed, ew.records...)
+			ew.limitBuffer()
+			ew.mu.Unlock()
+			if err == nil {
+				err = ErrFailedRecords
+			}
+			return err
+		}
+	}
+}
+
+// nextBatch removes and returns the next batch of records to send, staying
+// within the batch and request size. If all is false only a full batch is
+// returned. ew.mu must be held.
+func (ew *eventWriter) nextBatch(all bool) []record {
+	var n, size int
+	for n < len(ew.records) && n < ew.config.BatchSize {
+		size += ew.records[n].size()
+		if size > ew.maxRequestSize {
+			break
+		}
+		n++
+	}
+	if n == 0 || (!all && n < ew.config.BatchSize && size <= ew.maxRequestSize) {
+		return nil
+	}
+	batch := make([]record, n)
+	copy(batch, ew.records)
+	ew.records = append(ew.records[:0], ew.records[n:]...)
+	return batch
+}
+
+// PutRecords sends the records and returns the records that failed.
+func (ew *eventWriter) putRecords(records []record) ([]record, error) {
+	request := map[string]interface{}{"Records": records}
+	if ew.config.Firehose {
+		request["DeliveryStreamName"] = ew.config.Stream
+	} else {
+		request["StreamName"] = ew.config.Stream
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", ew.config.Endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", ew.target)
+	sign(req, body, ew.config, ew.service, now())
+
+	resp, err := ew.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	} else if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("kinesis: unexpected response: %s: %s", resp.Status, respBody)
+	}
+	return ew.failedRecords(records, respBody)
+}
+
+// failedRecords returns the records that failed according to the response
+// body.
+func (ew *eventWriter) failedRecords(records []record, respBody []byte) ([]record, error) {
+	// PutRecords returns "Records", PutRecordBatch "RequestResponses".
+	var response struct {
+		Records          []struct{ ErrorCode string }
+		RequestResponses []struct{ ErrorCode string }
+	}
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, err
+	}
+	results := response.Records
+	if ew.config.Firehose {
+		results = response.RequestResponses
+	}
+
+	var failed []record
+	for i, result := range results {
+		if result.ErrorCode != "" && i < len(records) {
+			failed = append(failed, records[i])
+		}
+	}
+	return failed, nil
+}
+
+// Stubbed for testing.
+var now = time.Now
+
+// FlushPeriodically sends the full batches once they're full and all records
+// every flush interval, until the writer is closed.
+func (ew *eventWriter) flushPeriodically() {
+	ticker := time.NewTicker(ew.config.FlushInterval)
+	defer ticker.Stop()
+	defer close(ew.done)
+
+	for {
+		var err error
+		select {
+		case <-ew.full:
+			err = ew.flush(false)
+		case <-ticker.C:
+			err = ew.flush(true)
+		case <-ew.closed:
+			return
+		}
+		if err != nil {
+			ew.HandleError(err)
+		}
+	}
+}
+
+func (ew *eventWriter) HandleError(err error) {
+	ew.errorMu.Lock()
+	defer ew.errorMu.Unlock()
+	ew.errorHandler(err)
+}
+
+// Close sends all buffered events, calling it more then once is safe.
+func (ew *eventWriter) Close() error {
+	ew.once.Do(func() { close(ew.closed) })
+	<-ew.done
+	return ew.flush(true)
+}
+
+// NewEventWriter creates a new EventWriter that ships events, formatted as
+// JSON followed by a newline, to a Kinesis data stream or Firehose delivery
+// stream. Events are buffered and send in batches, either once the batch is
+// full or after the flush interval, by a goroutine so Write never waits on the
+// API. Failed records are retried in the next request, see Config.BufferSize
+// for how many records are kept. The errorHandler is called from both Write and
+// the goroutine, but never concurrently. MinType is the minimal EventType an
+// event must have to be logged. For example if minType is InfoEvent, then any
+// events with an EventType of DebugEvent will not be logged.
+func NewEventWriter(minType logger.EventType, config Config, errorHandler func(error)) logger.EventWriter {
+	setDefaults(&config)
+	service, target := "kinesis", "Kinesis_20131202.PutRecords"
+	maxRecordSize, maxRequestSize := MaxRecordSize, MaxRequestSize
+	if config.Firehose {
+		service, target = "firehose", "Firehose_20150804.PutRecordBatch"
+		maxRecordSize, maxRequestSize = MaxFirehoseRecordSize, MaxFirehoseRequestSize
+	}
+	if config.Endpoint == "" {
+		config.Endpoint = "https://" + service + "." + config.Region + ".amazonaws.com"
+	}
+
+	ew := &eventWriter{
+		config:         config,
+		client:         &http.Client{Timeout: config.Timeout},
+		service:        service,
+		target:         target,
+		maxRecordSize:  maxRecordSize,
+		maxRequestSize: maxRequestSize,
+		full:           make(chan struct{}, 1),
+		closed:         make(chan struct{}),
+		done:           make(chan struct{}),
+		errorHandler:   errorHandler,
+		minType:        minType,
+	}
+	go ew.flushPeriodically()
+	return ew
+}
+
+// setDefaults sets the defaults of the unset options of config.
+func setDefaults(config *Config) {
+	if config.Region == "" {
+		config.Region = os.Getenv("AWS_REGION")
+	}
+	if config.AccessKeyID == "" {
+		config.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		config.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		config.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+	if config.PartitionKey == nil {
+		config.PartitionKey = TagsPartitionKey
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = DefaultBatchSize
+	} else if config.BatchSize > MaxBatchSize {
+		config.BatchSize = MaxBatchSize
+	}
+	if config.BufferSize <= 0 {
+		config.BufferSize = DefaultBufferSize
+	} else if config.BufferSize < config.BatchSize {
+		config.BufferSize = config.BatchSize
+	}
+	if config.FlushInterval == 0 {
+		config.FlushInterval = DefaultFlushInterval
+	}
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package kinesis
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+var t1 = time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC)
+
+type putRequest struct {
+	Target  string
+	Stream  string
+	Records []record
+}
+
+func newServer(t *testing.T, requests chan<- putRequest, failFirst bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=id/") {
+			t.Errorf("Unexpected Authorization header: %q", r.Header.Get("Authorization"))
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		var req struct {
+			StreamName         string
+			DeliveryStreamName string
+			Records            []record
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Error("Unexpected error decoding request: " + err.Error())
+		}
+
+		results := make([]map[string]string, len(req.Records))
+		for i := range results {
+			results[i] = map[string]string{}
+		}
+		if failFirst {
+			failFirst = false
+			results[0]["ErrorCode"] = "ProvisionedThroughputExceededException"
+		}
+		key := "Records"
+		if req.DeliveryStreamName != "" {
+			key = "RequestResponses"
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{key: results})
+
+		requests <- putRequest{
+			Target:  r.Header.Get("X-Amz-Target"),
+			Stream:  req.StreamName + req.DeliveryStreamName,
+			Records: req.Records,
+		}
+	}))
+}
+
+func TestEventWriter(t *testing.T) {
+	requests := make(chan putRequest, 10)
+	server := newServer(t, requests, false)
+	defer server.Close()
+
+	config := Config{
+		Stream:          "stream",
+		Region:          "us-east-1",
+		AccessKeyID:     "id",
+		SecretAccessKey: "secret",
+		Endpoint:        server.URL,
+		BatchSize:       2,
+		FlushInterval:   time.Hour,
+	}
+	ew := NewEventWriter(logger.InfoEvent, config, func(error) {})
+
+	events := []logger.Event{
+		{Type: logger.DebugEvent, Timestamp: t1, Message: "Debug message"},
+		{Type: logger.InfoEvent, Timestamp: t1, Tags: logger.Tags{"tag1"}, Message: "Info message"},
+		{Type: logger.ErrorEvent, Timestamp: t1, Message: "Error message"},
+		{Type: logger.WarnEvent, Timestamp: t1, Message: "Warn message"},
+	}
+	for _, event := range events {
+		if err := ew.Write(event); err != nil {
+			t.Fatal("Unexpected error writing event: " + err.Error())
+		}
+	}
+
+	checkFullBatch(t, <-requests)
+
+	// Remaining event is send on close.
+	if err := ew.Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+	req := <-requests
+	if len(req.Records) != 1 || !strings.Contains(string(req.Records[0].Data), "Warn message") {
+		t.Fatalf("Unexpected records send on close: %v", req.Records)
+	}
+
+	if err := ew.Close(); err != nil {
+		t.Fatal("Unexpected error closing a second time: " + err.Error())
+	}
+}
+
+func checkFullBatch(t *testing.T, req putRequest) {
+	if expected := "Kinesis_20131202.PutRecords"; req.Target != expected {
+		t.Fatalf("Expected target to be %q, but got %q", expected, req.Target)
+	} else if req.Stream != "stream" {
+		t.Fatalf("Expected stream to be %q, but got %q", "stream", req.Stream)
+	} else if len(req.Records) != 2 {
+		t.Fatalf("Expected 2 records, but got %d", len(req.Records))
+	}
+	expected := `{"schema_version": 1, "type": "Info", "timestamp": "2015-09-01T14:22:36Z", "tags": ["tag1"], "message": "Info message"}` + "\n"
+	if got := string(req.Records[0].Data); got != expected {
+		t.Fatalf("Expected record data %q, but got %q", expected, got)
+	} else if got := req.Records[0].PartitionKey; got != "tag1" {
+		t.Fatalf("Expected partition key %q, but got %q", "tag1", got)
+	} else if got := req.Records[1].PartitionKey; got != "Error" {
+		t.Fatalf("Expected partition key %q, but got %q", "Error", got)
+	}
+}
+
+func TestEventWriterFirehose(t *testing.T) {
+	requests := make(chan putRequest, 10)
+	server := newServer(t, requests, true)
+	defer server.Close()
+
+	config := Config{
+		Stream:          "delivery",
+		Firehose:        true,
+		Region:          "us-east-1",
+		AccessKeyID:     "id",
+		SecretAccessKey: "secret",
+		Endpoint:        server.URL,
+		BatchSize:       2,
+		FlushInterval:   time.Hour,
+	}
+	ew := NewEventWriter(logger.DebugEvent, config, func(error) {})
+
+	ew.Write(logger.Event{Type: logger.InfoEvent, Timestamp: t1, Message: "Message 1"})
+	// The batch is send in the background, so the writer shouldn't return an
+	// error if the first record fails (which would write the event again).
+	if err := ew.Write(logger.Event{Type: logger.InfoEvent, Timestamp: t1, Message: "Message 2"}); err != nil {
+		t.Fatal("Unexpected error writing event: " + err.Error())
+	}
+
+	req := <-requests
+	if expected := "Firehose_20150804.PutRecordBatch"; req.Target != expected {
+		t.Fatalf("Expected target to be %q, but got %q", expected, req.Target)
+	} else if req.Stream != "delivery" {
+		t.Fatalf("Expected stream to be %q, but got %q", "delivery", req.Stream)
+	} else if req.Records[0].PartitionKey != "" {
+		t.Fatalf("Expected no partition key, but got %q", req.Records[0].PartitionKey)
+	}
+
+	// The failed record should be retried.
+	if err := ew.Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+	req = <-requests
+	if len(req.Records) != 1 || !strings.Contains(string(req.Records[0].Data), "Message 1") {
+		t.Fatalf("Unexpected records retried: %v", req.Records)
+	}
+}
+
+// newLimitedEventWriter returns an eventWriter with small limits, that
+// doesn't send any requests.
+func newLimitedEventWriter(errs *[]error) *eventWriter {
+	return &eventWriter{
+		config:         Config{Firehose: true, BatchSize: 3, BufferSize: 4},
+		maxRecordSize:  10,
+		maxRequestSize: 20,
+		full:           make(chan struct{}, 1),
+		errorHandler:   func(err error) { *errs = append(*errs, err) },
+	}
+}
+
+func TestEventWriterRecordTooLarge(t *testing.T) {
+	var errs []error
+	ew := newLimitedEventWriter(&errs)
+	event := logger.Event{Type: logger.InfoEvent, Timestamp: t1, Message: "Message"}
+	if err := ew.Write(event); err != nil {
+		t.Fatal("Unexpected error writing event: " + err.Error())
+	} else if len(ew.records) != 0 || len(errs) != 1 || errs[0] != ErrRecordTooLarge {
+		t.Fatalf("Expected the event to be dropped, but got records %v and errors %v", ew.records, errs)
+	}
+}
+
+// records returns n records of 10 bytes each, with the index as data.
+func records(n int) []record {
+	records := make([]record, n)
+	for i := range records {
+		records[i] = record{Data: []byte{byte(i)}, PartitionKey: "123456789"}
+	}
+	return records
+}
+
+func TestEventWriterLimits(t *testing.T) {
+	var errs []error
+	ew := newLimitedEventWriter(&errs)
+	ew.records = records(6)
+	ew.limitBuffer()
+	if len(ew.records) != 4 || ew.records[0].Data[0] != 2 || ew.dropped != 2 {
+		t.Fatalf("Expected the 2 oldest records to be dropped, but got %v", ew.records)
+	}
+
+	// Limited by the request size, not the batch size.
+	if batch := ew.nextBatch(false); len(batch) != 2 || batch[0].Data[0] != 2 {
+		t.Fatalf("Expected a batch of 2 records, but got %v", batch)
+	} else if batch := ew.nextBatch(false); batch != nil {
+		t.Fatalf("Expected no partial batch, but got %v", batch)
+	} else if batch := ew.nextBatch(true); len(batch) != 2 || batch[0].Data[0] != 4 || len(ew.records) != 0 {
+		t.Fatalf("Expected the partial batch of 2 records, but got %v", batch)
+	}
+}
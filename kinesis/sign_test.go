@@ -0,0 +1,36 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package kinesis
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	// Example from the AWS Signature Version 4 test suite (get-vanilla).
+	req, _ := http.NewRequest("GET", "https://example.amazonaws.com/", nil)
+	config := Config{
+		Region:          "us-east-1",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}
+	sign(req, nil, config, "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	expected := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, " +
+		"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != expected {
+		t.Fatalf("Expected Authorization header:\n%s\nBut got:\n%s", expected, got)
+	}
+
+	config.SessionToken = "token"
+	sign(req, nil, config, "service", time.Now())
+	if got := req.Header.Get("Authorization"); !strings.Contains(got, "x-amz-security-token") {
+		t.Fatalf("Expected the session token to be signed, but got: %s", got)
+	}
+}
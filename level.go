@@ -0,0 +1,35 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import "sync/atomic"
+
+// Minimum EventType of events, must be accessed atomically.
+var minEventType uint32
+
+// SetMinEventType sets the minimum EventType an event must have to be logged,
+// events with a lower EventType are dropped before they're send to the
+// EventWriters. This works in addition to the minimum EventType of the
+// EventWriters. Defaults to TraceEvent, i.e. no events are dropped.
+//
+// Unlike most options SetMinEventType is safe for concurrent use and can be
+// called while the logger is running, e.g. to enable debug logging.
+func SetMinEventType(eventType EventType) {
+	atomic.StoreUint32(&minEventType, uint32(eventType))
+}
+
+// MinEventType returns the minimum EventType, see SetMinEventType.
+func MinEventType() EventType {
+	return EventType(atomic.LoadUint32(&minEventType))
+}
+
+// Enabled returns true if events of the EventType are logged, i.e. if it's not
+// below the minimum EventType, see SetMinEventType. This can be used to skip
+// expensive work, e.g. creating the data of a debug event, if the event would
+// be dropped anyway. The formatted log operations, e.g. Debugf, use it to
+// skip formatting the message.
+func Enabled(eventType EventType) bool {
+	return eventType >= MinEventType()
+}
@@ -0,0 +1,75 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import "testing"
+
+func TestSetMinEventType(t *testing.T) {
+	defer reset()
+	var ew eventWriter
+	Start(&ew)
+
+	Debug(Tags{"level"}, "Debug message 1")
+	SetMinEventType(InfoEvent)
+	if got := MinEventType(); got != InfoEvent {
+		t.Fatalf("Expected the minimum EventType to be %s, but got %s", InfoEvent, got)
+	}
+	Debug(Tags{"level"}, "Debug message 2")
+	Info(Tags{"level"}, "Info message")
+	SetMinEventType(TraceEvent)
+	Debug(Tags{"level"}, "Debug message 3")
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	expected := []string{"Debug message 1", "Info message", "Debug message 3"}
+	if len(ew.events) != len(expected) {
+		t.Fatalf("Expected %d events, but got %v", len(expected), ew.events)
+	}
+	for i, event := range ew.events {
+		if event.Message != expected[i] {
+			t.Errorf("Expected event %d to have message %q, but got %q", i, expected[i], event.Message)
+		}
+	}
+}
+
+// countingStringer counts the number of times it's formatted.
+type countingStringer struct{ n int }
+
+func (s *countingStringer) String() string {
+	s.n++
+	return "stringer"
+}
+
+func TestFormattedSkipped(t *testing.T) {
+	defer reset()
+	var ew eventWriter
+	Start(&ew)
+
+	SetMinEventType(SecurityEvent)
+	if Enabled(FatalEvent) || !Enabled(SecurityEvent) {
+		t.Fatal("Expected only Security events to be enabled")
+	}
+	var s countingStringer
+	Tracef(nil, "%s", &s)
+	Debugf(nil, "%s", &s)
+	Infof(nil, "%s", &s)
+	Noticef(nil, "%s", &s)
+	Warnf(nil, "%s", &s)
+	Errorf(nil, "%s", &s)
+	if s.n != 0 {
+		t.Fatalf("Expected the message not to be formatted, but it was formatted %d times", s.n)
+	}
+	Securityf(nil, "%s", &s)
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if s.n != 1 {
+		t.Fatalf("Expected the message to be formatted once, but it was formatted %d times", s.n)
+	} else if len(ew.events) != 1 || ew.events[0].Message != "stringer" {
+		t.Fatalf("Expected only the Security event, but got %v", ew.events)
+	}
+}
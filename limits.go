@@ -0,0 +1,93 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"unicode/utf8"
+
+	"github.com/Thomasdezeeuw/logger/internal/util"
+)
+
+// TruncatedTag is added to the tags of events that are truncated, see
+// SetSizeLimits.
+const TruncatedTag = "truncated=true"
+
+var (
+	maxMessageSize int
+	maxDataSize    int
+)
+
+// SetSizeLimits sets the maximum size, in bytes, of the message and of the data
+// of events. Events with a message or data that is bigger get truncated and
+// TruncatedTag is added to their tags. This prevents a single event from
+// producing a multi-megabyte log line that breaks downstream parsers. A limit
+// of zero (the default) means no limit. To limit the nesting of structured
+// data see SetDataLimits.
+//
+// The size of the data is the size of the data converted to a string, in the
+// same way as in Event.String. Data of type string and []byte is truncated
+// keeping the type, any other type is converted to a string first.
+//
+// Note: this must be called before Start and is not safe for concurrent use.
+func SetSizeLimits(maxMessage, maxData int) {
+	maxMessageSize = maxMessage
+	maxDataSize = maxData
+}
+
+// truncateEvent truncates the message and data of the event to the given
+// limits, after limiting the data to the limits set with SetDataLimits. It
+// never modifies the tags of the event in place.
+func truncateEvent(event Event, maxMessage, maxData int) Event {
+	var truncated bool
+	if maxMessage > 0 && len(event.Message) > maxMessage {
+		event.Message = truncateString(event.Message, maxMessage)
+		truncated = true
+	}
+
+	if event.Data != nil {
+		var limited bool
+		if event.Data, limited = limitData(event.Data, maxDataDepth, maxDataElements); limited {
+			truncated = true
+		}
+	}
+
+	if maxData > 0 && event.Data != nil {
+		var cut bool
+		if event.Data, cut = truncateData(event.Data, maxData); cut {
+			truncated = true
+		}
+	}
+
+	if truncated {
+		tags := make(Tags, len(event.Tags), len(event.Tags)+1)
+		copy(tags, event.Tags)
+		event.Tags = append(tags, TruncatedTag)
+	}
+	return event
+}
+
+// truncateData truncates the data to at most max bytes, converting it into a
+// string if it's not a []byte. It returns true if the data is truncated.
+func truncateData(data interface{}, max int) (interface{}, bool) {
+	if b, ok := data.([]byte); ok {
+		if len(b) > max {
+			return b[:max:max], true
+		}
+		return b, false
+	}
+	if str := util.InterfaceToString(data); len(str) > max {
+		return truncateString(str, max), true
+	}
+	return data, false
+}
+
+// truncateString truncates s to at most max bytes, without splitting an UTF-8
+// encoded character.
+func truncateString(s string, max int) string {
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
+	}
+	return s[:max]
+}
@@ -0,0 +1,65 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTruncateEvent(t *testing.T) {
+	t.Parallel()
+
+	t1 := time.Now()
+	tags := Tags{"tag"}
+	tests := []struct {
+		event    Event
+		expected Event
+	}{
+		{Event{InfoEvent, t1, tags, "short", nil, 1}, Event{InfoEvent, t1, tags, "short", nil, 1}},
+		{Event{InfoEvent, t1, tags, "too long message", nil, 1},
+			Event{InfoEvent, t1, Tags{"tag", TruncatedTag}, "too lon", nil, 1}},
+		// Don't split an UTF-8 encoded character.
+		{Event{InfoEvent, t1, nil, "123456€", nil, 1},
+			Event{InfoEvent, t1, Tags{TruncatedTag}, "123456", nil, 1}},
+		{Event{FatalEvent, t1, tags, "msg", []byte("goroutine 1 [running]"), 1},
+			Event{FatalEvent, t1, Tags{"tag", TruncatedTag}, "msg", []byte("goroutine"), 1}},
+		{Event{InfoEvent, t1, tags, "msg", "data string", 1},
+			Event{InfoEvent, t1, Tags{"tag", TruncatedTag}, "msg", "data stri", 1}},
+		{Event{InfoEvent, t1, tags, "msg", []int{1, 2, 3, 4, 5}, 1},
+			Event{InfoEvent, t1, Tags{"tag", TruncatedTag}, "msg", "[1 2 3 4 ", 1}},
+		{Event{InfoEvent, t1, tags, "msg", 123, 1}, Event{InfoEvent, t1, tags, "msg", 123, 1}},
+	}
+
+	for _, test := range tests {
+		got := truncateEvent(test.event, 7, 9)
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("Expected truncateEvent(%v) to return %v, but got %v",
+				test.event, test.expected, got)
+		}
+	}
+
+	if len(tags) != 1 || cap(tags) != 1 {
+		t.Fatalf("Expected the tags to not be modified, but got %v", tags)
+	}
+}
+
+func TestSetSizeLimits(t *testing.T) {
+	defer reset()
+	SetSizeLimits(4, 0)
+
+	var ew eventWriter
+	Start(&ew)
+	Info(Tags{"limits"}, "Info message")
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	expected := []Event{{InfoEvent, ew.events[0].Timestamp, Tags{"limits", TruncatedTag}, "Info", nil, 1}}
+	if !reflect.DeepEqual(ew.events, expected) {
+		t.Fatalf("Expected events %v, but got %v", expected, ew.events)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// localBuffer is a single shard of the local buffers, see SetLocalBuffering.
+type localBuffer struct {
+	mu     sync.Mutex
+	events []Event
+	// Prevent false sharing between the shards.
+	_ [64]byte
+}
+
+var (
+	localBuffers        []*localBuffer
+	localBufferSize     int
+	localBufferInterval time.Duration
+	localBufferDone     chan struct{}
+	localBufferStopped  chan struct{}
+
+	// The shard used for the next event, must be accessed atomically.
+	localBufferNext uint32
+)
+
+// SetLocalBuffering enables buffering of events in local buffers, split in
+// shards, before they're send to the EventWriters. Log operations append the
+// event to one of the shards, rather than sending it to the shared event
+// channel, which dramatically reduces the contention of logging in hot paths.
+// A shard is send once it holds size events, or when interval has passed,
+// whichever comes first. This means events are delayed by at most interval.
+//
+// Because the events are buffered in different shards events logged by
+// different goroutines, and sometimes those logged by the same goroutine, can
+// be written out of order, the timestamps of the events are kept however.
+// Error, Fatal and higher events are never buffered, they're send right away.
+// Flush and Close send all buffered events first.
+//
+// Zero shards disables the local buffering, which is the default.
+//
+// Note: this must be called before Start and is not safe for concurrent use.
+func SetLocalBuffering(shards, size int, interval time.Duration) {
+	localBuffers = nil
+	if shards <= 0 {
+		return
+	}
+	localBuffers = make([]*localBuffer, shards)
+	for i := range localBuffers {
+		localBuffers[i] = &localBuffer{events: make([]Event, 0, size)}
+	}
+	localBufferSize = size
+	localBufferInterval = interval
+}
+
+// bufferEvent adds the event to one of the local buffers, it returns false if
+// the event should be send right away.
+func bufferEvent(event Event) bool {
+	if localBuffers == nil || event.Type >= ErrorEvent {
+		return false
+	}
+
+	n := atomic.AddUint32(&localBufferNext, 1)
+	buf := localBuffers[n%uint32(len(localBuffers))]
+	buf.mu.Lock()
+	buf.events = append(buf.events, event)
+	if len(buf.events) < localBufferSize {
+		buf.mu.Unlock()
+		return true
+	}
+	events := buf.swap()
+	buf.mu.Unlock()
+
+	for _, event := range events {
+		eventChannel <- event
+	}
+	return true
+}
+
+// swap swaps the buffered events with a new buffer, returning the buffered
+// events. The mutex must be locked.
+func (buf *localBuffer) swap() []Event {
+	events := buf.events
+	buf.events = make([]Event, 0, localBufferSize)
+	return events
+}
+
+// flushLocalBuffers sends the events in all local buffers to the event
+// channel.
+func flushLocalBuffers() {
+	for _, buf := range localBuffers {
+		buf.mu.Lock()
+		var events []Event
+		if len(buf.events) != 0 {
+			events = buf.swap()
+		}
+		buf.mu.Unlock()
+
+		for _, event := range events {
+			eventChannel <- event
+		}
+	}
+}
+
+// startLocalBuffers starts the goroutine that periodically flushes the local
+// buffers, if enabled.
+func startLocalBuffers() {
+	if localBuffers == nil || localBufferInterval <= 0 {
+		return
+	}
+
+	localBufferDone = make(chan struct{})
+	localBufferStopped = make(chan struct{})
+	go func(done <-chan struct{}, stopped chan<- struct{}) {
+		defer close(stopped)
+		ticker := time.NewTicker(localBufferInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				flushLocalBuffers()
+			case <-done:
+				return
+			}
+		}
+	}(localBufferDone, localBufferStopped)
+}
+
+// stopLocalBuffers stops the flushing goroutine, if running, and flushes the
+// local buffers one last time.
+func stopLocalBuffers() {
+	if localBufferDone != nil {
+		close(localBufferDone)
+		<-localBufferStopped
+		localBufferDone, localBufferStopped = nil, nil
+	}
+	flushLocalBuffers()
+}
@@ -0,0 +1,98 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLocalBuffering(t *testing.T) {
+	defer reset()
+	SetLocalBuffering(4, 8, time.Hour)
+	var ew eventWriter
+	Start(&ew)
+
+	const goroutines, n = 8, 100
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+	for i := 0; i < goroutines; i++ {
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < n; j++ {
+				Info(Tags{strconv.Itoa(i)}, strconv.Itoa(j))
+			}
+		}(i)
+	}
+	wg.Wait()
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if len(ew.events) != goroutines*n {
+		t.Fatalf("Expected %d events, but got %d", goroutines*n, len(ew.events))
+	}
+	seen := make(map[string]bool, len(ew.events))
+	for _, event := range ew.events {
+		key := event.Tags[0] + "/" + event.Message
+		if seen[key] {
+			t.Fatalf("Unexpected duplicate event %v", event)
+		}
+		seen[key] = true
+	}
+}
+
+func TestLocalBufferingUnbuffered(t *testing.T) {
+	defer reset()
+	SetLocalBuffering(1, 100, time.Hour)
+	var ew eventWriter
+	Start(&ew)
+
+	Info(nil, "Info message")
+	Error(nil, errors.New("Error message"))
+	if err := Flush(); err != nil {
+		t.Fatal("Unexpected error flushing: " + err.Error())
+	}
+	Warn(nil, "Warn message")
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	expected := []string{"Error message", "Info message", "Warn message"}
+	if len(ew.events) != len(expected) {
+		t.Fatalf("Expected %d events, but got %d: %v", len(expected), len(ew.events), ew.events)
+	}
+	for i, msg := range expected {
+		if got := ew.events[i].Message; got != msg {
+			t.Errorf("Expected event %d to be %q, but got %q", i, msg, got)
+		}
+	}
+}
+
+func TestLocalBufferingInterval(t *testing.T) {
+	defer reset()
+	SetLocalBuffering(2, 100, time.Millisecond)
+	events := make(chan Event, 1)
+	Start(WriterFunc(func(event Event) error {
+		events <- event
+		return nil
+	}))
+
+	Info(nil, "Info message")
+	select {
+	case event := <-events:
+		if event.Message != "Info message" {
+			t.Fatalf("Expected the info message, but got %v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the buffered event to be send after the interval")
+	}
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+}
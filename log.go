@@ -6,9 +6,11 @@ package logger
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Thomasdezeeuw/logger/internal/util"
@@ -28,16 +30,22 @@ type EventWriter interface {
 	// block until the event is written. Advised is to buffer the events. All
 	// calls to Write are synchronous.
 	//
+	// The EventWriter gets its own copy of the tags of the event, and of the
+	// data if it's a byte slice, which it may modify. Other data is shared with
+	// the other EventWriters and must not be modified.
+	//
 	// If an error is returned the event is expected to NOT have been written. The
 	// event will written again after the error handler is called. If the
-	// EventWriter returns 5 errors in a row the EventWriter is consided to be bad
-	// and will be removed from the list of event writers. HandlerError will be
-	// called with ErrBadEventWriter if this happens.
+	// EventWriter returns 5 errors in a row, or whatever is set using
+	// SetFailureThreshold, the EventWriter is consided to be bad and will be
+	// removed from the list of event writers. HandlerError will be called with
+	// a *BadEventWriterError if this happens.
 	Write(Event) error
 
 	// HandleError is called every time Write returns an error. A special case is
-	// ErrBadEventWriter, if this error gets passed it means the EventWriter is
-	// considered bad and will no longer receive events.
+	// ErrBadEventWriter, if this error gets passed (see errors.Is) it means the
+	// EventWriter is considered bad and will no longer receive events, unless
+	// it's re-attached, see SetReattachCooldown.
 	HandleError(error)
 
 	// Close is called on the EventWriter once Close() (on the package) is called.
@@ -49,6 +57,23 @@ var (
 	eventChannelClosed = make(chan struct{}, 1) // Can't block.
 	eventWriters       []EventWriter
 	started            bool
+	closed             bool
+
+	// Internal events not created by an EventWriter, see logInternal.
+	internalEventChannel = make(chan internalEvent, defaultInternalChannelSize)
+
+	// State of the logger, one of the logger* constants. Before the logger is
+	// started events are buffered, see SetPreStartBuffer, and after it's
+	// closed they're dropped, see DroppedAfterClose. Must be accessed
+	// atomically.
+	running uint32
+)
+
+// States of the logger.
+const (
+	loggerNotStarted uint32 = iota
+	loggerRunning
+	loggerClosed
 )
 
 // Start starts the logger package and enables writing to the given
@@ -62,157 +87,611 @@ func Start(ews ...EventWriter) {
 
 	started = true
 	eventWriters = ews
+	writerStates = make([]*writerState, len(ews))
+	for i := range writerStates {
+		writerStates[i] = newWriterState(i, ews[i])
+		writerStates[i].events = make(chan queuedEvent, defaultEventChannelSize)
+	}
 
-	go writeEvents()
+	go writeEvents(eventChannel, internalEventChannel, eventChannelClosed)
+	startWatchdog()
+	startStats()
+	startLocalBuffers()
+	startSpill()
+	startRunning()
 }
 
 // ErrBadEventWriter gets passed to the error handler of an EventWriter after it
-// returned too many write errors in a row. After the error handler of the
-// EventWriter is called with this error the writer is considered faulty and
-// will no longer recive any Events.
-var ErrBadEventWriter = fmt.Errorf("EventWriter is bad, %d faulty writes, EventWriter will be dropped", maxNWriteErrors)
-
-// Needs to be run in it's own goroutine, it blocks until eventChannel is
-// closed. After eventChannel is closed it sends a signal to eventChannelClosed.
-func writeEvents() {
+// returned too many write errors in a row, wrapped in a *BadEventWriterError.
+// After the error handler of the EventWriter is called with this error the
+// writer is considered faulty and will no longer recive any Events. Use
+// errors.Is(err, ErrBadEventWriter) to check for it.
+var ErrBadEventWriter = errors.New("EventWriter is bad, too many faulty writes, EventWriter will be dropped")
+
+// BadEventWriterError is the error passed to the error handler of an
+// EventWriter that is considered bad, see ErrBadEventWriter.
+type BadEventWriterError struct {
+	Writer EventWriter
+	Errors int   // Number of faulty writes that made the EventWriter bad.
+	Err    error // Last write error.
+}
+
+func (err *BadEventWriterError) Error() string {
+	return fmt.Sprintf("EventWriter %T is bad, %d faulty writes, EventWriter will be dropped: %s",
+		err.Writer, err.Errors, err.Err)
+}
+
+// Is returns true if target is ErrBadEventWriter.
+func (err *BadEventWriterError) Is(target error) bool {
+	return target == ErrBadEventWriter
+}
+
+// Unwrap returns the last write error.
+func (err *BadEventWriterError) Unwrap() error {
+	return err.Err
+}
+
+// Needs to be run in it's own goroutine, it blocks until the events channel is
+// closed. After it's closed it sends a signal to the closed channel.
+func writeEvents(events <-chan Event, internalEvents chan internalEvent, closed chan<- struct{}) {
 	var wg sync.WaitGroup
 	wg.Add(len(eventWriters))
 
 	// Create event sub channels for each EventWriter and start each EventWriter.
-	var eventSubChannels = make([]chan Event, len(eventWriters))
+	f := &fanOut{
+		subChannels: make([]chan queuedEvent, len(eventWriters)),
+		encodings:   make([]Encoding, len(eventWriters)),
+		copies:      make([]Event, len(eventWriters)),
+		escalations: newEscalator(escalationPolicy),
+		anomalies:   newAnomalyMonitor(anomalyDetection),
+	}
 	for i, ew := range eventWriters {
-		eventSubChannels[i] = make(chan Event, defaultEventChannelSize)
-		go startEventWriter(ew, eventSubChannels[i], &wg)
+		f.subChannels[i] = writerStates[i].events
+		f.encodings[i] = eventWriterEncoding(ew)
+		go startEventWriter(ew, writerStates[i], f.subChannels[i], internalEvents, &wg)
 	}
 
-	// Fan out the events to all the sub channels.
-	for event := range eventChannel {
-		for _, eventSubChannel := range eventSubChannels {
-			eventSubChannel <- event
-		}
+	// Internal events are written to the diagnostics EventWriter, if any,
+	// rather than to the other EventWriters.
+	var diagnosticsWg sync.WaitGroup
+	if diagnosticsWriter != nil {
+		diagnosticsWg.Add(1)
+		f.diagnostics = make(chan queuedEvent, defaultInternalChannelSize)
+		go startEventWriter(diagnosticsWriter, newWriterState(-1, diagnosticsWriter), f.diagnostics, nil, &diagnosticsWg)
 	}
 
+	f.run(events, internalEvents)
+
 	// Close each sub channel.
-	for _, eventSubChannel := range eventSubChannels {
-		close(eventSubChannel)
+	for _, subChannel := range f.subChannels {
+		close(subChannel)
 	}
-
 	wg.Wait()
-	eventChannelClosed <- struct{}{}
+
+	// The diagnostics EventWriter is closed last, so it also receives the
+	// internal events send while the other EventWriters finished writing.
+	if f.diagnostics != nil {
+		for len(internalEvents) > 0 {
+			f.diagnostics <- queuedEvent{Event: (<-internalEvents).event}
+		}
+		close(f.diagnostics)
+		diagnosticsWg.Wait()
+	}
+	closed <- struct{}{}
+}
+
+// fanOut fans out the events to all the sub channels of the EventWriters.
+// This is the only place the events are ordered, so here we set the sequence
+// number. Every EventWriter gets its own copy of the event, see copyEvent, and
+// the event encoded in its Encoding, see EncodedEventWriter.
+type fanOut struct {
+	subChannels []chan queuedEvent
+	encodings   []Encoding
+	// Only set if there is a diagnostics EventWriter.
+	diagnostics chan queuedEvent
+
+	escalations *escalator
+	anomalies   *anomalyMonitor
+
+	seq     uint64
+	copies  []Event
+	encoded [numEncodings]*encodedBuffer
+}
+
+// run fans out the events until the events channel is closed.
+func (f *fanOut) run(events <-chan Event, internalEvents chan internalEvent) {
+	anomalyTicks, stopAnomalyTicker := f.anomalies.ticker()
+	defer stopAnomalyTicker()
+
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				// Don't drop the internal events already send.
+				for len(internalEvents) > 0 {
+					f.dispatchInternal(<-internalEvents)
+				}
+				return
+			}
+			f.dispatchLogged(event)
+		case internal := <-internalEvents:
+			f.dispatchInternal(internal)
+		case t := <-anomalyTicks:
+			for _, event := range f.anomalies.tick(t) {
+				f.dispatch(event, -1)
+			}
+		}
+	}
+}
+
+// dispatchLogged dispatches an event from the event channel.
+func (f *fanOut) dispatchLogged(event Event) {
+	if event.Type == controlEventType {
+		// Control requests are passed as is to all EventWriters.
+		for _, subChannel := range f.subChannels {
+			subChannel <- queuedEvent{Event: event}
+		}
+		return
+	}
+	f.dispatch(event, -1)
+	f.anomalies.count(event)
+	if escalation, ok := f.escalations.record(event); ok {
+		f.dispatch(escalation, -1)
+	}
+}
+
+// dispatchInternal dispatches an internal event to the diagnostics
+// EventWriter, if any, or to the other EventWriters.
+func (f *fanOut) dispatchInternal(internal internalEvent) {
+	if f.diagnostics != nil {
+		f.diagnostics <- queuedEvent{Event: internal.event}
+		return
+	}
+	f.dispatch(internal.event, internal.from)
+}
+
+// dispatch sends the event to all EventWriters, except the one at index skip.
+func (f *fanOut) dispatch(event Event, skip int) {
+	f.seq++
+	event.Seq = f.seq
+	event = truncateEvent(event, maxMessageSize, maxDataSize)
+	event = applyTransforms(event, transforms)
+	event.Tags = addMandatoryTags(event.Tags, mandatoryTags)
+	event.Tags = normalizeTags(event.Tags, tagNormalization)
+	copyEvent(event, f.copies)
+	encodeEvents(event, f.encodings, &f.encoded)
+	// The references must be set before any EventWriter can release them.
+	for i, encoding := range f.encodings {
+		if i != skip && f.encoded[encoding] != nil {
+			f.encoded[encoding].refs++
+		}
+	}
+	for i, subChannel := range f.subChannels {
+		if i == skip {
+			continue
+		}
+		event := queuedEvent{Event: f.copies[i]}
+		if buffer := f.encoded[f.encodings[i]]; buffer != nil {
+			event.encoded, event.buffer = buffer.buf, buffer
+		}
+		subChannel <- event
+	}
 }
 
 // StartEventWriter blocks until the events channel is closed.
-func startEventWriter(ew EventWriter, events <-chan Event, wg *sync.WaitGroup) {
+func startEventWriter(ew EventWriter, state *writerState, events <-chan queuedEvent, internalEvents chan<- internalEvent, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	// Only used if the EventWriter is bad and will be re-attached, see
+	// SetReattachCooldown.
+	var bad *reattachment
+
+	// Only set if the EventWriter batches events, see BatchEventWriter.
+	batcher := batchFlusher(ew)
+
 	for event := range events {
-		err := writeEvent(ew, event)
+		if event.Type == controlEventType {
+			req := event.Data.(*controlRequest)
+			if bad != nil {
+				req.wg.Done()
+			} else {
+				req.do(ew, state)
+			}
+			continue
+		}
+
+		if bad != nil {
+			if bad.retry(ew, state, event, internalEvents) {
+				bad = nil
+			}
+			continue
+		}
+
+		state.startWrite()
+		err := writeEvent(ew, state, event, internalEvents)
+		state.endWrite()
+		event.done()
 		if err == nil {
+			if batcher != nil && len(events) == 0 {
+				flushBatch(ew, batcher, state)
+			}
 			continue
 		}
 
-		// At this point the EventWriter is bad and we won't write to it anymore.
-		ew.HandleError(err)
+		// At this point the EventWriter is bad and we won't write to it anymore,
+		// unless it's re-attached later.
+		if bad = markBad(ew, state, err, internalEvents); bad == nil {
+			// todo: improve this, don't send to the channel anymore if the
+			// writer is bad.
+			drain(events, state)
+			return
+		}
+	}
+}
+
+// markBad marks the EventWriter as bad, after it returned err. It returns the
+// reattachment state if the EventWriter will be re-attached, see
+// SetReattachCooldown, or nil if it won't be.
+func markBad(ew EventWriter, state *writerState, err error, internalEvents chan<- internalEvent) *reattachment {
+	msg := fmt.Sprintf("EventWriter %T is bad and is dropped", ew)
+	if reattachCooldown > 0 {
+		msg += fmt.Sprintf(", retrying in %s", reattachCooldown)
+	}
+	sendInternalEvent(internalEvents, state.index, Event{ErrorEvent, now(), Tags{"logger"}, msg, nil, 0})
+	ew.HandleError(err)
+
+	state.setBad(true)
+	state.drop()
+	if reattachCooldown <= 0 {
+		return nil
+	}
+	return &reattachment{retryAt: time.Now().Add(reattachCooldown), dropped: 1}
+}
+
+// reattachment is the state of a bad EventWriter that will be re-attached.
+type reattachment struct {
+	retryAt time.Time
+	dropped int
+}
 
-		// todo: improve this, don't send to the channel anymore if the writer is
-		// bad.
-		drain(events)
-		break
+// retry drops the event until the cooldown has passed, after which it tries
+// writing the event. It returns true if the EventWriter recovered.
+func (r *reattachment) retry(ew EventWriter, state *writerState, event queuedEvent, internalEvents chan<- internalEvent) bool {
+	if time.Now().Before(r.retryAt) {
+		r.dropped++
+		state.drop()
+		event.done()
+		return false
 	}
 
-	wg.Done()
+	state.startWrite()
+	err := safeWrite(ew, event.Event, event.encoded)
+	state.endWrite()
+	event.done()
+	if err != nil {
+		state.setLastError(err)
+		ew.HandleError(err)
+		r.dropped++
+		state.drop()
+		r.retryAt = time.Now().Add(reattachCooldown)
+		return false
+	}
+
+	state.setBad(false)
+	state.failures = failureCounter{threshold: state.failures.threshold}
+	msg := fmt.Sprintf("EventWriter %T recovered and is re-attached, dropped %d events", ew, r.dropped)
+	sendInternalEvent(internalEvents, state.index, Event{NoticeEvent, now(), Tags{"logger"}, msg, nil, 0})
+	return true
 }
 
 // Drain an events channel. It returns once the event channel is closed.
-func drain(events <-chan Event) {
-	for range events {
+// Control requests are acknowledged without performing them, the events are
+// counted as dropped.
+func drain(events <-chan queuedEvent, state *writerState) {
+	for event := range events {
+		if event.Type == controlEventType {
+			event.Data.(*controlRequest).wg.Done()
+		} else {
+			state.drop()
+			event.done()
+		}
 	}
 }
 
-// WriteEvent tries to write the event to the given EventWriter, it tries it up
-// to maxNWriteErrors times. If EventWriter.Write returns an error it gets
-// passed to the error handler of the EventWriter.
+// WriteEvent tries to write the event to the given EventWriter, it tries it
+// until the FailureThreshold of the EventWriter is reached, or at most
+// MaxErrors times after which the event is dropped. If EventWriter.Write
+// returns an error it gets passed to the error handler of the EventWriter. If
+// EventWriter.Write panics the panic is converted into a *PanicError, which is
+// handled like any other error, and an Error event is send to the other
+// EventWriters.
 //
-// This function either returns ErrBadEventWriter or nil as an error.
-func writeEvent(ew EventWriter, event Event) error {
-	for n := 1; n <= maxNWriteErrors; n++ {
-		err := ew.Write(event)
+// This function either returns a *BadEventWriterError or nil as an error.
+func writeEvent(ew EventWriter, state *writerState, event queuedEvent, internalEvents chan<- internalEvent) error {
+	for attempts := 1; ; attempts++ {
+		err := safeWrite(ew, event.Event, event.encoded)
 		if err == nil {
+			state.failures.succeed()
 			return nil
 		}
 
-		// Handle the error and try again.
+		if panicErr, ok := err.(*PanicError); ok {
+			sendInternalEvent(internalEvents, state.index, panicErr.event(ew))
+		}
+
+		// Handle the error and try again, if the EventWriter isn't bad.
+		state.setLastError(err)
 		ew.HandleError(err)
+		if state.failures.fail(time.Now()) {
+			return &BadEventWriterError{ew, state.failures.errors(), err}
+		} else if attempts >= state.failures.threshold.MaxErrors {
+			// With a window the EventWriter might fail too slowly to ever be
+			// considered bad, don't block on this event forever.
+			state.drop()
+			return nil
+		}
 	}
+}
 
-	return ErrBadEventWriter
+// CloseError is returned by Close if one or more EventWriters returned an error
+// when closed. Both errors.Is and errors.As check all the returned errors.
+type CloseError struct {
+	Writers []EventWriter // EventWriters that failed to close, in order.
+	Errors  []error       // Errors returned by the EventWriters, same order.
 }
 
-// Close stops all the Log Operations from being usable, and they will panic if
-// used after Close is called. It also closes all EventWriters and returns the
-// first returned error. The EventWriters are closed in the order they are
-// passed to Start.
+func (err *CloseError) Error() string {
+	if len(err.Errors) == 1 {
+		return fmt.Sprintf("logger: error closing EventWriter %T: %s", err.Writers[0], err.Errors[0])
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "logger: error closing %d EventWriters", len(err.Errors))
+	for i, er := range err.Errors {
+		sep := "; "
+		if i == 0 {
+			sep = ": "
+		}
+		fmt.Fprintf(&buf, "%s%T: %s", sep, err.Writers[i], er)
+	}
+	return buf.String()
+}
+
+// Unwrap returns the errors returned by the EventWriters.
+func (err *CloseError) Unwrap() []error {
+	return err.Errors
+}
+
+// Close stops all the Log Operations from being usable, events logged after
+// Close is called are dropped, see DroppedAfterClose. It also closes all
+// EventWriters, if any of them return an error a *CloseError is returned,
+// listing all of them. The EventWriters are closed in the order they are
+// passed to Start, the diagnostics EventWriter is closed last.
+//
+// If Close gives up waiting for the events to be written, see
+// SetCloseTimeout, it returns an *UnflushedError without closing the
+// EventWriters, as they may still be in use.
+//
+// If the logger is not started Close does nothing.
+//
+// Note: log operations called concurrently with Close may still panic.
 func Close() error {
+	if !started {
+		return nil
+	}
+
+	stopLocalBuffers()
+	stopSpill()
+	logThumbstoneCounts()
+	closed = true
+	atomic.StoreUint32(&running, loggerClosed)
 	close(eventChannel)
-	<-eventChannelClosed
+	if !waitClosed(eventChannelClosed) {
+		stopWatchdog()
+		stopStats()
+		return &UnflushedError{
+			Pending: pendingEvents(eventChannel, writerStates),
+			Dropped: droppedEvents(writerStates),
+		}
+	}
+	stopWatchdog()
+	stopStats()
 
-	var err error
-	for _, eventWriter := range eventWriters {
-		er := eventWriter.Close()
-		if er != nil && err == nil {
-			err = er
+	var closeErr CloseError
+	ews := eventWriters
+	if diagnosticsWriter != nil {
+		ews = append(ews[:len(ews):len(ews)], diagnosticsWriter)
+	}
+	for _, eventWriter := range ews {
+		if err := eventWriter.Close(); err != nil {
+			closeErr.Writers = append(closeErr.Writers, eventWriter)
+			closeErr.Errors = append(closeErr.Errors, err)
 		}
 	}
+	if len(closeErr.Errors) != 0 {
+		return &closeErr
+	}
+	return nil
+}
+
+// Reset returns the logger package to its initial state, as if Start was never
+// called, so it can be started again. If the logger is started, and not yet
+// closed, it's closed first and the error from Close is returned. It also
+// resets all options, e.g. SetTagNormalization, and removes all functions
+// registered with AtExit, but not the ContextTaggers.
+//
+// Reset is mainly useful in tests, also see the logtest package.
+//
+// Note: Reset is not safe for concurrent use, all calls to log operations must
+// have returned before calling it.
+func Reset() error {
+	var err error
+	if started && !closed {
+		err = Close()
+	}
+
+	eventChannel = make(chan Event, defaultEventChannelSize)
+	eventChannelClosed = make(chan struct{}, 1)
+	internalEventChannel = make(chan internalEvent, defaultInternalChannelSize)
+	eventWriters = nil
+	writerStates = nil
+	started = false
+	closed = false
+	atomic.StoreUint32(&running, loggerNotStarted)
+	atomic.StoreUint64(&afterCloseDropped, 0)
+
+	exitMu.Lock()
+	exitFuncs = nil
+	exitMu.Unlock()
+
+	SetThumbstoneOnce(false)
+	SetTagNormalization(0)
+	SetMandatoryTags()
+	SetSizeLimits(0, 0)
+	SetDataLimits(0, 0)
+	SetFatalSource(false)
+	SetStartupEnvironment()
+	SetWatchdog(0, nil)
+	SetDiagnosticsEventWriter(nil)
+	failureThresholds = nil
+	SetReattachCooldown(0)
+	SetWriteTimeout(0)
+	SetTransforms()
+	SetEscalationPolicy(EscalationPolicy{})
+	SetAnomalyDetection(AnomalyDetection{})
+	SetSampler(nil)
+	SetLocalBuffering(0, 0, 0)
+	SetSpillQueue("", 0)
+	SetPreStartBuffer(defaultPreStartBufferSize)
+	SetCloseTimeout(0)
+	SetMinEventType(TraceEvent)
+	OnStats(nil)
+	enqueueLatency.summarize(true)
 	return err
 }
 
 // Subbed for testing.
 var now = time.Now
 
+// Trace logs a trace message, for extremely verbose diagnostics. Use a
+// minimum EventType of DebugEvent, or higher, in the EventWriters to filter
+// these out.
+//
+// The formatted functions, e.g. Tracef, don't format the message if the
+// EventType is below the minimum EventType, see Enabled.
+func Trace(tags Tags, msg string) {
+	sendEvent(Event{TraceEvent, now(), tags, msg, nil, 0})
+}
+
+// Tracef is a formatted function of Trace.
+func Tracef(tags Tags, format string, v ...interface{}) {
+	if !Enabled(TraceEvent) {
+		return
+	}
+	Trace(tags, fmt.Sprintf(format, v...))
+}
+
 // Debug logs a debug message.
 func Debug(tags Tags, msg string) {
-	eventChannel <- Event{DebugEvent, now(), tags, msg, nil}
+	sendEvent(Event{DebugEvent, now(), tags, msg, nil, 0})
 }
 
 // Debugf is a formatted function of Debug.
 func Debugf(tags Tags, format string, v ...interface{}) {
+	if !Enabled(DebugEvent) {
+		return
+	}
 	Debug(tags, fmt.Sprintf(format, v...))
 }
 
 // Info logs an informational message.
 func Info(tags Tags, msg string) {
-	eventChannel <- Event{InfoEvent, now(), tags, msg, nil}
+	sendEvent(Event{InfoEvent, now(), tags, msg, nil, 0})
 }
 
 // Infof is a formatted function of Info.
 func Infof(tags Tags, format string, v ...interface{}) {
+	if !Enabled(InfoEvent) {
+		return
+	}
 	Info(tags, fmt.Sprintf(format, v...))
 }
 
+// Notice logs a notice message, for normal but significant events.
+func Notice(tags Tags, msg string) {
+	sendEvent(Event{NoticeEvent, now(), tags, msg, nil, 0})
+}
+
+// Noticef is a formatted function of Notice.
+func Noticef(tags Tags, format string, v ...interface{}) {
+	if !Enabled(NoticeEvent) {
+		return
+	}
+	Notice(tags, fmt.Sprintf(format, v...))
+}
+
 // Warn logs a warning message.
 func Warn(tags Tags, msg string) {
-	eventChannel <- Event{WarnEvent, now(), tags, msg, nil}
+	sendEvent(Event{WarnEvent, now(), tags, msg, nil, 0})
 }
 
 // Warnf is a formatted function of Warn.
 func Warnf(tags Tags, format string, v ...interface{}) {
+	if !Enabled(WarnEvent) {
+		return
+	}
 	Warn(tags, fmt.Sprintf(format, v...))
 }
 
-// Error logs an error message.
+// Error logs an error message. If the error, or an error it wraps, has a stack
+// trace (e.g. created by github.com/pkg/errors) the stack frames (type
+// []StackFrame) of the origin of the error are added as Event.Data.
 func Error(tags Tags, err error) {
-	eventChannel <- Event{ErrorEvent, now(), tags, err.Error(), nil}
+	var data interface{}
+	if frames := errorStackFrames(err); frames != nil {
+		data = frames
+	}
+	sendEvent(Event{ErrorEvent, now(), tags, err.Error(), data, 0})
 }
 
 // Errorf is a formatted function of Error.
 func Errorf(tags Tags, format string, v ...interface{}) {
+	if !Enabled(ErrorEvent) {
+		return
+	}
 	Error(tags, fmt.Errorf(format, v...))
 }
 
 // Fatal logs a recovered error which could have killed the application. Fatal
-// adds a stack trace (type []byte) as Event.Data.
+// adds a stack trace (type []byte) as Event.Data, optionally followed by a
+// snippet of the source, see SetFatalSource.
 func Fatal(tags Tags, recv interface{}) {
-	stackTrace := getStackTrace()
+	sendEvent(fatalEvent(tags, recv, getStackTrace()))
+}
+
+// fatalEvent creates a Fatal event.
+func fatalEvent(tags Tags, recv interface{}, stackTrace []byte) Event {
+	if fatalSource {
+		stackTrace = appendSourceSnippet(stackTrace)
+	}
 	msg := util.InterfaceToString(recv)
-	eventChannel <- Event{FatalEvent, now(), tags, msg, stackTrace}
+	return Event{FatalEvent, now(), tags, msg, stackTrace, 0}
+}
+
+// Security logs a security message, for authentication, authorization and
+// other audit-adjacent events. SecurityEvent is higher then FatalEvent, so it
+// isn't filtered by a minimum EventType, see NewSecurityEventWriter to send
+// these events to a separate EventWriter.
+func Security(tags Tags, msg string) {
+	sendEvent(Event{SecurityEvent, now(), tags, msg, nil, 0})
+}
+
+// Securityf is a formatted function of Security.
+func Securityf(tags Tags, format string, v ...interface{}) {
+	if !Enabled(SecurityEvent) {
+		return
+	}
+	Security(tags, fmt.Sprintf(format, v...))
 }
 
 // Create a stack trace and remove the caller's function from the trace.
@@ -279,7 +758,13 @@ func removeFnsFromStack(stackTrace []byte) []byte {
 //	Function functionName called by callerFunctionName, from file /path/to/file on line lineNumber
 // For example:
 //	Function myFunction called by main.main, from file /main.go on line 20
+//
+// See SetThumbstoneOnce to only log the first call for each function.
 func Thumbstone(tags Tags, functionName string) {
+	if !countThumbstone(tags, functionName) {
+		return
+	}
+
 	var msg string
 	if pc, file, line, ok := runtime.Caller(2); ok {
 		fn := runtime.FuncForPC(pc)
@@ -289,7 +774,7 @@ func Thumbstone(tags Tags, functionName string) {
 		msg = "Function " + functionName + " called from unkown location"
 	}
 
-	eventChannel <- Event{ThumbEvent, now(), tags, msg, nil}
+	sendEvent(Event{ThumbEvent, now(), tags, msg, nil, 0})
 }
 
 // Log logs a custom created event.
@@ -297,5 +782,13 @@ func Thumbstone(tags Tags, functionName string) {
 // Note: the timestamp doesn't need to be set, because it will be set by Log.
 func Log(event Event) {
 	event.Timestamp = now()
-	eventChannel <- event
+	sendEvent(event)
+}
+
+// Forward logs an event received from another process, e.g. by the ingest
+// package. Unlike Log it keeps the timestamp of the event, the sequence number
+// is set by the logger.
+func Forward(event Event) {
+	event.Seq = 0
+	sendEvent(event)
 }
@@ -60,23 +60,23 @@ func (l *logToEvent) Write(b []byte) (int, error) {
 	n := len(b)
 
 	if !strings.HasPrefix(line, logPrefix) || len(line) < logMetadataLength {
-		eventChannel <- createErrorLogEvent(ErrLogFormat, line, l.tags)
+		sendEvent(createErrorLogEvent(ErrLogFormat, line, l.tags))
 		return n, nil
 	}
 
 	timeStr := line[logPrefixLength:logMetadataLength]
 	t, err := time.ParseInLocation(logTimeLayout, timeStr, l.loc)
 	if err != nil {
-		eventChannel <- createErrorLogEvent(err, line, l.tags)
+		sendEvent(createErrorLogEvent(err, line, l.tags))
 		return n, nil
 	}
 
-	eventChannel <- Event{
+	sendEvent(Event{
 		Type:      LogEvent,
 		Timestamp: t,
 		Tags:      l.tags,
 		Message:   line[logMetadataLength+1 : n-1], // Drop metadata and newline.
-	}
+	})
 
 	return n, nil
 }
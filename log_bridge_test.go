@@ -45,6 +45,7 @@ func TestBridgeLogPgk(t *testing.T) {
 		const margin = time.Millisecond
 		for i, event := range ew.events {
 			expectedEvent := expected[i]
+			expectedEvent.Seq = uint64(i + 1)
 
 			// Can't mock time in the log package, so we'll make sure it falls within
 			// the margin.
@@ -65,15 +65,21 @@ func TestLog(t *testing.T) {
 	}
 	recv := getPanicRecoveredValue("Fatal message")
 
+	Trace(tags, "Trace message")
+	Tracef(tags, "Trace %s message", "formatted")
 	Debug(tags, "Debug message")
 	Debugf(tags, "Debug %s message", "formatted")
 	Info(tags, "Info message")
 	Infof(tags, "Info %s message", "formatted")
+	Notice(tags, "Notice message")
+	Noticef(tags, "Notice %s message", "formatted")
 	Warn(tags, "Warn message")
 	Warnf(tags, "Warn %s message", "formatted")
 	Error(tags, errors.New("Error message"))
 	Errorf(tags, "Error %s message", "formatted")
 	Fatal(tags, recv)
+	Security(tags, "Security message")
+	Securityf(tags, "Security %s message", "formatted")
 	testThumstone(tags)
 	Log(event)
 
@@ -88,17 +94,23 @@ func TestLog(t *testing.T) {
 	_, file, _, _ := runtime.Caller(0)
 
 	expected := []Event{
+		{Type: TraceEvent, Message: "Trace message"},
+		{Type: TraceEvent, Message: "Trace formatted message"},
 		{Type: DebugEvent, Message: "Debug message"},
 		{Type: DebugEvent, Message: "Debug formatted message"},
 		{Type: InfoEvent, Message: "Info message"},
 		{Type: InfoEvent, Message: "Info formatted message"},
+		{Type: NoticeEvent, Message: "Notice message"},
+		{Type: NoticeEvent, Message: "Notice formatted message"},
 		{Type: WarnEvent, Message: "Warn message"},
 		{Type: WarnEvent, Message: "Warn formatted message"},
 		{Type: ErrorEvent, Message: "Error message"},
 		{Type: ErrorEvent, Message: "Error formatted message"},
 		{Type: FatalEvent, Message: "Fatal message"},
+		{Type: SecurityEvent, Message: "Security message"},
+		{Type: SecurityEvent, Message: "Security formatted message"},
 		{Type: ThumbEvent, Message: "Function testThumstone called by github.com" +
-			"/Thomasdezeeuw/logger.TestLog, from file " + file + " on line 77"},
+			"/Thomasdezeeuw/logger.TestLog, from file " + file + " on line 83"},
 		event,
 	}
 
@@ -111,6 +123,7 @@ func TestLog(t *testing.T) {
 		expectedEvent := expected[i]
 		expectedEvent.Timestamp = now()
 		expectedEvent.Tags = tags
+		expectedEvent.Seq = uint64(i + 1)
 
 		if expectedEvent.Type == FatalEvent {
 			// sortof test the stack trace, best we can do.
@@ -168,7 +181,11 @@ func expectPanic(t *testing.T, expected string) {
 		t.Fatal(`Expected a panic, but didn't get one`)
 	}
 
-	got := recv.(string)
+	// Panic values are either strings or errors.
+	got, ok := recv.(string)
+	if err, isErr := recv.(error); !ok && isErr {
+		got = err.Error()
+	}
 	if got != expected {
 		t.Fatalf("Expected panic value to be %s, but got %s", expected, got)
 	}
@@ -192,6 +209,54 @@ func (eew *errorEventWriter) Close() error {
 	return eew.closeError
 }
 
+func TestForward(t *testing.T) {
+	defer reset()
+	var ew eventWriter
+	Start(&ew)
+
+	event := Event{Type: InfoEvent, Timestamp: t1, Tags: Tags{"forward"}, Message: "Forwarded", Seq: 100}
+	Forward(event)
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	event.Seq = 1
+	if len(ew.events) != 1 || !reflect.DeepEqual(ew.events[0], event) {
+		t.Fatalf("Expected the event %v, but got %v", event, ew.events)
+	}
+}
+
+func TestCloseError(t *testing.T) {
+	defer reset()
+	closeError1 := errors.New("Close error 1")
+	closeError2 := errors.New("Close error 2")
+	eew1 := errorEventWriter{closeError: closeError1}
+	eew2 := errorEventWriter{closeError: closeError2}
+	var ew eventWriter
+	Start(&eew1, &ew, &eew2)
+
+	err := Close()
+	var closeErr *CloseError
+	if !errors.As(err, &closeErr) {
+		t.Fatalf("Expected a *CloseError, but got %#v", err)
+	}
+	if len(closeErr.Writers) != 2 || closeErr.Writers[0] != &eew1 || closeErr.Writers[1] != &eew2 {
+		t.Fatalf("Expected both errorEventWriters, but got %v", closeErr.Writers)
+	}
+	if !errors.Is(err, closeError1) || !errors.Is(err, closeError2) {
+		t.Fatalf("Expected both closing errors, but got %v", closeErr.Errors)
+	}
+	if !ew.closed {
+		t.Fatal("Expected the EventWriter to be closed")
+	}
+
+	expected := "logger: error closing 2 EventWriters: *logger.errorEventWriter: Close error 1; " +
+		"*logger.errorEventWriter: Close error 2"
+	if got := err.Error(); got != expected {
+		t.Fatalf("Expected the error message to be %q, but got %q", expected, got)
+	}
+}
+
 func TestErrorEventWriter(t *testing.T) {
 	closeError := errors.New("Close error")
 
@@ -203,7 +268,7 @@ func TestErrorEventWriter(t *testing.T) {
 	Info(tags, "Info message1")
 	Info(tags, "Won't be written to the writer")
 
-	if err := Close(); err != closeError {
+	if err := Close(); !errors.Is(err, closeError) {
 		t.Fatalf("Expceted the closing error to be %v, but got %v",
 			closeError, err)
 	}
@@ -218,7 +283,15 @@ func TestErrorEventWriter(t *testing.T) {
 	expected := errors.New("Write error: Info message1")
 	for i, got := range eew.errors {
 		if i == 5 {
-			expected = ErrBadEventWriter
+			if !errors.Is(got, ErrBadEventWriter) {
+				t.Errorf("Expected error #%d to be ErrBadEventWriter, but got %v", i, got)
+			}
+			var badErr *BadEventWriterError
+			if !errors.As(got, &badErr) || badErr.Writer != &eew || badErr.Errors != 5 {
+				t.Errorf("Expected error #%d to be a *BadEventWriterError, but got %#v", i, got)
+			}
+			expected = errors.New("EventWriter *logger.errorEventWriter is bad, 5 faulty writes, " +
+				"EventWriter will be dropped: Write error: Info message1")
 		}
 
 		if got.Error() != expected.Error() {
@@ -229,10 +302,8 @@ func TestErrorEventWriter(t *testing.T) {
 }
 
 func reset() {
-	eventChannel = make(chan Event, defaultEventChannelSize)
-	eventChannelClosed = make(chan struct{}, 1)
-	eventWriters = []EventWriter{}
-	started = false
+	Reset()
+	contextTaggers = nil
 }
 
 func TestGetStackTrace(t *testing.T) {
@@ -254,3 +325,74 @@ func TestGetStackTrace(t *testing.T) {
 			string(stackTrace))
 	}
 }
+
+func TestReset(t *testing.T) {
+	defer reset()
+
+	var ew1 eventWriter
+	Start(&ew1)
+	SetMandatoryTags("env=test")
+	Info(Tags{"reset"}, "Before reset")
+	if err := Reset(); err != nil {
+		t.Fatal("Unexpected error resetting: " + err.Error())
+	}
+
+	if !ew1.closed || len(ew1.events) != 1 {
+		t.Fatalf("Expected Reset to close the logger, writing the event first, but got %v", ew1)
+	}
+
+	// Should be able to start again, without the options set before.
+	var ew2 eventWriter
+	Start(&ew2)
+	Info(Tags{"reset"}, "After reset")
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if len(ew2.events) != 1 {
+		t.Fatalf("Expected a single event, but got %v", ew2.events)
+	}
+	if got := ew2.events[0]; got.Message != "After reset" || got.Seq != 1 || len(got.Tags) != 1 {
+		t.Fatalf("Expected the event to not be changed by the options before Reset, but got %v", got)
+	}
+
+	// Reset after Close shouldn't close again.
+	if err := Reset(); err != nil {
+		t.Fatal("Unexpected error resetting: " + err.Error())
+	}
+}
+
+func TestNoopBeforeStart(t *testing.T) {
+	defer reset()
+	SetPreStartBuffer(0)
+
+	done := make(chan struct{})
+	go func() {
+		// More then fit in the event channel, shouldn't block.
+		for i := 0; i < 2*defaultEventChannelSize; i++ {
+			Info(Tags{"library"}, "Info message")
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected log operations not to block before Start")
+	}
+
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	} else if err := Flush(); err != ErrNotRunning {
+		t.Fatalf("Expected Flush to return ErrNotRunning, but got %v", err)
+	}
+
+	var ew eventWriter
+	Start(&ew)
+	Info(Tags{"application"}, "Info message")
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+	if len(ew.events) != 1 || ew.events[0].Tags[0] != "application" {
+		t.Fatalf("Expected only the event logged after Start, but got %v", ew.events)
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+// Package logstash provides an EventWriter that streams events to a Logstash
+// TCP input, using the json_lines codec.
+package logstash
+
+import (
+	"crypto/tls"
+	"net"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+	"github.com/Thomasdezeeuw/logger/internal/json"
+	"github.com/Thomasdezeeuw/logger/internal/util"
+)
+
+// DefaultTimeout is used if Config.Timeout is not set.
+const DefaultTimeout = 5 * time.Second
+
+// Version is the value of the "@version" field, as expected by Logstash.
+const Version = "1"
+
+// Config is the configuration for the EventWriter created by NewEventWriter.
+type Config struct {
+	// Addr is the TCP address of the Logstash TCP input.
+	Addr string
+
+	// TLSConfig, if not nil, is used to create a TLS connection.
+	TLSConfig *tls.Config
+
+	// Timeout for connecting and writing, defaults to DefaultTimeout.
+	Timeout time.Duration
+}
+
+type eventWriter struct {
+	config       Config
+	conn         net.Conn
+	buf          []byte
+	errorHandler func(error)
+	minType      logger.EventType
+}
+
+func (ew *eventWriter) Write(event logger.Event) error {
+	if event.Type < ew.minType {
+		return nil
+	}
+
+	if ew.conn == nil {
+		if err := ew.connect(); err != nil {
+			return err
+		}
+	}
+
+	// Write is never called concurrently, so we can reuse the buffer.
+	ew.buf = appendEvent(ew.buf[:0], event)
+
+	ew.conn.SetWriteDeadline(time.Now().Add(ew.config.Timeout))
+	if _, err := ew.conn.Write(ew.buf); err != nil {
+		// Reconnect on the next write.
+		ew.conn.Close()
+		ew.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (ew *eventWriter) connect() error {
+	dialer := &net.Dialer{Timeout: ew.config.Timeout}
+	var conn net.Conn
+	var err error
+	if ew.config.TLSConfig != nil {
+		conn, err = tls.DialWithDialer(dialer, "tcp", ew.config.Addr, ew.config.TLSConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", ew.config.Addr)
+	}
+	if err != nil {
+		return err
+	}
+	ew.conn = conn
+	return nil
+}
+
+func (ew *eventWriter) HandleError(err error) {
+	ew.errorHandler(err)
+}
+
+func (ew *eventWriter) Close() error {
+	if ew.conn == nil {
+		return nil
+	}
+	return ew.conn.Close()
+}
+
+// AppendEvent appends the event as a single line of JSON to buf.
+func appendEvent(buf []byte, event logger.Event) []byte {
+	buf = append(buf, `{"@timestamp":"`...)
+	buf = event.Timestamp.UTC().AppendFormat(buf, time.RFC3339Nano)
+	buf = append(buf, `","@version":"`+Version+`","type":`...)
+	buf = json.AppendString(buf, event.Type.String())
+	buf = append(buf, `,"tags":[`...)
+	for i, tag := range event.Tags {
+		if i != 0 {
+			buf = append(buf, ',')
+		}
+		buf = json.AppendString(buf, tag)
+	}
+	buf = append(buf, `],"message":`...)
+	buf = json.AppendString(buf, event.Message)
+	if event.Data != nil {
+		buf = append(buf, `,"data":`...)
+		buf = json.AppendString(buf, util.InterfaceToString(event.Data))
+	}
+	return append(buf, '}', '\n')
+}
+
+// NewEventWriter creates a new EventWriter that streams events to a Logstash
+// TCP input, which must use the json_lines codec. Each event is written as a
+// JSON object containing the "@timestamp", "@version", "type", "tags",
+// "message" and "data" (if not nil) fields. If the connection fails it will
+// reconnect on the next write. MinType is the minimal EventType an event must
+// have to be logged. For example if minType is InfoEvent, then any events with
+// an EventType of DebugEvent will not be logged.
+func NewEventWriter(minType logger.EventType, config Config, errorHandler func(error)) (logger.EventWriter, error) {
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+
+	ew := &eventWriter{config: config, errorHandler: errorHandler, minType: minType}
+	if err := ew.connect(); err != nil {
+		return nil, err
+	}
+	return ew, nil
+}
@@ -0,0 +1,88 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logstash
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+var t1 = time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC)
+
+// listen starts a server that sends the received lines on the returned
+// channel.
+func listen(t *testing.T) (net.Listener, <-chan string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Unexpected error listening: " + err.Error())
+	}
+
+	lines := make(chan string, 4)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			s := bufio.NewScanner(conn)
+			for s.Scan() {
+				lines <- s.Text()
+			}
+			conn.Close()
+		}
+	}()
+	return l, lines
+}
+
+func TestEventWriter(t *testing.T) {
+	l, lines := listen(t)
+	defer l.Close()
+
+	ew, err := NewEventWriter(logger.InfoEvent, Config{Addr: l.Addr().String()}, func(error) {})
+	if err != nil {
+		t.Fatal("Unexpected error creating EventWriter: " + err.Error())
+	}
+
+	events := []logger.Event{
+		{Type: logger.DebugEvent, Timestamp: t1, Message: "Never gets logged"},
+		{Type: logger.InfoEvent, Timestamp: t1, Tags: logger.Tags{"tag1", "tag2"}, Message: "Message"},
+		{Type: logger.ErrorEvent, Timestamp: t1, Tags: logger.Tags{}, Message: "Message", Data: 1},
+	}
+	for _, event := range events {
+		if err := ew.Write(event); err != nil {
+			t.Fatal("Unexpected error writing event: " + err.Error())
+		}
+	}
+
+	expected := []string{
+		`{"@timestamp":"2015-09-01T14:22:36Z","@version":"1","type":"Info",` +
+			`"tags":["tag1","tag2"],"message":"Message"}`,
+		`{"@timestamp":"2015-09-01T14:22:36Z","@version":"1","type":"Error",` +
+			`"tags":[],"message":"Message","data":"1"}`,
+	}
+	for _, want := range expected {
+		if got := <-lines; got != want {
+			t.Fatalf("Expected Logstash to receive:\n%s\nBut got:\n%s", want, got)
+		}
+	}
+
+	// Force a reconnect.
+	ew.(*eventWriter).conn.Close()
+	if err := ew.Write(events[1]); err == nil {
+		t.Fatal("Expected an error writing to a closed connection")
+	} else if err := ew.Write(events[1]); err != nil {
+		t.Fatal("Unexpected error writing after reconnecting: " + err.Error())
+	} else if got := <-lines; got != expected[0] {
+		t.Fatalf("Expected Logstash to receive:\n%s\nBut got:\n%s", expected[0], got)
+	}
+
+	if err := ew.Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logtest
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+// captureMu makes sure only a single test at a time uses the logger package.
+var captureMu sync.Mutex
+
+// capturing is the name of the test holding captureMu, if any. It's protected
+// by capturingMu, as captureMu is held for the entire test.
+var (
+	capturingMu sync.Mutex
+	capturing   string
+)
+
+// Recorder records the events logged during a single test, see Capture.
+type Recorder struct {
+	mu     sync.Mutex
+	events []logger.Event
+	closed bool
+}
+
+func (r *Recorder) Write(event logger.Event) error {
+	r.mu.Lock()
+	r.events = append(r.events, event)
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *Recorder) HandleError(err error) {}
+
+func (r *Recorder) Close() error {
+	return nil
+}
+
+// Events closes the logger, making sure all events are written, and returns
+// all events logged during the test. After this is called the events of all log
+// operations are dropped, just like after calling logger.Close, see
+// logger.DroppedAfterClose.
+func (r *Recorder) Events() []logger.Event {
+	r.mu.Lock()
+	closed := r.closed
+	r.closed = true
+	r.mu.Unlock()
+	if !closed {
+		logger.Close()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	events := make([]logger.Event, len(r.events))
+	copy(events, r.events)
+	return events
+}
+
+// Capture resets the logger package, using logger.Reset, and starts it with a
+// new Recorder which records all events logged during the test. Once the test
+// (and all its subtests) completes the logger package is reset again, so that
+// every test gets an isolated logger.
+//
+// Because the logger package uses global state only a single test can use it
+// at a time. Capture blocks until the previous test using Capture completes,
+// which makes it safe to use in tests that call t.Parallel. Tests that use the
+// logger package without calling Capture must not run in parallel with tests
+// that do. Since a test completes after its subtests, a subtest can't call
+// Capture if the test itself already did, doing so fails the subtest rather
+// then blocking forever.
+func Capture(tb testing.TB) *Recorder {
+	tb.Helper()
+	name := tb.Name()
+	capturingMu.Lock()
+	owner := capturing
+	capturingMu.Unlock()
+	if owner != "" && (name == owner || strings.HasPrefix(name, owner+"/")) {
+		tb.Fatal("logtest: Capture called while " + owner + " is already capturing")
+		return nil
+	}
+
+	captureMu.Lock()
+	capturingMu.Lock()
+	capturing = name
+	capturingMu.Unlock()
+	logger.Reset()
+
+	r := &Recorder{}
+	logger.Start(r)
+	tb.Cleanup(func() {
+		logger.Reset()
+		capturingMu.Lock()
+		capturing = ""
+		capturingMu.Unlock()
+		captureMu.Unlock()
+	})
+	return r
+}
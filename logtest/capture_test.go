@@ -0,0 +1,68 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logtest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+func TestCapture(t *testing.T) {
+	for i := 0; i < 5; i++ {
+		i := i
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			t.Parallel()
+			recorder := Capture(t)
+			logger.SetMandatoryTags(fmt.Sprintf("test=%d", i))
+
+			msg := fmt.Sprintf("Message %d", i)
+			logger.Info(logger.Tags{"capture"}, msg)
+			logger.Info(logger.Tags{"capture"}, msg)
+
+			events := recorder.Events()
+			if len(events) != 2 {
+				t.Fatalf("Expected 2 events, but got %d: %v", len(events), events)
+			}
+			for j, event := range events {
+				if event.Message != msg {
+					t.Errorf("Expected message %q, but got %q", msg, event.Message)
+				} else if event.Seq != uint64(j+1) {
+					t.Errorf("Expected sequence number %d, but got %d", j+1, event.Seq)
+				} else if got := event.Tags.Get("test"); got != fmt.Sprintf("%d", i) {
+					t.Errorf("Expected tag test=%d, but got %q", i, got)
+				}
+			}
+		})
+	}
+}
+
+// nestedTB is a fakeTB with a name that records the calls to Fatal.
+type nestedTB struct {
+	fakeTB
+	name string
+}
+
+func (tb *nestedTB) Name() string {
+	return tb.name
+}
+
+func (tb *nestedTB) Fatal(args ...interface{}) {
+	tb.errors = append(tb.errors, args[0].(string))
+}
+
+func TestCaptureNested(t *testing.T) {
+	Capture(t)
+
+	tb := nestedTB{name: t.Name() + "/subtest"}
+	if recorder := Capture(&tb); recorder != nil {
+		t.Fatal("Expected no Recorder for a nested Capture")
+	}
+	expected := "logtest: Capture called while TestCaptureNested is already capturing"
+	if len(tb.errors) != 1 || tb.errors[0] != expected {
+		t.Fatalf("Expected error %q, but got %q", expected, tb.errors)
+	}
+}
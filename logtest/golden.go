@@ -0,0 +1,86 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logtest
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+// TimestampPlaceholder replaces all timestamps in the output compared by
+// AssertGolden, see NormalizeTimestamps.
+const TimestampPlaceholder = "<timestamp>"
+
+var update = flag.Bool("logtest.update", false, "update the golden files used by logtest.AssertGolden")
+
+// Matches timestamps in the format of logger.TimeFormat and RFC 3339, with
+// optional fractional seconds and time zone.
+var timestampRegexp = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`)
+
+// NormalizeTimestamps replaces all timestamps in the output with
+// TimestampPlaceholder. Both timestamps in the format of logger.TimeFormat and
+// RFC 3339 (with or without fractional seconds) are replaced.
+func NormalizeTimestamps(output []byte) []byte {
+	return timestampRegexp.ReplaceAll(output, []byte(TimestampPlaceholder))
+}
+
+// AssertGolden compares the formatted log output with the golden file
+// testdata/<name>.golden, the timestamps in the output are normalized first
+// using NormalizeTimestamps. If the output doesn't match the test fails with
+// the first line that differs.
+//
+// Running the tests with the -logtest.update flag writes the (normalized)
+// output to the golden file, rather then comparing it.
+func AssertGolden(tb testing.TB, name string, output []byte) {
+	tb.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	output = NormalizeTimestamps(output)
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			tb.Fatal("logtest: unexpected error creating testdata directory: " + err.Error())
+		}
+		if err := ioutil.WriteFile(path, output, 0644); err != nil {
+			tb.Fatal("logtest: unexpected error writing golden file: " + err.Error())
+		}
+		return
+	}
+
+	expected, err := ioutil.ReadFile(path)
+	if err != nil {
+		tb.Fatal("logtest: unexpected error reading golden file: " + err.Error() +
+			", run with -logtest.update to create it")
+	}
+
+	if !bytes.Equal(output, expected) {
+		n, expectedLine, gotLine := firstDiff(expected, output)
+		tb.Errorf("logtest: output doesn't match golden file %s, on line %d expected:\n%s\nbut got:\n%s",
+			path, n, expectedLine, gotLine)
+	}
+}
+
+// firstDiff returns the number of the first line (starting at 1) that differs
+// between the two outputs and the lines themselves.
+func firstDiff(expected, got []byte) (int, []byte, []byte) {
+	expectedLines := bytes.Split(expected, []byte("\n"))
+	gotLines := bytes.Split(got, []byte("\n"))
+	for n := 0; ; n++ {
+		var expectedLine, gotLine []byte
+		if n < len(expectedLines) {
+			expectedLine = expectedLines[n]
+		}
+		if n < len(gotLines) {
+			gotLine = gotLines[n]
+		}
+		if !bytes.Equal(expectedLine, gotLine) || n >= len(expectedLines) || n >= len(gotLines) {
+			return n + 1, expectedLine, gotLine
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logtest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+func TestNormalizeTimestamps(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"2015-09-01 14:22:36 [Info]", "<timestamp> [Info]"},
+		{`{"timestamp": "2015-09-01T14:22:36Z"}`, `{"timestamp": "<timestamp>"}`},
+		{`{"timestamp": "2015-09-01T14:22:36.123456789+02:00"}`, `{"timestamp": "<timestamp>"}`},
+		{"no timestamp 2015-09-01", "no timestamp 2015-09-01"},
+	}
+
+	for _, test := range tests {
+		if got := string(NormalizeTimestamps([]byte(test.input))); got != test.expected {
+			t.Errorf("Expected NormalizeTimestamps(%q) to return %q, but got %q",
+				test.input, test.expected, got)
+		}
+	}
+}
+
+func goldenOutput(timestamp time.Time, msg string) []byte {
+	var buf bytes.Buffer
+	events := []logger.Event{
+		{Type: logger.InfoEvent, Timestamp: timestamp, Tags: logger.Tags{"tag"}, Message: msg},
+		{Type: logger.ErrorEvent, Timestamp: timestamp, Message: "Error message"},
+	}
+	for _, event := range events {
+		buf.WriteString(event.String())
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+func TestAssertGolden(t *testing.T) {
+	AssertGolden(t, "text", goldenOutput(time.Now(), "Info message"))
+}
+
+func TestAssertGoldenMismatch(t *testing.T) {
+	var tb fakeTB
+	AssertGolden(&tb, "text", goldenOutput(t1, "Other message"))
+
+	if len(tb.errors) != 1 {
+		t.Fatalf("Expected a single error, but got %q", tb.errors)
+	}
+	expected := "on line 1 expected:\n<timestamp> [Info] tag: Info message\n" +
+		"but got:\n<timestamp> [Info] tag: Other message"
+	if !strings.Contains(tb.errors[0], expected) {
+		t.Fatalf("Expected the error to contain %q, but got %q", expected, tb.errors[0])
+	}
+}
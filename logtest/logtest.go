@@ -0,0 +1,52 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+// Package logtest provides helpers for testing code that uses the logger
+// package.
+package logtest
+
+import (
+	"testing"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+type eventWriter struct {
+	minType logger.EventType
+	tb      testing.TB
+}
+
+func (ew *eventWriter) Write(event logger.Event) error {
+	if event.Type < ew.minType {
+		return nil
+	}
+
+	switch event.Type {
+	case logger.ErrorEvent, logger.FatalEvent:
+		ew.tb.Error(event.String())
+	default:
+		ew.tb.Log(event.String())
+	}
+	return nil
+}
+
+func (ew *eventWriter) HandleError(err error) {
+	ew.tb.Error("logtest: " + err.Error())
+}
+
+func (ew *eventWriter) Close() error {
+	return nil
+}
+
+// NewEventWriter creates a new EventWriter that writes the events to the
+// log of the test, using tb.Log. This way the output of the code under test is
+// attached to the right test case and only shown if the test fails, or when
+// running in verbose mode. Error and Fatal events are written using tb.Error,
+// failing the test on unexpected errors.
+//
+// Note: testing.TB may not be used after the test has completed, so
+// logger.Close must be called before the test returns.
+func NewEventWriter(minType logger.EventType, tb testing.TB) logger.EventWriter {
+	return &eventWriter{minType, tb}
+}
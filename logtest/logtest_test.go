@@ -0,0 +1,77 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logtest
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+var t1 = time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC)
+
+// fakeTB records the calls to Log and Error.
+type fakeTB struct {
+	testing.TB
+	logs   []string
+	errors []string
+}
+
+func (tb *fakeTB) Log(args ...interface{}) {
+	tb.logs = append(tb.logs, args[0].(string))
+}
+
+func (tb *fakeTB) Error(args ...interface{}) {
+	tb.errors = append(tb.errors, args[0].(string))
+}
+
+func (tb *fakeTB) Errorf(format string, args ...interface{}) {
+	tb.errors = append(tb.errors, fmt.Sprintf(format, args...))
+}
+
+func (tb *fakeTB) Helper() {}
+
+func TestEventWriter(t *testing.T) {
+	var tb fakeTB
+	ew := NewEventWriter(logger.InfoEvent, &tb)
+
+	events := []logger.Event{
+		{Type: logger.DebugEvent, Timestamp: t1, Message: "Debug message"},
+		{Type: logger.InfoEvent, Timestamp: t1, Tags: logger.Tags{"tag"}, Message: "Info message"},
+		{Type: logger.ErrorEvent, Timestamp: t1, Message: "Error message"},
+		{Type: logger.FatalEvent, Timestamp: t1, Message: "Fatal message"},
+		{Type: logger.ThumbEvent, Timestamp: t1, Message: "Thumb message"},
+	}
+	for _, event := range events {
+		if err := ew.Write(event); err != nil {
+			t.Fatal("Unexpected error writing event: " + err.Error())
+		}
+	}
+	ew.HandleError(errors.New("some error"))
+	if err := ew.Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	expectedLogs := []string{
+		"2015-09-01 14:22:36 [Info] tag: Info message",
+		"2015-09-01 14:22:36 [Thumb] : Thumb message",
+	}
+	expectedErrors := []string{
+		"2015-09-01 14:22:36 [Error] : Error message",
+		"2015-09-01 14:22:36 [Fatal] : Fatal message",
+		"logtest: some error",
+	}
+
+	if !reflect.DeepEqual(tb.logs, expectedLogs) {
+		t.Errorf("Expected logs %q, but got %q", expectedLogs, tb.logs)
+	}
+	if !reflect.DeepEqual(tb.errors, expectedErrors) {
+		t.Errorf("Expected errors %q, but got %q", expectedErrors, tb.errors)
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+// Package mmapring provides an EventWriter that writes events into a ring
+// buffer in a memory-mapped file. Because the memory is shared with the
+// operating system the most recent events survive an abrupt kill of the
+// process (but not a power failure), Dump can be used to read the events from
+// the file afterwards, also see the cmd/ringdump tool.
+//
+// The file starts with a header of 32 bytes: the magic "LOGRING1", the
+// capacity of the ring, the head and the tail, all as little endian uint64s.
+// The head and the tail are the total number of bytes written to, and
+// removed from, the ring. Following the header is the ring, containing
+// records of a little endian uint32 length followed by the event in the text
+// format of logger.Event.String.
+package mmapring
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+const (
+	magic      = "LOGRING1"
+	headerSize = 32
+	recordSize = 4 // Size of the length of a record.
+
+	capacityOffset = 8
+	headOffset     = 16
+	tailOffset     = 24
+)
+
+// DefaultCapacity is the default capacity of the ring, in bytes.
+const DefaultCapacity = 1024 * 1024
+
+// Errors returned by the EventWriter and Dump.
+var (
+	ErrEventTooBig = errors.New("mmapring: event doesn't fit in the ring")
+	ErrInvalidFile = errors.New("mmapring: invalid ring file")
+)
+
+// ring is the ring buffer in memory, the first headerSize bytes are the
+// header.
+type ring []byte
+
+func (r ring) capacity() uint64 { return binary.LittleEndian.Uint64(r[capacityOffset:]) }
+func (r ring) head() uint64     { return binary.LittleEndian.Uint64(r[headOffset:]) }
+func (r ring) tail() uint64     { return binary.LittleEndian.Uint64(r[tailOffset:]) }
+
+func (r ring) setHead(head uint64) { binary.LittleEndian.PutUint64(r[headOffset:], head) }
+func (r ring) setTail(tail uint64) { binary.LittleEndian.PutUint64(r[tailOffset:], tail) }
+
+// init initializes the header, if the ring doesn't contain a valid header for
+// the capacity.
+func (r ring) init(capacity uint64) {
+	if r.valid() && r.capacity() == capacity {
+		return
+	}
+
+	copy(r, magic)
+	binary.LittleEndian.PutUint64(r[capacityOffset:], capacity)
+	r.setHead(0)
+	r.setTail(0)
+}
+
+// valid returns true if the ring has a valid header.
+func (r ring) valid() bool {
+	if len(r) < headerSize || string(r[:len(magic)]) != magic {
+		return false
+	}
+	capacity, head, tail := r.capacity(), r.head(), r.tail()
+	return uint64(len(r)-headerSize) == capacity && tail <= head && head-tail <= capacity
+}
+
+// copyTo copies data into the ring at the (total) offset.
+func (r ring) copyTo(offset uint64, data []byte) {
+	capacity := r.capacity()
+	start := offset % capacity
+	n := copy(r[headerSize+start:], data)
+	copy(r[headerSize:], data[n:])
+}
+
+// copyFrom copies data from the ring at the (total) offset.
+func (r ring) copyFrom(offset uint64, data []byte) {
+	capacity := r.capacity()
+	start := offset % capacity
+	n := copy(data, r[headerSize+start:])
+	copy(data[n:], r[headerSize:])
+}
+
+// recordLength returns the length of the record, including the length itself,
+// at the offset.
+func (r ring) recordLength(offset uint64) uint64 {
+	var buf [recordSize]byte
+	r.copyFrom(offset, buf[:])
+	return recordSize + uint64(binary.LittleEndian.Uint32(buf[:]))
+}
+
+// append appends a record to the ring, removing the oldest records to make
+// room. The tail is updated before the old records are overwritten and the
+// head after the record is written, so the ring is always consistent.
+func (r ring) append(record []byte) error {
+	capacity := r.capacity()
+	size := uint64(recordSize + len(record))
+	if size > capacity {
+		return ErrEventTooBig
+	}
+
+	head, tail := r.head(), r.tail()
+	for head+size-tail > capacity {
+		tail += r.recordLength(tail)
+	}
+	r.setTail(tail)
+
+	var length [recordSize]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(record)))
+	r.copyTo(head, length[:])
+	r.copyTo(head+recordSize, record)
+	r.setHead(head + size)
+	return nil
+}
+
+// records calls fn for every record in the ring, oldest first.
+func (r ring) records(fn func(record []byte) error) error {
+	head := r.head()
+	for offset := r.tail(); offset < head; {
+		length := r.recordLength(offset)
+		if offset+length > head {
+			return ErrInvalidFile
+		}
+
+		record := make([]byte, length-recordSize)
+		r.copyFrom(offset+recordSize, record)
+		if err := fn(record); err != nil {
+			return err
+		}
+		offset += length
+	}
+	return nil
+}
+
+type eventWriter struct {
+	f            *os.File
+	ring         ring
+	buf          []byte
+	errorHandler func(error)
+	minType      logger.EventType
+}
+
+func (ew *eventWriter) Write(event logger.Event) error {
+	if event.Type < ew.minType {
+		return nil
+	}
+
+	// Write is never called concurrently, so we can reuse the buffer.
+	ew.buf = append(event.AppendText(ew.buf[:0], logger.TimestampFormat{}), '\n')
+	return ew.ring.append(ew.buf)
+}
+
+func (ew *eventWriter) HandleError(err error) {
+	ew.errorHandler(err)
+}
+
+func (ew *eventWriter) Close() error {
+	err := unmap(ew.ring)
+	if er := ew.f.Close(); er != nil && err == nil {
+		err = er
+	}
+	return err
+}
+
+// NewEventWriter creates a new EventWriter that writes events to a ring buffer,
+// with the given capacity in bytes, in the memory-mapped file at path. If the
+// file already contains a ring with the same capacity the events are appended
+// to it, otherwise the file is (re)initialised. If capacity is zero, or lower,
+// DefaultCapacity is used. MinType is the minimal EventType an event must have
+// to be logged. For example if minType is InfoEvent, then any events with an
+// EventType of DebugEvent will not be logged.
+//
+// Memory-mapped files are only supported on Unix systems, on other systems
+// NewEventWriter returns an error.
+func NewEventWriter(minType logger.EventType, path string, capacity int, errorHandler func(error)) (logger.EventWriter, error) {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	size := headerSize + capacity
+	if err := f.Truncate(int64(size)); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	mem, err := mmap(f, size)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	r := ring(mem)
+	r.init(uint64(capacity))
+	return &eventWriter{f: f, ring: r, errorHandler: errorHandler, minType: minType}, nil
+}
+
+// Dump writes all events in the ring buffer file at path to w, oldest first,
+// in the text format of logger.Event.String. This works on all systems and
+// doesn't modify the file.
+func Dump(path string, w io.Writer) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	r := ring(data)
+	if !r.valid() {
+		return ErrInvalidFile
+	}
+
+	return r.records(func(record []byte) error {
+		_, err := w.Write(record)
+		return err
+	})
+}
@@ -0,0 +1,125 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package mmapring
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+var t1 = time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC)
+
+func tempFile(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "logger_mmapring")
+	if err != nil {
+		t.Fatal("Unexpected error creating temporary directory: " + err.Error())
+	}
+	return filepath.Join(dir, "ring"), func() { os.RemoveAll(dir) }
+}
+
+func writeEvents(t *testing.T, ew logger.EventWriter, from, to int) {
+	for i := from; i < to; i++ {
+		event := logger.Event{Type: logger.InfoEvent, Timestamp: t1, Message: fmt.Sprintf("Message %d", i)}
+		if err := ew.Write(event); err != nil {
+			t.Fatal("Unexpected error writing event: " + err.Error())
+		}
+	}
+}
+
+func expectedDump(from, to int) string {
+	var expected string
+	for i := from; i < to; i++ {
+		expected += fmt.Sprintf("2015-09-01 14:22:36 [Info] : Message %d\n", i)
+	}
+	return expected
+}
+
+// checkDump checks that the dump of the file at path contains the events
+// written by writeEvents(from, to).
+func checkDump(t *testing.T, path string, from, to int) {
+	var buf bytes.Buffer
+	if err := Dump(path, &buf); err != nil {
+		t.Fatal("Unexpected error dumping: " + err.Error())
+	} else if expected := expectedDump(from, to); buf.String() != expected {
+		t.Fatalf("Expected dump %q, but got %q", expected, buf.String())
+	}
+}
+
+func TestEventWriter(t *testing.T) {
+	path, cleanup := tempFile(t)
+	defer cleanup()
+
+	// Each record is 4 + 39 bytes, so 3 fit in the ring.
+	ew, err := NewEventWriter(logger.InfoEvent, path, 150, func(err error) {
+		t.Error("Unexpected error: " + err.Error())
+	})
+	if err != nil {
+		t.Fatal("Unexpected error creating event writer: " + err.Error())
+	}
+
+	writeEvents(t, ew, 0, 2)
+	if err := ew.Write(logger.Event{Type: logger.DebugEvent, Message: "Never shows up"}); err != nil {
+		t.Fatal("Unexpected error writing event: " + err.Error())
+	}
+
+	// Dump while the writer is still open, like after a crash.
+	checkDump(t, path, 0, 2)
+
+	// Wrap around the ring multiple times.
+	writeEvents(t, ew, 2, 10)
+	if err := ew.Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	checkDump(t, path, 7, 10)
+
+	// Reopening keeps the events.
+	ew, err = NewEventWriter(logger.InfoEvent, path, 150, nil)
+	if err != nil {
+		t.Fatal("Unexpected error creating event writer: " + err.Error())
+	}
+	writeEvents(t, ew, 10, 11)
+	if err := ew.Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	checkDump(t, path, 8, 11)
+}
+
+func TestEventWriterEventTooBig(t *testing.T) {
+	path, cleanup := tempFile(t)
+	defer cleanup()
+
+	ew, err := NewEventWriter(logger.InfoEvent, path, 32, nil)
+	if err != nil {
+		t.Fatal("Unexpected error creating event writer: " + err.Error())
+	}
+	defer ew.Close()
+
+	event := logger.Event{Type: logger.InfoEvent, Timestamp: t1, Message: strings.Repeat("a", 32)}
+	if err := ew.Write(event); err != ErrEventTooBig {
+		t.Fatalf("Expected error %v, but got %v", ErrEventTooBig, err)
+	}
+}
+
+func TestDumpInvalidFile(t *testing.T) {
+	path, cleanup := tempFile(t)
+	defer cleanup()
+
+	if err := ioutil.WriteFile(path, []byte("not a ring file"), 0600); err != nil {
+		t.Fatal("Unexpected error writing file: " + err.Error())
+	}
+	if err := Dump(path, ioutil.Discard); err != ErrInvalidFile {
+		t.Fatalf("Expected error %v, but got %v", ErrInvalidFile, err)
+	}
+}
@@ -0,0 +1,306 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+// Package mqtt provides an EventWriter that publishes events to a MQTT topic,
+// useful for (embedded) devices that already report telemetry over a MQTT
+// broker. It implements the required parts of MQTT version 3.1.1, see
+// http://docs.oasis-open.org/mqtt/mqtt/v3.1.1/mqtt-v3.1.1.html.
+package mqtt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+// QoS is the quality of service level used in publishing events.
+type QoS uint8
+
+// The quality of service levels.
+const (
+	AtMostOnce QoS = iota
+	AtLeastOnce
+	ExactlyOnce
+)
+
+// Defaults used if the Config fields are not set.
+const (
+	DefaultTopic   = "logger"
+	DefaultTimeout = 5 * time.Second
+)
+
+// Control packet types, already shifted into the top four bits.
+const (
+	packetConnect byte = 1 << 4
+	packetConnAck byte = 2 << 4
+	packetPublish byte = 3 << 4
+	packetPubAck  byte = 4 << 4
+	packetPubRec  byte = 5 << 4
+	packetPubRel  byte = 6 << 4
+	packetPubComp byte = 7 << 4
+)
+
+// Config is the configuration for the EventWriter created by NewEventWriter.
+type Config struct {
+	// Addr is the TCP address of the MQTT broker.
+	Addr string
+
+	// ClientID used in connecting to the broker, defaults to "logger-" + the
+	// process id.
+	ClientID string
+
+	// Username and Password are used to authenticate with the broker, if not
+	// empty. MQTT 3.1.1 doesn't allow a password without a username.
+	Username string
+	Password string
+
+	// Topic the events are published to, defaults to DefaultTopic.
+	Topic string
+
+	// QoS is the quality of service level used in publishing events, defaults
+	// to AtMostOnce.
+	QoS QoS
+
+	// Retain sets the retain flag on published events.
+	Retain bool
+
+	// Timeout for connecting, writing and waiting for acknowledgements,
+	// defaults to DefaultTimeout.
+	Timeout time.Duration
+}
+
+// ErrConnectionRefused is returned if the broker refused the connection, the
+// return code of the broker is added to the error message.
+var ErrConnectionRefused = errors.New("mqtt: connection refused")
+
+// ErrUnexpectedPacket is returned if the broker returns an unexpected packet.
+var ErrUnexpectedPacket = errors.New("mqtt: unexpected packet")
+
+// ErrPasswordWithoutUsername is returned by NewEventWriter if the Config
+// contains a password, but no username.
+var ErrPasswordWithoutUsername = errors.New("mqtt: password without username")
+
+type eventWriter struct {
+	config       Config
+	conn         net.Conn
+	r            *bufio.Reader
+	packetID     uint16
+	buf          []byte
+	errorHandler func(error)
+	minType      logger.EventType
+}
+
+func (ew *eventWriter) Write(event logger.Event) error {
+	if event.Type < ew.minType {
+		return nil
+	}
+
+	if ew.conn == nil {
+		if err := ew.connect(); err != nil {
+			return err
+		}
+	}
+
+	if err := ew.publish(event); err != nil {
+		// Reconnect on the next write.
+		ew.conn.Close()
+		ew.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (ew *eventWriter) connect() error {
+	conn, err := net.DialTimeout("tcp", ew.config.Addr, ew.config.Timeout)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(ew.config.Timeout))
+
+	var flags byte = 0x02 // Clean session.
+	var payload []byte
+	payload = appendString(payload, ew.config.ClientID)
+	if ew.config.Username != "" {
+		flags |= 0x80
+		payload = appendString(payload, ew.config.Username)
+	}
+	if ew.config.Password != "" {
+		flags |= 0x40
+		payload = appendString(payload, ew.config.Password)
+	}
+
+	var variable []byte
+	variable = appendString(variable, "MQTT")
+	variable = append(variable, 4, flags, 0, 0) // Level 4, no keep alive.
+
+	buf := appendPacket(nil, packetConnect, variable, payload)
+	if _, err := conn.Write(buf); err != nil {
+		conn.Close()
+		return err
+	}
+
+	r := bufio.NewReader(conn)
+	body, err := readPacket(r, packetConnAck)
+	if err != nil {
+		conn.Close()
+		return err
+	} else if len(body) != 2 {
+		conn.Close()
+		return ErrUnexpectedPacket
+	} else if body[1] != 0 {
+		conn.Close()
+		return fmt.Errorf("%s: return code %d", ErrConnectionRefused, body[1])
+	}
+
+	ew.conn = conn
+	ew.r = r
+	return nil
+}
+
+func (ew *eventWriter) publish(event logger.Event) error {
+	ew.conn.SetDeadline(time.Now().Add(ew.config.Timeout))
+
+	header := packetPublish | byte(ew.config.QoS)<<1
+	if ew.config.Retain {
+		header |= 0x01
+	}
+
+	var variable []byte
+	variable = appendString(variable, ew.config.Topic)
+	if ew.config.QoS != AtMostOnce {
+		ew.packetID++
+		if ew.packetID == 0 {
+			ew.packetID = 1
+		}
+		variable = append(variable, byte(ew.packetID>>8), byte(ew.packetID))
+	}
+
+	// Write is never called concurrently, so we can reuse the buffer.
+	ew.buf = event.AppendJSON(ew.buf[:0])
+	if _, err := ew.conn.Write(appendPacket(nil, header, variable, ew.buf)); err != nil {
+		return err
+	}
+
+	switch ew.config.QoS {
+	case AtLeastOnce:
+		return ew.expectAck(packetPubAck)
+	case ExactlyOnce:
+		if err := ew.expectAck(packetPubRec); err != nil {
+			return err
+		}
+		pubRel := []byte{packetPubRel | 0x02, 2, byte(ew.packetID >> 8), byte(ew.packetID)}
+		if _, err := ew.conn.Write(pubRel); err != nil {
+			return err
+		}
+		return ew.expectAck(packetPubComp)
+	}
+	return nil
+}
+
+// ExpectAck reads an acknowledgement packet, of the given type, for the last
+// send packet.
+func (ew *eventWriter) expectAck(packetType byte) error {
+	body, err := readPacket(ew.r, packetType)
+	if err != nil {
+		return err
+	} else if len(body) != 2 || uint16(body[0])<<8|uint16(body[1]) != ew.packetID {
+		return ErrUnexpectedPacket
+	}
+	return nil
+}
+
+func (ew *eventWriter) HandleError(err error) {
+	ew.errorHandler(err)
+}
+
+func (ew *eventWriter) Close() error {
+	if ew.conn == nil {
+		return nil
+	}
+	// Disconnect packet.
+	ew.conn.Write([]byte{0xe0, 0})
+	return ew.conn.Close()
+}
+
+func appendPacket(buf []byte, header byte, variable, payload []byte) []byte {
+	buf = append(buf, header)
+	buf = appendRemainingLength(buf, len(variable)+len(payload))
+	buf = append(buf, variable...)
+	return append(buf, payload...)
+}
+
+func appendRemainingLength(buf []byte, n int) []byte {
+	for n >= 0x80 {
+		buf = append(buf, byte(n)|0x80)
+		n >>= 7
+	}
+	return append(buf, byte(n))
+}
+
+func appendString(buf []byte, str string) []byte {
+	buf = append(buf, byte(len(str)>>8), byte(len(str)))
+	return append(buf, str...)
+}
+
+// ReadPacket reads a single packet of the given type and returns the body.
+func readPacket(r *bufio.Reader, packetType byte) ([]byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	} else if header&0xf0 != packetType {
+		return nil, ErrUnexpectedPacket
+	}
+
+	var length, shift uint
+	for i := 0; ; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		} else if i == 3 && b&0x80 != 0 {
+			return nil, ErrUnexpectedPacket
+		}
+		length |= uint(b&0x7f) << shift
+		shift += 7
+		if b&0x80 == 0 {
+			break
+		}
+	}
+
+	body := make([]byte, length)
+	_, err = io.ReadFull(r, body)
+	return body, err
+}
+
+// NewEventWriter creates a new EventWriter that publishes events as JSON, see
+// Event.MarshalJSON, to a MQTT topic. If the connection fails it will
+// reconnect on the next write. MinType is the minimal EventType an event must
+// have to be logged. For example if minType is InfoEvent, then any events with
+// an EventType of DebugEvent will not be logged.
+func NewEventWriter(minType logger.EventType, config Config, errorHandler func(error)) (logger.EventWriter, error) {
+	if config.Password != "" && config.Username == "" {
+		return nil, ErrPasswordWithoutUsername
+	}
+	if config.ClientID == "" {
+		config.ClientID = "logger-" + strconv.Itoa(os.Getpid())
+	}
+	if config.Topic == "" {
+		config.Topic = DefaultTopic
+	}
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+
+	ew := &eventWriter{config: config, errorHandler: errorHandler, minType: minType}
+	if err := ew.connect(); err != nil {
+		return nil, err
+	}
+	return ew, nil
+}
@@ -0,0 +1,141 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package mqtt
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+var t1 = time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC)
+
+// Broker accepts a single connection and responds to the packets as a MQTT
+// broker would. The payloads of the published packets are send on the
+// returned channel.
+func broker(t *testing.T, l net.Listener, returnCode byte) <-chan string {
+	payloads := make(chan string, 4)
+	go func() {
+		defer close(payloads)
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+
+		if body, err := readPacket(r, packetConnect); err != nil ||
+			!strings.Contains(string(body), "client-id") ||
+			!strings.Contains(string(body), "user") {
+			t.Errorf("Unexpected connect packet: %v, %q", err, body)
+			return
+		}
+		conn.Write([]byte{packetConnAck, 2, 0, returnCode})
+		servePublishes(conn, r, payloads)
+	}()
+	return payloads
+}
+
+// servePublishes acknowledges the published packets, until an error occurs.
+func servePublishes(conn net.Conn, r *bufio.Reader, payloads chan<- string) {
+	for {
+		header, err := r.Peek(1)
+		if err != nil {
+			return
+		}
+		qos := (header[0] >> 1) & 0x03
+		body, err := readPacket(r, packetPublish)
+		if err != nil {
+			return
+		}
+
+		topicLength := int(body[0])<<8 | int(body[1])
+		body = body[2+topicLength:]
+		var id []byte
+		if qos != 0 {
+			id, body = body[:2], body[2:]
+		}
+		payloads <- string(body)
+
+		if err := acknowledge(conn, r, qos, id); err != nil {
+			return
+		}
+	}
+}
+
+// acknowledge acknowledges a published packet with the QoS and packet id.
+func acknowledge(conn net.Conn, r *bufio.Reader, qos byte, id []byte) error {
+	switch qos {
+	case 1:
+		conn.Write([]byte{packetPubAck, 2, id[0], id[1]})
+	case 2:
+		conn.Write([]byte{packetPubRec, 2, id[0], id[1]})
+		if _, err := readPacket(r, packetPubRel); err != nil {
+			return err
+		}
+		conn.Write([]byte{packetPubComp, 2, id[0], id[1]})
+	}
+	return nil
+}
+
+func TestEventWriter(t *testing.T) {
+	event := logger.Event{Type: logger.InfoEvent, Timestamp: t1, Tags: logger.Tags{"tag"}, Message: "Msg"}
+	expected, _ := event.MarshalJSON()
+
+	for _, qos := range []QoS{AtMostOnce, AtLeastOnce, ExactlyOnce} {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal("Unexpected error listening: " + err.Error())
+		}
+		payloads := broker(t, l, 0)
+
+		config := Config{Addr: l.Addr().String(), ClientID: "client-id",
+			Username: "user", Password: "pass", QoS: qos}
+		ew, err := NewEventWriter(logger.InfoEvent, config, func(error) {})
+		if err != nil {
+			t.Fatal("Unexpected error creating EventWriter: " + err.Error())
+		}
+
+		ew.Write(logger.Event{Type: logger.DebugEvent, Message: "Never gets logged"})
+		for i := 0; i < 2; i++ {
+			if err := ew.Write(event); err != nil {
+				t.Fatalf("Unexpected error writing event with QoS %d: %s", qos, err.Error())
+			} else if got := <-payloads; got != string(expected) {
+				t.Fatalf("Expected payload %s, but got %s", expected, got)
+			}
+		}
+
+		if err := ew.Close(); err != nil {
+			t.Fatal("Unexpected error closing: " + err.Error())
+		}
+		l.Close()
+	}
+}
+
+func TestEventWriterConnectionRefused(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Unexpected error listening: " + err.Error())
+	}
+	defer l.Close()
+	broker(t, l, 5)
+
+	config := Config{Addr: l.Addr().String(), ClientID: "client-id", Username: "user"}
+	_, err = NewEventWriter(logger.InfoEvent, config, func(error) {})
+	if expected := "mqtt: connection refused: return code 5"; err == nil || err.Error() != expected {
+		t.Fatalf("Expected error %q, but got %v", expected, err)
+	}
+}
+
+func TestEventWriterPasswordWithoutUsername(t *testing.T) {
+	config := Config{Addr: "127.0.0.1:1", Password: "pass"}
+	if _, err := NewEventWriter(logger.InfoEvent, config, func(error) {}); err != ErrPasswordWithoutUsername {
+		t.Fatalf("Expected error %v, but got %v", ErrPasswordWithoutUsername, err)
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"io"
+
+	"github.com/Thomasdezeeuw/logger/internal/msgpack"
+	"github.com/Thomasdezeeuw/logger/internal/util"
+)
+
+// MsgpackFormatter formats events as MessagePack maps, with the following
+// keys: "schema_version" (see SchemaVersion), "type", "timestamp", "tags",
+// "message" and "data". The timestamp uses the timestamp extension type and
+// data, if not nil, is converted into a string. This format is understood by
+// fluentd compatible consumers.
+type MsgpackFormatter struct{}
+
+// Format appends the MessagePack encoded event to buf.
+func (MsgpackFormatter) Format(buf []byte, event Event) ([]byte, error) {
+	return appendEventMsgpack(buf, event), nil
+}
+
+func appendEventMsgpack(buf []byte, event Event) []byte {
+	n := 5
+	if event.Data != nil {
+		n++
+	}
+
+	buf = msgpack.AppendMapHeader(buf, n)
+	buf = msgpack.AppendString(buf, "schema_version")
+	buf = msgpack.AppendUint(buf, SchemaVersion)
+	buf = msgpack.AppendString(buf, "type")
+	buf = msgpack.AppendString(buf, event.Type.String())
+	buf = msgpack.AppendString(buf, "timestamp")
+	buf = msgpack.AppendTime(buf, event.Timestamp)
+	buf = msgpack.AppendString(buf, "tags")
+	buf = msgpack.AppendArrayHeader(buf, len(event.Tags))
+	for _, tag := range event.Tags {
+		buf = msgpack.AppendString(buf, tag)
+	}
+	buf = msgpack.AppendString(buf, "message")
+	buf = msgpack.AppendString(buf, event.Message)
+	if event.Data != nil {
+		buf = msgpack.AppendString(buf, "data")
+		buf = msgpack.AppendString(buf, util.InterfaceToString(event.Data))
+	}
+	return buf
+}
+
+// NewMsgpackEventWriter creates a new EventWriter that writes MessagePack
+// encoded events, see MsgpackFormatter, to the given writer. MinType is the
+// minimal EventType an event must have to be logged. For example if minType
+// is InfoEvent, then any events with an EventType of DebugEvent will not be
+// logged.
+func NewMsgpackEventWriter(minType EventType, w io.Writer, errorHandler func(error)) EventWriter {
+	return NewFormatEventWriter(minType, w, MsgpackFormatter{}, errorHandler)
+}
@@ -0,0 +1,44 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMsgpackEventWriter(t *testing.T) {
+	var buf bytes.Buffer
+	ew := NewMsgpackEventWriter(InfoEvent, &buf, func(error) {})
+
+	event := Event{InfoEvent, now(), Tags{"tag"}, "Msg", "data", 0}
+	if err := ew.Write(event); err != nil {
+		t.Fatal("Unexpected error writing to MsgpackEventWriter: " + err.Error())
+	}
+
+	// Must not be written.
+	event = Event{DebugEvent, now(), Tags{"tag"}, "Never gets logged", nil, 0}
+	if err := ew.Write(event); err != nil {
+		t.Fatal("Unexpected error writing to MsgpackEventWriter: " + err.Error())
+	}
+
+	if err := ew.Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	expected := []byte{0x86,
+		0xae, 's', 'c', 'h', 'e', 'm', 'a', '_', 'v', 'e', 'r', 's', 'i', 'o', 'n', 0x01,
+		0xa4, 't', 'y', 'p', 'e', 0xa4, 'I', 'n', 'f', 'o',
+		0xa9, 't', 'i', 'm', 'e', 's', 't', 'a', 'm', 'p',
+		0xc7, 12, 0xff, 0, 0, 0, 0, 0, 0, 0, 0, 0x55, 0xe5, 0xb4, 0xac,
+		0xa4, 't', 'a', 'g', 's', 0x91, 0xa3, 't', 'a', 'g',
+		0xa7, 'm', 'e', 's', 's', 'a', 'g', 'e', 0xa3, 'M', 's', 'g',
+		0xa4, 'd', 'a', 't', 'a', 0xa4, 'd', 'a', 't', 'a',
+	}
+
+	if got := buf.Bytes(); !bytes.Equal(got, expected) {
+		t.Fatalf("Expected buffer to contain:\n% x\nBut got:\n% x", expected, got)
+	}
+}
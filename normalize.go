@@ -0,0 +1,114 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"sort"
+	"strings"
+)
+
+// TagNormalization defines how the tags of events are normalized before they
+// are passed to the EventWriters, see SetTagNormalization. Options can be
+// combined, e.g. LowercaseTags | DedupTags.
+type TagNormalization uint8
+
+// Available tag normalization options. If multiple options are set the tags
+// are first lowercased, then deduplicated and finally sorted.
+const (
+	// LowercaseTags lowercases all tags, for key=value tags (see Tag) only the
+	// key is lowercased.
+	LowercaseTags TagNormalization = 1 << iota
+
+	// DedupTags removes duplicate tags, keeping the first.
+	DedupTags
+
+	// SortTags sorts the tags.
+	SortTags
+)
+
+var (
+	tagNormalization TagNormalization
+	mandatoryTags    Tags
+)
+
+// SetMandatoryTags sets tags that are added to every event, for example the
+// tenant, region or deployment. This enforces the tags centrally, rather then
+// relying on each log call to add them. For key=value tags, see Tag, the
+// mandatory tag replaces any tag with the same key set by the log call, for
+// example:
+//
+//	logger.SetMandatoryTags(logger.Tag("tenant", tenantID), "eu-west-1")
+//
+// The mandatory tags are added after the tags of the event and before the tags
+// are normalized, see SetTagNormalization.
+//
+// Note: this must be called before Start and is not safe for concurrent use.
+func SetMandatoryTags(tags ...string) {
+	mandatoryTags = Tags(tags)
+}
+
+// SetTagNormalization sets how tags are normalized, this allows backends that
+// index on tags to not see, for example, "DB" and "db", or duplicate tags, as
+// different tags. By default tags are not normalized.
+//
+// Note: this must be called before Start and is not safe for concurrent use.
+func SetTagNormalization(normalization TagNormalization) {
+	tagNormalization = normalization
+}
+
+// addMandatoryTags adds the mandatory tags to tags, it never modifies the given
+// tags.
+func addMandatoryTags(tags, mandatory Tags) Tags {
+	if len(mandatory) == 0 {
+		return tags
+	}
+
+	added := make(Tags, 0, len(tags)+len(mandatory))
+	for _, tag := range tags {
+		if key, _, ok := splitTag(tag); ok && isDuplicateTagKey(mandatory, key) {
+			continue
+		}
+		added = append(added, tag)
+	}
+	return append(added, mandatory...)
+}
+
+// normalizeTags normalizes the tags, it never modifies the given tags since
+// those might be shared, e.g. if created by InternTags.
+func normalizeTags(tags Tags, normalization TagNormalization) Tags {
+	if normalization == 0 || len(tags) == 0 {
+		return tags
+	}
+
+	normalized := make(Tags, 0, len(tags))
+	for _, tag := range tags {
+		if normalization&LowercaseTags != 0 {
+			if key, value, ok := splitTag(tag); ok {
+				tag = strings.ToLower(key) + "=" + value
+			} else {
+				tag = strings.ToLower(tag)
+			}
+		}
+
+		if normalization&DedupTags != 0 && containsTag(normalized, tag) {
+			continue
+		}
+		normalized = append(normalized, tag)
+	}
+
+	if normalization&SortTags != 0 {
+		sort.Strings(normalized)
+	}
+	return normalized
+}
+
+func containsTag(tags Tags, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	t.Parallel()
+
+	tags := Tags{"DB", "http", "db", "User=Thomas", "user=Thomas", "Http"}
+	tests := []struct {
+		normalization TagNormalization
+		expected      Tags
+	}{
+		{0, tags},
+		{LowercaseTags, Tags{"db", "http", "db", "user=Thomas", "user=Thomas", "http"}},
+		{DedupTags, Tags{"DB", "http", "db", "User=Thomas", "user=Thomas", "Http"}},
+		{SortTags, Tags{"DB", "Http", "User=Thomas", "db", "http", "user=Thomas"}},
+		{LowercaseTags | DedupTags, Tags{"db", "http", "user=Thomas"}},
+		{LowercaseTags | DedupTags | SortTags, Tags{"db", "http", "user=Thomas"}},
+		{DedupTags | SortTags, Tags{"DB", "Http", "User=Thomas", "db", "http", "user=Thomas"}},
+	}
+
+	for _, test := range tests {
+		original := make(Tags, len(tags))
+		copy(original, tags)
+
+		got := normalizeTags(tags, test.normalization)
+		if !reflect.DeepEqual(got, test.expected) {
+			t.Fatalf("Expected normalization %d to return %v, but got %v",
+				test.normalization, test.expected, got)
+		} else if !reflect.DeepEqual(tags, original) {
+			t.Fatalf("Expected the tags to not be modified, but got %v", tags)
+		}
+	}
+
+	if got := normalizeTags(Tags{"a", "a"}, DedupTags); !reflect.DeepEqual(got, Tags{"a"}) {
+		t.Fatalf("Expected duplicate tags to be removed, but got %v", got)
+	}
+}
+
+func TestSetTagNormalization(t *testing.T) {
+	defer reset()
+	var ew eventWriter
+	SetTagNormalization(LowercaseTags | DedupTags | SortTags)
+	Start(&ew)
+
+	Info(Tags{"http", "DB", "db"}, "Message")
+
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	expected := Tags{"db", "http"}
+	if len(ew.events) != 1 {
+		t.Fatalf("Expected 1 event, but got %d", len(ew.events))
+	} else if got := ew.events[0].Tags; !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected tags %v, but got %v", expected, got)
+	}
+}
+
+func TestAddMandatoryTags(t *testing.T) {
+	t.Parallel()
+
+	mandatory := Tags{Tag("tenant", "abc"), "eu-west-1", Tag("empty", "")}
+	tests := []struct {
+		tags     Tags
+		expected Tags
+	}{
+		{nil, mandatory},
+		{Tags{"http"}, Tags{"http", Tag("tenant", "abc"), "eu-west-1", Tag("empty", "")}},
+		{Tags{Tag("tenant", "xyz"), "http", Tag("empty", "value"), Tag("user", 1)},
+			Tags{"http", Tag("user", 1), Tag("tenant", "abc"), "eu-west-1", Tag("empty", "")}},
+	}
+
+	for _, test := range tests {
+		if got := addMandatoryTags(test.tags, mandatory); !reflect.DeepEqual(got, test.expected) {
+			t.Fatalf("Expected addMandatoryTags(%v) to return %v, but got %v",
+				test.tags, test.expected, got)
+		}
+	}
+
+	tags := Tags{"http"}
+	if got := addMandatoryTags(tags, nil); !reflect.DeepEqual(got, tags) {
+		t.Fatalf("Expected tags to be unchanged, but got %v", got)
+	}
+}
+
+func TestSetMandatoryTags(t *testing.T) {
+	defer reset()
+	var ew eventWriter
+	SetMandatoryTags(Tag("tenant", "abc"))
+	SetTagNormalization(SortTags)
+	Start(&ew)
+
+	Info(Tags{"http", Tag("tenant", "xyz")}, "Message")
+
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	expected := Tags{"http", "tenant=abc"}
+	if len(ew.events) != 1 {
+		t.Fatalf("Expected 1 event, but got %d", len(ew.events))
+	} else if got := ew.events[0].Tags; !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected tags %v, but got %v", expected, got)
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// defaultInternalChannelSize is the size of the channel used for internal
+// events, e.g. about a panicking EventWriter.
+const defaultInternalChannelSize = 64
+
+// PanicError is passed to EventWriter.HandleError if EventWriter.Write panics.
+// A panicking Write is handled like a Write that returns an error, so the
+// event is written again and the EventWriter is dropped after too many
+// failures, but the other EventWriters keep running.
+type PanicError struct {
+	Value      interface{} // Value passed to panic.
+	StackTrace []byte      // Stack trace of the panicking goroutine.
+}
+
+func (err *PanicError) Error() string {
+	return fmt.Sprintf("EventWriter panicked: %s", valueString(err.Value))
+}
+
+func valueString(value interface{}) string {
+	switch v := value.(type) {
+	case error:
+		return v.Error()
+	case string:
+		return v
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// event returns an Error event describing the panic of the EventWriter.
+func (err *PanicError) event(ew EventWriter) Event {
+	msg := fmt.Sprintf("EventWriter %T panicked: %s", ew, valueString(err.Value))
+	return Event{ErrorEvent, now(), Tags{"logger"}, msg, err.StackTrace, 0}
+}
+
+// safeWrite calls ew.Write, or ew.WriteEncoded or ew.WriteContext if
+// supported, converting a panic into a *PanicError.
+func safeWrite(ew EventWriter, event Event, encoded []byte) (err error) {
+	defer func() {
+		if recv := recover(); recv != nil {
+			stackTrace := make([]byte, defaultStackSize)
+			stackTrace = stackTrace[:runtime.Stack(stackTrace, false)]
+			err = &PanicError{recv, stackTrace}
+		}
+	}()
+	return write(ew, event, encoded)
+}
+
+// internalEvent is an event created by the logger itself, which isn't send to
+// the EventWriter at index from.
+type internalEvent struct {
+	event Event
+	from  int
+}
+
+// sendInternalEvent sends an internal event without blocking, if the channel
+// is full the event is dropped. Blocking could deadlock the fan out.
+func sendInternalEvent(internalEvents chan<- internalEvent, from int, event Event) {
+	select {
+	case internalEvents <- internalEvent{event, from}:
+	default:
+	}
+}
+
+// logInternal logs an internal event not created by an EventWriter, e.g. by
+// the spill queue. Like the internal events of EventWriters it's written to
+// the diagnostics EventWriter, if any, see SetDiagnosticsEventWriter.
+func logInternal(event Event) {
+	sendInternalEvent(internalEventChannel, -1, event)
+}
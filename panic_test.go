@@ -0,0 +1,88 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// panicEventWriter panics on the first write of an event with the message
+// "panic".
+type panicEventWriter struct {
+	eventWriter
+	panicked bool
+	handled  chan struct{}
+}
+
+func (ew *panicEventWriter) Write(event Event) error {
+	if event.Message == "panic" && !ew.panicked {
+		ew.panicked = true
+		panic("oops")
+	}
+	return ew.eventWriter.Write(event)
+}
+
+func (ew *panicEventWriter) HandleError(err error) {
+	ew.eventWriter.HandleError(err)
+	ew.handled <- struct{}{}
+}
+
+func TestEventWriterPanic(t *testing.T) {
+	defer reset()
+	pew := panicEventWriter{handled: make(chan struct{}, 1)}
+	var ew eventWriter
+	Start(&pew, &ew)
+
+	Info(Tags{"panic"}, "panic")
+	<-pew.handled
+	Info(Tags{"panic"}, "after panic")
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	checkPanicError(t, pew.errors)
+
+	// The event is written again after the panic.
+	if len(pew.events) != 2 || pew.events[0].Message != "panic" || pew.events[1].Message != "after panic" {
+		t.Fatalf("Expected the events to be written to the panicking EventWriter, but got %v", pew.events)
+	}
+
+	// The other EventWriter receives the events and an Error event about the
+	// panic.
+	if len(ew.events) != 3 {
+		t.Fatalf("Expected 3 events, but got %v", ew.events)
+	}
+	checkPanicEvent(t, ew.events)
+}
+
+func checkPanicError(t *testing.T, errors []error) {
+	if len(errors) != 1 {
+		t.Fatalf("Expected a single error, but got %v", errors)
+	}
+	panicErr, ok := errors[0].(*PanicError)
+	if !ok || panicErr.Value != "oops" || panicErr.Error() != "EventWriter panicked: oops" {
+		t.Fatalf("Expected a PanicError, but got %#v", errors[0])
+	} else if !bytes.Contains(panicErr.StackTrace, []byte("panicEventWriter).Write")) {
+		t.Fatalf("Expected the stack trace to contain the panicking Write, but got:\n%s", panicErr.StackTrace)
+	}
+}
+
+func checkPanicEvent(t *testing.T, events []Event) {
+	var found bool
+	for _, event := range events {
+		if event.Type == ErrorEvent {
+			found = true
+			expected := "EventWriter *logger.panicEventWriter panicked: oops"
+			if event.Message != expected || !strings.Contains(event.Tags.String(), "logger") {
+				t.Errorf("Expected an Error event with message %q, but got %v", expected, event)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("Expected an Error event about the panic, but got %v", events)
+	}
+}
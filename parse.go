@@ -0,0 +1,242 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxLineSize is the maximum size of a single line the Parser accepts.
+const maxLineSize = 1024 * 1024
+
+// ParseError is set as Event.Data on events returned by the Parser for input
+// that couldn't be parsed. These events have ErrorEvent as type, the error as
+// message and, if the line could be parsed upto the timestamp, the timestamp.
+type ParseError struct {
+	Line int    // Number of the first line, starting at 1.
+	Raw  string // Raw input, can span multiple lines.
+	Err  error
+}
+
+func (err *ParseError) Error() string {
+	return fmt.Sprintf("logger: unable to parse line %d: %s", err.Line, err.Err)
+}
+
+// Unwrap returns the underlying error.
+func (err *ParseError) Unwrap() error {
+	return err.Err
+}
+
+// Errors used in ParseError, next to errors returned by EventType.UnmarshalText
+// and TimestampFormat.Parse.
+var (
+	ErrNoTagsSeparator = errors.New("missing tags separator")
+	ErrNoEvent         = errors.New("continuation line without an event")
+	ErrStackTrace      = errors.New("stack trace not written by the logger")
+
+	errNoHeader = errors.New("missing timestamp and event type")
+)
+
+// Parser parses events in the text format of Event.String, as written by the
+// EventWriters created by NewFileEventWriter and NewConsoleEventWriter. It's
+// used in the same way as bufio.Scanner:
+//
+//	parser := logger.NewParser(f, logger.TimestampFormat{})
+//	for parser.Scan() {
+//		event := parser.Event()
+//		// Use event.
+//	}
+//	if err := parser.Err(); err != nil {
+//		// Handle error.
+//	}
+//
+// The Parser doesn't stop at malformed input. Lines that don't start with a
+// timestamp and event type are considered to be continuations of the message of
+// the previous event, e.g. a message with an embedded newline or the stack
+// trace of a Fatal event. Input that can't be parsed is returned as an event
+// with *ParseError as data, this includes Go panics and other stack traces not
+// written by the logger.
+//
+// Note: since the text format doesn't separate Event.Message from Event.Data
+// the data is part of the parsed message. Event.Seq is always zero.
+type Parser struct {
+	scanner *bufio.Scanner
+	format  TimestampFormat
+	escaped bool
+	lineN   int
+
+	// Next line to process, if it was read ahead.
+	next    string
+	hasNext bool
+
+	event Event
+	err   error
+}
+
+// NewParser creates a new Parser that reads from r. The timestamps are parsed
+// using the given format, which must be the same format used to write the
+// events, see WithTimestampFormat.
+func NewParser(r io.Reader, format TimestampFormat) *Parser {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxLineSize)
+	return &Parser{scanner: scanner, format: format}
+}
+
+// NewEscapedParser does the same as NewParser, but parses events that are
+// escaped, see WithEscaping. Since escaped events are always written on a
+// single line there are no continuation lines, every line that can't be parsed
+// is returned as an event with *ParseError as data.
+func NewEscapedParser(r io.Reader, format TimestampFormat) *Parser {
+	parser := NewParser(r, format)
+	parser.escaped = true
+	return parser
+}
+
+// readLine returns the next line.
+func (p *Parser) readLine() (string, bool) {
+	if p.hasNext {
+		p.hasNext = false
+		return p.next, true
+	}
+
+	if !p.scanner.Scan() {
+		return "", false
+	}
+	p.lineN++
+	return strings.TrimSuffix(p.scanner.Text(), "\r"), true
+}
+
+// unreadLine makes line the next line returned by readLine.
+func (p *Parser) unreadLine(line string) {
+	p.next = line
+	p.hasNext = true
+}
+
+// Scan parses the next event, which will be available through the Event
+// method. It returns false when the end of the input is reached or an I/O
+// error occurs, see Err.
+func (p *Parser) Scan() bool {
+	line, ok := p.readLine()
+	if !ok {
+		p.err = p.scanner.Err()
+		return false
+	}
+	lineN := p.lineN
+
+	event, err := p.parseLine(line)
+	if err != nil {
+		if err == errNoHeader {
+			err = ErrNoEvent
+		}
+		if isStackTraceStart(line) {
+			err = ErrStackTrace
+		}
+		event = Event{
+			Type:      ErrorEvent,
+			Timestamp: event.Timestamp,
+			Message:   err.Error(),
+		}
+		event.Data = &ParseError{
+			Line: lineN,
+			Raw:  p.appendContinuations(line),
+			Err:  err,
+		}
+	} else {
+		event.Message = p.appendContinuations(event.Message)
+	}
+
+	p.event = event
+	return true
+}
+
+// appendContinuations appends all following continuation lines to s.
+func (p *Parser) appendContinuations(s string) string {
+	if p.escaped {
+		return s
+	}
+
+	for {
+		line, ok := p.readLine()
+		if !ok {
+			return s
+		}
+
+		if _, err := p.parseLine(line); err != errNoHeader || isStackTraceStart(line) {
+			p.unreadLine(line)
+			return s
+		}
+		s += "\n" + line
+	}
+}
+
+// isStackTraceStart returns true if the line is the start of the output of
+// a Go panic or fatal runtime error.
+func isStackTraceStart(line string) bool {
+	return strings.HasPrefix(line, "panic: ") ||
+		strings.HasPrefix(line, "fatal error: ")
+}
+
+// parseLine parses a single line in the format of Event.String. If the line
+// doesn't start with a timestamp and event type errNoHeader is returned.
+func (p *Parser) parseLine(line string) (Event, error) {
+	var event Event
+	i := strings.Index(line, " [")
+	if i == -1 {
+		return event, errNoHeader
+	}
+	j := strings.Index(line[i:], "] ")
+	if j == -1 {
+		return event, errNoHeader
+	}
+	j += i
+
+	timestamp, err := p.format.Parse(line[:i])
+	if err != nil {
+		return event, errNoHeader
+	}
+	event.Timestamp = timestamp
+
+	if err := event.Type.UnmarshalText([]byte(line[i+2 : j])); err != nil {
+		return event, err
+	}
+
+	rest := line[j+2:]
+	k := strings.Index(rest, ": ")
+	if k == -1 {
+		// Event without a message.
+		if !strings.HasSuffix(rest, ":") {
+			return event, ErrNoTagsSeparator
+		}
+		k = len(rest) - 1
+		rest += " "
+	}
+	if k > 0 {
+		event.Tags = Tags(strings.Split(rest[:k], ", "))
+	}
+	event.Message = rest[k+2:]
+
+	if p.escaped {
+		for i, tag := range event.Tags {
+			event.Tags[i] = unescape(tag)
+		}
+		event.Message = unescape(event.Message)
+	}
+	return event, nil
+}
+
+// Event returns the most recent event parsed by Scan.
+func (p *Parser) Event() Event {
+	return p.event
+}
+
+// Err returns the first non-EOF error that was encountered by the Parser.
+// Malformed input is not considered an error, see ParseError.
+func (p *Parser) Err() error {
+	return p.err
+}
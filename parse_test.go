@@ -0,0 +1,122 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParser(t *testing.T) {
+	t1 := time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC)
+	t2 := t1.Add(time.Second)
+
+	input := strings.Join([]string{
+		"continuation before any event",
+		"2015-09-01 14:22:36 [Info] tag1, tag2: Info message",
+		"2015-09-01 14:22:36 [Debug] : Multi",
+		"line message [with] brackets",
+		"2015-09-01 14:22:36 [Error] key=value: Error: with colon",
+		"2015-09-01 14:22:36 [Fatal] : Fatal message, goroutine 1 [running]:",
+		"main.main()",
+		"\t/path/to/main.go:10 +0x10",
+		"2015-09-01 14:22:36 [Unknown] tag: message",
+		"2015-09-01 14:22:37 [Warn] no separator",
+		"panic: something went wrong",
+		"",
+		"goroutine 1 [running]:",
+		"main.main()",
+		"2015-09-01 14:22:37 [Thumb] tag:",
+		"2015-09-01 14:22:37 [Info] : Windows line ending\r",
+	}, "\n")
+
+	expected := []Event{
+		{Type: ErrorEvent, Message: ErrNoEvent.Error(), Data: &ParseError{
+			Line: 1, Raw: "continuation before any event", Err: ErrNoEvent,
+		}},
+		{Type: InfoEvent, Timestamp: t1, Tags: Tags{"tag1", "tag2"}, Message: "Info message"},
+		{Type: DebugEvent, Timestamp: t1, Message: "Multi\nline message [with] brackets"},
+		{Type: ErrorEvent, Timestamp: t1, Tags: Tags{"key=value"}, Message: "Error: with colon"},
+		{Type: FatalEvent, Timestamp: t1, Message: "Fatal message, goroutine 1 [running]:\nmain.main()\n\t/path/to/main.go:10 +0x10"},
+		{Type: ErrorEvent, Timestamp: t1, Message: `logger: unknown EventType: "Unknown"`, Data: &ParseError{
+			Line: 9, Raw: "2015-09-01 14:22:36 [Unknown] tag: message", Err: &EventTypeError{"Unknown", ErrEventTypeUnknown},
+		}},
+		{Type: ErrorEvent, Timestamp: t2, Message: ErrNoTagsSeparator.Error(), Data: &ParseError{
+			Line: 10, Raw: "2015-09-01 14:22:37 [Warn] no separator", Err: ErrNoTagsSeparator,
+		}},
+		{Type: ErrorEvent, Message: ErrStackTrace.Error(), Data: &ParseError{
+			Line: 11, Raw: "panic: something went wrong\n\ngoroutine 1 [running]:\nmain.main()", Err: ErrStackTrace,
+		}},
+		{Type: ThumbEvent, Timestamp: t2, Tags: Tags{"tag"}, Message: ""},
+		{Type: InfoEvent, Timestamp: t2, Message: "Windows line ending"},
+	}
+
+	parser := NewParser(strings.NewReader(input), TimestampFormat{})
+	var got []Event
+	for parser.Scan() {
+		got = append(got, parser.Event())
+	}
+	if err := parser.Err(); err != nil {
+		t.Fatal("Unexpected error parsing: " + err.Error())
+	}
+
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %d events, but got %d: %v", len(expected), len(got), got)
+	}
+	for i, event := range got {
+		if !reflect.DeepEqual(event, expected[i]) {
+			t.Errorf("Expected event #%d to be %#v, but got %#v", i, expected[i], event)
+		}
+	}
+}
+
+func TestParserRoundTrip(t *testing.T) {
+	t1 := time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC)
+	events := []Event{
+		{Type: InfoEvent, Timestamp: t1, Tags: Tags{"tag"}, Message: "Info message"},
+		{Type: WarnEvent, Timestamp: t1, Message: "Multi\nline\nmessage"},
+		{Type: ErrorEvent, Timestamp: t1, Tags: Tags{"a", "b=c"}, Message: "Error message"},
+	}
+
+	var input []byte
+	for _, event := range events {
+		input = event.AppendText(input, RFC3339Timestamp)
+		input = append(input, '\n')
+	}
+
+	parser := NewParser(strings.NewReader(string(input)), RFC3339Timestamp)
+	for i := 0; parser.Scan(); i++ {
+		if got := parser.Event(); !reflect.DeepEqual(got, events[i]) {
+			t.Errorf("Expected event #%d to be %v, but got %v", i, events[i], got)
+		}
+	}
+	if err := parser.Err(); err != nil {
+		t.Fatal("Unexpected error parsing: " + err.Error())
+	}
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errors.New("read error")
+}
+
+func TestParserError(t *testing.T) {
+	parser := NewParser(errReader{}, TimestampFormat{})
+	if parser.Scan() {
+		t.Fatal("Expected Scan to return false")
+	}
+	if err := parser.Err(); err == nil || err.Error() != "read error" {
+		t.Fatalf("Expected the read error, but got %v", err)
+	}
+
+	err := &ParseError{Line: 2, Err: ErrNoEvent}
+	if got, expected := err.Error(), "logger: unable to parse line 2: continuation line without an event"; got != expected {
+		t.Fatalf("Expected error %q, but got %q", expected, got)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import "fmt"
+
+// Preset is a set of sensible defaults to start the logger with, see
+// StartPreset. Use DevelopmentPreset or ProductionPreset as a starting point.
+type Preset struct {
+	// MinType is the minimum EventType of the events logged, see
+	// SetMinEventType.
+	MinType EventType
+
+	// Pretty writes the events to standard out, see NewPrettyEventWriter,
+	// rather then as JSON, see NewJSONFileEventWriter.
+	Pretty bool
+
+	// Path is the path of the file the JSON events are written to, if empty
+	// they're written to standard out, e.g. to be collected from a container.
+	// Only used if Pretty is false.
+	Path string
+
+	// Sampling, if not nil, enables adaptive sampling of the events, see
+	// AdaptiveSampler.
+	Sampling *AdaptiveSampling
+
+	// Writers are additional EventWriters the events are written to.
+	Writers []EventWriter
+}
+
+// DevelopmentPreset returns the Preset used by StartDevelopment: all events,
+// from Debug up, pretty printed to standard out.
+func DevelopmentPreset() Preset {
+	return Preset{MinType: DebugEvent, Pretty: true}
+}
+
+// ProductionPreset returns the Preset used by StartProduction: Info and higher
+// events, written as JSON to the file at path, with adaptive sampling to keep
+// the overhead of logging bounded under load. Error and higher events are
+// never sampled.
+func ProductionPreset(path string) Preset {
+	return Preset{MinType: InfoEvent, Path: path, Sampling: &AdaptiveSampling{}}
+}
+
+// StartDevelopment starts the logger with the DevelopmentPreset, for local
+// development. For example:
+//
+//	func main() {
+//		logger.StartDevelopment()
+//		defer logger.Close()
+//
+//		logger.Debug(logger.Tags{"main"}, "Starting")
+//	}
+func StartDevelopment() {
+	if err := StartPreset(DevelopmentPreset()); err != nil {
+		// Writing to standard out doesn't require any setup, so this can't
+		// happen.
+		panic(err)
+	}
+}
+
+// StartProduction starts the logger with the ProductionPreset, writing to the
+// file at path, or standard out if empty. To change any of the defaults use
+// StartPreset instead, e.g.:
+//
+//	preset := logger.ProductionPreset("/var/log/app.log")
+//	preset.Writers = []logger.EventWriter{alertWriter}
+//	if err := logger.StartPreset(preset); err != nil {
+//		panic(err)
+//	}
+func StartProduction(path string) error {
+	return StartPreset(ProductionPreset(path))
+}
+
+// StartPreset sets the options of the Preset, e.g. SetMinEventType, and starts
+// the logger, see Start. It only returns an error if the file can't be opened.
+// Other options can be set before calling it, like before calling Start.
+func StartPreset(preset Preset) error {
+	var ew EventWriter
+	switch {
+	case preset.Pretty:
+		ew = NewPrettyEventWriter(preset.MinType, stdout, presetErrorHandler)
+	case preset.Path != "":
+		var err error
+		if ew, err = NewJSONFileEventWriter(preset.MinType, preset.Path); err != nil {
+			return err
+		}
+	default:
+		ew = NewJSONEventWriter(preset.MinType, stdout, presetErrorHandler)
+	}
+
+	SetMinEventType(preset.MinType)
+	if preset.Sampling != nil {
+		SetSampler(NewAdaptiveSampler(*preset.Sampling).Sample)
+	}
+	Start(append([]EventWriter{ew}, preset.Writers...)...)
+	return nil
+}
+
+// presetErrorHandler writes errors of the EventWriters writing to standard out
+// to standard error.
+func presetErrorHandler(err error) {
+	fmt.Fprintf(stderr, "logger: error writing to standard out: %s\n", err)
+}
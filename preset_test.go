@@ -0,0 +1,94 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStartDevelopment(t *testing.T) {
+	defer reset()
+	var buf bytes.Buffer
+	oldStdout := stdout
+	defer func() { stdout = oldStdout }()
+	stdout = &buf
+
+	StartDevelopment()
+	Trace(nil, "Trace message")
+	Debug(Tags{"dev"}, "Debug message")
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	got := buf.String()
+	if strings.Contains(got, "Trace message") || !strings.Contains(got, "Debug message") {
+		t.Fatalf("Expected only the Debug event to be written, but got %q", got)
+	} else if strings.HasPrefix(got, "{") {
+		t.Fatalf("Expected the events to be pretty printed, but got %q", got)
+	}
+}
+
+func TestStartProduction(t *testing.T) {
+	defer reset()
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := StartProduction(path); err != nil {
+		t.Fatal("Unexpected error starting: " + err.Error())
+	}
+	if sampler == nil {
+		t.Fatal("Expected a Sampler to be set")
+	}
+
+	Debug(nil, "Debug message")
+	Info(Tags{"prod"}, "Info message")
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal("Unexpected error reading file: " + err.Error())
+	}
+	expected := `{"schema_version":1,"type":"Info","timestamp":"2015-09-01T14:22:36Z","seq":1,"tags":["prod"],"message":"Info message"}` + "\n"
+	if got := string(b); got != expected {
+		t.Fatalf("Expected file to contain:\n%s\nBut got:\n%s", expected, got)
+	}
+}
+
+func TestStartPreset(t *testing.T) {
+	defer reset()
+	var buf bytes.Buffer
+	oldStdout := stdout
+	defer func() { stdout = oldStdout }()
+	stdout = &buf
+
+	var ew eventWriter
+	preset := ProductionPreset("")
+	preset.MinType = WarnEvent
+	preset.Sampling = nil
+	preset.Writers = []EventWriter{&ew}
+	if err := StartPreset(preset); err != nil {
+		t.Fatal("Unexpected error starting: " + err.Error())
+	}
+	Info(nil, "Info message")
+	Warn(nil, "Warn message")
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if got := buf.String(); !strings.HasPrefix(got, `{"schema_version":1,"type":"Warn"`) || strings.Count(got, "\n") != 1 {
+		t.Fatalf("Expected a single JSON event on standard out, but got %q", got)
+	} else if len(ew.events) != 1 || ew.events[0].Message != "Warn message" {
+		t.Fatalf("Expected the Warn event to be written to the additional writer, but got %v", ew.events)
+	}
+
+	preset.Path = filepath.Join(t.TempDir(), "missing", "app.log")
+	if err := StartPreset(preset); err == nil {
+		t.Fatal("Expected an error opening the file")
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+const defaultPreStartBufferSize = 256
+
+var (
+	preStartMu         sync.Mutex
+	preStartEvents     []Event
+	preStartBufferSize = defaultPreStartBufferSize
+	preStartDropped    int
+)
+
+// SetPreStartBuffer sets the maximum number of events buffered before Start is
+// called, defaults to 256. Once Start is called the buffered events are send
+// to the EventWriters, before any other event, so events logged during the
+// initialisation of the program, e.g. errors parsing the configuration, aren't
+// lost. Events logged once the buffer is full are dropped, this is reported in
+// a Notice event once the logger is started, which is written to the
+// diagnostics EventWriter if set, see SetDiagnosticsEventWriter. Zero disables
+// the buffering, making all log operations before Start no-ops.
+//
+// Note: this must be called before Start and is not safe for concurrent use.
+func SetPreStartBuffer(size int) {
+	preStartMu.Lock()
+	defer preStartMu.Unlock()
+	preStartBufferSize = size
+	preStartEvents = nil
+	preStartDropped = 0
+}
+
+// bufferPreStart buffers the event if the logger is not yet started, it
+// returns false if the logger is started and the event should be send.
+func bufferPreStart(event Event) bool {
+	preStartMu.Lock()
+	defer preStartMu.Unlock()
+	if atomic.LoadUint32(&running) != loggerNotStarted {
+		return false
+	}
+
+	if len(preStartEvents) < preStartBufferSize {
+		preStartEvents = append(preStartEvents, event)
+	} else if preStartBufferSize > 0 {
+		preStartDropped++
+	}
+	return true
+}
+
+// startRunning sends the events buffered before Start to the event channel and
+// marks the logger as running. Log operations that wait on the buffer are
+// send after the buffered events, so the order is kept.
+func startRunning() {
+	preStartMu.Lock()
+	defer preStartMu.Unlock()
+	for _, event := range preStartEvents {
+		eventChannel <- event
+	}
+	if preStartDropped != 0 {
+		msg := fmt.Sprintf("Dropped %d events logged before Start, the buffer was full", preStartDropped)
+		logInternal(Event{NoticeEvent, now(), Tags{"logger"}, msg, nil, 0})
+	}
+	preStartEvents, preStartDropped = nil, 0
+	atomic.StoreUint32(&running, loggerRunning)
+}
@@ -0,0 +1,85 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestPreStartBuffer(t *testing.T) {
+	defer reset()
+	SetPreStartBuffer(3)
+
+	for i := 0; i < 5; i++ {
+		Info(Tags{"init"}, strconv.Itoa(i))
+	}
+	var ew eventWriter
+	Start(&ew)
+	Info(Tags{"main"}, "5")
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	// The Notice is an internal event, which isn't ordered with the other
+	// events.
+	const notice = "Dropped 2 events logged before Start, the buffer was full"
+	var got []string
+	var gotNotice bool
+	for _, event := range ew.events {
+		if event.Message == notice {
+			gotNotice = true
+		} else {
+			got = append(got, event.Message)
+		}
+	}
+	expected := []string{"0", "1", "2", "5"}
+	if !gotNotice {
+		t.Fatalf("Expected the %q Notice, but got %v", notice, ew.events)
+	} else if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected events %v, but got %v", expected, got)
+	}
+}
+
+func TestPreStartBufferDiagnostics(t *testing.T) {
+	defer reset()
+	SetPreStartBuffer(1)
+	var diagnostics eventWriter
+	SetDiagnosticsEventWriter(&diagnostics)
+
+	Info(nil, "0")
+	Info(nil, "1")
+	var ew eventWriter
+	Start(&ew)
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if len(ew.events) != 1 || ew.events[0].Message != "0" {
+		t.Fatalf("Expected only the buffered event, but got %v", ew.events)
+	}
+	expected := "Dropped 1 events logged before Start, the buffer was full"
+	if len(diagnostics.events) != 1 || diagnostics.events[0].Message != expected {
+		t.Fatalf("Expected the Notice to be written to the diagnostics EventWriter, but got %v", diagnostics.events)
+	}
+}
+
+func TestPreStartBufferMinEventType(t *testing.T) {
+	defer reset()
+	SetMinEventType(InfoEvent)
+
+	Debug(nil, "Debug message")
+	Info(nil, "Info message")
+	var ew eventWriter
+	Start(&ew)
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if len(ew.events) != 1 || ew.events[0].Message != "Info message" {
+		t.Fatalf("Expected only the Info event, but got %v", ew.events)
+	}
+}
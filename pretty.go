@@ -0,0 +1,161 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/Thomasdezeeuw/logger/internal/util"
+)
+
+// PrettyFormatter formats events in a human-oriented, multi-line format,
+// intended for local development. The timestamp, type and message are printed
+// on the first line, followed by the tags, data fields and stack trace, if
+// any, as aligned and indented blocks. For example:
+//
+//	2015-09-01 14:22:36 Error  Message
+//	    tags: tag1, tag2
+//	    path: /home
+//	    user: 123
+//
+//	2015-09-01 14:22:36 Fatal  Message
+//	     tags: tag1
+//	    stack: main.main
+//	               /path/to/main.go:10
+//
+// Data maps are printed as fields, sorted by key, a stack trace (e.g. of a
+// Fatal event) is printed frame by frame and any other data is printed as
+// the "data" field.
+type PrettyFormatter struct {
+	// TimestampFormat used to format the timestamp, the zero value uses
+	// TimeFormat in UTC.
+	TimestampFormat TimestampFormat
+}
+
+const (
+	// prettyIndent is the indentation of the field blocks.
+	prettyIndent = "    "
+	// prettyTypeWidth is the width the EventType is padded to, the length
+	// of the longest builtin EventType.
+	prettyTypeWidth = 6
+)
+
+// Format appends the pretty formatted event to buf.
+func (formatter PrettyFormatter) Format(buf []byte, event Event) ([]byte, error) {
+	buf = formatter.TimestampFormat.Append(buf, event.Timestamp)
+	buf = append(buf, ' ')
+	eventType := event.Type.String()
+	buf = append(buf, eventType...)
+	for i := len(eventType); i < prettyTypeWidth; i++ {
+		buf = append(buf, ' ')
+	}
+	buf = append(buf, ' ')
+	buf = append(buf, event.Message...)
+	buf = append(buf, newLine)
+
+	var keys, values []string
+	if len(event.Tags) != 0 {
+		keys = append(keys, "tags")
+		values = append(values, event.Tags.String())
+	}
+	keys, values = appendPrettyData(keys, values, event.Data)
+
+	width := 0
+	for _, key := range keys {
+		if len(key) > width {
+			width = len(key)
+		}
+	}
+
+	// Multi-line values are indented to line up with the value on the first
+	// line.
+	valueIndent := "\n" + prettyIndent + strings.Repeat(" ", width+2)
+	for i, key := range keys {
+		buf = append(buf, prettyIndent...)
+		for j := len(key); j < width; j++ {
+			buf = append(buf, ' ')
+		}
+		buf = append(buf, key...)
+		buf = append(buf, ": "...)
+		buf = append(buf, strings.Replace(values[i], "\n", valueIndent, -1)...)
+		buf = append(buf, newLine)
+	}
+	return buf, nil
+}
+
+func (formatter PrettyFormatter) withTimestampFormat(format TimestampFormat) Formatter {
+	formatter.TimestampFormat = format
+	return formatter
+}
+
+// appendPrettyData appends the data as key-value pairs to keys and values.
+func appendPrettyData(keys, values []string, data interface{}) ([]string, []string) {
+	switch data := data.(type) {
+	case nil:
+	case map[string]string:
+		n := len(keys)
+		for key, value := range data {
+			keys = append(keys, key)
+			values = append(values, value)
+		}
+		sort.Sort(prettyFields{keys[n:], values[n:]})
+	case map[string]interface{}:
+		n := len(keys)
+		for key, value := range data {
+			keys = append(keys, key)
+			values = append(values, util.InterfaceToString(value))
+		}
+		sort.Sort(prettyFields{keys[n:], values[n:]})
+	case []StackFrame:
+		keys = append(keys, "stack")
+		values = append(values, prettyStackFrames(data))
+	case []byte:
+		if frames, ok := parseStackTrace(data); ok {
+			keys = append(keys, "stack")
+			values = append(values, prettyStackFrames(frames))
+			break
+		}
+		keys = append(keys, "data")
+		values = append(values, string(data))
+	default:
+		keys = append(keys, "data")
+		values = append(values, util.InterfaceToString(data))
+	}
+	return keys, values
+}
+
+// prettyFields sorts key-value pairs by key.
+type prettyFields struct {
+	keys, values []string
+}
+
+func (f prettyFields) Len() int           { return len(f.keys) }
+func (f prettyFields) Less(i, j int) bool { return f.keys[i] < f.keys[j] }
+func (f prettyFields) Swap(i, j int) {
+	f.keys[i], f.keys[j] = f.keys[j], f.keys[i]
+	f.values[i], f.values[j] = f.values[j], f.values[i]
+}
+
+// prettyStackFrames returns the frames with the file indented below the
+// function.
+func prettyStackFrames(frames []StackFrame) string {
+	lines := make([]string, len(frames))
+	for i, frame := range frames {
+		lines[i] = strings.Replace(frame.String(), "\t", prettyIndent, 1)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// NewPrettyEventWriter creates a new EventWriter that writes pretty formatted
+// events, see PrettyFormatter, to the given writer. This is intended for
+// local development, use a structured format in production. MinType is the
+// minimal EventType an event must have to be logged. For example if minType
+// is InfoEvent, then any events with an EventType of DebugEvent will not be
+// logged.
+func NewPrettyEventWriter(minType EventType, w io.Writer, errorHandler func(error)) EventWriter {
+	return NewFormatEventWriter(minType, w, PrettyFormatter{}, errorHandler)
+}
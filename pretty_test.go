@@ -0,0 +1,85 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPrettyFormatter(t *testing.T) {
+	t1 := now()
+	stackTrace := []byte("goroutine 1 [running]:\n" +
+		"main.main()\n" +
+		"\t/path/to/main.go:10 +0x20\n")
+
+	tests := []struct {
+		event    Event
+		expected string
+	}{
+		{Event{Type: InfoEvent, Timestamp: t1, Message: "Message"},
+			"2015-09-01 14:22:36 Info   Message\n"},
+		{Event{Type: WarnEvent, Timestamp: t1, Tags: Tags{"tag1", "tag2"}, Message: "Message"},
+			"2015-09-01 14:22:36 Warn   Message\n" +
+				"    tags: tag1, tag2\n"},
+		{Event{Type: ErrorEvent, Timestamp: t1, Tags: Tags{"tag1"}, Message: "Message",
+			Data: map[string]interface{}{"user": 123, "path": "/home", "a": "multi\nline"}},
+			"2015-09-01 14:22:36 Error  Message\n" +
+				"    tags: tag1\n" +
+				"       a: multi\n" +
+				"          line\n" +
+				"    path: /home\n" +
+				"    user: 123\n"},
+		{Event{Type: DebugEvent, Timestamp: t1, Message: "Message",
+			Data: map[string]string{"key": "value"}},
+			"2015-09-01 14:22:36 Debug  Message\n" +
+				"    key: value\n"},
+		{Event{Type: FatalEvent, Timestamp: t1, Tags: Tags{"tag1"}, Message: "Message", Data: stackTrace},
+			"2015-09-01 14:22:36 Fatal  Message\n" +
+				"     tags: tag1\n" +
+				"    stack: main.main\n" +
+				"               /path/to/main.go:10\n"},
+		{Event{Type: ErrorEvent, Timestamp: t1, Message: "Message", Data: []StackFrame{
+			{"main.fn", "/main.go", 20}, {"main.main", "/main.go", 10}}},
+			"2015-09-01 14:22:36 Error  Message\n" +
+				"    stack: main.fn\n" +
+				"               /main.go:20\n" +
+				"           main.main\n" +
+				"               /main.go:10\n"},
+		{Event{Type: InfoEvent, Timestamp: t1, Message: "Message", Data: []byte("some data")},
+			"2015-09-01 14:22:36 Info   Message\n" +
+				"    data: some data\n"},
+	}
+
+	for _, test := range tests {
+		got, err := PrettyFormatter{}.Format(nil, test.event)
+		if err != nil {
+			t.Fatal("Unexpected error formatting event: " + err.Error())
+		} else if string(got) != test.expected {
+			t.Fatalf("Expected the event to be formatted as:\n%s\nBut got:\n%s", test.expected, got)
+		}
+	}
+}
+
+func TestPrettyEventWriter(t *testing.T) {
+	var buf bytes.Buffer
+	ew := NewPrettyEventWriter(InfoEvent, &buf, func(error) {})
+
+	events := []Event{
+		{Type: DebugEvent, Timestamp: now(), Message: "Debug message"},
+		{Type: InfoEvent, Timestamp: now(), Tags: Tags{"tag1"}, Message: "Info message"},
+	}
+	for _, event := range events {
+		if err := ew.Write(event); err != nil {
+			t.Fatal("Unexpected error writing event: " + err.Error())
+		}
+	}
+
+	expected := "2015-09-01 14:22:36 Info   Info message\n" +
+		"    tags: tag1\n"
+	if got := buf.String(); got != expected {
+		t.Fatalf("Expected buffer to contain:\n%s\nBut got:\n%s", expected, got)
+	}
+}
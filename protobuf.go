@@ -0,0 +1,342 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger/internal/protobuf"
+	"github.com/Thomasdezeeuw/logger/internal/util"
+)
+
+// Field numbers, see event.proto.
+const (
+	protoType          = 1
+	protoTimestamp     = 2
+	protoTags          = 3
+	protoMessage       = 4
+	protoFields        = 5
+	protoStackFrames   = 6
+	protoData          = 7
+	protoSeq           = 8
+	protoSchemaVersion = 9
+
+	protoTimestampSeconds = 1
+	protoTimestampNanos   = 2
+
+	protoFrameFunction = 1
+	protoFrameFile     = 2
+	protoFrameLine     = 3
+
+	protoMapKey   = 1
+	protoMapValue = 2
+)
+
+// MarshalProtobuf converts the event into the Protocol Buffers format, the
+// schema can be found in the event.proto file.
+//
+// Data is encoded based on its type. Maps are encoded as fields (with the
+// values converted into strings), []StackFrame and stack traces created by
+// Fatal are encoded as stack frames. All other data is converted into a string.
+func (event Event) MarshalProtobuf() ([]byte, error) {
+	return event.AppendProtobuf(nil), nil
+}
+
+// AppendProtobuf does the same as Event.MarshalProtobuf, but appends the
+// event to the given buffer and returns the extended buffer.
+func (event Event) AppendProtobuf(buf []byte) []byte {
+	buf = protobuf.AppendVarintField(buf, protoSchemaVersion, SchemaVersion)
+	buf = protobuf.AppendStringField(buf, protoType, event.Type.String())
+
+	var ts []byte
+	ts = protobuf.AppendVarintField(ts, protoTimestampSeconds, uint64(event.Timestamp.Unix()))
+	ts = protobuf.AppendVarintField(ts, protoTimestampNanos, uint64(event.Timestamp.Nanosecond()))
+	buf = protobuf.AppendBytesField(buf, protoTimestamp, ts)
+
+	for _, tag := range event.Tags {
+		buf = protobuf.AppendBytesField(buf, protoTags, []byte(tag))
+	}
+	buf = protobuf.AppendStringField(buf, protoMessage, event.Message)
+
+	switch data := event.Data.(type) {
+	case nil:
+	case map[string]string:
+		buf = appendProtobufFields(buf, data)
+	case map[string]interface{}:
+		fields := make(map[string]string, len(data))
+		for key, value := range data {
+			fields[key] = util.InterfaceToString(value)
+		}
+		buf = appendProtobufFields(buf, fields)
+	case []StackFrame:
+		buf = appendProtobufFrames(buf, data)
+	case []byte:
+		if frames, ok := parseStackTrace(data); ok {
+			buf = appendProtobufFrames(buf, frames)
+			break
+		}
+		buf = protobuf.AppendBytesField(buf, protoData, data)
+	default:
+		buf = protobuf.AppendStringField(buf, protoData, util.InterfaceToString(data))
+	}
+
+	if event.Seq != 0 {
+		buf = protobuf.AppendVarintField(buf, protoSeq, event.Seq)
+	}
+	return buf
+}
+
+func appendProtobufFields(buf []byte, fields map[string]string) []byte {
+	// Sort the keys to get a stable output.
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var entry []byte
+	for _, key := range keys {
+		entry = protobuf.AppendStringField(entry[:0], protoMapKey, key)
+		entry = protobuf.AppendStringField(entry, protoMapValue, fields[key])
+		buf = protobuf.AppendBytesField(buf, protoFields, entry)
+	}
+	return buf
+}
+
+func appendProtobufFrames(buf []byte, frames []StackFrame) []byte {
+	var frameBuf []byte
+	for _, frame := range frames {
+		frameBuf = protobuf.AppendStringField(frameBuf[:0], protoFrameFunction, frame.Function)
+		frameBuf = protobuf.AppendStringField(frameBuf, protoFrameFile, frame.File)
+		frameBuf = protobuf.AppendVarintField(frameBuf, protoFrameLine, uint64(frame.Line))
+		buf = protobuf.AppendBytesField(buf, protoStackFrames, frameBuf)
+	}
+	return buf
+}
+
+// protobufDecoders decode events in the Protocol Buffers format, indexed by
+// the schema version, see SchemaVersion.
+var protobufDecoders = [SchemaVersion + 1]func([]byte) (Event, error){
+	0: decodeProtobufEvent, // Before the version was added, same as version 1.
+	1: decodeProtobufEvent,
+}
+
+// UnmarshalProtobuf converts an event in the Protocol Buffers format, as
+// created by Event.MarshalProtobuf, back into an Event. The event is decoded
+// based on its schema_version field, see SchemaVersion.
+//
+// Fields are set as Data with type map[string]string, stack frames as
+// []StackFrame and other data as a string.
+//
+// Note: custom EventTypes are supported, but must created using NewEventType.
+func (event *Event) UnmarshalProtobuf(data []byte) error {
+	version, err := protobufSchemaVersion(data)
+	if err != nil {
+		return err
+	}
+	e, err := protobufDecoders[decodeVersion(version)](data)
+	if err != nil {
+		return err
+	}
+	*event = e
+	return nil
+}
+
+// protobufSchemaVersion returns the schema version of the event, or 0 if it
+// doesn't have one.
+func protobufSchemaVersion(data []byte) (uint64, error) {
+	for len(data) > 0 {
+		field, n, err := protobuf.ConsumeField(data)
+		if err != nil {
+			return 0, err
+		}
+		data = data[n:]
+		if field.Number == protoSchemaVersion {
+			return field.Varint, nil
+		}
+	}
+	return 0, nil
+}
+
+// decodeProtobufEvent decodes an event in version 1 of the Protocol Buffers
+// format.
+func decodeProtobufEvent(data []byte) (Event, error) {
+	var d protobufEventDecoder
+	for len(data) > 0 {
+		field, n, err := protobuf.ConsumeField(data)
+		if err != nil {
+			return Event{}, err
+		}
+		data = data[n:]
+		if err := d.decodeField(field); err != nil {
+			return Event{}, err
+		}
+	}
+
+	if d.fields != nil {
+		d.event.Data = d.fields
+	} else if d.frames != nil {
+		d.event.Data = d.frames
+	}
+	if d.event.Tags == nil {
+		d.event.Tags = Tags{}
+	}
+	return d.event, nil
+}
+
+// protobufEventDecoder holds the state of decodeProtobufEvent.
+type protobufEventDecoder struct {
+	event  Event
+	fields map[string]string
+	frames []StackFrame
+}
+
+func (d *protobufEventDecoder) decodeField(field protobuf.Field) (err error) {
+	switch field.Number {
+	case protoType:
+		return d.event.Type.UnmarshalText(field.Bytes)
+	case protoTimestamp:
+		d.event.Timestamp, err = unmarshalProtobufTimestamp(field.Bytes)
+	case protoTags:
+		d.event.Tags = append(d.event.Tags, string(field.Bytes))
+	case protoMessage:
+		d.event.Message = string(field.Bytes)
+	case protoFields:
+		return d.decodeMapEntry(field.Bytes)
+	case protoStackFrames:
+		var frame StackFrame
+		frame, err = unmarshalProtobufFrame(field.Bytes)
+		d.frames = append(d.frames, frame)
+	case protoData:
+		d.event.Data = string(field.Bytes)
+	case protoSeq:
+		d.event.Seq = field.Varint
+	}
+	return err
+}
+
+func (d *protobufEventDecoder) decodeMapEntry(data []byte) error {
+	key, value, err := unmarshalProtobufMapEntry(data)
+	if err != nil {
+		return err
+	}
+	if d.fields == nil {
+		d.fields = make(map[string]string)
+	}
+	d.fields[key] = value
+	return nil
+}
+
+func unmarshalProtobufTimestamp(data []byte) (time.Time, error) {
+	var seconds, nanos int64
+	for len(data) > 0 {
+		field, n, err := protobuf.ConsumeField(data)
+		if err != nil {
+			return time.Time{}, err
+		}
+		data = data[n:]
+
+		switch field.Number {
+		case protoTimestampSeconds:
+			seconds = int64(field.Varint)
+		case protoTimestampNanos:
+			nanos = int64(field.Varint)
+		}
+	}
+	return time.Unix(seconds, nanos).UTC(), nil
+}
+
+func unmarshalProtobufMapEntry(data []byte) (key, value string, err error) {
+	for len(data) > 0 {
+		field, n, err := protobuf.ConsumeField(data)
+		if err != nil {
+			return "", "", err
+		}
+		data = data[n:]
+
+		switch field.Number {
+		case protoMapKey:
+			key = string(field.Bytes)
+		case protoMapValue:
+			value = string(field.Bytes)
+		}
+	}
+	return key, value, nil
+}
+
+func unmarshalProtobufFrame(data []byte) (frame StackFrame, err error) {
+	for len(data) > 0 {
+		field, n, err := protobuf.ConsumeField(data)
+		if err != nil {
+			return frame, err
+		}
+		data = data[n:]
+
+		switch field.Number {
+		case protoFrameFunction:
+			frame.Function = string(field.Bytes)
+		case protoFrameFile:
+			frame.File = string(field.Bytes)
+		case protoFrameLine:
+			frame.Line = int(field.Varint)
+		}
+	}
+	return frame, nil
+}
+
+// ProtobufFormatter formats events in the Protocol Buffers format, see
+// Event.MarshalProtobuf, prefixed with the length of the event encoded as a
+// varint. Such a stream of events can be read using ReadProtobufEvent.
+type ProtobufFormatter struct{}
+
+// Format appends the length prefixed Protocol Buffers encoded event to buf.
+func (ProtobufFormatter) Format(buf []byte, event Event) ([]byte, error) {
+	// Reserve the maximum space for the length prefix, and move the event
+	// backwards once we know the length.
+	start := len(buf)
+	buf = append(buf, make([]byte, binary.MaxVarintLen64)...)
+	buf = event.AppendProtobuf(buf)
+
+	length := len(buf) - start - binary.MaxVarintLen64
+	prefix := protobuf.AppendVarint(buf[start:start], uint64(length))
+	n := copy(buf[start+len(prefix):], buf[start+binary.MaxVarintLen64:])
+	return buf[:start+len(prefix)+n], nil
+}
+
+// NewProtobufEventWriter creates a new EventWriter that writes length prefixed
+// Protocol Buffers encoded events, see ProtobufFormatter, to the given writer.
+// MinType is the minimal EventType an event must have to be logged. For
+// example if minType is InfoEvent, then any events with an EventType of
+// DebugEvent will not be logged.
+func NewProtobufEventWriter(minType EventType, w io.Writer, errorHandler func(error)) EventWriter {
+	return NewFormatEventWriter(minType, w, ProtobufFormatter{}, errorHandler)
+}
+
+// ReadProtobufEvent reads a single length prefixed event, as written by the
+// EventWriter created by NewProtobufEventWriter, from r. It returns io.EOF if
+// no more events are available.
+func ReadProtobufEvent(r *bufio.Reader) (Event, error) {
+	length, err := binary.ReadUvarint(r)
+	if err != nil {
+		return Event{}, err
+	}
+
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, r, int64(length)); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return Event{}, err
+	}
+
+	var event Event
+	err = event.UnmarshalProtobuf(buf.Bytes())
+	return event, err
+}
@@ -0,0 +1,84 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestEventProtobuf(t *testing.T) {
+	t.Parallel()
+
+	stackTrace := []byte("goroutine 1 [running]:\nmain.main()\n\t/main.go:10 +0x20\n")
+	tests := []struct {
+		event    Event
+		expected Event
+	}{
+		{Event{InfoEvent, t1, Tags{"tag1", "tag2"}, "Message", nil, 0},
+			Event{InfoEvent, t1, Tags{"tag1", "tag2"}, "Message", nil, 0}},
+		{Event{ErrorEvent, t1, Tags{}, "Message", user{1, "Thomas"}, 0},
+			Event{ErrorEvent, t1, Tags{}, "Message", "{1 Thomas}", 0}},
+		{Event{WarnEvent, t1, Tags{"tag"}, "", map[string]interface{}{"user": 1, "name": "Thomas"}, 0},
+			Event{WarnEvent, t1, Tags{"tag"}, "", map[string]string{"user": "1", "name": "Thomas"}, 0}},
+		{Event{FatalEvent, t1, Tags{"tag"}, "Message", stackTrace, 0},
+			Event{FatalEvent, t1, Tags{"tag"}, "Message", []StackFrame{{"main.main", "/main.go", 10}}, 0}},
+		{Event{InfoEvent, t1, Tags{}, "Message", nil, 1 << 40},
+			Event{InfoEvent, t1, Tags{}, "Message", nil, 1 << 40}},
+	}
+
+	for _, test := range tests {
+		data, err := test.event.MarshalProtobuf()
+		if err != nil {
+			t.Fatal("Unexpected error marshaling event: " + err.Error())
+		}
+
+		var got Event
+		if err := got.UnmarshalProtobuf(data); err != nil {
+			t.Fatal("Unexpected error unmarshaling event: " + err.Error())
+		} else if !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("Expected unmarshaled event to be %#v, but got %#v", test.expected, got)
+		}
+	}
+
+	var event Event
+	if err := event.UnmarshalProtobuf([]byte{0x0a, 0x01, 'X'}); !errors.Is(err, ErrEventTypeUnknown) {
+		t.Fatalf("Expected an unknown EventType error, but got %v", err)
+	}
+}
+
+func TestProtobufEventWriter(t *testing.T) {
+	var buf bytes.Buffer
+	ew := NewProtobufEventWriter(InfoEvent, &buf, func(error) {})
+
+	events := []Event{
+		{InfoEvent, t1, Tags{"tag1"}, "Message1", nil, 0},
+		{DebugEvent, t1, Tags{"tag1"}, "Never gets logged", nil, 0},
+		{ErrorEvent, t1, Tags{"tag2"}, string(make([]byte, 200)), nil, 0},
+	}
+	for _, event := range events {
+		if err := ew.Write(event); err != nil {
+			t.Fatal("Unexpected error writing to ProtobufEventWriter: " + err.Error())
+		}
+	}
+
+	r := bufio.NewReader(&buf)
+	for _, expected := range []Event{events[0], events[2]} {
+		got, err := ReadProtobufEvent(r)
+		if err != nil {
+			t.Fatal("Unexpected error reading event: " + err.Error())
+		} else if !reflect.DeepEqual(got, expected) {
+			t.Fatalf("Expected to read event %v, but got %v", expected, got)
+		}
+	}
+
+	if _, err := ReadProtobufEvent(r); err != io.EOF {
+		t.Fatalf("Expected io.EOF after reading all events, but got %v", err)
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import "time"
+
+var reattachCooldown time.Duration
+
+// SetReattachCooldown enables the re-attachment of bad EventWriters. Rather
+// then dropping a bad EventWriter for good, it's tried again after the
+// cooldown, by writing the next event to it. If the write succeeds the
+// EventWriter is re-attached and receives events again, otherwise it's tried
+// again after another cooldown. Events logged while the EventWriter is bad are
+// dropped for it. This way e.g. a restarted syslog daemon receives events again
+// without restarting the application.
+//
+// Both dropping and re-attaching an EventWriter is reported using an event
+// tagged with "logger", see SetDiagnosticsEventWriter. A cooldown of zero, the
+// default, disables the re-attachment.
+//
+// Note: this must be called before Start and is not safe for concurrent use.
+func SetReattachCooldown(cooldown time.Duration) {
+	reattachCooldown = cooldown
+}
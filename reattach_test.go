@@ -0,0 +1,67 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// recoveringEventWriter is a flakyEventWriter that signals bad once it's
+// considered bad.
+type recoveringEventWriter struct {
+	flakyEventWriter
+	bad chan struct{}
+}
+
+func (ew *recoveringEventWriter) HandleError(err error) {
+	ew.flakyEventWriter.HandleError(err)
+	if errors.Is(err, ErrBadEventWriter) {
+		close(ew.bad)
+	}
+}
+
+func TestSetReattachCooldown(t *testing.T) {
+	defer reset()
+	SetReattachCooldown(20 * time.Millisecond)
+	// The diagnostics EventWriter receives all internal events, even those send
+	// while closing.
+	var diag eventWriter
+	SetDiagnosticsEventWriter(&diag)
+	rew := recoveringEventWriter{
+		flakyEventWriter: flakyEventWriter{failures: maxNWriteErrors},
+		bad:              make(chan struct{}),
+	}
+	Start(&rew)
+
+	Info(Tags{"reattach"}, "Info message 1")
+	<-rew.bad
+	Info(Tags{"reattach"}, "Info message 2")
+	time.Sleep(40 * time.Millisecond)
+	Info(Tags{"reattach"}, "Info message 3")
+	Info(Tags{"reattach"}, "Info message 4")
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if len(rew.events) != 2 || rew.events[0].Message != "Info message 3" ||
+		rew.events[1].Message != "Info message 4" {
+		t.Fatalf("Expected the EventWriter to be re-attached, but got %v", rew.events)
+	}
+
+	expected := []Event{
+		{Type: ErrorEvent, Message: "EventWriter *logger.recoveringEventWriter is bad and is dropped, retrying in 20ms"},
+		{Type: NoticeEvent, Message: "EventWriter *logger.recoveringEventWriter recovered and is re-attached, dropped 2 events"},
+	}
+	if len(diag.events) != len(expected) {
+		t.Fatalf("Expected the events %v, but got %v", expected, diag.events)
+	}
+	for i, event := range diag.events {
+		if event.Type != expected[i].Type || event.Message != expected[i].Message {
+			t.Fatalf("Expected the event %v, but got %v", expected[i], event)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+// Recover recovers from a panic, logs it as a Fatal event and then closes the
+// logger, synchronously writing all events to the EventWriters, before
+// panicking again with the same value. Since logging is asynchronous a panic
+// that crashes the program would otherwise lose the buffered events, which are
+// usually the most important ones. Recover must be deferred directly, for
+// example at the start of main or of a goroutine:
+//
+//	func main() {
+//		logger.Start(ew)
+//		defer logger.Close()
+//		defer logger.Recover(logger.Tags{"main"})
+//
+//		// Do work.
+//	}
+//
+// After Recover closed the logger all log operations are dropped, so it should
+// only be used for panics that crash the program. If there is no panic Recover
+// does nothing. To exit the program without a panic see Exit.
+func Recover(tags Tags) {
+	recv := recover()
+	if recv == nil {
+		return
+	}
+
+	sendEvent(fatalEvent(tags, recv, getStackTrace()))
+	if started && !closed {
+		// The panic is more important then any error closing.
+		Close()
+	}
+	panic(recv)
+}
@@ -0,0 +1,63 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	defer reset()
+	var ew eventWriter
+	Start(&ew)
+
+	var recv interface{}
+	func() {
+		defer func() {
+			recv = recover()
+		}()
+		defer Recover(Tags{"recover"})
+
+		Info(Tags{"recover"}, "Info message")
+		panic("Panic message")
+	}()
+
+	if recv != "Panic message" {
+		t.Fatalf("Expected the panic to be propagated, but got %v", recv)
+	} else if !ew.closed {
+		t.Fatal("Expected the logger to be closed")
+	} else if len(ew.events) != 2 {
+		t.Fatalf("Expected 2 events, but got %d", len(ew.events))
+	}
+
+	event := ew.events[1]
+	if event.Type != FatalEvent || event.Message != "Panic message" {
+		t.Fatalf("Expected a Fatal event with the panic message, but got %v", event)
+	}
+	stackTrace, ok := event.Data.([]byte)
+	if !ok || !bytes.HasPrefix(stackTrace, []byte("goroutine")) {
+		t.Fatalf("Expected a stack trace, but got %v", event.Data)
+	} else if bytes.Contains(stackTrace, []byte("logger.Recover")) ||
+		bytes.Contains(stackTrace, []byte("logger.getStackTrace")) {
+		t.Errorf("Expected the stack trace to not contain Recover, but got:\n%s", stackTrace)
+	}
+}
+
+func TestRecoverNoPanic(t *testing.T) {
+	defer reset()
+	var ew eventWriter
+	Start(&ew)
+
+	func() {
+		defer Recover(Tags{"recover"})
+	}()
+
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	} else if len(ew.events) != 0 {
+		t.Fatalf("Expected no events, but got %v", ew.events)
+	}
+}
@@ -0,0 +1,150 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// retentionCheckInterval is the minimum interval between checks of the
+// retention of the log directory.
+const retentionCheckInterval = time.Minute
+
+// Retention controls how many log files are kept in the directory of the file
+// written by the EventWriter created by NewFileEventWriter, see WithRetention.
+// Zero values means no limit.
+type Retention struct {
+	// MaxBytes is the maximum total size of the log files in the directory,
+	// including the file currently being written.
+	MaxBytes int64
+
+	// MaxAge is the maximum age of a log file, based on the last modification
+	// time.
+	MaxAge time.Duration
+
+	// Pattern matches the log files in the directory that are subject to the
+	// retention, see filepath.Match. Defaults to "*.log".
+	Pattern string
+}
+
+// ErrRetentionUnsupported is returned by WithRetention if the EventWriter
+// doesn't support retention.
+var ErrRetentionUnsupported = errors.New("logger: EventWriter doesn't support retention")
+
+// WithRetention adds retention controls to the EventWriter created by
+// NewFileEventWriter and returns it, for other EventWriters
+// ErrRetentionUnsupported is returned. Log files in the directory of the file
+// that are older then the MaxAge, or exceed the MaxBytes in total, are
+// removed, oldest first. The file currently being written is never removed.
+// The retention is enforced directly and at most once a minute when events are
+// written. If files are removed a Warn event is written to the file, listing
+// the number of removed files.
+//
+// Note: this must be called before the EventWriter is passed to Start.
+func WithRetention(ew EventWriter, retention Retention) (EventWriter, error) {
+	fileEW, ok := ew.(*fileEventWriter)
+	if !ok {
+		return nil, ErrRetentionUnsupported
+	}
+
+	if retention.Pattern == "" {
+		retention.Pattern = "*.log"
+	}
+	fileEW.retention = &retention
+	fileEW.purge()
+	return ew, nil
+}
+
+// logFile is a file subject to the retention.
+type logFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// logFiles returns the regular files at the paths, except the current file,
+// oldest first, and their total size.
+func logFiles(paths []string, current string) ([]logFile, int64) {
+	var files []logFile
+	var total int64
+	current = filepath.Clean(current)
+	for _, path := range paths {
+		if filepath.Clean(path) == current {
+			continue
+		}
+		stat, err := os.Stat(path)
+		if err != nil || !stat.Mode().IsRegular() {
+			continue
+		}
+		files = append(files, logFile{path, stat.Size(), stat.ModTime()})
+		total += stat.Size()
+	}
+
+	// Oldest first.
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+	return files, total
+}
+
+// removeLogFiles removes the files, oldest first, that exceed the retention,
+// given the total size of all log files. It returns the number of files
+// removed and their size.
+func (ew *fileEventWriter) removeLogFiles(files []logFile, total int64) (int, int64) {
+	retention := ew.retention
+	var removed int
+	var removedBytes int64
+	for _, file := range files {
+		tooOld := retention.MaxAge > 0 && ew.lastPurge.Sub(file.modTime) > retention.MaxAge
+		tooBig := retention.MaxBytes > 0 && total > retention.MaxBytes
+		if !tooOld && !tooBig {
+			continue
+		}
+
+		if err := os.Remove(file.path); err != nil {
+			ew.HandleError(err)
+			continue
+		}
+		removed++
+		removedBytes += file.size
+		total -= file.size
+	}
+	return removed, removedBytes
+}
+
+// purge removes the log files that exceed the retention and writes a warning
+// if any files are removed.
+func (ew *fileEventWriter) purge() {
+	ew.lastPurge = now()
+	dir := filepath.Dir(ew.path)
+	paths, err := filepath.Glob(filepath.Join(dir, ew.retention.Pattern))
+	if err != nil {
+		ew.HandleError(err)
+		return
+	}
+
+	var total int64
+	if err := ew.w.Flush(); err != nil {
+		ew.HandleError(err)
+	} else if stat, err := ew.f.Stat(); err == nil {
+		total = stat.Size()
+	}
+
+	files, size := logFiles(paths, ew.path)
+	removed, removedBytes := ew.removeLogFiles(files, total+size)
+
+	if removed > 0 {
+		msg := "Purged " + strconv.Itoa(removed) + " log files (" +
+			strconv.FormatInt(removedBytes, 10) + " bytes) to enforce the retention"
+		event := Event{Type: WarnEvent, Timestamp: ew.lastPurge, Tags: Tags{"FileEventWriter"}, Message: msg}
+		ew.buf = append(appendText(ew.buf[:0], event, ew.timestampFormat, ew.escape), '\n')
+		ew.w.Write(ew.buf)
+	}
+}
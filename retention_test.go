@@ -0,0 +1,98 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// createLogFiles creates files of different sizes and ages in dir.
+func createLogFiles(t *testing.T, dir string) {
+	// Relative to the stubbed now.
+	t1 := now()
+	files := []struct {
+		name string
+		size int
+		age  time.Duration
+	}{
+		{"very-old.log", 10, 48 * time.Hour},
+		{"old.log", 10, 3 * time.Hour},
+		{"older.log", 10, 4 * time.Hour},
+		{"new.log", 10, time.Hour},
+		{"other.txt", 100, 48 * time.Hour},
+	}
+	for _, file := range files {
+		path := filepath.Join(dir, file.name)
+		if err := ioutil.WriteFile(path, bytes.Repeat([]byte("a"), file.size), 0600); err != nil {
+			t.Fatal("Unexpected error writing file: " + err.Error())
+		}
+		modTime := t1.Add(-file.age)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal("Unexpected error changing file times: " + err.Error())
+		}
+	}
+}
+
+func TestWithRetention(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logger_retention")
+	if err != nil {
+		t.Fatal("Unexpected error creating temporary directory: " + err.Error())
+	}
+	defer os.RemoveAll(dir)
+	createLogFiles(t, dir)
+
+	path := filepath.Join(dir, "current.log")
+	ew, err := NewFileEventWriter(InfoEvent, path)
+	if err != nil {
+		t.Fatal("Unexpected error creating new file event writer: " + err.Error())
+	}
+	// The very old file is removed because of its age, the older and old file
+	// because of the size.
+	if ew, err = WithRetention(ew, Retention{MaxBytes: 15, MaxAge: 24 * time.Hour}); err != nil {
+		t.Fatal("Unexpected error adding retention: " + err.Error())
+	}
+	if err := ew.Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal("Unexpected error reading directory: " + err.Error())
+	}
+	var got []string
+	for _, info := range infos {
+		got = append(got, info.Name())
+	}
+	sort.Strings(got)
+	expected := []string{"current.log", "new.log", "other.txt"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected files %v, but got %v", expected, got)
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal("Unexpected error reading file: " + err.Error())
+	}
+	expectedContent := "2015-09-01 14:22:36 [Warn] FileEventWriter: Purged 3 log files (30 bytes) to enforce the retention\n"
+	if string(content) != expectedContent {
+		t.Fatalf("Expected file to contain %q, but got %q", expectedContent, content)
+	}
+}
+
+func TestWithRetentionUnsupported(t *testing.T) {
+	ew, err := WithRetention(NewConsoleEventWriter(InfoEvent), Retention{MaxBytes: 1})
+	if err != ErrRetentionUnsupported {
+		t.Fatalf("Expected ErrRetentionUnsupported, but got %v", err)
+	} else if ew != nil {
+		t.Fatalf("Expected no EventWriter, but got %v", ew)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import "errors"
+
+type typeRouteEventWriter struct {
+	routes   map[EventType]EventWriter
+	fallback EventWriter
+	// Last EventWriter written to, used to pass errors returned by it back to
+	// it. Write is never called concurrently so we don't need to lock it.
+	last EventWriter
+}
+
+func (ew *typeRouteEventWriter) Write(event Event) error {
+	w, ok := ew.routes[event.Type]
+	if !ok {
+		w = ew.fallback
+	}
+	if w == nil {
+		return nil
+	}
+	ew.last = w
+	return w.Write(event)
+}
+
+// HandleError passes the error to the EventWriter that returned it, or to all
+// EventWriters in case of ErrBadEventWriter.
+func (ew *typeRouteEventWriter) HandleError(err error) {
+	if !errors.Is(err, ErrBadEventWriter) && ew.last != nil {
+		ew.last.HandleError(err)
+		return
+	}
+	for _, w := range ew.eventWriters() {
+		w.HandleError(err)
+	}
+}
+
+// Close closes all EventWriters and returns the first error.
+func (ew *typeRouteEventWriter) Close() error {
+	var err error
+	for _, w := range ew.eventWriters() {
+		if er := w.Close(); er != nil && err == nil {
+			err = er
+		}
+	}
+	return err
+}
+
+// eventWriters returns all unique EventWriters, the fallback first.
+func (ew *typeRouteEventWriter) eventWriters() []EventWriter {
+	var ews []EventWriter
+	if ew.fallback != nil {
+		ews = append(ews, ew.fallback)
+	}
+outer:
+	for _, w := range ew.routes {
+		for _, seen := range ews {
+			if w == seen {
+				continue outer
+			}
+		}
+		ews = append(ews, w)
+	}
+	return ews
+}
+
+// NewTypeRouteEventWriter creates a new EventWriter that routes events based
+// on their EventType. Events with an EventType in routes are only written to
+// the corresponding EventWriter, all other events are written to the
+// fallback, which may be nil to drop the events. Closing the returned
+// EventWriter closes all given EventWriters.
+func NewTypeRouteEventWriter(routes map[EventType]EventWriter, fallback EventWriter) EventWriter {
+	return &typeRouteEventWriter{routes: routes, fallback: fallback}
+}
+
+// NewSecurityEventWriter creates a new EventWriter that writes events with
+// SecurityEvent as EventType only to the security EventWriter and all other
+// events to the fallback, which may be nil. This allows security events to be
+// stored separately, for example in a file with stricter permissions:
+//
+//	security, err := logger.NewFileEventWriter(logger.SecurityEvent, "/var/log/app/security.log")
+//	if err != nil {
+//		panic(err)
+//	}
+//	console := logger.NewConsoleEventWriter(logger.InfoEvent)
+//	logger.Start(logger.NewSecurityEventWriter(security, console))
+//
+//	logger.Security(logger.Tags{"auth"}, "User 123 failed to log in")
+//
+// To also write security events to the fallback, pass the fallback to Start
+// separately, e.g. logger.Start(console, logger.NewSecurityEventWriter(security,
+// nil)).
+func NewSecurityEventWriter(security, fallback EventWriter) EventWriter {
+	return NewTypeRouteEventWriter(map[EventType]EventWriter{SecurityEvent: security}, fallback)
+}
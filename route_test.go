@@ -0,0 +1,72 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSecurityEventWriter(t *testing.T) {
+	defer reset()
+	var security, fallback eventWriter
+	Start(NewSecurityEventWriter(&security, &fallback))
+
+	tags := Tags{"auth"}
+	Info(tags, "Info message")
+	Security(tags, "Security message")
+	Securityf(tags, "Security %s message", "formatted")
+	Error(tags, errors.New("Error message"))
+
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if !security.closed || !fallback.closed {
+		t.Fatal("Expected both EventWriters to be closed")
+	}
+
+	expectedMessages := func(name string, ew eventWriter, messages ...string) {
+		if len(ew.events) != len(messages) {
+			t.Fatalf("Expected %d events in the %s EventWriter, but got %d",
+				len(messages), name, len(ew.events))
+		}
+		for i, msg := range messages {
+			if got := ew.events[i].Message; got != msg {
+				t.Fatalf("Expected event #%d in the %s EventWriter to be %q, but got %q",
+					i, name, msg, got)
+			}
+		}
+	}
+	expectedMessages("security", security, "Security message", "Security formatted message")
+	expectedMessages("fallback", fallback, "Info message", "Error message")
+}
+
+func TestTypeRouteEventWriterErrors(t *testing.T) {
+	var eew errorEventWriter
+	var ew eventWriter
+	rew := NewTypeRouteEventWriter(map[EventType]EventWriter{ErrorEvent: &eew}, nil)
+
+	if err := rew.Write(Event{Type: InfoEvent}); err != nil {
+		t.Fatal("Unexpected error writing event without a route: " + err.Error())
+	}
+
+	err := rew.Write(Event{Type: ErrorEvent, Message: "Message"})
+	if err == nil {
+		t.Fatal("Expected a write error, but got none")
+	}
+	rew.HandleError(err)
+	if len(eew.errors) != 1 || eew.errors[0] != err {
+		t.Fatalf("Expected the error to be passed to the EventWriter, but got %v", eew.errors)
+	}
+
+	rew = NewTypeRouteEventWriter(map[EventType]EventWriter{ErrorEvent: &eew, FatalEvent: &eew}, &ew)
+	rew.HandleError(ErrBadEventWriter)
+	if len(eew.errors) != 2 || !errors.Is(eew.errors[1], ErrBadEventWriter) {
+		t.Fatalf("Expected ErrBadEventWriter to be passed once, but got %v", eew.errors)
+	} else if len(ew.errors) != 1 || !errors.Is(ew.errors[0], ErrBadEventWriter) {
+		t.Fatalf("Expected ErrBadEventWriter to be passed to the fallback, but got %v", ew.errors)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"hash/fnv"
+	"math"
+	"sync/atomic"
+)
+
+// Sampler decides whether or not an event is logged, it returns true if the
+// event should be kept. It must be safe for concurrent use, see SetSampler.
+type Sampler func(Event) bool
+
+var (
+	sampler Sampler
+
+	// Number of events dropped by the sampler, must be accessed atomically.
+	sampledEvents uint64
+)
+
+// SetSampler sets the Sampler used to drop events, before they're send to the
+// EventWriters. The Sampler is called in the goroutine calling the log
+// operation. A nil Sampler keeps all events, which is the default. The number
+// of dropped events is reported in Stats.Sampled.
+//
+// A Sampler can also be used for a single EventWriter, see Filtered.
+//
+// Note: this must be called before Start and is not safe for concurrent use.
+func SetSampler(s Sampler) {
+	sampler = s
+	atomic.StoreUint64(&sampledEvents, 0)
+}
+
+// sample returns true if the event should be kept.
+func sample(event Event) bool {
+	if sampler == nil || sampler(event) {
+		return true
+	}
+	atomic.AddUint64(&sampledEvents, 1)
+	return false
+}
+
+// HashSampler returns a Sampler that keeps the fraction rate, between 0 and 1,
+// of the keys returned by key. The key is hashed, so all events with the same
+// key are either kept or dropped, e.g. to keep all events of 1% of the users:
+//
+//	logger.SetSampler(logger.HashSampler(0.01, logger.TagKey("user")))
+//
+// Events for which key returns an empty string are always kept. To never drop
+// Error and Fatal events combine it with a check on the type:
+//
+//	sampler := logger.HashSampler(0.01, logger.TagKey("request"))
+//	logger.SetSampler(func(event logger.Event) bool {
+//		return event.Type >= logger.ErrorEvent || sampler(event)
+//	})
+func HashSampler(rate float64, key func(Event) string) Sampler {
+	if rate >= 1 {
+		return func(Event) bool { return true }
+	}
+	// Compare the hashes in float64, to avoid overflowing an uint64.
+	max := rate * math.MaxUint64
+	return func(event Event) bool {
+		k := key(event)
+		if k == "" {
+			return true
+		}
+		return float64(hashKey(k)) < max
+	}
+}
+
+// hashKey hashes the key using FNV-1a, the bits are mixed afterwards so that
+// similar keys, e.g. "user1" and "user2", are spread evenly.
+func hashKey(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// MessageKey returns the message of the event, to be used with HashSampler.
+func MessageKey(event Event) string {
+	return event.Message
+}
+
+// TagKey returns a function that returns the value of the key=value tag with
+// the key, to be used with HashSampler.
+func TagKey(key string) func(Event) string {
+	return func(event Event) string {
+		return event.Tags.Get(key)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestHashSampler(t *testing.T) {
+	sampler := HashSampler(0.1, TagKey("user"))
+
+	kept := 0
+	const n = 10000
+	for i := 0; i < n; i++ {
+		event := Event{Type: InfoEvent, Tags: Tags{Tag("user", i)}, Message: "msg"}
+		keep := sampler(event)
+		if keep {
+			kept++
+		}
+
+		// All events for the same key must be kept, or dropped.
+		event.Message = "other msg"
+		event.Type = ErrorEvent
+		if got := sampler(event); got != keep {
+			t.Fatalf("Expected all events for user %d to be kept (%t), but got %t", i, keep, got)
+		}
+	}
+	if kept < n/10-300 || kept > n/10+300 {
+		t.Fatalf("Expected about %d events to be kept, but got %d", n/10, kept)
+	}
+
+	if !sampler(Event{Type: InfoEvent, Message: "no user tag"}) {
+		t.Fatal("Expected an event without key to be kept")
+	}
+}
+
+func TestHashSamplerRates(t *testing.T) {
+	all, none := HashSampler(1, MessageKey), HashSampler(0, MessageKey)
+	for i := 0; i < 100; i++ {
+		event := Event{Type: InfoEvent, Message: strconv.Itoa(i)}
+		if !all(event) {
+			t.Fatalf("Expected event %d to be kept with a rate of 1", i)
+		} else if none(event) {
+			t.Fatalf("Expected event %d to be dropped with a rate of 0", i)
+		}
+	}
+}
+
+func TestSetSampler(t *testing.T) {
+	defer reset()
+	SetSampler(func(event Event) bool { return event.Message != "drop" })
+	var ew eventWriter
+	Start(&ew)
+
+	Info(nil, "keep")
+	Info(nil, "drop")
+	Info(nil, "drop")
+	if stats := ReadStats(); stats.Sampled != 2 {
+		t.Fatalf("Expected 2 sampled events, but got %d", stats.Sampled)
+	}
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if len(ew.events) != 1 || ew.events[0].Message != "keep" {
+		t.Fatalf("Expected only the kept event, but got %v", ew.events)
+	}
+}
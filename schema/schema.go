@@ -0,0 +1,146 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+// Package schema provides the JSON Schema of events serialized as JSON by the
+// logger package, e.g. by Event.MarshalJSON and the EventWriter created by
+// NewJSONEventWriter. The schema is published as event.schema.json and can be
+// used by downstream consumers, Validate can be used in contract tests.
+package schema
+
+import (
+	_ "embed" // For the schema.
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+)
+
+// JSON is the JSON Schema, as found in event.schema.json.
+//
+//go:embed event.schema.json
+var JSON []byte
+
+// ValidationError is returned by Validate if the event doesn't match the
+// schema.
+type ValidationError struct {
+	Field string // Empty if the error is not about a single field.
+	Msg   string
+}
+
+func (err *ValidationError) Error() string {
+	if err.Field == "" {
+		return "schema: invalid event: " + err.Msg
+	}
+	return fmt.Sprintf("schema: invalid event: field %q: %s", err.Field, err.Msg)
+}
+
+// Required fields, see the schema.
+var required = []string{"type", "timestamp", "tags", "message"}
+
+// Validate validates that the JSON serialized event matches the schema.
+func Validate(b []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		if _, ok := err.(*json.UnmarshalTypeError); ok {
+			return &ValidationError{Msg: "not an object"}
+		}
+		return &ValidationError{Msg: err.Error()}
+	} else if fields == nil {
+		return &ValidationError{Msg: "not an object"}
+	}
+
+	for _, name := range required {
+		if _, ok := fields[name]; !ok {
+			return &ValidationError{name, "missing"}
+		}
+	}
+
+	for name, value := range fields {
+		validate, ok := validators[name]
+		if !ok {
+			return &ValidationError{name, "unknown field"}
+		}
+		if err := validate(value); err != nil {
+			if _, ok := err.(errorString); !ok {
+				err = errorString("invalid type, " + string(value))
+			}
+			return &ValidationError{name, err.Error()}
+		}
+	}
+	return nil
+}
+
+// validators validate the value of the fields, indexed by the field name.
+// Errors other then errorString are reported as an invalid type.
+var validators = map[string]func(json.RawMessage) error{
+	"schema_version": validatePositiveInteger,
+	"type":           validateType,
+	"timestamp":      validateTimestamp,
+	"seq":            validatePositiveInteger,
+	"tags":           validateTags,
+	"fields":         validateFields,
+	"message":        validateString,
+	"data":           validateString,
+}
+
+func validateType(value json.RawMessage) error {
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return err
+	} else if s == "" {
+		return errorString("must not be empty")
+	}
+	return nil
+}
+
+func validateTimestamp(value json.RawMessage) error {
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return err
+	} else if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+		return errorString("not a date-time")
+	}
+	return nil
+}
+
+func validatePositiveInteger(value json.RawMessage) error {
+	var n float64
+	if err := json.Unmarshal(value, &n); err != nil {
+		return err
+	} else if n < 1 || n != math.Trunc(n) {
+		return errorString("must be a positive integer")
+	}
+	return nil
+}
+
+func validateTags(value json.RawMessage) error {
+	var tags []string
+	if err := json.Unmarshal(value, &tags); err != nil {
+		return err
+	} else if tags == nil {
+		return errorString("must be an array")
+	}
+	return nil
+}
+
+func validateFields(value json.RawMessage) error {
+	var m map[string]string
+	if err := json.Unmarshal(value, &m); err != nil {
+		return err
+	} else if m == nil {
+		return errorString("must be an object")
+	}
+	return nil
+}
+
+func validateString(value json.RawMessage) error {
+	var s string
+	return json.Unmarshal(value, &s)
+}
+
+type errorString string
+
+func (err errorString) Error() string {
+	return string(err)
+}
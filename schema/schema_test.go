@@ -0,0 +1,91 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package schema
+
+import (
+	"encoding/json"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+var t1 = time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC)
+
+func TestValidateEvents(t *testing.T) {
+	events := []logger.Event{
+		{Type: logger.InfoEvent, Timestamp: t1, Message: "Info message"},
+		{Type: logger.ErrorEvent, Timestamp: t1, Tags: logger.Tags{"db", "user=1"},
+			Message: "Error message", Data: map[string]int{"n": 1}, Seq: 2},
+	}
+
+	for _, event := range events {
+		b, err := event.MarshalJSON()
+		if err != nil {
+			t.Fatal("Unexpected error marshaling event: " + err.Error())
+		}
+		if err := Validate(b); err != nil {
+			t.Errorf("Unexpected error validating %s: %s", b, err.Error())
+		}
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[]`, "schema: invalid event: not an object"},
+		{`null`, "schema: invalid event: not an object"},
+		{`{"type":"Info","timestamp":"2015-09-01T14:22:36Z","tags":[]}`, `schema: invalid event: field "message": missing`},
+		{`{"type":"","timestamp":"2015-09-01T14:22:36Z","tags":[],"message":""}`, `schema: invalid event: field "type": must not be empty`},
+		{`{"type":"Info","timestamp":"yesterday","tags":[],"message":""}`, `schema: invalid event: field "timestamp": not a date-time`},
+		{`{"type":"Info","timestamp":"2015-09-01T14:22:36Z","tags":null,"message":""}`, `schema: invalid event: field "tags": must be an array`},
+		{`{"type":"Info","timestamp":"2015-09-01T14:22:36Z","tags":[1],"message":""}`, `schema: invalid event: field "tags": invalid type, [1]`},
+		{`{"type":"Info","timestamp":"2015-09-01T14:22:36Z","tags":[],"message":"","seq":0}`, `schema: invalid event: field "seq": must be a positive integer`},
+		{`{"schema_version":0,"type":"Info","timestamp":"2015-09-01T14:22:36Z","tags":[],"message":""}`, `schema: invalid event: field "schema_version": must be a positive integer`},
+		{`{"type":"Info","timestamp":"2015-09-01T14:22:36Z","tags":[],"message":"","fields":{"a":1}}`, `schema: invalid event: field "fields": invalid type, {"a":1}`},
+		{`{"type":"Info","timestamp":"2015-09-01T14:22:36Z","tags":[],"message":"","level":"Info"}`, `schema: invalid event: field "level": unknown field`},
+	}
+
+	for _, test := range tests {
+		err := Validate([]byte(test.input))
+		if err == nil || err.Error() != test.expected {
+			t.Errorf("Expected validating %s to return error %q, but got %v", test.input, test.expected, err)
+		}
+	}
+}
+
+// The schema and Validate must agree on the fields.
+func TestSchema(t *testing.T) {
+	var schema struct {
+		Required   []string                   `json:"required"`
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal(JSON, &schema); err != nil {
+		t.Fatal("Unexpected error unmarshaling the schema: " + err.Error())
+	}
+
+	got := append([]string(nil), schema.Required...)
+	sort.Strings(got)
+	expected := append([]string(nil), required...)
+	sort.Strings(expected)
+	if len(got) != len(expected) {
+		t.Fatalf("Expected the required fields to be %v, but got %v", expected, got)
+	}
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Fatalf("Expected the required fields to be %v, but got %v", expected, got)
+		}
+	}
+
+	for name := range schema.Properties {
+		b := []byte(`{"type":"Info","timestamp":"2015-09-01T14:22:36Z","tags":[],"message":"","` + name + `":null}`)
+		if err := Validate(b); err != nil && err.(*ValidationError).Msg == "unknown field" {
+			t.Errorf("Expected field %q to be known by Validate", name)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+// SchemaVersion is the version of the JSON and binary formats of events. It's
+// written as the "schema_version" field by Event.MarshalJSON,
+// Event.MarshalProtobuf, MsgpackFormatter and CBORFormatter, so consumers can
+// tell which version of the format they're reading.
+//
+// Events without a version were written before the field was added, their
+// format is the same as version 1. Newer versions only add fields, so
+// Event.UnmarshalJSON and Event.UnmarshalProtobuf decode events of a newer
+// version, e.g. written by a newer version of the program, as the latest
+// version they know, ignoring the unknown fields.
+const SchemaVersion = 1
+
+// decodeVersion returns the version of the decoder used to decode an event
+// with the given schema version.
+func decodeVersion(version uint64) uint64 {
+	if version > SchemaVersion {
+		return SchemaVersion
+	}
+	return version
+}
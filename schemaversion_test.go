@@ -0,0 +1,65 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Thomasdezeeuw/logger/internal/protobuf"
+)
+
+func TestSchemaVersionJSON(t *testing.T) {
+	expected := Event{Type: InfoEvent, Timestamp: t1, Tags: Tags{"tag1"}, Message: "Message"}
+	tests := []string{
+		// Before the version was added.
+		`{"type":"Info","timestamp":"2015-09-01T14:22:36Z","tags":["tag1"],"message":"Message"}`,
+		`{"schema_version":1,"type":"Info","timestamp":"2015-09-01T14:22:36Z","tags":["tag1"],"message":"Message"}`,
+		// Newer versions are decoded as the latest version, ignoring unknown
+		// fields.
+		`{"schema_version":2,"type":"Info","timestamp":"2015-09-01T14:22:36Z","tags":["tag1"],"message":"Message","new":{"a":1}}`,
+	}
+
+	for _, input := range tests {
+		var got Event
+		if err := got.UnmarshalJSON([]byte(input)); err != nil {
+			t.Errorf("Unexpected error unmarshaling %s: %s", input, err.Error())
+		} else if !reflect.DeepEqual(got, expected) {
+			t.Errorf("Expected unmarshaling %s to return %#v, but got %#v", input, expected, got)
+		}
+	}
+
+	var got Event
+	if err := got.UnmarshalJSON([]byte(`{"schema_version":-1,"type":"Info","timestamp":"2015-09-01T14:22:36Z","tags":[],"message":""}`)); err == nil {
+		t.Error("Expected an error unmarshaling a negative schema version")
+	}
+}
+
+func TestSchemaVersionProtobuf(t *testing.T) {
+	event := Event{Type: InfoEvent, Timestamp: t1, Tags: Tags{"tag1"}, Message: "Message"}
+	current := event.AppendProtobuf(nil)
+	if version, err := protobufSchemaVersion(current); err != nil || version != SchemaVersion {
+		t.Fatalf("Expected schema version %d, but got %d (error: %v)", SchemaVersion, version, err)
+	}
+
+	// Strip the version, as written before it was added.
+	_, n, err := protobuf.ConsumeField(current)
+	if err != nil {
+		t.Fatal("Unexpected error: " + err.Error())
+	}
+	old := current[n:]
+	newer := protobuf.AppendVarintField(nil, protoSchemaVersion, SchemaVersion+1)
+	newer = append(newer, old...)
+	newer = protobuf.AppendStringField(newer, 100, "unknown field")
+
+	for _, data := range [][]byte{old, current, newer} {
+		var got Event
+		if err := got.UnmarshalProtobuf(data); err != nil {
+			t.Errorf("Unexpected error unmarshaling % x: %s", data, err.Error())
+		} else if !reflect.DeepEqual(got, event) {
+			t.Errorf("Expected unmarshaling % x to return %#v, but got %#v", data, event, got)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	closeTimeout time.Duration
+
+	// Number of events logged after Close, must be accessed atomically.
+	afterCloseDropped uint64
+)
+
+// SetCloseTimeout sets the maximum time Close waits for the queued events to
+// be written. If the timeout passes Close gives up and returns an
+// *UnflushedError with the number of events that are not written. Zero, the
+// default, waits until all events are written.
+//
+// Note: this must be called before Start and is not safe for concurrent use.
+func SetCloseTimeout(timeout time.Duration) {
+	closeTimeout = timeout
+}
+
+// UnflushedError is returned by Close if it gave up waiting for the events to
+// be written, so the tail of the log can't be trusted.
+type UnflushedError struct {
+	// Pending is the number of events that were still queued when Close gave
+	// up waiting, see SetCloseTimeout.
+	Pending int
+
+	// Dropped is the number of events dropped because an EventWriter was bad,
+	// see WriterStats.Dropped.
+	Dropped uint64
+}
+
+func (err *UnflushedError) Error() string {
+	return fmt.Sprintf("logger: gave up closing with %d events pending, %d events dropped",
+		err.Pending, err.Dropped)
+}
+
+// DroppedAfterClose returns the number of events logged after Close was
+// called, these events are dropped.
+func DroppedAfterClose() uint64 {
+	return atomic.LoadUint64(&afterCloseDropped)
+}
+
+// waitClosed waits until all events are written, or the close timeout passes,
+// it returns false in the later case.
+func waitClosed(done <-chan struct{}) bool {
+	if closeTimeout <= 0 {
+		<-done
+		return true
+	}
+
+	timer := time.NewTimer(closeTimeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// pendingEvents returns the number of events queued, but not yet written.
+func pendingEvents(events chan Event, states []*writerState) int {
+	n := len(events)
+	for _, state := range states {
+		n += len(state.events)
+	}
+	return n
+}
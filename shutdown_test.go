@@ -0,0 +1,62 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCloseTimeout(t *testing.T) {
+	defer reset()
+	SetCloseTimeout(10 * time.Millisecond)
+	unblock := make(chan struct{})
+	writing := make(chan struct{}, 10)
+	Start(WriterFunc(func(Event) error {
+		writing <- struct{}{}
+		<-unblock
+		return nil
+	}))
+
+	for i := 0; i < 5; i++ {
+		Info(nil, "Info message")
+	}
+	<-writing
+
+	err := Close()
+	var unflushedErr *UnflushedError
+	if !errors.As(err, &unflushedErr) {
+		t.Fatalf("Expected an *UnflushedError, but got %v", err)
+	} else if unflushedErr.Pending != 4 || unflushedErr.Dropped != 0 {
+		t.Fatalf("Expected 4 pending events, but got %d (and %d dropped)",
+			unflushedErr.Pending, unflushedErr.Dropped)
+	}
+	expected := "logger: gave up closing with 4 events pending, 0 events dropped"
+	if got := err.Error(); got != expected {
+		t.Fatalf("Expected the error %q, but got %q", expected, got)
+	}
+
+	// Let the EventWriter finish.
+	close(unblock)
+	<-eventChannelClosed
+}
+
+func TestDroppedAfterClose(t *testing.T) {
+	defer reset()
+	var ew eventWriter
+	Start(&ew)
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	Info(nil, "Info message")
+	Errorf(nil, "Error message")
+	if got := DroppedAfterClose(); got != 2 {
+		t.Fatalf("Expected 2 events dropped after Close, but got %d", got)
+	} else if len(ew.events) != 0 {
+		t.Fatalf("Expected no events, but got %v", ew.events)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"runtime"
+	"sync"
+)
+
+var signalTags = Tags{"logger", "signal"}
+
+var (
+	// Minimum EventType restored after debug logging is disabled again.
+	debugMu      sync.Mutex
+	debugRestore = InfoEvent
+)
+
+// toggleDebug enables debug logging, by lowering the minimum EventType to
+// DebugEvent, or disables it again, by restoring the previous minimum
+// EventType, or InfoEvent if debug logging was enabled from the start.
+func toggleDebug() {
+	debugMu.Lock()
+	defer debugMu.Unlock()
+
+	if current := MinEventType(); current > DebugEvent {
+		debugRestore = current
+		SetMinEventType(DebugEvent)
+		Notice(signalTags, "Debug logging enabled")
+	} else {
+		// Log before restoring, otherwise the event might be dropped.
+		Notice(signalTags, "Debug logging disabled")
+		SetMinEventType(debugRestore)
+	}
+}
+
+// dumpPipeline logs the statistics of the logger and a dump of the stacks of
+// all goroutines.
+func dumpPipeline() {
+	Log(Event{Type: NoticeEvent, Tags: signalTags, Message: "Pipeline stats", Data: ReadStats()})
+	Log(Event{Type: NoticeEvent, Tags: signalTags, Message: "Goroutine dump", Data: string(allStacks())})
+}
+
+// allStacks returns the stack traces of all goroutines.
+func allStacks() []byte {
+	buf := make([]byte, defaultStackSize)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
@@ -0,0 +1,14 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package logger
+
+// HandleSignals does nothing on this platform, see the documentation on
+// platforms that support SIGUSR1 and SIGUSR2.
+func HandleSignals() (stop func()) {
+	return func() {}
+}
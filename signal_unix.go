@@ -0,0 +1,52 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package logger
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// HandleSignals handles the signals commonly used to control long-running
+// daemons. On SIGUSR1 debug logging is toggled, see SetMinEventType, and on
+// SIGUSR2 the statistics of the logger (see Stats) and a dump of the stacks of
+// all goroutines are logged as Notice events. All events are tagged with
+// "logger" and "signal".
+//
+// The returned function stops handling the signals, it must be called before
+// the logger is closed. On platforms without these signals HandleSignals does
+// nothing.
+func HandleSignals() (stop func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-done:
+				return
+			case sig := <-signals:
+				if sig == syscall.SIGUSR1 {
+					toggleDebug()
+				} else {
+					dumpPipeline()
+				}
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+		<-stopped
+	}
+}
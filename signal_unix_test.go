@@ -0,0 +1,99 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package logger
+
+import (
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// signalEventWriter signals every event tagged with "signal".
+type signalEventWriter struct {
+	mu     sync.Mutex
+	events []Event
+	signal chan struct{}
+}
+
+func (ew *signalEventWriter) Write(event Event) error {
+	ew.mu.Lock()
+	ew.events = append(ew.events, event)
+	ew.mu.Unlock()
+	if strings.Contains(event.Tags.String(), "signal") {
+		ew.signal <- struct{}{}
+	}
+	return nil
+}
+
+func (ew *signalEventWriter) HandleError(err error) {}
+func (ew *signalEventWriter) Close() error          { return nil }
+
+// wait waits for an event tagged with "signal".
+func (ew *signalEventWriter) wait(t *testing.T) {
+	select {
+	case <-ew.signal:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for signal event")
+	}
+}
+
+func TestHandleSignals(t *testing.T) {
+	defer reset()
+	ew := signalEventWriter{signal: make(chan struct{}, 10)}
+	Start(&ew)
+	stop := HandleSignals()
+	wait := func() { ew.wait(t) }
+
+	SetMinEventType(WarnEvent)
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	wait()
+	if got := MinEventType(); got != DebugEvent {
+		t.Fatalf("Expected debug logging to be enabled, but the minimum EventType is %s", got)
+	}
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	wait()
+	// The minimum EventType is restored after the event is logged.
+	for i := 0; MinEventType() != WarnEvent; i++ {
+		if i == 100 {
+			t.Fatalf("Expected the minimum EventType to be restored, but got %s", MinEventType())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	SetMinEventType(TraceEvent)
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)
+	wait()
+	wait()
+	stop()
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	checkSignalEvents(t, ew.events)
+}
+
+func checkSignalEvents(t *testing.T, events []Event) {
+	expected := []string{"Debug logging enabled", "Debug logging disabled",
+		"Pipeline stats", "Goroutine dump"}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d events, but got %v", len(expected), events)
+	}
+	for i, event := range events {
+		if event.Message != expected[i] {
+			t.Errorf("Expected event %d to have message %q, but got %q", i, expected[i], event.Message)
+		}
+	}
+	if stats, ok := events[2].Data.(Stats); !ok || stats.QueueCapacity != defaultEventChannelSize {
+		t.Errorf("Expected the stats as data, but got %#v", events[2].Data)
+	}
+	if dump, ok := events[3].Data.(string); !ok || !strings.Contains(dump, "goroutine ") {
+		t.Errorf("Expected a goroutine dump as data, but got %#v", events[3].Data)
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strconv"
+)
+
+// sourceContext is the number of lines before and after the panicking line
+// included in the source snippet.
+const sourceContext = 3
+
+var fatalSource bool
+
+// Stubbed for testing.
+var readFile = ioutil.ReadFile
+
+// SetFatalSource enables adding a snippet of the source code around the
+// panicking line to Fatal events, making post-mortem debugging from the logs a
+// lot faster. The snippet contains three lines before and after the line, if
+// the source is available on the machine, and is appended to the stack trace,
+// separated by an empty line, for example:
+//
+//	goroutine 1 [running]:
+//	main.main()
+//		/path/to/main.go:10 +0x10
+//
+//	/path/to/main.go:10
+//	   7 | func main() {
+//	   8 | 	defer recoverPanic()
+//	   9 |
+//	> 10 | 	panic("oops")
+//	  11 | }
+//
+// The panicking line is the line that called panic, or if the stack trace
+// doesn't contain a panic, the line that called Fatal.
+//
+// Note: this must be called before Start and is not safe for concurrent use.
+func SetFatalSource(enabled bool) {
+	fatalSource = enabled
+}
+
+// appendSourceSnippet appends the source snippet of the panicking frame to the
+// stack trace, if the source is available.
+func appendSourceSnippet(stackTrace []byte) []byte {
+	frames, ok := parseStackTrace(stackTrace)
+	if !ok || len(frames) == 0 {
+		return stackTrace
+	}
+
+	frame := frames[0]
+	for i, f := range frames[:len(frames)-1] {
+		if f.Function == "panic" || f.Function == "runtime.gopanic" {
+			frame = frames[i+1]
+			break
+		}
+	}
+
+	snippet, ok := sourceSnippet(frame.File, frame.Line)
+	if !ok {
+		return stackTrace
+	}
+
+	stackTrace = append(bytes.TrimRight(stackTrace, "\n"), "\n\n"...)
+	stackTrace = append(stackTrace, frame.File...)
+	stackTrace = append(stackTrace, ':')
+	stackTrace = strconv.AppendInt(stackTrace, int64(frame.Line), 10)
+	stackTrace = append(stackTrace, '\n')
+	return append(stackTrace, snippet...)
+}
+
+// sourceSnippet returns the lines around line n (starting at 1) of the file.
+func sourceSnippet(path string, n int) ([]byte, bool) {
+	source, err := readFile(path)
+	if err != nil {
+		return nil, false
+	}
+
+	lines := bytes.Split(source, []byte{newLine})
+	if n < 1 || n > len(lines) {
+		return nil, false
+	}
+
+	start, end := n-sourceContext, n+sourceContext
+	if start < 1 {
+		start = 1
+	}
+	if end > len(lines) {
+		end = len(lines)
+	}
+	width := len(strconv.Itoa(end))
+
+	var snippet []byte
+	for i := start; i <= end; i++ {
+		if i == n {
+			snippet = append(snippet, "> "...)
+		} else {
+			snippet = append(snippet, "  "...)
+		}
+		number := strconv.Itoa(i)
+		snippet = append(snippet, bytes.Repeat([]byte{' '}, width-len(number))...)
+		snippet = append(snippet, number...)
+		snippet = append(snippet, " |"...)
+		if line := bytes.TrimRight(lines[i-1], "\r"); len(line) != 0 {
+			snippet = append(snippet, ' ')
+			snippet = append(snippet, line...)
+		}
+		snippet = append(snippet, newLine)
+	}
+	return snippet, true
+}
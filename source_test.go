@@ -0,0 +1,79 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestAppendSourceSnippet(t *testing.T) {
+	oldReadFile := readFile
+	defer func() { readFile = oldReadFile }()
+	readFile = func(path string) ([]byte, error) {
+		if path != "/main.go" {
+			return nil, errors.New("file not found")
+		}
+		return []byte("package main\n\nfunc main() {\n\tdefer recoverPanic()\n\n\tpanic(\"oops\")\n}\n"), nil
+	}
+
+	stackTrace := []byte(`goroutine 1 [running]:
+main.recoverPanic()
+	/main.go:20 +0x10
+panic({0x1, 0x2})
+	/usr/local/go/src/runtime/panic.go:770 +0x132
+main.main()
+	/main.go:6 +0x10
+`)
+
+	expected := string(stackTrace) + `
+/main.go:6
+  3 | func main() {
+  4 | 	defer recoverPanic()
+  5 |
+> 6 | 	panic("oops")
+  7 | }
+  8 |
+`
+	got := appendSourceSnippet(stackTrace)
+	if string(got) != expected {
+		t.Fatalf("Expected the stack trace to be\n%s\nbut got\n%s", expected, got)
+	}
+
+	// The stack trace must still be recognised.
+	if frames, ok := parseStackTrace(got); !ok || len(frames) != 3 {
+		t.Fatalf("Expected the stack trace with snippet to be parsed, but got %v", frames)
+	}
+
+	// Source not available.
+	stackTrace = []byte("goroutine 1 [running]:\nmain.main()\n\t/other.go:6 +0x10\n")
+	if got := appendSourceSnippet(stackTrace); !bytes.Equal(got, stackTrace) {
+		t.Fatalf("Expected the stack trace to be unchanged, but got %s", got)
+	}
+}
+
+func TestFatalSource(t *testing.T) {
+	defer reset()
+	SetFatalSource(true)
+
+	var ew eventWriter
+	Start(&ew)
+	func() {
+		defer func() {
+			Fatal(Tags{"source"}, recover())
+		}()
+		panic("Fatal message")
+	}()
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	stackTrace := string(ew.events[0].Data.([]byte))
+	if !strings.Contains(stackTrace, "\n\n") || !strings.Contains(stackTrace, `| 		panic("Fatal message")`) {
+		t.Fatalf("Expected the stack trace to contain the source of the panic, but got:\n%s", stackTrace)
+	}
+}
@@ -0,0 +1,446 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger/internal/protobuf"
+)
+
+// The initial number of bytes read from the spill file at a time.
+const spillReadSize = 64 * 1024
+
+// spillQueue is an on-disk queue of events that didn't fit in the event
+// channel, see SetSpillQueue.
+type spillQueue struct {
+	dir     string
+	maxSize int64
+
+	// Mutex protecting the fields below, cond is signaled every time the
+	// number of pending events changes or the queue is stopping.
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	// The file is created once the first event is spilled, events are written
+	// at writeOff and replayed from readOff. Pending is the number of spilled
+	// events not yet replayed.
+	file              *os.File
+	readOff, writeOff int64
+	pending           int
+
+	// Pending events that can't be stored in the file without changing them,
+	// in order, see appendSpillRecord.
+	memory []Event
+
+	stopping bool
+	stopped  chan struct{}
+}
+
+var spill *spillQueue
+
+// SetSpillQueue enables spilling events to a temporary file in dir, or the
+// default directory for temporary files if dir is empty, once the event
+// channel is full. Rather then blocking log operations until the EventWriters
+// catch up, e.g. during an outage of a remote EventWriter, the events are
+// written to the file and replayed, in order, once there is room in the event
+// channel again. This bounds the memory used by the logger, without dropping
+// events.
+//
+// Spilled events are replayed unchanged. Events with data other then nil, a
+// string or []byte, or with a timestamp in a location other then UTC or Local,
+// can't be stored in the file without changing them, so they're kept in memory
+// until they're replayed and don't count towards maxSize. If the file can't be
+// read the spilled events are dropped, which is reported in an Error event written to the diagnostics EventWriter if set,
+// see SetDiagnosticsEventWriter. Once the file holds maxSize bytes log
+// operations block, like they would without spilling, until all spilled
+// events are replayed. Flush and Close wait for all spilled events to be
+// replayed, after which Close removes the file. If an event can't be written
+// to the file it's send to the event channel directly.
+//
+// Zero maxSize disables spilling, which is the default.
+//
+// Note: this must be called before Start and is not safe for concurrent use.
+func SetSpillQueue(dir string, maxSize int64) {
+	if maxSize <= 0 {
+		spill = nil
+		return
+	}
+	spill = &spillQueue{dir: dir, maxSize: maxSize}
+	spill.cond = sync.NewCond(&spill.mu)
+}
+
+// spillEvent sends the event to the event channel if there's room and no
+// events are spilled, otherwise it writes the event to the spill queue. It
+// returns false if the event should be send to the event channel, blocking.
+func spillEvent(event Event) bool {
+	q := spill
+	if q == nil {
+		return false
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var record []byte
+	var inMemory bool
+	for {
+		if q.stopping {
+			return false
+		} else if q.pending == 0 {
+			select {
+			case eventChannel <- event:
+				return true
+			default:
+			}
+		}
+
+		if record == nil {
+			record, inMemory = appendSpillRecord(nil, event)
+		}
+		if q.writeOff+int64(len(record)) <= q.maxSize {
+			break
+		} else if q.pending == 0 {
+			// The event is larger then the spill queue.
+			return false
+		}
+		q.cond.Wait()
+	}
+	return q.write(record, event, inMemory)
+}
+
+// write appends the record to the spill file, creating it if needed, and keeps
+// the event in memory if inMemory is true. It returns false if the record
+// can't be written. The mutex must be locked.
+func (q *spillQueue) write(record []byte, event Event, inMemory bool) bool {
+	if q.file == nil {
+		f, err := ioutil.TempFile(q.dir, "logger-spill-")
+		if err != nil {
+			return false
+		}
+		q.file = f
+	}
+	if _, err := q.file.WriteAt(record, q.writeOff); err != nil {
+		return false
+	}
+	q.writeOff += int64(len(record))
+	if inMemory {
+		q.memory = append(q.memory, event)
+	}
+	q.pending++
+	q.cond.Broadcast()
+	return true
+}
+
+// replay sends the spilled events to the event channel until the queue is
+// stopped, after which the spill file is removed.
+func (q *spillQueue) replay(events chan<- Event) {
+	defer close(q.stopped)
+	for {
+		q.mu.Lock()
+		for q.pending == 0 && !q.stopping {
+			q.cond.Wait()
+		}
+		if q.pending == 0 {
+			q.removeFile()
+			q.mu.Unlock()
+			return
+		}
+		// Events are only appended after writeOff and the file is only
+		// truncated once all events are replayed, so it's safe to read without
+		// holding the lock.
+		file, off, n := q.file, q.readOff, q.writeOff-q.readOff
+		q.mu.Unlock()
+
+		records, err := readSpilled(file, off, n)
+		if err != nil {
+			q.mu.Lock()
+			dropped := q.pending
+			q.pending = 0
+			q.truncate()
+			q.cond.Broadcast()
+			q.mu.Unlock()
+
+			msg := fmt.Sprintf("Dropped %d spilled events, reading the spill file failed: %s", dropped, err)
+			logInternal(Event{ErrorEvent, now(), Tags{"logger"}, msg, nil, 0})
+			continue
+		}
+
+		var count int
+		for len(records) != 0 {
+			length, n := binary.Uvarint(records)
+			end := n + int(length)
+			// Events that can't be decoded are skipped, they're written by
+			// spillEvent so this shouldn't happen.
+			if event, inMemory, err := decodeSpillRecord(records[n:end]); err == nil {
+				if inMemory {
+					event = q.popMemory()
+				}
+				events <- event
+			}
+			off += int64(end)
+			records = records[end:]
+			count++
+		}
+
+		q.mu.Lock()
+		q.readOff = off
+		q.pending -= count
+		if q.pending == 0 {
+			q.truncate()
+		}
+		q.cond.Broadcast()
+		q.mu.Unlock()
+	}
+}
+
+// popMemory removes and returns the oldest spilled event kept in memory.
+func (q *spillQueue) popMemory() Event {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	event := q.memory[0]
+	q.memory[0] = Event{}
+	q.memory = q.memory[1:]
+	return event
+}
+
+// Fields of a spill record, see appendSpillRecord.
+const (
+	spillFieldType = 1 + iota
+	spillFieldTimestamp
+	spillFieldHasTags
+	spillFieldTags
+	spillFieldMessage
+	spillFieldDataString
+	spillFieldDataBytes
+	spillFieldSeq
+	spillFieldInMemory
+)
+
+// errInvalidSpillRecord is returned by decodeSpillRecord if a field has an
+// unexpected wire type.
+var errInvalidSpillRecord = errors.New("invalid spill record")
+
+// appendSpillRecord appends the length prefixed record of the event to buf.
+// The record is a Protocol Buffers message, but unlike Event.MarshalProtobuf
+// it stores the event unchanged, so it's only valid within the process. If the
+// event can't be stored unchanged the record only marks its position and
+// inMemory is true, in which case the event must be kept in memory.
+func appendSpillRecord(buf []byte, event Event) (record []byte, inMemory bool) {
+	var msg []byte
+	if timestamp, ok := spillTimestamp(event); ok {
+		msg = appendSpillEvent(msg, event, timestamp)
+	} else {
+		msg = protobuf.AppendVarintField(msg, spillFieldInMemory, 1)
+		inMemory = true
+	}
+	buf = protobuf.AppendVarint(buf, uint64(len(msg)))
+	return append(buf, msg...), inMemory
+}
+
+// spillTimestamp returns the binary timestamp of the event, or false if the
+// event can't be stored in the spill file unchanged.
+func spillTimestamp(event Event) ([]byte, bool) {
+	switch event.Data.(type) {
+	case nil, string, []byte:
+	default:
+		return nil, false
+	}
+	// The binary format only stores the offset of the location, which is
+	// enough to restore UTC and Local.
+	if location := event.Timestamp.Location(); location != time.UTC && location != time.Local {
+		return nil, false
+	}
+	timestamp, err := event.Timestamp.MarshalBinary()
+	return timestamp, err == nil
+}
+
+// appendSpillEvent appends the fields of the event to buf.
+func appendSpillEvent(buf []byte, event Event, timestamp []byte) []byte {
+	buf = protobuf.AppendVarintField(buf, spillFieldType, uint64(event.Type))
+	buf = protobuf.AppendBytesField(buf, spillFieldTimestamp, timestamp)
+	if event.Tags != nil {
+		buf = protobuf.AppendVarintField(buf, spillFieldHasTags, 1)
+	}
+	for _, tag := range event.Tags {
+		buf = protobuf.AppendBytesField(buf, spillFieldTags, []byte(tag))
+	}
+	buf = protobuf.AppendStringField(buf, spillFieldMessage, event.Message)
+	switch data := event.Data.(type) {
+	case string:
+		buf = protobuf.AppendBytesField(buf, spillFieldDataString, []byte(data))
+	case []byte:
+		buf = protobuf.AppendBytesField(buf, spillFieldDataBytes, data)
+	}
+	return protobuf.AppendVarintField(buf, spillFieldSeq, event.Seq)
+}
+
+// decodeSpillRecord decodes a record created by appendSpillRecord, without
+// the length prefix. If inMemory is true the event is kept in memory.
+func decodeSpillRecord(record []byte) (event Event, inMemory bool, err error) {
+	for len(record) > 0 {
+		field, n, err := protobuf.ConsumeField(record)
+		if err != nil {
+			return Event{}, false, err
+		}
+		record = record[n:]
+
+		switch field.Number {
+		case spillFieldTimestamp, spillFieldTags, spillFieldMessage, spillFieldDataString, spillFieldDataBytes:
+			if field.WireType != protobuf.WireBytes {
+				return Event{}, false, errInvalidSpillRecord
+			}
+		default:
+			if field.WireType != protobuf.WireVarint {
+				return Event{}, false, errInvalidSpillRecord
+			}
+		}
+		if field.Number == spillFieldInMemory {
+			inMemory = true
+		} else if err := decodeSpillField(&event, field); err != nil {
+			return Event{}, false, err
+		}
+	}
+	return event, inMemory, nil
+}
+
+// decodeSpillField decodes a single field of a spill record into event.
+func decodeSpillField(event *Event, field protobuf.Field) error {
+	switch field.Number {
+	case spillFieldType:
+		event.Type = EventType(field.Varint)
+	case spillFieldTimestamp:
+		return event.Timestamp.UnmarshalBinary(field.Bytes)
+	case spillFieldHasTags:
+		if event.Tags == nil {
+			event.Tags = Tags{}
+		}
+	case spillFieldTags:
+		event.Tags = append(event.Tags, string(field.Bytes))
+	case spillFieldMessage:
+		event.Message = string(field.Bytes)
+	case spillFieldDataString:
+		event.Data = string(field.Bytes)
+	case spillFieldDataBytes:
+		event.Data = append([]byte{}, field.Bytes...)
+	case spillFieldSeq:
+		event.Seq = field.Varint
+	}
+	return nil
+}
+
+// readSpilled reads the complete records, of at most n bytes, from the file
+// starting at off.
+func readSpilled(file *os.File, off, n int64) ([]byte, error) {
+	size := int64(spillReadSize)
+	for {
+		if size > n {
+			size = n
+		}
+		buf := make([]byte, size)
+		if _, err := file.ReadAt(buf, off); err != nil {
+			return nil, err
+		}
+		if complete, next := completeSpilled(buf); complete != 0 {
+			return buf[:complete], nil
+		} else if size == n {
+			return nil, fmt.Errorf("incomplete event at offset %d", off)
+		} else if next > size*2 {
+			size = next
+		} else {
+			size *= 2
+		}
+	}
+}
+
+// completeSpilled returns the number of bytes of the complete records in buf,
+// each an event prefixed with its length, and if the first record is
+// incomplete the size of it, if known.
+func completeSpilled(buf []byte) (int, int64) {
+	var complete int
+	for {
+		length, n := binary.Uvarint(buf[complete:])
+		if n <= 0 {
+			return complete, 0
+		} else if uint64(len(buf)-complete-n) < length {
+			return complete, int64(n) + int64(length)
+		}
+		complete += n + int(length)
+	}
+}
+
+// truncate empties the spill file. The mutex must be locked.
+func (q *spillQueue) truncate() {
+	q.readOff, q.writeOff = 0, 0
+	q.memory = nil
+	if q.file != nil {
+		q.file.Truncate(0)
+	}
+}
+
+// removeFile closes and removes the spill file, if any. The mutex must be
+// locked.
+func (q *spillQueue) removeFile() {
+	if q.file != nil {
+		q.file.Close()
+		os.Remove(q.file.Name())
+		q.file = nil
+	}
+	q.readOff, q.writeOff = 0, 0
+	q.memory = nil
+}
+
+// spilledEvents returns the number of spilled events waiting to be replayed.
+func spilledEvents() int {
+	q := spill
+	if q == nil {
+		return 0
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.pending
+}
+
+// startSpill starts the goroutine that replays the spilled events, if
+// spilling is enabled.
+func startSpill() {
+	if spill == nil {
+		return
+	}
+	spill.stopped = make(chan struct{})
+	go spill.replay(eventChannel)
+}
+
+// flushSpill waits until all spilled events are replayed.
+func flushSpill() {
+	q := spill
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	for q.pending != 0 {
+		q.cond.Wait()
+	}
+	q.mu.Unlock()
+}
+
+// stopSpill replays all spilled events and stops the replaying goroutine, if
+// running. Events logged afterwards are send to the event channel directly.
+func stopSpill() {
+	q := spill
+	if q == nil || q.stopped == nil {
+		return
+	}
+	q.mu.Lock()
+	q.stopping = true
+	q.cond.Broadcast()
+	q.mu.Unlock()
+	<-q.stopped
+}
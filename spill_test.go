@@ -0,0 +1,219 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// Duplicate keys must survive spilling.
+var spillTags = Tags{"spill", Tag("key", "1"), Tag("key", "2")}
+
+func TestSpillQueue(t *testing.T) {
+	defer reset()
+	dir := t.TempDir()
+	SetSpillQueue(dir, 1<<20)
+	eventChannel = make(chan Event, 1)
+
+	ew := blockingEventWriter{unblock: make(chan struct{})}
+	Start(&ew)
+
+	// The EventWriter blocks, so without spilling the log operations would
+	// block once all channels are full.
+	const n = 3 * defaultEventChannelSize
+	logged := make(chan struct{})
+	go func() {
+		for i := 0; i < n; i++ {
+			Info(spillTags, strconv.Itoa(i))
+		}
+		close(logged)
+	}()
+	select {
+	case <-logged:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the log operations not to block")
+	}
+
+	if got := ReadStats().Spilled; got == 0 {
+		t.Fatal("Expected events to be spilled")
+	} else if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Fatalf("Expected a single spill file, but got %v", files)
+	}
+
+	close(ew.unblock)
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	checkSpilledEvents(t, ew.events, n)
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Fatalf("Expected the spill file to be removed, but got %v", files)
+	}
+}
+
+// checkSpilledEvents checks that the n events are logged in order, with the
+// index as message.
+func checkSpilledEvents(t *testing.T, events []Event, n int) {
+	if len(events) != n {
+		t.Fatalf("Expected %d events, but got %d", n, len(events))
+	}
+	for i, event := range events {
+		if event.Message != strconv.Itoa(i) || !reflect.DeepEqual(event.Tags, spillTags) {
+			t.Fatalf("Expected event %d to be %q, but got %v", i, strconv.Itoa(i), event)
+		}
+	}
+}
+
+func TestSpillQueueFull(t *testing.T) {
+	defer reset()
+	SetSpillQueue(t.TempDir(), 512)
+	eventChannel = make(chan Event, 1)
+
+	ew := blockingEventWriter{unblock: make(chan struct{})}
+	Start(&ew)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		close(ew.unblock)
+	}()
+
+	// Once the spill queue is full the log operations block, but no events
+	// are dropped.
+	const n = 2 * defaultEventChannelSize
+	for i := 0; i < n; i++ {
+		Info(nil, strconv.Itoa(i))
+	}
+	if err := Flush(); err != nil {
+		t.Fatal("Unexpected error flushing: " + err.Error())
+	} else if got := ReadStats().Spilled; got != 0 {
+		t.Fatalf("Expected all spilled events to be replayed after Flush, but got %d", got)
+	}
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if len(ew.events) != n {
+		t.Fatalf("Expected %d events, but got %d", n, len(ew.events))
+	}
+	for i, event := range ew.events {
+		if event.Message != strconv.Itoa(i) {
+			t.Fatalf("Expected event %d to be %q, but got %v", i, strconv.Itoa(i), event)
+		}
+	}
+}
+
+func TestSpillQueueData(t *testing.T) {
+	defer reset()
+	SetSpillQueue(t.TempDir(), 1<<20)
+	eventChannel = make(chan Event, 1)
+
+	ew := blockingEventWriter{unblock: make(chan struct{})}
+	Start(&ew)
+
+	// Spilled events, including those that can't be stored in the spill file,
+	// must be replayed unchanged.
+	const n = 3 * defaultEventChannelSize
+	expected := spillDataEvents(n)
+
+	logged := make(chan struct{})
+	go func() {
+		for _, event := range expected {
+			Forward(event)
+		}
+		close(logged)
+	}()
+	select {
+	case <-logged:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the log operations not to block")
+	}
+	if ReadStats().Spilled == 0 {
+		t.Fatal("Expected events to be spilled")
+	}
+
+	close(ew.unblock)
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if len(ew.events) != n {
+		t.Fatalf("Expected %d events, but got %d", n, len(ew.events))
+	}
+	for i, event := range ew.events {
+		expected := expected[i]
+		expected.Seq = event.Seq
+		if !reflect.DeepEqual(event, expected) {
+			t.Fatalf("Expected event %d to be %#v, but got %#v", i, expected, event)
+		}
+	}
+}
+
+// spillDataEvents returns n events with different data, tags and timestamp
+// locations.
+func spillDataEvents(n int) []Event {
+	locations := []*time.Location{time.UTC, time.Local, time.FixedZone("CET", 3600)}
+	data := []interface{}{
+		nil,
+		"",
+		"string",
+		[]byte("stack trace"),
+		[]StackFrame{{"main.main", "/main.go", 10}},
+		map[string]int{"key": 1},
+		Tags{"data"},
+	}
+	events := make([]Event, n)
+	for i := range events {
+		events[i] = Event{
+			Type:      FatalEvent,
+			Timestamp: time.Date(2016, 1, 2, 3, 4, 5, 6, locations[i%len(locations)]),
+			Message:   strconv.Itoa(i),
+			Data:      data[i%len(data)],
+		}
+		if i%2 == 0 {
+			events[i].Tags = Tags{"", "spill"}
+		}
+	}
+	return events
+}
+
+func TestReadSpilled(t *testing.T) {
+	large := Event{Type: InfoEvent, Tags: Tags{}, Message: string(make([]byte, spillReadSize))}
+	var buf []byte
+	buf, _ = appendSpillRecord(buf, Event{Type: InfoEvent, Tags: Tags{}, Message: "small"})
+	small := len(buf)
+	buf, _ = appendSpillRecord(buf, large)
+	path := filepath.Join(t.TempDir(), "spill")
+	if err := ioutil.WriteFile(path, buf, 0644); err != nil {
+		t.Fatal("Unexpected error writing file: " + err.Error())
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal("Unexpected error opening file: " + err.Error())
+	}
+	defer file.Close()
+
+	// Only complete events are returned.
+	got, err := readSpilled(file, 0, int64(len(buf)))
+	if err != nil {
+		t.Fatal("Unexpected error reading: " + err.Error())
+	} else if len(got) != small {
+		t.Fatalf("Expected %d bytes, but got %d", small, len(got))
+	}
+	// The large event doesn't fit in the initial read size.
+	got, err = readSpilled(file, int64(small), int64(len(buf)-small))
+	if err != nil {
+		t.Fatal("Unexpected error reading: " + err.Error())
+	} else if len(got) != len(buf)-small {
+		t.Fatalf("Expected %d bytes, but got %d", len(buf)-small, len(got))
+	}
+	if _, err := readSpilled(file, int64(small), int64(len(buf)-small-1)); err == nil {
+		t.Fatal("Expected an error reading an incomplete event")
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"runtime"
+	"strconv"
+)
+
+// StackFrame is a single frame, or function call, in a stack trace.
+type StackFrame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+// String returns the frame in the following format:
+//
+//	function
+//		/path/to/file.go:line
+func (frame StackFrame) String() string {
+	return frame.Function + "\n\t" + frame.File + ":" + strconv.Itoa(frame.Line)
+}
+
+// ParseStackTrace parses a stack trace, as created by runtime.Stack, into
+// stack frames. The first line, containing the goroutine id, is ignored, as is
+// anything after an empty line, e.g. the source snippet added by Fatal. It
+// returns false if the stack trace doesn't have the expected format.
+func parseStackTrace(stackTrace []byte) ([]StackFrame, bool) {
+	if n := bytes.Index(stackTrace, []byte("\n\n")); n != -1 {
+		stackTrace = stackTrace[:n]
+	}
+	lines := bytes.Split(bytes.TrimSpace(stackTrace), []byte{newLine})
+	if len(lines) < 3 || !bytes.HasPrefix(lines[0], []byte("goroutine ")) {
+		return nil, false
+	}
+
+	lines = lines[1:]
+	frames := make([]StackFrame, 0, len(lines)/2)
+	for i := 0; i+1 < len(lines); i += 2 {
+		fn, location := lines[i], bytes.TrimSpace(lines[i+1])
+
+		// Drop the arguments from the function.
+		if n := bytes.LastIndexByte(fn, '('); n > 0 {
+			fn = fn[:n]
+		}
+
+		// Drop the program counter offset from the location.
+		if n := bytes.LastIndex(location, []byte(" +0x")); n != -1 {
+			location = location[:n]
+		}
+
+		n := bytes.LastIndexByte(location, ':')
+		if n == -1 {
+			return nil, false
+		}
+		line, err := strconv.Atoi(string(location[n+1:]))
+		if err != nil {
+			return nil, false
+		}
+
+		frames = append(frames, StackFrame{string(fn), string(location[:n]), line})
+	}
+	return frames, true
+}
+
+// maxErrorChain is the maximum number of wrapped errors errorStackFrames
+// inspects, protecting against cycles.
+const maxErrorChain = 100
+
+var (
+	framesType = reflect.TypeOf((*runtime.Frames)(nil))
+	frameType  = reflect.TypeOf(runtime.Frame{})
+)
+
+// errorStackFrames returns the stack frames of an error that has a
+// StackTrace method, e.g. errors created by github.com/pkg/errors. Supported
+// are StackTrace methods that return a slice of program counters (uintptr, or a
+// type based on it, like pkg/errors' Frame), *runtime.Frames or
+// []runtime.Frame. The wrapped errors, using errors.Unwrap or a Cause method,
+// are inspected as well and the frames of the innermost error, which is closest
+// to the origin, are returned. If none of the errors have a stack trace it
+// returns nil.
+func errorStackFrames(err error) []StackFrame {
+	var frames []StackFrame
+	for i := 0; err != nil && i < maxErrorChain; i++ {
+		if f := stackTraceFrames(err); len(f) != 0 {
+			frames = f
+		}
+		err = unwrapError(err)
+	}
+	return frames
+}
+
+// unwrapError returns the error wrapped by err, or nil.
+func unwrapError(err error) error {
+	if unwrapped := errors.Unwrap(err); unwrapped != nil {
+		return unwrapped
+	} else if causer, ok := err.(interface{ Cause() error }); ok {
+		if cause := causer.Cause(); cause != err {
+			return cause
+		}
+	}
+	return nil
+}
+
+// stackTraceFrames calls the StackTrace method of the error, if it has one.
+func stackTraceFrames(err error) []StackFrame {
+	method := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return nil
+	}
+
+	outType := method.Type().Out(0)
+	switch {
+	case outType == framesType:
+		return runtimeFrames(method.Call(nil)[0].Interface().(*runtime.Frames))
+	case outType.Kind() == reflect.Slice && outType.Elem() == frameType:
+		var frames []StackFrame
+		for _, frame := range method.Call(nil)[0].Interface().([]runtime.Frame) {
+			frames = append(frames, StackFrame{frame.Function, frame.File, frame.Line})
+		}
+		return frames
+	case outType.Kind() == reflect.Slice && outType.Elem().Kind() == reflect.Uintptr:
+		return programCounterFrames(method.Call(nil)[0])
+	}
+	return nil
+}
+
+// programCounterFrames converts a slice of program counters, e.g. the
+// StackTrace of pkg/errors, into stack frames.
+func programCounterFrames(trace reflect.Value) []StackFrame {
+	pcs := make([]uintptr, trace.Len())
+	for i := range pcs {
+		// Like runtime.Callers, pkg/errors stores the return program counter,
+		// which is the instruction after the call.
+		pcs[i] = uintptr(trace.Index(i).Uint())
+	}
+	if len(pcs) == 0 {
+		return nil
+	}
+	return runtimeFrames(runtime.CallersFrames(pcs))
+}
+
+// runtimeFrames converts runtime frames into stack frames.
+func runtimeFrames(frames *runtime.Frames) []StackFrame {
+	if frames == nil {
+		return nil
+	}
+
+	var stackFrames []StackFrame
+	for {
+		frame, more := frames.Next()
+		if frame.Function != "" || frame.File != "" {
+			stackFrames = append(stackFrames, StackFrame{frame.Function, frame.File, frame.Line})
+		}
+		if !more {
+			return stackFrames
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestParseStackTrace(t *testing.T) {
+	t.Parallel()
+
+	stackTrace := []byte(`goroutine 17 [running]:
+github.com/Thomasdezeeuw/logger.TestLog.func1(0xc82000cb40, 0x2, 0x2)
+	/Users/thomas/go/src/github.com/Thomasdezeeuw/logger/log_test.go:87 +0x9f
+github.com/Thomasdezeeuw/logger.TestLog(0xc8200a0e10)
+	/Users/thomas/go/src/github.com/Thomasdezeeuw/logger/log_test.go:147 +0x9de
+created by testing.RunTests
+	/usr/local/go/src/testing/testing.go:582 +0x892
+`)
+
+	expected := []StackFrame{
+		{"github.com/Thomasdezeeuw/logger.TestLog.func1",
+			"/Users/thomas/go/src/github.com/Thomasdezeeuw/logger/log_test.go", 87},
+		{"github.com/Thomasdezeeuw/logger.TestLog",
+			"/Users/thomas/go/src/github.com/Thomasdezeeuw/logger/log_test.go", 147},
+		{"created by testing.RunTests", "/usr/local/go/src/testing/testing.go", 582},
+	}
+
+	got, ok := parseStackTrace(stackTrace)
+	if !ok {
+		t.Fatal("Expected the stack trace to be parsed")
+	} else if !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected parseStackTrace to return %v, but got %v", expected, got)
+	}
+
+	if expected, got := "main.main\n\t/main.go:10", (StackFrame{"main.main", "/main.go", 10}).String(); got != expected {
+		t.Fatalf("Expected StackFrame.String() to return %q, but got %q", expected, got)
+	}
+
+	for _, invalid := range []string{"", "not a stack trace", "goroutine 1 [running]:\nmain.main()\n\t/main.go"} {
+		if _, ok := parseStackTrace([]byte(invalid)); ok {
+			t.Errorf("Expected parseStackTrace(%q) to fail", invalid)
+		}
+	}
+}
+
+// frame mimics github.com/pkg/errors.Frame.
+type frame uintptr
+
+// stackError mimics the errors created by github.com/pkg/errors.
+type stackError struct {
+	msg   string
+	stack []uintptr
+}
+
+func newStackError(msg string) error {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	return &stackError{msg, pcs[:n]}
+}
+
+func (err *stackError) Error() string { return err.msg }
+
+func (err *stackError) StackTrace() []frame {
+	frames := make([]frame, len(err.stack))
+	for i, pc := range err.stack {
+		frames[i] = frame(pc)
+	}
+	return frames
+}
+
+// causeError mimics errors.Wrap from github.com/pkg/errors.
+type causeError struct{ cause error }
+
+func (err causeError) Error() string { return "wrapped: " + err.cause.Error() }
+func (err causeError) Cause() error  { return err.cause }
+
+// framesError has a StackTrace method returning *runtime.Frames.
+type framesError struct{ pcs []uintptr }
+
+func (err framesError) Error() string { return "frames error" }
+func (err framesError) StackTrace() *runtime.Frames {
+	return runtime.CallersFrames(err.pcs)
+}
+
+func TestErrorStackFrames(t *testing.T) {
+	t.Parallel()
+
+	const fn = "github.com/Thomasdezeeuw/logger.TestErrorStackFrames"
+	origin := newStackError("origin")
+	pcs := make([]uintptr, 32)
+	pcs = pcs[:runtime.Callers(1, pcs)]
+
+	tests := []struct {
+		err      error
+		function string
+	}{
+		{errors.New("no stack"), ""},
+		{origin, fn},
+		{fmt.Errorf("wrapped: %w", origin), fn},
+		{causeError{origin}, fn},
+		{framesError{pcs}, fn},
+		{causeError{errors.New("no stack")}, ""},
+	}
+
+	for _, test := range tests {
+		frames := errorStackFrames(test.err)
+		if test.function == "" {
+			if frames != nil {
+				t.Errorf("Expected no stack frames for %v, but got %v", test.err, frames)
+			}
+			continue
+		}
+
+		if len(frames) == 0 {
+			t.Errorf("Expected stack frames for %v, but got none", test.err)
+		} else if frames[0].Function != test.function {
+			t.Errorf("Expected the first frame to be %s, but got %s", test.function, frames[0].Function)
+		} else if !strings.HasSuffix(frames[0].File, "stack_test.go") || frames[0].Line == 0 {
+			t.Errorf("Expected the first frame to be in stack_test.go, but got %v", frames[0])
+		}
+	}
+}
+
+func TestErrorWithStackTrace(t *testing.T) {
+	defer reset()
+	var ew eventWriter
+	Start(&ew)
+	Error(Tags{"stack"}, newStackError("Error message"))
+	Error(Tags{"stack"}, errors.New("Error message"))
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if frames, ok := ew.events[0].Data.([]StackFrame); !ok || len(frames) == 0 {
+		t.Errorf("Expected the data to contain the stack frames, but got %#v", ew.events[0].Data)
+	}
+	if ew.events[1].Data != nil {
+		t.Errorf("Expected no data, but got %#v", ew.events[1].Data)
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+var startupEnv []string
+
+// SetStartupEnvironment sets the names of the environment variables that are
+// included in the event logged by LogStartup. Only these environment variables
+// are included, so no secrets end up in the logs by accident. By default no
+// environment variables are included.
+//
+// Note: this must be called before Start and is not safe for concurrent use.
+func SetStartupEnvironment(names ...string) {
+	startupEnv = names
+}
+
+// Stubbed for testing.
+var lookupEnv = os.LookupEnv
+
+// LogStartup logs an Info event, "Startup", with a description of the
+// environment as data (type map[string]string). This gives every log file a
+// self-describing header. The data contains the following keys:
+//
+//	go_version  Go version used to build the binary.
+//	os          Operating system, see runtime.GOOS.
+//	arch        Architecture, see runtime.GOARCH.
+//	writers     Types of the EventWriters passed to Start, comma separated.
+//	env.<name>  Environment variables, see SetStartupEnvironment.
+//
+// The build info keys, see LogBuildInfo, are included as well, if available.
+// LogStartup must be called after Start.
+func LogStartup(tags Tags) {
+	data := map[string]string{
+		"go_version": runtime.Version(),
+		"os":         runtime.GOOS,
+		"arch":       runtime.GOARCH,
+	}
+
+	writers := make([]string, len(eventWriters))
+	for i, ew := range eventWriters {
+		writers[i] = fmt.Sprintf("%T", ew)
+	}
+	data["writers"] = strings.Join(writers, ", ")
+
+	for _, field := range ReadBuildInfo().fields() {
+		if field[1] != "" {
+			data[field[0]] = field[1]
+		}
+	}
+
+	for _, name := range startupEnv {
+		if value, ok := lookupEnv(name); ok {
+			data["env."+name] = value
+		}
+	}
+
+	sendEvent(Event{InfoEvent, now(), tags, "Startup", data, 0})
+}
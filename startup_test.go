@@ -0,0 +1,51 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"reflect"
+	"runtime"
+	"runtime/debug"
+	"testing"
+)
+
+func TestLogStartup(t *testing.T) {
+	defer reset()
+	oldLookupEnv, oldReadBuildInfo := lookupEnv, readBuildInfo
+	defer func() { lookupEnv, readBuildInfo = oldLookupEnv, oldReadBuildInfo }()
+
+	lookupEnv = func(name string) (string, bool) {
+		switch name {
+		case "ENV":
+			return "production", true
+		case "SECRET":
+			return "secret", true
+		}
+		return "", false
+	}
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{Main: debug.Module{Version: "v1.2.3"}}, true
+	}
+	SetStartupEnvironment("ENV", "MISSING")
+
+	var ew eventWriter
+	Start(&ew)
+	LogStartup(Tags{"startup"})
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	expected := Event{InfoEvent, ew.events[0].Timestamp, Tags{"startup"}, "Startup", map[string]string{
+		"go_version": runtime.Version(),
+		"os":         runtime.GOOS,
+		"arch":       runtime.GOARCH,
+		"writers":    "*logger.eventWriter",
+		"version":    "v1.2.3",
+		"env.ENV":    "production",
+	}, 1}
+	if got := ew.events[0]; !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected event %v, but got %v", expected, got)
+	}
+}
@@ -0,0 +1,265 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats are statistics about the logger, see OnStats.
+type Stats struct {
+	// QueueDepth is the number of events in the event channel, waiting to be
+	// written, and QueueCapacity is the capacity of it. Once the queue is full
+	// log operations block.
+	QueueDepth    int
+	QueueCapacity int
+
+	// Spilled is the number of events in the spill queue, waiting to be send
+	// to the event channel, see SetSpillQueue.
+	Spilled int
+
+	// EnqueueLatency is the time log operations spend sending the event to the
+	// event channel, since the previous Stats.
+	EnqueueLatency Latency
+
+	// Sampled is the number of events dropped by the Sampler, since Start,
+	// see SetSampler.
+	Sampled uint64
+
+	// Writers are the statistics for each EventWriter, in the order they're
+	// passed to Start.
+	Writers []WriterStats
+}
+
+// WriterStats are statistics about a single EventWriter.
+type WriterStats struct {
+	Writer EventWriter
+
+	// Bad is true if the EventWriter is considered bad, see ErrBadEventWriter.
+	Bad bool
+
+	// Slow is true if the EventWriter is a Failover of which the primary
+	// EventWriter is considered slow, see ErrSlowEventWriter.
+	Slow bool
+
+	// WriteLatency is the time spend in Write, since the previous Stats.
+	WriteLatency Latency
+
+	// Dropped is the number of events dropped, since Start, because the
+	// EventWriter is bad.
+	Dropped uint64
+
+	// LatencyHistogram is the number of Writes per latency bucket, since
+	// Start. LatencyHistogram[i] is the number of Writes that took at most
+	// LatencyBuckets[i], and longer then the previous bucket, the last element
+	// is the number of Writes that took longer then all buckets. Unlike
+	// WriteLatency it's recorded even if statistics are not enabled, so a
+	// slowing EventWriter can be spotted using ReadStats.
+	LatencyHistogram [len(LatencyBuckets) + 1]uint64
+
+	// LastError is the last error returned by Write and LastErrorTime is the
+	// time it was returned, both are zero if Write never failed.
+	LastError     error
+	LastErrorTime time.Time
+}
+
+// LatencyBuckets are the upper bounds of the buckets of
+// WriterStats.LatencyHistogram.
+var LatencyBuckets = [...]time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	5 * time.Second,
+}
+
+// Latency is a summary of the measured latencies. If more then
+// maxLatencySamples are measured within a single period, the percentiles are
+// based on the latest samples.
+type Latency struct {
+	Count         int
+	P50, P90, P99 time.Duration
+	Max           time.Duration
+}
+
+var (
+	statsFn   func(Stats)
+	statsDone chan struct{}
+
+	// Stubbed for testing.
+	statsInterval = 10 * time.Second
+
+	enqueueLatency latencyRecorder
+)
+
+// The maximum number of samples kept for each Latency.
+const maxLatencySamples = 1024
+
+// OnStats calls fn every 10 seconds, from a separate goroutine, with the
+// statistics about the logger. This allows the logger to be monitored using any
+// metrics system. A nil fn disables the statistics, which is the default.
+//
+// Note: this must be called before Start and is not safe for concurrent use.
+func OnStats(fn func(Stats)) {
+	statsFn = fn
+}
+
+// sendEvent sends the event to the event channel, measuring the latency if
+// statistics are enabled. If the logger is not started the event is buffered,
+// see SetPreStartBuffer, if it's closed the event is dropped. Events below the
+// minimum EventType, or dropped by the Sampler, are not send. Events buffered
+// locally, or spilled to disk, are send later, see SetLocalBuffering and
+// SetSpillQueue.
+func sendEvent(event Event) {
+	if event.Type < MinEventType() || !sample(event) {
+		return
+	}
+	switch atomic.LoadUint32(&running) {
+	case loggerNotStarted:
+		if bufferPreStart(event) {
+			return
+		}
+	case loggerClosed:
+		atomic.AddUint64(&afterCloseDropped, 1)
+		return
+	}
+	if bufferEvent(event) || spillEvent(event) {
+		return
+	} else if statsFn == nil {
+		eventChannel <- event
+		return
+	}
+
+	start := time.Now()
+	eventChannel <- event
+	enqueueLatency.record(time.Since(start))
+}
+
+// startStats starts the statistics goroutine, if enabled.
+func startStats() {
+	if statsFn == nil {
+		return
+	}
+
+	statsDone = make(chan struct{})
+	go reportStats(statsInterval, statsFn, eventChannel, eventWriters, writerStates, statsDone)
+}
+
+// stopStats stops the statistics goroutine, if running.
+func stopStats() {
+	if statsDone != nil {
+		close(statsDone)
+		statsDone = nil
+	}
+}
+
+// reportStats calls fn every interval until done is closed.
+func reportStats(interval time.Duration, fn func(Stats), events chan Event, ews []EventWriter, states []*writerState, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			fn(collectStats(events, ews, states, true))
+		}
+	}
+}
+
+// ReadStats returns the current statistics about the logger, the latencies are
+// those measured since the last call to the function passed to OnStats, if any.
+// The logger must be started.
+func ReadStats() Stats {
+	return collectStats(eventChannel, eventWriters, writerStates, false)
+}
+
+// collectStats collects the statistics, if reset is true the latencies are
+// reset.
+func collectStats(events chan Event, ews []EventWriter, states []*writerState, reset bool) Stats {
+	stats := Stats{
+		QueueDepth:     len(events),
+		QueueCapacity:  cap(events),
+		Spilled:        spilledEvents(),
+		EnqueueLatency: enqueueLatency.summarize(reset),
+		Sampled:        atomic.LoadUint64(&sampledEvents),
+		Writers:        make([]WriterStats, len(ews)),
+	}
+	for i, ew := range ews {
+		stats.Writers[i] = WriterStats{
+			Writer:       ew,
+			Bad:          states[i].isBad(),
+			Slow:         isSlowEventWriter(ew),
+			WriteLatency: states[i].latency.summarize(reset),
+			Dropped:      atomic.LoadUint64(&states[i].dropped),
+		}
+		for j := range states[i].histogram {
+			stats.Writers[i].LatencyHistogram[j] = atomic.LoadUint64(&states[i].histogram[j])
+		}
+		stats.Writers[i].LastError, stats.Writers[i].LastErrorTime = states[i].lastError()
+	}
+	return stats
+}
+
+// latencyRecorder records latencies, it's safe for concurrent use.
+type latencyRecorder struct {
+	mu      sync.Mutex
+	count   int
+	samples []time.Duration
+}
+
+func (recorder *latencyRecorder) record(d time.Duration) {
+	recorder.mu.Lock()
+	if len(recorder.samples) < maxLatencySamples {
+		recorder.samples = append(recorder.samples, d)
+	} else {
+		recorder.samples[recorder.count%maxLatencySamples] = d
+	}
+	recorder.count++
+	recorder.mu.Unlock()
+}
+
+// summarize returns the summary of the recorded latencies, if reset is true
+// the recorder is reset.
+func (recorder *latencyRecorder) summarize(reset bool) Latency {
+	recorder.mu.Lock()
+	samples, count := recorder.samples, recorder.count
+	if reset {
+		recorder.samples, recorder.count = nil, 0
+	} else {
+		samples = append([]time.Duration(nil), samples...)
+	}
+	recorder.mu.Unlock()
+
+	if len(samples) == 0 {
+		return Latency{}
+	}
+
+	sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+	return Latency{
+		Count: count,
+		P50:   percentile(samples, 50),
+		P90:   percentile(samples, 90),
+		P99:   percentile(samples, 99),
+		Max:   samples[len(samples)-1],
+	}
+}
+
+// percentile returns the p-th percentile, using the nearest-rank method, of the
+// sorted samples.
+func percentile(samples []time.Duration, p int) time.Duration {
+	rank := (len(samples)*p + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return samples[rank-1]
+}
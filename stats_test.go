@@ -0,0 +1,170 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOnStats(t *testing.T) {
+	defer reset()
+	oldStatsInterval := statsInterval
+	defer func() { statsInterval = oldStatsInterval }()
+	statsInterval = 10 * time.Millisecond
+
+	statsCh := make(chan Stats, 100)
+	OnStats(func(stats Stats) {
+		select {
+		case statsCh <- stats:
+		default:
+		}
+	})
+
+	var ew eventWriter
+	Start(&ew)
+	for i := 0; i < 10; i++ {
+		Info(Tags{"stats"}, "Info message")
+	}
+
+	// The events might be logged over multiple periods.
+	var enqueued, written int
+	timeout := time.After(5 * time.Second)
+	for enqueued < 10 || written < 10 {
+		select {
+		case stats := <-statsCh:
+			checkStats(t, stats, &ew)
+			enqueued += stats.EnqueueLatency.Count
+			written += stats.Writers[0].WriteLatency.Count
+		case <-timeout:
+			t.Fatalf("Expected 10 enqueued and written events, but got %d and %d", enqueued, written)
+		}
+	}
+
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+}
+
+// checkStats checks the Stats of a logger started with only ew.
+func checkStats(t *testing.T, stats Stats, ew EventWriter) {
+	if stats.QueueCapacity != defaultEventChannelSize {
+		t.Fatalf("Expected the queue capacity to be %d, but got %d",
+			defaultEventChannelSize, stats.QueueCapacity)
+	} else if len(stats.Writers) != 1 || stats.Writers[0].Writer != ew {
+		t.Fatalf("Expected the stats of a single EventWriter, but got %v", stats.Writers)
+	}
+}
+
+func TestLatencyRecorder(t *testing.T) {
+	var recorder latencyRecorder
+	if got := recorder.summarize(true); got != (Latency{}) {
+		t.Fatalf("Expected an empty Latency, but got %v", got)
+	}
+
+	for i := 100; i > 0; i-- {
+		recorder.record(time.Duration(i) * time.Millisecond)
+	}
+	expected := Latency{
+		Count: 100,
+		P50:   50 * time.Millisecond,
+		P90:   90 * time.Millisecond,
+		P99:   99 * time.Millisecond,
+		Max:   100 * time.Millisecond,
+	}
+	if got := recorder.summarize(true); got != expected {
+		t.Fatalf("Expected the Latency to be %v, but got %v", expected, got)
+	}
+
+	// Summarizing resets the recorder.
+	if got := recorder.summarize(true); got != (Latency{}) {
+		t.Fatalf("Expected an empty Latency, but got %v", got)
+	}
+
+	// Only the latest samples are kept.
+	for i := 0; i < 2*maxLatencySamples; i++ {
+		recorder.record(time.Duration(i))
+	}
+	got := recorder.summarize(true)
+	if got.Count != 2*maxLatencySamples || got.Max != 2*maxLatencySamples-1 ||
+		got.P50 != maxLatencySamples+maxLatencySamples/2-1 {
+		t.Fatalf("Unexpected Latency: %v", got)
+	}
+}
+
+func TestStatsDropped(t *testing.T) {
+	defer reset()
+	var ew errorEventWriter
+	Start(&ew)
+	for i := 0; i < 3; i++ {
+		Info(nil, "Info message")
+	}
+	Close()
+
+	stats := ReadStats()
+	if len(stats.Writers) != 1 || stats.Writers[0].Dropped != 3 {
+		t.Fatalf("Expected 3 dropped events, but got %v", stats.Writers)
+	}
+}
+
+func TestStatsLastError(t *testing.T) {
+	defer reset()
+	var ew errorEventWriter
+	Start(&ew)
+	Info(nil, "Info message")
+	Close()
+
+	stats := ReadStats()
+	got := stats.Writers[0]
+	if got.LastError == nil || got.LastError.Error() != "Write error: Info message" {
+		t.Fatalf("Expected the last error to be %q, but got %v", "Write error: Info message", got.LastError)
+	} else if !got.LastErrorTime.Equal(t1) {
+		t.Fatalf("Expected the last error time to be %v, but got %v", t1, got.LastErrorTime)
+	}
+}
+
+func TestStatsLatencyHistogram(t *testing.T) {
+	defer reset()
+	var ew eventWriter
+	Start(&ew)
+	for i := 0; i < 5; i++ {
+		Info(nil, "Info message")
+	}
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	got := ReadStats().Writers[0]
+	var n uint64
+	for _, count := range got.LatencyHistogram {
+		n += count
+	}
+	if n < 5 {
+		t.Fatalf("Expected at least 5 writes in the histogram, but got %v", got.LatencyHistogram)
+	} else if got.LastError != nil || !got.LastErrorTime.IsZero() {
+		t.Fatalf("Expected no last error, but got %v at %v", got.LastError, got.LastErrorTime)
+	}
+}
+
+func TestLatencyBucket(t *testing.T) {
+	tests := []struct {
+		latency  time.Duration
+		expected int
+	}{
+		{0, 0},
+		{time.Millisecond, 0},
+		{time.Millisecond + 1, 1},
+		{75 * time.Millisecond, 4},
+		{5 * time.Second, len(LatencyBuckets) - 1},
+		{time.Minute, len(LatencyBuckets)},
+	}
+
+	for _, test := range tests {
+		if got := latencyBucket(test.latency); got != test.expected {
+			t.Errorf("Expected latency %s to be in bucket %d, but got %d",
+				test.latency, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+// Package statsd provides an EventWriter that doesn't store events, but
+// increments StatsD counters for them. This allows dashboards to chart, for
+// example, error rates without parsing the logs. Use it next to another
+// EventWriter that stores the events.
+package statsd
+
+import (
+	"net"
+	"strings"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+// Defaults used if the Config fields are not set.
+const (
+	DefaultAddr   = "127.0.0.1:8125"
+	DefaultPrefix = "logger"
+)
+
+// Config is the configuration for the EventWriter created by NewEventWriter.
+type Config struct {
+	// Addr is the UDP address of the StatsD daemon, defaults to DefaultAddr.
+	Addr string
+
+	// Prefix of the counter, the counter name will be Prefix + "." + the
+	// EventType in lowercase, e.g. "logger.error". Defaults to DefaultPrefix.
+	Prefix string
+
+	// Types are the EventTypes to count, defaults to ErrorEvent and
+	// FatalEvent.
+	Types []logger.EventType
+
+	// Tagged adds the tags of the event to the counter, using the DogStatsD
+	// tag extension, e.g. "logger.error:1|c|#tag1,tag2".
+	Tagged bool
+}
+
+type eventWriter struct {
+	conn         net.Conn
+	config       Config
+	names        map[logger.EventType]string
+	buf          []byte
+	errorHandler func(error)
+}
+
+func (ew *eventWriter) Write(event logger.Event) error {
+	name, ok := ew.names[event.Type]
+	if !ok {
+		return nil
+	}
+
+	// Write is never called concurrently, so we can reuse the buffer.
+	buf := append(ew.buf[:0], name...)
+	buf = append(buf, ":1|c"...)
+	if ew.config.Tagged && len(event.Tags) != 0 {
+		buf = append(buf, "|#"...)
+		for i, tag := range event.Tags {
+			if i != 0 {
+				buf = append(buf, ',')
+			}
+			buf = append(buf, tagReplacer.Replace(tag)...)
+		}
+	}
+	ew.buf = buf
+
+	_, err := ew.conn.Write(buf)
+	return err
+}
+
+// TagReplacer replaces characters that have a special meaning in the StatsD
+// protocol.
+var tagReplacer = strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", "_")
+
+func (ew *eventWriter) HandleError(err error) {
+	ew.errorHandler(err)
+}
+
+func (ew *eventWriter) Close() error {
+	return ew.conn.Close()
+}
+
+// NewEventWriter creates a new EventWriter that increments a StatsD counter
+// for every event of the configured types, see Config.
+func NewEventWriter(config Config, errorHandler func(error)) (logger.EventWriter, error) {
+	if config.Addr == "" {
+		config.Addr = DefaultAddr
+	}
+	if config.Prefix == "" {
+		config.Prefix = DefaultPrefix
+	}
+	if len(config.Types) == 0 {
+		config.Types = []logger.EventType{logger.ErrorEvent, logger.FatalEvent}
+	}
+
+	names := make(map[logger.EventType]string, len(config.Types))
+	for _, eventType := range config.Types {
+		names[eventType] = config.Prefix + "." + strings.ToLower(eventType.String())
+	}
+
+	conn, err := net.Dial("udp", config.Addr)
+	if err != nil {
+		return nil, err
+	}
+	return &eventWriter{conn, config, names, nil, errorHandler}, nil
+}
@@ -0,0 +1,67 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package statsd
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+func TestEventWriter(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Unexpected error listening: " + err.Error())
+	}
+	defer conn.Close()
+
+	tests := []struct {
+		config   Config
+		events   []logger.Event
+		expected []string
+	}{
+		{Config{}, []logger.Event{
+			{Type: logger.InfoEvent, Tags: logger.Tags{"tag1"}},
+			{Type: logger.ErrorEvent, Tags: logger.Tags{"tag1"}},
+			{Type: logger.FatalEvent},
+		}, []string{"logger.error:1|c", "logger.fatal:1|c"}},
+		{Config{Prefix: "app", Types: []logger.EventType{logger.WarnEvent}, Tagged: true}, []logger.Event{
+			{Type: logger.ErrorEvent, Tags: logger.Tags{"tag1"}},
+			{Type: logger.WarnEvent, Tags: logger.Tags{"tag1", "user:1", "a,b|c"}},
+			{Type: logger.WarnEvent},
+		}, []string{"app.warn:1|c|#tag1,user:1,a_b_c", "app.warn:1|c"}},
+	}
+
+	for _, test := range tests {
+		test.config.Addr = conn.LocalAddr().String()
+		ew, err := NewEventWriter(test.config, func(error) {})
+		if err != nil {
+			t.Fatal("Unexpected error creating EventWriter: " + err.Error())
+		}
+
+		for _, event := range test.events {
+			if err := ew.Write(event); err != nil {
+				t.Fatal("Unexpected error writing event: " + err.Error())
+			}
+		}
+
+		buf := make([]byte, 512)
+		for _, expected := range test.expected {
+			conn.SetReadDeadline(time.Now().Add(time.Second))
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				t.Fatal("Unexpected error reading packet: " + err.Error())
+			} else if got := string(buf[:n]); got != expected {
+				t.Fatalf("Expected StatsD to receive %q, but got %q", expected, got)
+			}
+		}
+
+		if err := ew.Close(); err != nil {
+			t.Fatal("Unexpected error closing: " + err.Error())
+		}
+	}
+}
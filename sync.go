@@ -0,0 +1,73 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"errors"
+	"time"
+)
+
+// SyncPolicy defines when the EventWriter created by NewFileEventWriter
+// flushes its buffer and commits the file to stable storage (using fsync), see
+// WithSync. The file is synced once either Events events are written or
+// Interval has passed since the last sync, whichever comes first. If both are
+// zero the file is synced after every event.
+type SyncPolicy struct {
+	Events   int
+	Interval time.Duration
+}
+
+// SyncEveryEvent syncs the file after every event.
+var SyncEveryEvent = SyncPolicy{Events: 1}
+
+// due returns true if the file should be synced, given the number of unsynced
+// events and the time of the last sync.
+func (policy SyncPolicy) due(unsynced int, lastSync time.Time) bool {
+	if policy.Events <= 0 && policy.Interval <= 0 {
+		return true
+	}
+	return (policy.Events > 0 && unsynced >= policy.Events) ||
+		(policy.Interval > 0 && now().Sub(lastSync) >= policy.Interval)
+}
+
+// ErrSyncUnsupported is returned by WithSync if the EventWriter doesn't support
+// syncing.
+var ErrSyncUnsupported = errors.New("logger: EventWriter doesn't support syncing")
+
+// WithSync makes the EventWriter created by NewFileEventWriter sync the file
+// to stable storage according to the given policy and returns it, for other
+// EventWriters ErrSyncUnsupported is returned. By default events are buffered
+// in memory and only written to the file once the buffer is full, or when the
+// EventWriter is closed, which means buffered events are lost on a crash or
+// power failure. This is unacceptable for some workloads, e.g. audit logs.
+// Note that syncing is slow, syncing after every event can severely limit the
+// throughput.
+//
+// Errors syncing the file are passed to HandleError, which writes them to the
+// file, like write errors.
+//
+// Note: this must be called before the EventWriter is passed to Start.
+func WithSync(ew EventWriter, policy SyncPolicy) (EventWriter, error) {
+	fileEW, ok := ew.(*fileEventWriter)
+	if !ok {
+		return nil, ErrSyncUnsupported
+	}
+
+	fileEW.sync = &policy
+	fileEW.lastSync = now()
+	return ew, nil
+}
+
+// syncFile flushes the buffer and syncs the file.
+func (ew *fileEventWriter) syncFile() error {
+	if err := ew.w.Flush(); err != nil {
+		return err
+	} else if err := ew.f.Sync(); err != nil {
+		return err
+	}
+	ew.unsynced = 0
+	ew.lastSync = now()
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithSync(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logger_sync")
+	if err != nil {
+		t.Fatal("Unexpected error creating temporary directory: " + err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	oldNow := now
+	defer func() { now = oldNow }()
+	t1 := oldNow()
+	current := t1
+	now = func() time.Time { return current }
+
+	tests := []struct {
+		policy   SyncPolicy
+		advance  time.Duration
+		expected []int // Number of lines on disk after each write.
+	}{
+		{SyncEveryEvent, 0, []int{1, 2, 3}},
+		{SyncPolicy{}, 0, []int{1, 2, 3}},
+		{SyncPolicy{Events: 2}, 0, []int{0, 2, 2, 4}},
+		{SyncPolicy{Interval: time.Minute}, 40 * time.Second, []int{0, 2, 2, 4}},
+	}
+
+	for i, test := range tests {
+		current = t1
+		path := filepath.Join(dir, strings.Repeat("a", i+1)+".log")
+		ew, err := NewFileEventWriter(InfoEvent, path)
+		if err != nil {
+			t.Fatal("Unexpected error creating new file event writer: " + err.Error())
+		}
+		if ew, err = WithSync(ew, test.policy); err != nil {
+			t.Fatal("Unexpected error enabling syncing: " + err.Error())
+		}
+
+		for j, expected := range test.expected {
+			current = current.Add(test.advance)
+			event := Event{Type: InfoEvent, Timestamp: current, Message: "Message"}
+			if err := ew.Write(event); err != nil {
+				t.Fatal("Unexpected error writing event: " + err.Error())
+			}
+
+			content, err := ioutil.ReadFile(path)
+			if err != nil {
+				t.Fatal("Unexpected error reading file: " + err.Error())
+			}
+			if got := strings.Count(string(content), "\n"); got != expected {
+				t.Errorf("Test #%d: expected %d lines after write #%d, but got %d",
+					i, expected, j, got)
+			}
+		}
+
+		if err := ew.Close(); err != nil {
+			t.Fatal("Unexpected error closing: " + err.Error())
+		}
+	}
+}
+
+func TestWithSyncUnsupported(t *testing.T) {
+	ew, err := WithSync(NewConsoleEventWriter(InfoEvent), SyncEveryEvent)
+	if err != ErrSyncUnsupported {
+		t.Fatalf("Expected ErrSyncUnsupported, but got %v", err)
+	} else if ew != nil {
+		t.Fatalf("Expected no EventWriter, but got %v", ew)
+	}
+}
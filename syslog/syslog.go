@@ -0,0 +1,291 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+// Package syslog provides an EventWriter that writes events to a syslog
+// daemon. Both the RFC 5424 format and the legacy BSD (RFC 3164) format are
+// supported, the latter for older daemons and appliances that don't accept
+// the newer format.
+package syslog
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+// Protocol is the syslog protocol, which defines the message format.
+type Protocol uint8
+
+// The supported syslog protocols.
+const (
+	// RFC5424 is the format defined in RFC 5424, the default protocol.
+	RFC5424 Protocol = iota
+
+	// RFC3164 is the legacy BSD format, defined in RFC 3164.
+	RFC3164
+)
+
+// Facility is the syslog facility, indicating the type of program logging.
+type Facility uint8
+
+// Facilities as defined in RFC 5424.
+const (
+	User Facility = iota + 1
+	Mail
+	Daemon
+	Auth
+	Syslog
+	LPR
+	News
+	UUCP
+	Cron
+	AuthPriv
+	FTP
+
+	Local0 Facility = iota + 5
+	Local1
+	Local2
+	Local3
+	Local4
+	Local5
+	Local6
+	Local7
+)
+
+// Severities as defined in RFC 5424.
+const (
+	severityEmergency = iota
+	severityAlert
+	severityCritical
+	severityError
+	severityWarning
+	severityNotice
+	severityInformational
+	severityDebug
+)
+
+// Severity maps an EventType to a syslog severity, custom event types are
+// mapped to the informational severity.
+func severity(eventType logger.EventType) int {
+	switch eventType {
+	case logger.TraceEvent, logger.DebugEvent, logger.ThumbEvent:
+		return severityDebug
+	case logger.NoticeEvent, logger.SecurityEvent:
+		return severityNotice
+	case logger.WarnEvent:
+		return severityWarning
+	case logger.ErrorEvent:
+		return severityError
+	case logger.FatalEvent:
+		return severityCritical
+	default:
+		return severityInformational
+	}
+}
+
+const (
+	rfc5424TimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+	rfc3164TimeFormat = time.Stamp
+	rfc3164MaxTagSize = 32
+)
+
+// Formatter formats events as syslog messages. The zero value is usable and
+// formats events in the RFC 5424 format using the user facility and the
+// hostname and name of the current process.
+type Formatter struct {
+	// Protocol used to format the message, defaults to RFC5424.
+	Protocol Protocol
+
+	// Facility of the message, defaults to User.
+	Facility Facility
+
+	// Hostname is the hostname of the machine, defaults to os.Hostname.
+	Hostname string
+
+	// AppName is the name of the application, in the RFC 3164 format this is
+	// known as the tag. Defaults to the name of the executable.
+	AppName string
+}
+
+// Format appends the event as a syslog message to buf. In the RFC 5424 format
+// the EventType is used as message id, e.g.:
+//
+//	<14>1 2015-09-01T14:22:36.000000Z hostname app 123 Info - tag1, tag2: message
+//
+// And in the RFC 3164 format the timestamp is in the local timezone, e.g.:
+//
+//	<14>Sep  1 14:22:36 hostname app[123]: tag1, tag2: message
+func (f Formatter) Format(buf []byte, event logger.Event) ([]byte, error) {
+	facility := f.Facility
+	if facility == 0 {
+		facility = User
+	}
+	hostname := f.Hostname
+	if hostname == "" {
+		hostname = defaultHostname
+	}
+	appName := f.AppName
+	if appName == "" {
+		appName = defaultAppName
+	}
+
+	buf = append(buf, '<')
+	buf = strconv.AppendInt(buf, int64(facility)*8+int64(severity(event.Type)), 10)
+	buf = append(buf, '>')
+
+	if f.Protocol == RFC3164 {
+		if len(appName) > rfc3164MaxTagSize {
+			appName = appName[:rfc3164MaxTagSize]
+		}
+		buf = event.Timestamp.Local().AppendFormat(buf, rfc3164TimeFormat)
+		buf = append(buf, ' ')
+		buf = append(buf, hostname...)
+		buf = append(buf, ' ')
+		buf = append(buf, appName...)
+		buf = append(buf, '[')
+		buf = strconv.AppendInt(buf, int64(pid), 10)
+		buf = append(buf, "]: "...)
+	} else {
+		buf = append(buf, "1 "...)
+		buf = event.Timestamp.UTC().AppendFormat(buf, rfc5424TimeFormat)
+		buf = append(buf, ' ')
+		buf = append(buf, hostname...)
+		buf = append(buf, ' ')
+		buf = append(buf, appName...)
+		buf = append(buf, ' ')
+		buf = strconv.AppendInt(buf, int64(pid), 10)
+		buf = append(buf, ' ')
+		buf = append(buf, event.Type.String()...)
+		buf = append(buf, " - "...)
+	}
+
+	buf = append(buf, event.Tags.Bytes()...)
+	buf = append(buf, ": "...)
+	buf = append(buf, event.Message...)
+	return buf, nil
+}
+
+// Stubbed for testing.
+var (
+	pid             = os.Getpid()
+	defaultHostname = getHostname()
+	defaultAppName  = filepath.Base(os.Args[0])
+)
+
+func getHostname() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "-"
+	}
+	return hostname
+}
+
+type eventWriter struct {
+	network      string
+	raddr        string
+	conn         net.Conn
+	stream       bool
+	formatter    Formatter
+	buf          []byte
+	errorHandler func(error)
+	minType      logger.EventType
+}
+
+func (ew *eventWriter) Write(event logger.Event) error {
+	if event.Type < ew.minType {
+		return nil
+	}
+
+	if ew.conn == nil {
+		if err := ew.connect(); err != nil {
+			return err
+		}
+	}
+
+	// Write is never called concurrently, so we can reuse the buffer.
+	buf, _ := ew.formatter.Format(ew.buf[:0], event)
+	if ew.stream {
+		buf = append(buf, '\n')
+	}
+	ew.buf = buf
+	if _, err := ew.conn.Write(buf); err != nil {
+		// Reconnect on the next write, e.g. after the daemon restarted.
+		ew.conn.Close()
+		ew.conn = nil
+		return err
+	}
+	return nil
+}
+
+func (ew *eventWriter) connect() error {
+	conn, err := dial(ew.network, ew.raddr)
+	if err != nil {
+		return err
+	}
+	ew.conn = conn
+	ew.stream = conn.LocalAddr().Network() == "tcp" || conn.LocalAddr().Network() == "unix"
+	return nil
+}
+
+func (ew *eventWriter) HandleError(err error) {
+	ew.errorHandler(err)
+}
+
+func (ew *eventWriter) Close() error {
+	if ew.conn == nil {
+		return nil
+	}
+	return ew.conn.Close()
+}
+
+// ErrNoLocalSyslog is returned by NewEventWriter if no network is given and
+// the local syslog daemon can't be found.
+var ErrNoLocalSyslog = errors.New("syslog: can't find local syslog daemon")
+
+// Locations of the local syslog daemon's socket.
+var localSyslogPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// NewEventWriter creates a new EventWriter that writes to a syslog daemon,
+// using the given Formatter. If network is empty it will connect to the local
+// syslog daemon, otherwise it connects to raddr on the given network, see
+// net.Dial. For stream based networks, e.g. tcp, each message is followed by a
+// newline. MinType is the minimal EventType an event must have to be logged.
+// For example if minType is InfoEvent, then any events with an EventType of
+// DebugEvent will not be logged.
+//
+// If writing to the connection fails the connection is closed and a new
+// connection is made on the next write.
+func NewEventWriter(minType logger.EventType, network, raddr string, formatter Formatter, errorHandler func(error)) (logger.EventWriter, error) {
+	ew := &eventWriter{
+		network:      network,
+		raddr:        raddr,
+		formatter:    formatter,
+		errorHandler: errorHandler,
+		minType:      minType,
+	}
+	if err := ew.connect(); err != nil {
+		return nil, err
+	}
+	return ew, nil
+}
+
+func dial(network, raddr string) (net.Conn, error) {
+	if network != "" {
+		return net.Dial(network, raddr)
+	}
+
+	for _, path := range localSyslogPaths {
+		for _, network := range []string{"unixgram", "unix"} {
+			if conn, err := net.Dial(network, path); err == nil {
+				return conn, nil
+			}
+		}
+	}
+	return nil, ErrNoLocalSyslog
+}
@@ -0,0 +1,169 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package syslog
+
+import (
+	"bufio"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+var t1 = time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC)
+
+func init() {
+	pid = 123
+}
+
+func TestFormatter(t *testing.T) {
+	localTime := t1.Local().Format(time.Stamp)
+	tests := []struct {
+		formatter Formatter
+		event     logger.Event
+		expected  string
+	}{
+		{Formatter{Hostname: "host", AppName: "app"},
+			logger.Event{Type: logger.InfoEvent, Timestamp: t1, Tags: logger.Tags{"tag1", "tag2"}, Message: "Message"},
+			"<14>1 2015-09-01T14:22:36.000000Z host app 123 Info - tag1, tag2: Message"},
+		{Formatter{Facility: Local0, Hostname: "host", AppName: "app"},
+			logger.Event{Type: logger.ErrorEvent, Timestamp: t1, Tags: logger.Tags{"tag1"}, Message: "Message"},
+			"<131>1 2015-09-01T14:22:36.000000Z host app 123 Error - tag1: Message"},
+		{Formatter{Protocol: RFC3164, Hostname: "host", AppName: "app"},
+			logger.Event{Type: logger.WarnEvent, Timestamp: t1, Tags: logger.Tags{"tag1"}, Message: "Message"},
+			"<12>" + localTime + " host app[123]: tag1: Message"},
+		{Formatter{Protocol: RFC3164, Facility: Daemon, Hostname: "host",
+			AppName: "a-very-long-application-name-that-gets-truncated"},
+			logger.Event{Type: logger.FatalEvent, Timestamp: t1, Tags: logger.Tags{}, Message: "Message"},
+			"<26>" + localTime + " host a-very-long-application-name-tha[123]: : Message"},
+	}
+
+	for _, test := range tests {
+		got, err := test.formatter.Format(nil, test.event)
+		if err != nil {
+			t.Fatal("Unexpected error formatting event: " + err.Error())
+		} else if string(got) != test.expected {
+			t.Errorf("Expected %v.Format to return:\n%s\nBut got:\n%s",
+				test.formatter, test.expected, got)
+		}
+	}
+}
+
+func TestSeverity(t *testing.T) {
+	tests := []struct {
+		eventType logger.EventType
+		expected  int
+	}{
+		{logger.TraceEvent, severityDebug},
+		{logger.DebugEvent, severityDebug},
+		{logger.InfoEvent, severityInformational},
+		{logger.NoticeEvent, severityNotice},
+		{logger.WarnEvent, severityWarning},
+		{logger.ErrorEvent, severityError},
+		{logger.FatalEvent, severityCritical},
+		{logger.SecurityEvent, severityNotice},
+		{logger.ThumbEvent, severityDebug},
+		{logger.LogEvent, severityInformational},
+	}
+
+	for _, test := range tests {
+		if got := severity(test.eventType); got != test.expected {
+			t.Fatalf("Expected severity of %s to be %d, but got %d", test.eventType, test.expected, got)
+		}
+	}
+}
+
+func TestEventWriter(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Unexpected error listening: " + err.Error())
+	}
+	defer l.Close()
+
+	lines := make(chan string, 2)
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		s := bufio.NewScanner(conn)
+		for s.Scan() {
+			lines <- s.Text()
+		}
+		close(lines)
+	}()
+
+	formatter := Formatter{Hostname: "host", AppName: "app"}
+	ew, err := NewEventWriter(logger.InfoEvent, "tcp", l.Addr().String(), formatter, func(error) {})
+	if err != nil {
+		t.Fatal("Unexpected error creating EventWriter: " + err.Error())
+	}
+
+	ew.Write(logger.Event{Type: logger.DebugEvent, Timestamp: t1, Message: "Never gets logged"})
+	ew.Write(logger.Event{Type: logger.InfoEvent, Timestamp: t1, Message: "Message1"})
+	ew.Write(logger.Event{Type: logger.InfoEvent, Timestamp: t1, Message: "Message2"})
+	if err := ew.Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	expected := []string{
+		"<14>1 2015-09-01T14:22:36.000000Z host app 123 Info - : Message1",
+		"<14>1 2015-09-01T14:22:36.000000Z host app 123 Info - : Message2",
+	}
+	for _, want := range expected {
+		if got := <-lines; got != want {
+			t.Fatalf("Expected syslog to receive:\n%s\nBut got:\n%s", want, got)
+		}
+	}
+}
+
+func TestEventWriterReconnect(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Unexpected error listening: " + err.Error())
+	}
+	defer l.Close()
+
+	lines := make(chan string, 2)
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				s := bufio.NewScanner(conn)
+				for s.Scan() {
+					lines <- s.Text()
+				}
+			}()
+		}
+	}()
+
+	formatter := Formatter{Hostname: "host", AppName: "app"}
+	ew, err := NewEventWriter(logger.InfoEvent, "tcp", l.Addr().String(), formatter, func(error) {})
+	if err != nil {
+		t.Fatal("Unexpected error creating EventWriter: " + err.Error())
+	}
+	defer ew.Close()
+
+	// Break the connection, the write should fail and the next write should
+	// use a new connection.
+	ew.(*eventWriter).conn.Close()
+	if err := ew.Write(logger.Event{Type: logger.InfoEvent, Timestamp: t1, Message: "Message1"}); err == nil {
+		t.Fatal("Expected an error writing to a closed connection")
+	}
+	if err := ew.Write(logger.Event{Type: logger.InfoEvent, Timestamp: t1, Message: "Message2"}); err != nil {
+		t.Fatal("Unexpected error writing after reconnecting: " + err.Error())
+	}
+
+	expected := "<14>1 2015-09-01T14:22:36.000000Z host app 123 Info - : Message2"
+	if got := <-lines; got != expected {
+		t.Fatalf("Expected syslog to receive:\n%s\nBut got:\n%s", expected, got)
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"bufio"
+	"container/list"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// DefaultMaxOpenFiles is the default maximum number of files kept open by
+	// the EventWriter created by NewTagFileEventWriter.
+	DefaultMaxOpenFiles = 64
+
+	// UntaggedFile is the name of the file, without extension, used for
+	// events without the routing tag, see NewTagFileEventWriter.
+	UntaggedFile = "untagged"
+)
+
+// tagFile is a single open file of the tagFileEventWriter.
+type tagFile struct {
+	name string
+	w    *bufio.Writer
+	f    *os.File
+}
+
+func (file *tagFile) close() error {
+	flushErr := file.w.Flush()
+	err := file.f.Close()
+	if err == nil {
+		err = flushErr
+	}
+	return err
+}
+
+type tagFileEventWriter struct {
+	dir             string
+	key             string
+	maxOpen         int
+	files           map[string]*list.Element // Values are *tagFile.
+	lru             *list.List               // Most recently used at the front.
+	buf             []byte
+	timestampFormat TimestampFormat
+	escape          bool
+	errorHandler    func(error)
+	minType         EventType
+}
+
+func (ew *tagFileEventWriter) Write(event Event) error {
+	if event.Type < ew.minType {
+		return nil
+	}
+
+	file, err := ew.file(tagFileName(event.Tags.Get(ew.key)))
+	if err != nil {
+		return err
+	}
+
+	// Write is never called concurrently, so we can reuse the buffer.
+	ew.buf = append(appendText(ew.buf[:0], event, ew.timestampFormat, ew.escape), '\n')
+	_, err = file.w.Write(ew.buf)
+	return err
+}
+
+// file returns the open file with the given name, opening it if needed. If
+// more then maxOpen files are open the least recently used file is closed.
+func (ew *tagFileEventWriter) file(name string) (*tagFile, error) {
+	if elem, ok := ew.files[name]; ok {
+		ew.lru.MoveToFront(elem)
+		return elem.Value.(*tagFile), nil
+	}
+
+	if ew.lru.Len() >= ew.maxOpen {
+		elem := ew.lru.Back()
+		file := ew.lru.Remove(elem).(*tagFile)
+		delete(ew.files, file.name)
+		if err := file.close(); err != nil {
+			ew.errorHandler(err)
+		}
+	}
+
+	path := filepath.Join(ew.dir, name+".log")
+	f, err := os.OpenFile(path, defaultFileFlag, defaultFilePermission)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &tagFile{name, bufio.NewWriter(f), f}
+	ew.files[name] = ew.lru.PushFront(file)
+	return file, nil
+}
+
+// tagFileName returns a safe file name for the tag value, replacing any
+// character that isn't a letter, digit, '-', '_' or '.' with '_'.
+func tagFileName(value string) string {
+	if value == "" || value == "." || value == ".." {
+		return UntaggedFile
+	}
+
+	return strings.Map(safeFileNameRune, value)
+}
+
+func safeFileNameRune(r rune) rune {
+	if ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') ||
+		('0' <= r && r <= '9') || r == '-' || r == '_' || r == '.' {
+		return r
+	}
+	return '_'
+}
+
+func (ew *tagFileEventWriter) HandleError(err error) {
+	ew.errorHandler(err)
+}
+
+func (ew *tagFileEventWriter) setTimestampFormat(format TimestampFormat) bool {
+	ew.timestampFormat = format
+	return true
+}
+
+func (ew *tagFileEventWriter) setEscaping() {
+	ew.escape = true
+}
+
+func (ew *tagFileEventWriter) Flush() error {
+	var err error
+	for elem := ew.lru.Front(); elem != nil; elem = elem.Next() {
+		if er := elem.Value.(*tagFile).w.Flush(); er != nil && err == nil {
+			err = er
+		}
+	}
+	return err
+}
+
+// Rotate closes all open files, they're reopened once needed.
+func (ew *tagFileEventWriter) Rotate() error {
+	return ew.Close()
+}
+
+func (ew *tagFileEventWriter) Close() error {
+	var err error
+	for elem := ew.lru.Front(); elem != nil; elem = elem.Next() {
+		if er := elem.Value.(*tagFile).close(); er != nil && err == nil {
+			err = er
+		}
+	}
+	ew.files = map[string]*list.Element{}
+	ew.lru.Init()
+	return err
+}
+
+// NewTagFileEventWriter creates a new EventWriter that routes events into
+// files in dir, chosen by the value of the key=value tag with the given key,
+// see Tag. For example with key "tenant" an event with the tag "tenant=acme" is
+// written to dir/acme.log, events without the tag are written to
+// dir/untagged.log. Characters in the value that are not safe to use in a file
+// name are replaced with '_'. The events are written in the same format as the
+// EventWriter created by NewFileEventWriter.
+//
+// At most maxOpen files are kept open at the same time, if another file is
+// needed the least recently used file is closed. If maxOpen is zero, or lower,
+// DefaultMaxOpenFiles is used. MinType is the minimal EventType an event must
+// have to be logged. For example if minType is InfoEvent, then any events with
+// an EventType of DebugEvent will not be logged.
+func NewTagFileEventWriter(minType EventType, dir, key string, maxOpen int, errorHandler func(error)) (EventWriter, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	if maxOpen <= 0 {
+		maxOpen = DefaultMaxOpenFiles
+	}
+
+	return &tagFileEventWriter{
+		dir:          dir,
+		key:          key,
+		maxOpen:      maxOpen,
+		files:        map[string]*list.Element{},
+		lru:          list.New(),
+		errorHandler: errorHandler,
+		minType:      minType,
+	}, nil
+}
@@ -0,0 +1,101 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTagFileEventWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logger_tagfile")
+	if err != nil {
+		t.Fatal("Unexpected error creating temporary directory: " + err.Error())
+	}
+	defer os.RemoveAll(dir)
+
+	ew, err := NewTagFileEventWriter(InfoEvent, dir, "tenant", 2, func(err error) {
+		t.Error("Unexpected error: " + err.Error())
+	})
+	if err != nil {
+		t.Fatal("Unexpected error creating new tag file event writer: " + err.Error())
+	}
+
+	t1 := time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC)
+	tenants := []string{"a", "b", "a", "c", "b", "", "../etc/passwd", "a"}
+	for _, tenant := range tenants {
+		var tags Tags
+		if tenant != "" {
+			tags = Tags{Tag("tenant", tenant)}
+		}
+		event := Event{Type: InfoEvent, Timestamp: t1, Tags: tags, Message: "Message"}
+		if err := ew.Write(event); err != nil {
+			t.Fatal("Unexpected error writing event: " + err.Error())
+		}
+	}
+	if err := ew.Write(Event{Type: DebugEvent, Timestamp: t1, Tags: Tags{"tenant=a"}}); err != nil {
+		t.Fatal("Unexpected error writing event: " + err.Error())
+	}
+
+	if n := ew.(*tagFileEventWriter).lru.Len(); n != 2 {
+		t.Fatalf("Expected 2 files to be open, but got %d", n)
+	}
+	if err := ew.Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	checkTagFiles(t, dir)
+}
+
+func checkTagFiles(t *testing.T, dir string) {
+	expected := map[string]string{
+		"a.log": "2015-09-01 14:22:36 [Info] tenant=a: Message\n" +
+			"2015-09-01 14:22:36 [Info] tenant=a: Message\n" +
+			"2015-09-01 14:22:36 [Info] tenant=a: Message\n",
+		"b.log": "2015-09-01 14:22:36 [Info] tenant=b: Message\n" +
+			"2015-09-01 14:22:36 [Info] tenant=b: Message\n",
+		"c.log":             "2015-09-01 14:22:36 [Info] tenant=c: Message\n",
+		"untagged.log":      "2015-09-01 14:22:36 [Info] : Message\n",
+		".._etc_passwd.log": "2015-09-01 14:22:36 [Info] tenant=../etc/passwd: Message\n",
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal("Unexpected error reading directory: " + err.Error())
+	} else if len(files) != len(expected) {
+		t.Fatalf("Expected %d files, but got %d", len(expected), len(files))
+	}
+	for name, content := range expected {
+		got, err := ioutil.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal("Unexpected error reading file: " + err.Error())
+		} else if string(got) != content {
+			t.Errorf("Expected file %s to contain %q, but got %q", name, content, got)
+		}
+	}
+}
+
+func TestTagFileName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"", UntaggedFile},
+		{".", UntaggedFile},
+		{"..", UntaggedFile},
+		{"tenant-1_a.b", "tenant-1_a.b"},
+		{"a/b\\c d", "a_b_c_d"},
+	}
+
+	for _, test := range tests {
+		if got := tagFileName(test.value); got != test.expected {
+			t.Errorf("Expected tagFileName(%q) to return %q, but got %q", test.value, test.expected, got)
+		}
+	}
+}
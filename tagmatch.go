@@ -0,0 +1,68 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+// MatchTag returns true if the tag matches the glob pattern. In the pattern
+// '*' matches any sequence of characters, '?' matches a single character and
+// '\' escapes the next character. All other characters must match exactly,
+// for example "svc.*" matches "svc.api" and "svc.db", and "user=*" matches
+// all key=value tags with the "user" key.
+func MatchTag(pattern, tag string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			return matchStar(pattern, tag)
+		case '?':
+			if len(tag) == 0 {
+				return false
+			}
+			pattern, tag = pattern[1:], tag[1:]
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(tag) == 0 || pattern[0] != tag[0] {
+				return false
+			}
+			pattern, tag = pattern[1:], tag[1:]
+		}
+	}
+	return len(tag) == 0
+}
+
+// matchStar matches the pattern, starting with '*', to tag.
+func matchStar(pattern, tag string) bool {
+	// Collapse multiple stars and try every possible split.
+	for len(pattern) > 0 && pattern[0] == '*' {
+		pattern = pattern[1:]
+	}
+	if len(pattern) == 0 {
+		return true
+	}
+	for i := 0; i <= len(tag); i++ {
+		if MatchTag(pattern, tag[i:]) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchTags returns true if one of the tags matches the glob pattern, see
+// MatchTag. If the pattern starts with '!' the match is negated, e.g. "!debug"
+// is true if none of the tags is "debug", use "\!" to match a leading '!'.
+func MatchTags(pattern string, tags Tags) bool {
+	negate := len(pattern) > 0 && pattern[0] == '!'
+	if negate {
+		pattern = pattern[1:]
+	}
+	for _, tag := range tags {
+		if MatchTag(pattern, tag) {
+			return !negate
+		}
+	}
+	return negate
+}
@@ -0,0 +1,70 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import "testing"
+
+func TestMatchTag(t *testing.T) {
+	tests := []struct {
+		pattern, tag string
+		expected     bool
+	}{
+		{"", "", true},
+		{"", "svc", false},
+		{"svc", "svc", true},
+		{"svc", "svc.api", false},
+		{"svc.*", "svc.api", true},
+		{"svc.*", "svc.", true},
+		{"svc.*", "svc", false},
+		{"svc.*", "other.api", false},
+		{"user=*", "user=thomas", true},
+		{"*", "", true},
+		{"*", "anything", true},
+		{"*.db", "svc.db", true},
+		{"*.db", "svc.dbx", false},
+		{"s*c*b", "svc.db", true},
+		{"s**b", "svc.db", true},
+		{"sv?", "svc", true},
+		{"sv?", "sv", false},
+		{"sv?", "svcc", false},
+		{`svc\*`, "svc*", true},
+		{`svc\*`, "svc.api", false},
+		{`svc\?`, "svcx", false},
+		{`svc\`, `svc\`, true},
+	}
+
+	for _, test := range tests {
+		if got := MatchTag(test.pattern, test.tag); got != test.expected {
+			t.Errorf("Expected MatchTag(%q, %q) to return %t, but got %t",
+				test.pattern, test.tag, test.expected, got)
+		}
+	}
+}
+
+func TestMatchTags(t *testing.T) {
+	tags := Tags{"svc.api", "user=thomas"}
+	tests := []struct {
+		pattern  string
+		tags     Tags
+		expected bool
+	}{
+		{"svc.*", tags, true},
+		{"user=*", tags, true},
+		{"db", tags, false},
+		{"!db", tags, true},
+		{"!svc.*", tags, false},
+		{"svc.*", nil, false},
+		{"!svc.*", nil, true},
+		{`\!db`, Tags{"!db"}, true},
+		{`\!db`, Tags{"db"}, false},
+	}
+
+	for _, test := range tests {
+		if got := MatchTags(test.pattern, test.tags); got != test.expected {
+			t.Errorf("Expected MatchTags(%q, %v) to return %t, but got %t",
+				test.pattern, test.tags, test.expected, got)
+		}
+	}
+}
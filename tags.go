@@ -4,7 +4,13 @@
 
 package logger
 
-import "strconv"
+import (
+	"encoding/binary"
+	"strings"
+	"sync"
+
+	"github.com/Thomasdezeeuw/logger/internal/util"
+)
 
 // Tags are keywords usefull in searching through logs, for example:
 //
@@ -13,8 +19,41 @@ import "strconv"
 // With this information you can lookup any logs for a specific user reporting
 // an issue. Then you can find which function, in which file, is throwing the
 // error.
+//
+// Tags can also be structured key=value pairs, see Tag, its typed variants
+// like Int and Str, and Tags.Get. These are written as separate fields by the
+// JSON and ECS EventWriters, for example:
+//
+//	tags := Tags{"myFn", logger.Tag("user", userID), logger.Int("attempt", n)}
 type Tags []string
 
+// Tag creates a structured key=value tag, the value is converted into a
+// string. The key should not contain an equals sign.
+func Tag(key string, value interface{}) string {
+	return key + "=" + util.InterfaceToString(value)
+}
+
+// splitTag splits a key=value tag, it returns false if the tag isn't a
+// key=value tag. The key can't be empty.
+func splitTag(tag string) (key, value string, ok bool) {
+	i := strings.IndexByte(tag, '=')
+	if i <= 0 {
+		return "", "", false
+	}
+	return tag[:i], tag[i+1:], true
+}
+
+// Get returns the value of the first key=value tag with the given key, or an
+// empty string if no such tag exists.
+func (tags Tags) Get(key string) string {
+	for _, tag := range tags {
+		if k, value, ok := splitTag(tag); ok && k == key {
+			return value
+		}
+	}
+	return ""
+}
+
 // String creates a comma separated list from the tags in string.
 func (tags Tags) String() string {
 	return string(tags.Bytes())
@@ -26,40 +65,104 @@ func (tags Tags) Bytes() []byte {
 		return []byte{}
 	}
 
-	// Add each tag in the form of "tag, ".
-	var buf []byte
+	// Calculate the required size upfront so we only allocate once.
+	size := 2 * (len(tags) - 1)
 	for _, tag := range tags {
-		buf = append(buf, tag...)
-		buf = append(buf, ',')
-		buf = append(buf, ' ')
+		size += len(tag)
 	}
 
-	// Drop the last ", ".
-	buf = buf[:len(buf)-2]
+	// Add each tag in the form of "tag, ", except the last one.
+	buf := make([]byte, 0, size)
+	for i, tag := range tags {
+		if i != 0 {
+			buf = append(buf, ',', ' ')
+		}
+		buf = append(buf, tag...)
+	}
 	return buf
 }
 
 // MarshalJSON returns an JSON formatted string slice (or JSON array).
 func (tags Tags) MarshalJSON() ([]byte, error) {
-	if len(tags) == 0 {
-		return []byte("[]"), nil
-	}
-
-	// Add each tag in the form of `"tag", `
-	buf := []byte("[")
-	for _, tag := range tags {
-		qoutedTag := strconv.Quote(tag)
-		buf = append(buf, qoutedTag...)
-		buf = append(buf, ',')
-		buf = append(buf, ' ')
-	}
+	return tags.AppendJSON(nil), nil
+}
 
-	// Drop the last "," and add a closing bracket.
-	buf = append(buf[:len(buf)-2], ']')
-	return buf, nil
+// AppendJSON does the same as Tags.MarshalJSON, but appends the JSON to the
+// given buffer and returns the extended buffer.
+func (tags Tags) AppendJSON(buf []byte) []byte {
+	return appendTagsJSON(buf, tags, false)
 }
 
 // Append add new tags to given tags.
 func (tags Tags) Append(newTags ...string) Tags {
 	return append(tags, newTags...)
 }
+
+const (
+	// Maximum number of tag sets kept by InternTags, to protect against
+	// unbounded memory growth when used with high cardinality tags.
+	maxInternedTags = 4096
+
+	// Size of the buffer used to create the lookup key in InternTags, keys that
+	// fit in this buffer don't require an allocation.
+	internKeySize = 128
+)
+
+// Small sets of tags aren't stored in an inline array, because Tags is a slice
+// in the API and events are copied by value when send to the event channel, a
+// slice pointing into an array in the event would still point to the old
+// copy. Instead InternTags avoids allocating tags that are repeated.
+var (
+	internedTagsMu sync.RWMutex
+	internedTags   = make(map[string]Tags)
+)
+
+// InternTags returns a shared Tags for the given tags. Calling InternTags
+// multiple times with the same tags will return the same Tags, without
+// allocating a new slice on each call. This is useful for tags that are
+// repeated in every log call, for example:
+//
+//	func handleRequest() {
+//		tags := logger.InternTags("http", "handleRequest")
+//		logger.Info(tags, "Handling request")
+//	}
+//
+// The returned Tags must NOT be modified, use Tags.Append to create new tags
+// based on the returned Tags.
+//
+// Note: only use this for tags with a low cardinality, e.g. not for tags
+// containing an user id. A maximum of 4096 unique sets of tags are kept, after
+// that a new Tags is returned for unknown tags.
+func InternTags(tags ...string) Tags {
+	// Every tag is prefixed with its length, so that different tags can't
+	// result in the same key.
+	var keyBuf [internKeySize]byte
+	key := keyBuf[:0]
+	for _, tag := range tags {
+		key = binary.AppendUvarint(key, uint64(len(tag)))
+		key = append(key, tag...)
+	}
+
+	internedTagsMu.RLock()
+	interned, ok := internedTags[string(key)]
+	internedTagsMu.RUnlock()
+	if ok {
+		return interned
+	}
+
+	// Make sure the capacity is equal to the length so that calls to
+	// Tags.Append never modify the shared tags.
+	interned = make(Tags, len(tags))
+	copy(interned, tags)
+
+	internedTagsMu.Lock()
+	if len(internedTags) < maxInternedTags {
+		if existing, ok := internedTags[string(key)]; ok {
+			interned = existing
+		} else {
+			internedTags[string(key)] = interned
+		}
+	}
+	internedTagsMu.Unlock()
+	return interned
+}
@@ -43,6 +43,50 @@ func TestTags(t *testing.T) {
 	}
 }
 
+func TestTag(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		key      string
+		value    interface{}
+		expected string
+	}{
+		{"user", "Thomas", "user=Thomas"},
+		{"id", 123, "id=123"},
+		{"empty", "", "empty="},
+		{"url", "/path?a=b", "url=/path?a=b"},
+	}
+
+	for _, test := range tests {
+		if got := Tag(test.key, test.value); got != test.expected {
+			t.Fatalf("Expected Tag(%q, %v) to return %q, but got %q",
+				test.key, test.value, test.expected, got)
+		}
+	}
+}
+
+func TestTagsGet(t *testing.T) {
+	t.Parallel()
+
+	tags := Tags{"tag1", Tag("user", 123), "=value", Tag("url", "/path?a=b"), Tag("user", 456)}
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{"user", "123"},
+		{"url", "/path?a=b"},
+		{"tag1", ""},
+		{"", ""},
+		{"unknown", ""},
+	}
+
+	for _, test := range tests {
+		if got := tags.Get(test.key); got != test.expected {
+			t.Fatalf("Expected Tags.Get(%q) to return %q, but got %q", test.key, test.expected, got)
+		}
+	}
+}
+
 func TestTagsAppend(t *testing.T) {
 	t.Parallel()
 
@@ -82,3 +126,40 @@ func TestTagsAppend(t *testing.T) {
 		}
 	}
 }
+
+func TestInternTags(t *testing.T) {
+	t.Parallel()
+
+	tags1 := InternTags("intern1", "intern2")
+	tags2 := InternTags("intern1", "intern2")
+	tags3 := InternTags("intern1intern2")
+
+	if expected := (Tags{"intern1", "intern2"}); !reflect.DeepEqual(tags1, expected) {
+		t.Fatalf("Expected InternTags to return %v, but got %v", expected, tags1)
+	} else if &tags1[0] != &tags2[0] {
+		t.Fatal("Expected InternTags to return the same tags for the same input")
+	} else if expected := (Tags{"intern1intern2"}); !reflect.DeepEqual(tags3, expected) {
+		t.Fatalf("Expected InternTags to return %v, but got %v", expected, tags3)
+	}
+
+	// Tags containing the separator of other tags must not collide.
+	tags6 := InternTags("intern5\x00", "")
+	tags7 := InternTags("intern5", "\x00")
+	tags8 := InternTags("intern5", "")
+	if expected := (Tags{"intern5\x00", ""}); !reflect.DeepEqual(tags6, expected) {
+		t.Fatalf("Expected InternTags to return %q, but got %q", expected, tags6)
+	} else if expected := (Tags{"intern5", "\x00"}); !reflect.DeepEqual(tags7, expected) {
+		t.Fatalf("Expected InternTags to return %q, but got %q", expected, tags7)
+	} else if expected := (Tags{"intern5", ""}); !reflect.DeepEqual(tags8, expected) {
+		t.Fatalf("Expected InternTags to return %q, but got %q", expected, tags8)
+	}
+
+	// Appending must not modify the shared tags.
+	tags4 := tags1.Append("intern3")
+	tags5 := tags1.Append("intern4")
+	if expected := (Tags{"intern1", "intern2", "intern3"}); !reflect.DeepEqual(tags4, expected) {
+		t.Fatalf("Expected Tags.Append to return %v, but got %v", expected, tags4)
+	} else if expected := (Tags{"intern1", "intern2", "intern4"}); !reflect.DeepEqual(tags5, expected) {
+		t.Fatalf("Expected Tags.Append to return %v, but got %v", expected, tags5)
+	}
+}
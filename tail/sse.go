@@ -0,0 +1,85 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package tail
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+// Stubbed for testing.
+var keepAliveInterval = 15 * time.Second
+
+// SSE returns an http.Handler that streams the events to clients using
+// Server-Sent Events, for environments where WebSockets are blocked. Every
+// event is send as a message with the event in the JSON format of
+// logger.Event.MarshalJSON as data and, if set, the sequence number as id. The
+// "filter" query parameter is the same as for the WebSocket handler. The
+// response ends once the subscription is closed, e.g. because the Tail is
+// closed.
+func (t *Tail) SSE() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		filter, err := filterParam(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming not supported", http.StatusInternalServerError)
+			return
+		}
+
+		sub := t.Subscribe(filter)
+		defer sub.Close()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		// Comments are send periodically so proxies don't close the
+		// connection and closed connections are detected.
+		ticker := time.NewTicker(keepAliveInterval)
+		defer ticker.Stop()
+
+		var buf []byte
+		for {
+			select {
+			case event, ok := <-sub.Events():
+				if !ok {
+					return
+				}
+				buf = appendMessage(buf[:0], event)
+				if _, err := w.Write(buf); err != nil {
+					return
+				}
+			case <-ticker.C:
+				if _, err := w.Write([]byte(":\n\n")); err != nil {
+					return
+				}
+			case <-r.Context().Done():
+				return
+			}
+			flusher.Flush()
+		}
+	})
+}
+
+// appendMessage appends the event as Server-Sent Events message to buf.
+func appendMessage(buf []byte, event logger.Event) []byte {
+	if event.Seq != 0 {
+		buf = append(buf, "id: "...)
+		buf = strconv.AppendUint(buf, event.Seq, 10)
+		buf = append(buf, '\n')
+	}
+	// The JSON format never contains a newline.
+	buf = append(buf, "data: "...)
+	buf = event.AppendJSON(buf)
+	return append(buf, '\n', '\n')
+}
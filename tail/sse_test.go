@@ -0,0 +1,80 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package tail
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+func TestSSE(t *testing.T) {
+	oldInterval := keepAliveInterval
+	keepAliveInterval = 20 * time.Millisecond
+	defer func() { keepAliveInterval = oldInterval }()
+
+	tail := New(DefaultBufferSize, DropEvents)
+	server := httptest.NewServer(tail.SSE())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/?filter=" + url.QueryEscape(`tags has "sse"`))
+	if err != nil {
+		t.Fatal("Unexpected error requesting: " + err.Error())
+	}
+	defer resp.Body.Close()
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("Expected content type text/event-stream, but got %q", got)
+	}
+
+	ts := time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC)
+	tail.Write(logger.Event{Type: logger.InfoEvent, Timestamp: ts, Message: "Not matching"})
+	tail.Write(logger.Event{Type: logger.InfoEvent, Timestamp: ts, Tags: logger.Tags{"sse"}, Message: "Info message", Seq: 2})
+	tail.Write(logger.Event{Type: logger.InfoEvent, Timestamp: ts, Tags: logger.Tags{"sse"}, Message: "No seq"})
+
+	r := bufio.NewReader(resp.Body)
+	expected := []string{
+		"id: 2\n",
+		`data: {"schema_version": 1, "type": "Info", "timestamp": "2015-09-01T14:22:36Z", "seq": 2, "tags": ["sse"], "message": "Info message"}` + "\n",
+		"\n",
+		`data: {"schema_version": 1, "type": "Info", "timestamp": "2015-09-01T14:22:36Z", "tags": ["sse"], "message": "No seq"}` + "\n",
+		"\n",
+	}
+	for _, want := range expected {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal("Unexpected error reading: " + err.Error())
+		}
+		if line != want {
+			t.Fatalf("Expected line %q, but got %q", want, line)
+		}
+	}
+
+	// Keep alive comment.
+	if line, err := r.ReadString('\n'); err != nil || line != ":\n" {
+		t.Fatalf("Expected a keep alive comment, but got %q, error: %v", line, err)
+	}
+
+	tail.Close()
+	for {
+		if _, err := r.ReadString('\n'); err != nil {
+			break
+		}
+	}
+}
+
+func TestSSEBadFilter(t *testing.T) {
+	tail := New(DefaultBufferSize, DropEvents)
+	req := httptest.NewRequest(http.MethodGet, "/?filter=type", nil)
+	rec := httptest.NewRecorder()
+	tail.SSE().ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("Expected status %d, but got %d", http.StatusBadRequest, rec.Code)
+	}
+}
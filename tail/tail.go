@@ -0,0 +1,148 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+// Package tail provides live tailing of the events of a running process. The
+// Tail is an EventWriter that passes all events to its subscribers, which can
+// be connected over HTTP using the WebSocket or Server-Sent Events handler,
+// e.g. for a browser-based log viewer:
+//
+//	t := tail.New(tail.DefaultBufferSize, tail.DropEvents)
+//	logger.Start(console, t)
+//	http.Handle("/logs/tail", t.WebSocket())
+//	http.Handle("/logs/events", t.SSE())
+//
+// Each subscriber can set a filter expression, see logger.CompileFilter, to
+// only receive the matching events. Subscribers that don't keep up with the
+// events are handled according to the SlowClientPolicy.
+package tail
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+// DefaultBufferSize is the default number of events buffered per subscriber.
+const DefaultBufferSize = 256
+
+// SlowClientPolicy determines what happens to subscribers that don't receive
+// the events as fast as they're written.
+type SlowClientPolicy uint8
+
+const (
+	// DropEvents drops the events that don't fit in the buffer of the
+	// subscriber, see Subscription.Dropped.
+	DropEvents SlowClientPolicy = iota
+
+	// Disconnect closes the subscription once its buffer is full.
+	Disconnect
+)
+
+// Tail is an EventWriter that passes the events to its subscribers. Writing
+// never blocks on the subscribers.
+type Tail struct {
+	bufferSize int
+	policy     SlowClientPolicy
+
+	mu     sync.Mutex
+	subs   map[*Subscription]struct{}
+	closed bool
+}
+
+// New creates a new Tail, that buffers bufferSize events per subscriber.
+func New(bufferSize int, policy SlowClientPolicy) *Tail {
+	return &Tail{
+		bufferSize: bufferSize,
+		policy:     policy,
+		subs:       make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscription is a subscription to the events of a Tail.
+type Subscription struct {
+	tail    *Tail
+	filter  *logger.Filter
+	events  chan logger.Event
+	dropped uint64
+}
+
+// Subscribe subscribes to the events written to the Tail, matching the filter.
+// If filter is nil all events are received. If the Tail is closed the
+// returned Subscription is already closed.
+func (t *Tail) Subscribe(filter *logger.Filter) *Subscription {
+	sub := &Subscription{tail: t, filter: filter, events: make(chan logger.Event, t.bufferSize)}
+	t.mu.Lock()
+	if t.closed {
+		close(sub.events)
+	} else {
+		t.subs[sub] = struct{}{}
+	}
+	t.mu.Unlock()
+	return sub
+}
+
+// Events returns the channel the events are send on. It's closed once the
+// Subscription is closed, by Close, by closing the Tail or because of the
+// Disconnect policy.
+func (sub *Subscription) Events() <-chan logger.Event {
+	return sub.events
+}
+
+// Dropped returns the number of events dropped because of the DropEvents
+// policy.
+func (sub *Subscription) Dropped() uint64 {
+	return atomic.LoadUint64(&sub.dropped)
+}
+
+// Close closes the subscription.
+func (sub *Subscription) Close() {
+	sub.tail.mu.Lock()
+	sub.tail.unsubscribe(sub)
+	sub.tail.mu.Unlock()
+}
+
+// unsubscribe removes the Subscription, t.mu must be locked.
+func (t *Tail) unsubscribe(sub *Subscription) {
+	if _, ok := t.subs[sub]; ok {
+		delete(t.subs, sub)
+		close(sub.events)
+	}
+}
+
+// Write passes the event to all subscribers with a matching filter.
+func (t *Tail) Write(event logger.Event) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for sub := range t.subs {
+		if sub.filter != nil && !sub.filter.Match(event) {
+			continue
+		}
+
+		select {
+		case sub.events <- event:
+		default:
+			if t.policy == Disconnect {
+				t.unsubscribe(sub)
+			} else {
+				atomic.AddUint64(&sub.dropped, 1)
+			}
+		}
+	}
+	return nil
+}
+
+// HandleError does nothing, Write never returns an error.
+func (t *Tail) HandleError(err error) {}
+
+// Close closes all Subscriptions.
+func (t *Tail) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closed = true
+	for sub := range t.subs {
+		t.unsubscribe(sub)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package tail
+
+import (
+	"testing"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+func TestTailSubscribe(t *testing.T) {
+	tail := New(2, DropEvents)
+	all := tail.Subscribe(nil)
+	warn := tail.Subscribe(logger.MustCompileFilter("type >= Warn"))
+
+	events := []logger.Event{
+		{Type: logger.InfoEvent, Message: "Info message 1"},
+		{Type: logger.WarnEvent, Message: "Warn message"},
+		{Type: logger.InfoEvent, Message: "Info message 2"},
+	}
+	for _, event := range events {
+		if err := tail.Write(event); err != nil {
+			t.Fatal("Unexpected error writing: " + err.Error())
+		}
+	}
+
+	if got := all.Dropped(); got != 1 {
+		t.Fatalf("Expected 1 dropped event, but got %d", got)
+	}
+	if got := warn.Dropped(); got != 0 {
+		t.Fatalf("Expected no dropped events, but got %d", got)
+	}
+
+	if err := tail.Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+	expectMessages(t, all, "Info message 1", "Warn message")
+	expectMessages(t, warn, "Warn message")
+
+	closed := tail.Subscribe(nil)
+	if _, ok := <-closed.Events(); ok {
+		t.Fatal("Expected the subscription of a closed Tail to be closed")
+	}
+}
+
+func TestTailDisconnect(t *testing.T) {
+	tail := New(1, Disconnect)
+	sub := tail.Subscribe(nil)
+
+	tail.Write(logger.Event{Type: logger.InfoEvent, Message: "Info message 1"})
+	tail.Write(logger.Event{Type: logger.InfoEvent, Message: "Info message 2"})
+	tail.Write(logger.Event{Type: logger.InfoEvent, Message: "Info message 3"})
+	expectMessages(t, sub, "Info message 1")
+	sub.Close()
+}
+
+// expectMessages expects the subscription to receive events with the
+// messages, after which it must be closed.
+func expectMessages(t *testing.T, sub *Subscription, messages ...string) {
+	var got []string
+	for event := range sub.Events() {
+		got = append(got, event.Message)
+	}
+	if len(got) != len(messages) {
+		t.Fatalf("Expected messages %q, but got %q", messages, got)
+	}
+	for i, msg := range messages {
+		if got[i] != msg {
+			t.Fatalf("Expected messages %q, but got %q", messages, got)
+		}
+	}
+}
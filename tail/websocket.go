@@ -0,0 +1,287 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package tail
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+// WebSocket opcodes, see RFC 6455.
+const (
+	opText  = 0x1
+	opClose = 0x8
+	opPing  = 0x9
+	opPong  = 0xa
+)
+
+const (
+	// GUID used to create the Sec-WebSocket-Accept header.
+	websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	// Maximum size of a frame send by the client, the client isn't expected to
+	// send anything but control frames.
+	maxClientFrameSize = 4096
+)
+
+// Stubbed for testing.
+var writeTimeout = 10 * time.Second
+
+// checkOrigin returns true if the request doesn't have an Origin header, i.e.
+// isn't made by a browser, the origin matches the host of the request or is
+// one of the allowed origins.
+func checkOrigin(r *http.Request, allowedOrigins []string) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range allowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// filterParam returns the filter of the "filter" query parameter of the
+// request, if any.
+func filterParam(r *http.Request) (*logger.Filter, error) {
+	expr := r.URL.Query().Get("filter")
+	if expr == "" {
+		return nil, nil
+	}
+	return logger.CompileFilter(expr)
+}
+
+// WebSocket returns an http.Handler that streams the events to WebSocket
+// clients. Every event is send as a text message containing the event in the
+// JSON format of logger.Event.MarshalJSON. The optional "filter" query
+// parameter is a filter expression, see logger.CompileFilter, that the events
+// must match. The connection is closed once the subscription is closed, e.g.
+// because the Tail is closed.
+//
+// Browsers allow any web page to open a WebSocket connection to any server, so
+// to prevent other web pages from reading the events, handshakes with an
+// Origin header that doesn't match the host of the request are rejected. Other
+// origins, e.g. "https://dashboard.example.com", can be allowed by passing
+// them as allowedOrigins, "*" allows all origins.
+func (t *Tail) WebSocket(allowedOrigins ...string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !checkOrigin(r, allowedOrigins) {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+		filter, err := filterParam(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		if !checkHandshake(w, r) {
+			return
+		}
+
+		hijacker, ok := w.(http.Hijacker)
+		if !ok {
+			http.Error(w, "WebSocket not supported", http.StatusInternalServerError)
+			return
+		}
+		conn, rw, err := hijacker.Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+			"Upgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: " + acceptKey(r.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n")
+		if err := rw.Flush(); err != nil {
+			return
+		}
+
+		sub := t.Subscribe(filter)
+		defer sub.Close()
+		ws := &websocket{w: rw.Writer, setDeadline: conn.SetWriteDeadline}
+		closed := make(chan struct{})
+		go func() {
+			ws.readFrames(rw.Reader)
+			close(closed)
+		}()
+		ws.writeEvents(sub, closed)
+	})
+}
+
+// checkHandshake returns true if the request is a valid WebSocket handshake,
+// otherwise it writes an error response.
+func checkHandshake(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method != http.MethodGet || r.Header.Get("Sec-WebSocket-Key") == "" ||
+		!headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, "Upgrade", "websocket") {
+		http.Error(w, "expected a WebSocket handshake", http.StatusBadRequest)
+		return false
+	} else if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "unsupported WebSocket version", http.StatusUpgradeRequired)
+		return false
+	}
+	return true
+}
+
+// writeEvents writes the events of the subscription, until either the
+// subscription or the connection (signaled by closed) is closed.
+func (ws *websocket) writeEvents(sub *Subscription, closed <-chan struct{}) {
+	var buf []byte
+	for {
+		select {
+		case event, ok := <-sub.Events():
+			if !ok {
+				ws.writeFrame(opClose, nil)
+				return
+			}
+			buf = event.AppendJSON(buf[:0])
+			if err := ws.writeFrame(opText, buf); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		}
+	}
+}
+
+// headerContains returns true if the comma separated header contains the
+// token, case-insensitively.
+func headerContains(header http.Header, name, token string) bool {
+	for _, value := range header[name] {
+		for _, v := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(v), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// acceptKey returns the value of the Sec-WebSocket-Accept header.
+func acceptKey(key string) string {
+	h := sha1.New()
+	io.WriteString(h, key+websocketGUID)
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// websocket is the server side of a WebSocket connection.
+type websocket struct {
+	mu          sync.Mutex
+	w           *bufio.Writer
+	setDeadline func(time.Time) error
+}
+
+// writeFrame writes a single, unfragmented, frame.
+func (ws *websocket) writeFrame(opcode byte, payload []byte) error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	var header [10]byte
+	header[0] = 0x80 | opcode // Final fragment.
+	n := 2
+	switch length := len(payload); {
+	case length < 126:
+		header[1] = byte(length)
+	case length <= 0xffff:
+		header[1] = 126
+		binary.BigEndian.PutUint16(header[2:], uint16(length))
+		n = 4
+	default:
+		header[1] = 127
+		binary.BigEndian.PutUint64(header[2:], uint64(length))
+		n = 10
+	}
+
+	ws.setDeadline(time.Now().Add(writeTimeout))
+	ws.w.Write(header[:n])
+	ws.w.Write(payload)
+	return ws.w.Flush()
+}
+
+var errFrameTooBig = errors.New("tail: WebSocket frame too big")
+
+// readFrame reads a single frame send by the client.
+func readFrame(r *bufio.Reader) (opcode byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	opcode = header[0] & 0x0f
+	masked := header[1]&0x80 != 0
+	length, err := readPayloadLength(r, header[1]&0x7f)
+	if err != nil {
+		return 0, nil, err
+	} else if length > maxClientFrameSize {
+		return 0, nil, errFrameTooBig
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(r, mask[:]); err != nil {
+			return 0, nil, err
+		}
+	}
+	payload = make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return opcode, payload, nil
+}
+
+// readPayloadLength reads the extended payload length, if any, given the 7 bit
+// payload length of the header.
+func readPayloadLength(r *bufio.Reader, length byte) (uint64, error) {
+	var ext [8]byte
+	switch length {
+	case 126:
+		_, err := io.ReadFull(r, ext[:2])
+		return uint64(binary.BigEndian.Uint16(ext[:2])), err
+	case 127:
+		_, err := io.ReadFull(r, ext[:])
+		return binary.BigEndian.Uint64(ext[:]), err
+	}
+	return uint64(length), nil
+}
+
+// readFrames reads the frames send by the client, it replies to pings and
+// returns once the client closes the connection or on the first error.
+func (ws *websocket) readFrames(r *bufio.Reader) {
+	for {
+		opcode, payload, err := readFrame(r)
+		if err != nil {
+			return
+		}
+		switch opcode {
+		case opClose:
+			ws.writeFrame(opClose, payload)
+			return
+		case opPing:
+			if ws.writeFrame(opPong, payload) != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package tail
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+// dialWebSocket does the WebSocket handshake with the server.
+func dialWebSocket(t *testing.T, server *httptest.Server, query string) (net.Conn, *bufio.Reader) {
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatal("Unexpected error dialing: " + err.Error())
+	}
+	key := "dGhlIHNhbXBsZSBub25jZQ=="
+	conn.Write([]byte("GET /?" + query + " HTTP/1.1\r\nHost: localhost\r\n" +
+		"Upgrade: websocket\r\nConnection: keep-alive, Upgrade\r\n" +
+		"Sec-WebSocket-Key: " + key + "\r\nSec-WebSocket-Version: 13\r\n\r\n"))
+
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatal("Unexpected error reading handshake: " + err.Error())
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected status %d, but got %d", http.StatusSwitchingProtocols, resp.StatusCode)
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("Unexpected Sec-WebSocket-Accept header: %q", got)
+	}
+	return conn, r
+}
+
+// waitForSubscription waits until the Tail has a single subscription.
+func waitForSubscription(t *testing.T, tail *Tail) {
+	for i := 0; ; i++ {
+		tail.mu.Lock()
+		n := len(tail.subs)
+		tail.mu.Unlock()
+		if n == 1 {
+			return
+		} else if i > 100 {
+			t.Fatal("Timed out waiting for the subscription")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWebSocket(t *testing.T) {
+	tail := New(DefaultBufferSize, DropEvents)
+	server := httptest.NewServer(tail.WebSocket())
+	defer server.Close()
+
+	conn, r := dialWebSocket(t, server, "filter="+url.QueryEscape("type >= Warn"))
+	defer conn.Close()
+
+	waitForSubscription(t, tail)
+
+	ts := time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC)
+	tail.Write(logger.Event{Type: logger.InfoEvent, Timestamp: ts, Message: "Info message"})
+	tail.Write(logger.Event{Type: logger.WarnEvent, Timestamp: ts, Tags: logger.Tags{"ws"}, Message: "Warn message"})
+
+	opcode, payload, err := readFrame(r)
+	if err != nil {
+		t.Fatal("Unexpected error reading frame: " + err.Error())
+	}
+	expected := `{"schema_version": 1, "type": "Warn", "timestamp": "2015-09-01T14:22:36Z", "tags": ["ws"], "message": "Warn message"}`
+	if opcode != opText || string(payload) != expected {
+		t.Fatalf("Expected text frame %s, but got opcode %d: %s", expected, opcode, payload)
+	}
+
+	// Masked ping.
+	conn.Write([]byte{0x80 | opPing, 0x80 | 2, 1, 2, 3, 4, 'h' ^ 1, 'i' ^ 2})
+	if opcode, payload, err := readFrame(r); err != nil || opcode != opPong || string(payload) != "hi" {
+		t.Fatalf("Expected a pong, but got opcode %d: %q, error: %v", opcode, payload, err)
+	}
+
+	tail.Close()
+	if opcode, _, err := readFrame(r); err != nil || opcode != opClose {
+		t.Fatalf("Expected a close frame, but got opcode %d, error: %v", opcode, err)
+	}
+}
+
+func TestWebSocketBadRequests(t *testing.T) {
+	tail := New(DefaultBufferSize, DropEvents)
+	server := httptest.NewServer(tail.WebSocket())
+	defer server.Close()
+
+	tests := []struct {
+		query    string
+		header   http.Header
+		expected int
+	}{
+		{"", nil, http.StatusBadRequest},
+		{"filter=" + url.QueryEscape("type =="), nil, http.StatusBadRequest},
+		{"", http.Header{"Connection": {"Upgrade"}, "Upgrade": {"websocket"},
+			"Sec-Websocket-Key": {"key"}, "Sec-Websocket-Version": {"8"}}, http.StatusUpgradeRequired},
+		{"", http.Header{"Connection": {"Upgrade"}, "Upgrade": {"websocket"}, "Origin": {"https://example.com"},
+			"Sec-Websocket-Key": {"key"}, "Sec-Websocket-Version": {"13"}}, http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/?"+test.query, nil)
+		for name, values := range test.header {
+			req.Header[name] = values
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal("Unexpected error requesting: " + err.Error())
+		}
+		resp.Body.Close()
+		if resp.StatusCode != test.expected {
+			t.Errorf("Expected status %d for %q, but got %d", test.expected, test.query, resp.StatusCode)
+		}
+	}
+}
+
+func TestCheckOrigin(t *testing.T) {
+	tests := []struct {
+		origin   string
+		allowed  []string
+		expected bool
+	}{
+		{"", nil, true},
+		{"http://localhost:8080", nil, true},
+		{"https://LOCALHOST:8080", nil, true},
+		{"http://localhost", nil, false},
+		{"https://example.com", nil, false},
+		{"https://example.com", []string{"https://dashboard.example.com"}, false},
+		{"https://dashboard.example.com", []string{"https://dashboard.example.com"}, true},
+		{"https://example.com", []string{"*"}, true},
+		{"null", nil, false},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodGet, "http://localhost:8080/", nil)
+		if test.origin != "" {
+			r.Header.Set("Origin", test.origin)
+		}
+		if got := checkOrigin(r, test.allowed); got != test.expected {
+			t.Errorf("Expected checkOrigin(%q, %v) to return %t, but got %t",
+				test.origin, test.allowed, test.expected, got)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/Thomasdezeeuw/logger/internal/json"
+	"github.com/Thomasdezeeuw/logger/internal/util"
+)
+
+// TemplateFormatter formats events using a text/template, this allows events
+// to be formatted to match an existing log format, for example one expected by
+// a legacy parser. The template is executed with the Event, for example:
+//
+//	{{.Timestamp.Format "Jan _2 15:04:05"}} {{.Type}} [{{.Tags}}] {{.Message}}
+//
+// Besides the builtin template functions the following functions are
+// available:
+//
+//	lower  Returns the lowercase version of the argument, e.g. {{lower .Type}}.
+//	upper  Returns the uppercase version of the argument.
+//	json   Returns the argument as JSON string, including quotes.
+//
+// A newline is added after each event, if the output of the template doesn't
+// end with one.
+type TemplateFormatter struct {
+	template *template.Template
+}
+
+var templateFuncs = template.FuncMap{
+	"lower": func(v interface{}) string { return strings.ToLower(util.InterfaceToString(v)) },
+	"upper": func(v interface{}) string { return strings.ToUpper(util.InterfaceToString(v)) },
+	"json":  func(v interface{}) string { return string(json.AppendString(nil, util.InterfaceToString(v))) },
+}
+
+// NewTemplateFormatter creates a new TemplateFormatter, it returns an error if
+// the template is invalid.
+func NewTemplateFormatter(text string) (*TemplateFormatter, error) {
+	tmpl, err := template.New("logger").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	return &TemplateFormatter{tmpl}, nil
+}
+
+// appendWriter is an io.Writer that appends to a byte slice.
+type appendWriter []byte
+
+func (w *appendWriter) Write(p []byte) (int, error) {
+	*w = append(*w, p...)
+	return len(p), nil
+}
+
+// Format appends the event, formatted using the template, to buf.
+func (formatter *TemplateFormatter) Format(buf []byte, event Event) ([]byte, error) {
+	start := len(buf)
+	w := appendWriter(buf)
+	if err := formatter.template.Execute(&w, event); err != nil {
+		return buf[:start], err
+	}
+
+	buf = []byte(w)
+	if len(buf) == start || buf[len(buf)-1] != newLine {
+		buf = append(buf, newLine)
+	}
+	return buf, nil
+}
+
+// NewTemplateEventWriter creates a new EventWriter that writes events
+// formatted using the template, see TemplateFormatter, to the given writer.
+// MinType is the minimal EventType an event must have to be logged. For
+// example if minType is InfoEvent, then any events with an EventType of
+// DebugEvent will not be logged. It returns an error if the template is
+// invalid.
+func NewTemplateEventWriter(minType EventType, w io.Writer, text string, errorHandler func(error)) (EventWriter, error) {
+	formatter, err := NewTemplateFormatter(text)
+	if err != nil {
+		return nil, err
+	}
+	return NewFormatEventWriter(minType, w, formatter, errorHandler), nil
+}
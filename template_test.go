@@ -0,0 +1,92 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTemplateFormatter(t *testing.T) {
+	event := Event{
+		Type:      WarnEvent,
+		Timestamp: now(),
+		Tags:      Tags{"tag1", "tag2"},
+		Message:   "Some \"message\"",
+		Data:      map[string]string{"user": "123"},
+	}
+
+	tests := []struct {
+		template string
+		expected string
+	}{
+		{`{{.Timestamp}} {{.Type}} {{.Message}}`,
+			"2015-09-01 14:22:36 +0000 UTC Warn Some \"message\"\n"},
+		{`{{.Timestamp.Format "Jan _2 15:04:05"}} {{upper .Type}} [{{.Tags}}] {{.Message}}`,
+			"Sep  1 14:22:36 WARN [tag1, tag2] Some \"message\"\n"},
+		{`level={{lower .Type}} msg={{json .Message}} user={{index .Data "user"}}` + "\n",
+			"level=warn msg=\"Some \\\"message\\\"\" user=123\n"},
+		{``, "\n"},
+	}
+
+	for _, test := range tests {
+		formatter, err := NewTemplateFormatter(test.template)
+		if err != nil {
+			t.Fatal("Unexpected error creating formatter: " + err.Error())
+		}
+
+		got, err := formatter.Format([]byte("prefix"), event)
+		if err != nil {
+			t.Fatal("Unexpected error formatting event: " + err.Error())
+		} else if expected := "prefix" + test.expected; string(got) != expected {
+			t.Fatalf("Expected the event to be formatted as %q, but got %q", expected, got)
+		}
+	}
+}
+
+func TestTemplateFormatterErrors(t *testing.T) {
+	if _, err := NewTemplateFormatter(`{{.Type`); err == nil {
+		t.Fatal("Expected an error creating a formatter with an invalid template, but got none")
+	}
+
+	formatter, err := NewTemplateFormatter(`{{.Unknown}}`)
+	if err != nil {
+		t.Fatal("Unexpected error creating formatter: " + err.Error())
+	}
+	got, err := formatter.Format([]byte("prefix"), Event{})
+	if err == nil {
+		t.Fatal("Expected an error executing the template, but got none")
+	} else if string(got) != "prefix" {
+		t.Fatalf("Expected the buffer to be unchanged, but got %q", got)
+	}
+}
+
+func TestTemplateEventWriter(t *testing.T) {
+	var buf bytes.Buffer
+	ew, err := NewTemplateEventWriter(InfoEvent, &buf, `{{.Type}}: {{.Message}}`, func(error) {})
+	if err != nil {
+		t.Fatal("Unexpected error creating EventWriter: " + err.Error())
+	}
+
+	events := []Event{
+		{Type: DebugEvent, Timestamp: now(), Message: "Debug message"},
+		{Type: InfoEvent, Timestamp: now(), Message: "Info message"},
+		{Type: ErrorEvent, Timestamp: now(), Message: "Error message"},
+	}
+	for _, event := range events {
+		if err := ew.Write(event); err != nil {
+			t.Fatal("Unexpected error writing event: " + err.Error())
+		}
+	}
+
+	expected := "Info: Info message\nError: Error message\n"
+	if got := buf.String(); got != expected {
+		t.Fatalf("Expected buffer to contain:\n%s\nBut got:\n%s", expected, got)
+	}
+
+	if _, err := NewTemplateEventWriter(InfoEvent, &buf, `{{`, func(error) {}); err == nil {
+		t.Fatal("Expected an error creating EventWriter with an invalid template, but got none")
+	}
+}
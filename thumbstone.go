@@ -0,0 +1,89 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// thumbstoneHits are the number of calls to Thumbstone for a single function.
+type thumbstoneHits struct {
+	tags Tags // Of the first call.
+	n    uint64
+}
+
+var (
+	thumbstoneOnce bool
+	thumbstoneMu   sync.Mutex
+	thumbstones    map[string]*thumbstoneHits
+)
+
+// SetThumbstoneOnce makes Thumbstone log only the first call for each function
+// (name), preventing a function that is suspected to be dead, but is actually
+// called often, from flooding the logs. All calls are still counted, see
+// ThumbstoneCounts, and for each function that is called more than once an
+// event with the count is logged when Close is called.
+//
+// Note: this must be called before Start and is not safe for concurrent use.
+func SetThumbstoneOnce(enabled bool) {
+	thumbstoneOnce = enabled
+	thumbstones = nil
+}
+
+// ThumbstoneCounts returns the number of calls to Thumbstone per function
+// name. It only returns counts if SetThumbstoneOnce is enabled.
+func ThumbstoneCounts() map[string]uint64 {
+	thumbstoneMu.Lock()
+	defer thumbstoneMu.Unlock()
+	counts := make(map[string]uint64, len(thumbstones))
+	for functionName, hits := range thumbstones {
+		counts[functionName] = hits.n
+	}
+	return counts
+}
+
+// countThumbstone counts a call to Thumbstone and returns true if the call
+// should be logged.
+func countThumbstone(tags Tags, functionName string) bool {
+	if !thumbstoneOnce {
+		return true
+	}
+
+	thumbstoneMu.Lock()
+	defer thumbstoneMu.Unlock()
+	if hits, ok := thumbstones[functionName]; ok {
+		hits.n++
+		return false
+	}
+
+	if thumbstones == nil {
+		thumbstones = make(map[string]*thumbstoneHits)
+	}
+	thumbstones[functionName] = &thumbstoneHits{tags, 1}
+	return true
+}
+
+// logThumbstoneCounts logs an event with the number of calls for each function
+// that is called more than once, sorted by function name.
+func logThumbstoneCounts() {
+	thumbstoneMu.Lock()
+	defer thumbstoneMu.Unlock()
+
+	functionNames := make([]string, 0, len(thumbstones))
+	for functionName, hits := range thumbstones {
+		if hits.n > 1 {
+			functionNames = append(functionNames, functionName)
+		}
+	}
+	sort.Strings(functionNames)
+
+	for _, functionName := range functionNames {
+		hits := thumbstones[functionName]
+		msg := fmt.Sprintf("Function %s called %d times", functionName, hits.n)
+		sendEvent(Event{ThumbEvent, now(), hits.tags, msg, nil, 0})
+	}
+}
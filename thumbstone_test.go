@@ -0,0 +1,64 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestThumbstoneOnce(t *testing.T) {
+	defer reset()
+	var ew eventWriter
+	SetThumbstoneOnce(true)
+	Start(&ew)
+
+	for i := 0; i < 3; i++ {
+		Thumbstone(Tags{"tag1"}, "fn1")
+	}
+	Thumbstone(Tags{"tag2"}, "fn2")
+
+	expectedCounts := map[string]uint64{"fn1": 3, "fn2": 1}
+	if got := ThumbstoneCounts(); !reflect.DeepEqual(got, expectedCounts) {
+		t.Fatalf("Expected counts %v, but got %v", expectedCounts, got)
+	}
+
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if len(ew.events) != 3 {
+		t.Fatalf("Expected 3 events, but got %d: %v", len(ew.events), ew.events)
+	}
+	for i, functionName := range []string{"fn1", "fn2"} {
+		if prefix := "Function " + functionName + " called by "; !strings.HasPrefix(ew.events[i].Message, prefix) {
+			t.Fatalf("Expected event #%d to start with %q, but got %q", i, prefix, ew.events[i].Message)
+		}
+	}
+
+	expected := Event{ThumbEvent, now(), Tags{"tag1"}, "Function fn1 called 3 times", nil, 3}
+	if got := ew.events[2]; !reflect.DeepEqual(got, expected) {
+		t.Fatalf("Expected the counts event to be %v, but got %v", expected, got)
+	}
+}
+
+func TestThumbstoneCountsDisabled(t *testing.T) {
+	defer reset()
+	var ew eventWriter
+	Start(&ew)
+
+	Thumbstone(Tags{"tag1"}, "fn1")
+	Thumbstone(Tags{"tag1"}, "fn1")
+
+	if got := ThumbstoneCounts(); len(got) != 0 {
+		t.Fatalf("Expected no counts, but got %v", got)
+	}
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	} else if len(ew.events) != 2 {
+		t.Fatalf("Expected 2 events, but got %d", len(ew.events))
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"errors"
+	"strconv"
+	"time"
+)
+
+// Special layouts for TimestampFormat that format the timestamp as the number
+// of seconds, or milliseconds, since the Unix epoch.
+const (
+	UnixLayout      = "unix"
+	UnixMilliLayout = "unixmilli"
+)
+
+// TimestampFormat defines how timestamps are formatted by EventWriters that
+// write text, see WithTimestampFormat. The zero value formats timestamps using
+// TimeFormat in the UTC timezone, like Event.String.
+type TimestampFormat struct {
+	// Layout as used by time.Time.Format, or UnixLayout or UnixMilliLayout.
+	// Defaults to TimeFormat.
+	Layout string
+
+	// Location (timezone) the timestamp is converted to before formatting,
+	// defaults to UTC.
+	Location *time.Location
+}
+
+// Commonly used TimestampFormats.
+var (
+	RFC3339Timestamp = TimestampFormat{Layout: time.RFC3339}
+	NanoTimestamp    = TimestampFormat{Layout: TimeFormatNano}
+	UnixTimestamp    = TimestampFormat{Layout: UnixLayout}
+	LocalTimestamp   = TimestampFormat{Location: time.Local}
+)
+
+// Append appends the formatted timestamp to buf and returns the extended
+// buffer.
+func (format TimestampFormat) Append(buf []byte, t time.Time) []byte {
+	switch format.Layout {
+	case "":
+		return t.In(format.location()).AppendFormat(buf, TimeFormat)
+	case UnixLayout:
+		return strconv.AppendInt(buf, t.Unix(), 10)
+	case UnixMilliLayout:
+		return strconv.AppendInt(buf, t.UnixNano()/int64(time.Millisecond), 10)
+	default:
+		return t.In(format.location()).AppendFormat(buf, format.Layout)
+	}
+}
+
+// Parse parses a timestamp formatted by Append.
+func (format TimestampFormat) Parse(value string) (time.Time, error) {
+	switch format.Layout {
+	case "":
+		return time.ParseInLocation(TimeFormat, value, format.location())
+	case UnixLayout, UnixMilliLayout:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		} else if format.Layout == UnixLayout {
+			return time.Unix(n, 0).In(format.location()), nil
+		}
+		return time.Unix(0, n*int64(time.Millisecond)).In(format.location()), nil
+	default:
+		return time.ParseInLocation(format.Layout, value, format.location())
+	}
+}
+
+func (format TimestampFormat) location() *time.Location {
+	if format.Location == nil {
+		return time.UTC
+	}
+	return format.Location
+}
+
+// timestampFormatSetter is implemented by EventWriters that support a custom
+// timestamp format, it returns false if the format isn't supported after all.
+type timestampFormatSetter interface {
+	setTimestampFormat(format TimestampFormat) bool
+}
+
+// ErrTimestampFormatUnsupported is returned by WithTimestampFormat if the
+// EventWriter doesn't support a custom timestamp format.
+var ErrTimestampFormatUnsupported = errors.New("logger: EventWriter doesn't support a custom timestamp format")
+
+// WithTimestampFormat changes the format of the timestamps written by the
+// given EventWriter and returns it. Supported are the EventWriters created by
+// NewFileEventWriter, NewTagFileEventWriter, NewConsoleEventWriter,
+// NewSplitConsoleEventWriter and NewPrettyEventWriter. Other EventWriters
+// write the timestamp as defined by the format they write, e.g. JSON, for those
+// ErrTimestampFormatUnsupported is returned.
+//
+// Note: this must be called before the EventWriter is passed to Start.
+func WithTimestampFormat(ew EventWriter, format TimestampFormat) (EventWriter, error) {
+	setter, ok := ew.(timestampFormatSetter)
+	if !ok || !setter.setTimestampFormat(format) {
+		return nil, ErrTimestampFormatUnsupported
+	}
+	return ew, nil
+}
@@ -0,0 +1,99 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestTimestampFormat(t *testing.T) {
+	t1 := time.Date(2015, 9, 1, 14, 22, 36, 123456789, time.UTC)
+	amsterdam := time.FixedZone("CEST", 2*60*60)
+
+	tests := []struct {
+		format   TimestampFormat
+		expected string
+	}{
+		{TimestampFormat{}, "2015-09-01 14:22:36"},
+		{RFC3339Timestamp, "2015-09-01T14:22:36Z"},
+		{NanoTimestamp, "2015-09-01 14:22:36.123456789"},
+		{UnixTimestamp, "1441117356"},
+		{TimestampFormat{Layout: UnixMilliLayout}, "1441117356123"},
+		{TimestampFormat{Location: amsterdam}, "2015-09-01 16:22:36"},
+		{TimestampFormat{Layout: time.RFC3339Nano, Location: amsterdam}, "2015-09-01T16:22:36.123456789+02:00"},
+		{TimestampFormat{Layout: "02/01/2006 15:04"}, "01/09/2015 14:22"},
+	}
+
+	for _, test := range tests {
+		if got := string(test.format.Append([]byte("prefix "), t1)); got != "prefix "+test.expected {
+			t.Fatalf("Expected timestamp %q, but got %q", "prefix "+test.expected, got)
+		}
+
+		parsed, err := test.format.Parse(test.expected)
+		if err != nil {
+			t.Fatal("Unexpected error parsing timestamp: " + err.Error())
+		} else if got := string(test.format.Append(nil, parsed)); got != test.expected {
+			t.Fatalf("Expected parsed timestamp to format as %q, but got %q", test.expected, got)
+		}
+	}
+}
+
+func TestEventAppendText(t *testing.T) {
+	event := Event{
+		Type:      InfoEvent,
+		Timestamp: time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC),
+		Tags:      Tags{"tag1", "tag2"},
+		Message:   "Message",
+		Data:      "data",
+	}
+
+	expected := "1441117356 [Info] tag1, tag2: Message, data"
+	if got := string(event.AppendText(nil, UnixTimestamp)); got != expected {
+		t.Fatalf("Expected %q, but got %q", expected, got)
+	}
+}
+
+func TestWithTimestampFormat(t *testing.T) {
+	var buf bytes.Buffer
+	cew := NewConsoleEventWriter(InfoEvent).(*consoleEventWriter)
+	cew.w = &buf
+	ew, err := WithTimestampFormat(cew, RFC3339Timestamp)
+	if err != nil {
+		t.Fatal("Unexpected error setting the timestamp format: " + err.Error())
+	}
+
+	var prettyBuf bytes.Buffer
+	pew, err := WithTimestampFormat(NewPrettyEventWriter(InfoEvent, &prettyBuf, func(error) {}), UnixTimestamp)
+	if err != nil {
+		t.Fatal("Unexpected error setting the timestamp format: " + err.Error())
+	}
+
+	event := Event{Type: InfoEvent, Timestamp: now(), Tags: Tags{"tag1"}, Message: "Message"}
+	if err := ew.Write(event); err != nil {
+		t.Fatal("Unexpected error writing event: " + err.Error())
+	} else if err := pew.Write(event); err != nil {
+		t.Fatal("Unexpected error writing event: " + err.Error())
+	}
+
+	expected := "2015-09-01T14:22:36Z [Info] tag1: Message\n"
+	if got := buf.String(); got != expected {
+		t.Fatalf("Expected buffer to contain %q, but got %q", expected, got)
+	}
+	expected = "1441117356 Info   Message\n    tags: tag1\n"
+	if got := prettyBuf.String(); got != expected {
+		t.Fatalf("Expected buffer to contain %q, but got %q", expected, got)
+	}
+}
+
+func TestWithTimestampFormatUnsupported(t *testing.T) {
+	ew, err := WithTimestampFormat(NewECSEventWriter(InfoEvent, &bytes.Buffer{}, func(error) {}), UnixTimestamp)
+	if err != ErrTimestampFormatUnsupported {
+		t.Fatalf("Expected ErrTimestampFormatUnsupported, but got %v", err)
+	} else if ew != nil {
+		t.Fatalf("Expected no EventWriter, but got %v", ew)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+// Transform transforms an event before it's written, e.g. to enrich it with
+// tags, see SetTransforms. Because the tags and data of the event may be
+// shared with the caller of the log operation, a Transform must not modify
+// them in place, but copy them first.
+type Transform func(Event) Event
+
+var transforms []Transform
+
+// SetTransforms sets the Transforms applied to every event, in order, before
+// it's written to the EventWriters. Unlike Transformed, which runs once per
+// EventWriter, the Transforms run once per event, in the goroutine that fans
+// out the events. For example to add the pod name and git commit to every
+// event:
+//
+//	logger.SetTransforms(logger.AddTags(
+//		logger.Tag("pod", os.Getenv("POD_NAME")),
+//		logger.Tag("commit", gitCommit),
+//	))
+//
+// The Transforms are applied before the mandatory tags are added, see
+// SetMandatoryTags. Internal events of the logger are transformed as well.
+//
+// Note: this must be called before Start and is not safe for concurrent use.
+func SetTransforms(fns ...Transform) {
+	transforms = fns
+}
+
+// AddTags returns a Transform that adds the tags to every event, if the event
+// doesn't already have them.
+func AddTags(tags ...string) Transform {
+	return func(event Event) Event {
+		newTags := make(Tags, len(event.Tags), len(event.Tags)+len(tags))
+		copy(newTags, event.Tags)
+		for _, tag := range tags {
+			if !containsTag(newTags, tag) {
+				newTags = append(newTags, tag)
+			}
+		}
+		event.Tags = newTags
+		return event
+	}
+}
+
+// applyTransforms applies all Transforms to the event.
+func applyTransforms(event Event, fns []Transform) Event {
+	for _, fn := range fns {
+		event = fn(event)
+	}
+	return event
+}
@@ -0,0 +1,41 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import "testing"
+
+func TestSetTransforms(t *testing.T) {
+	defer reset()
+	var calls int
+	SetTransforms(func(event Event) Event {
+		calls++
+		event.Message += "!"
+		return event
+	}, AddTags("pod=web-1", "transform"))
+	var ew1, ew2 eventWriter
+	Start(&ew1, &ew2)
+
+	tags := Tags{"transform"}
+	Info(tags, "Info message")
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if calls != 1 {
+		t.Fatalf("Expected the Transform to be called once, but got %d calls", calls)
+	}
+	for _, ew := range []eventWriter{ew1, ew2} {
+		if len(ew.events) != 1 {
+			t.Fatalf("Expected a single event, but got %v", ew.events)
+		}
+		event := ew.events[0]
+		if event.Message != "Info message!" || event.Tags.String() != "transform, pod=web-1" {
+			t.Fatalf("Expected the event to be transformed, but got %v", event)
+		}
+	}
+	if tags.String() != "transform" {
+		t.Fatalf("Expected the tags of the caller not to be modified, but got %v", tags)
+	}
+}
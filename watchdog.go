@@ -0,0 +1,226 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stall describes a stall of the logger, as detected by the watchdog, see
+// SetWatchdog.
+type Stall struct {
+	// Writer is the EventWriter that didn't complete a Write in time, or nil
+	// if the event channel is full.
+	Writer EventWriter
+
+	// Duration is how long the stall has lasted when it was detected.
+	Duration time.Duration
+}
+
+var (
+	watchdogThreshold time.Duration
+	watchdogFn        func(Stall)
+	watchdogDone      chan struct{}
+	writerStates      []*writerState
+)
+
+// writerState is the state of a running EventWriter, used by the watchdog and
+// the statistics.
+type writerState struct {
+	index    int
+	failures failureCounter
+
+	// Time (in Unix nanoseconds) the current write started, or 0 if not
+	// writing. Must be accessed atomically.
+	writeStart int64
+
+	// Only recorded if statistics are enabled, see OnStats.
+	latency latencyRecorder
+
+	// Number of Writes per bucket of LatencyBuckets, always recorded. Must be
+	// accessed atomically.
+	histogram [len(LatencyBuckets) + 1]uint64
+
+	// The last error returned by Write and the time it was returned.
+	errMu     sync.Mutex
+	lastErr   error
+	lastErrAt time.Time
+
+	// 1 if the EventWriter is bad, 0 otherwise. Must be accessed atomically.
+	bad uint32
+
+	// Number of events dropped because the EventWriter is bad. Must be
+	// accessed atomically.
+	dropped uint64
+
+	// Events queued for the EventWriter.
+	events chan queuedEvent
+}
+
+func newWriterState(index int, ew EventWriter) *writerState {
+	return &writerState{
+		index:    index,
+		failures: failureCounter{threshold: failureThreshold(ew)},
+	}
+}
+
+func (state *writerState) setBad(bad bool) {
+	var v uint32
+	if bad {
+		v = 1
+	}
+	atomic.StoreUint32(&state.bad, v)
+}
+
+func (state *writerState) isBad() bool {
+	return atomic.LoadUint32(&state.bad) == 1
+}
+
+func (state *writerState) drop() {
+	atomic.AddUint64(&state.dropped, 1)
+}
+
+// droppedEvents returns the total number of events dropped by the EventWriters.
+func droppedEvents(states []*writerState) uint64 {
+	var n uint64
+	for _, state := range states {
+		n += atomic.LoadUint64(&state.dropped)
+	}
+	return n
+}
+
+func (state *writerState) startWrite() {
+	atomic.StoreInt64(&state.writeStart, time.Now().UnixNano())
+}
+
+func (state *writerState) endWrite() {
+	start := atomic.SwapInt64(&state.writeStart, 0)
+	d := time.Since(time.Unix(0, start))
+	atomic.AddUint64(&state.histogram[latencyBucket(d)], 1)
+	if statsFn != nil {
+		state.latency.record(d)
+	}
+}
+
+// latencyBucket returns the index of the bucket in LatencyBuckets for the
+// latency.
+func latencyBucket(d time.Duration) int {
+	for i, max := range LatencyBuckets {
+		if d <= max {
+			return i
+		}
+	}
+	return len(LatencyBuckets)
+}
+
+func (state *writerState) setLastError(err error) {
+	state.errMu.Lock()
+	state.lastErr, state.lastErrAt = err, now()
+	state.errMu.Unlock()
+}
+
+func (state *writerState) lastError() (error, time.Time) {
+	state.errMu.Lock()
+	defer state.errMu.Unlock()
+	return state.lastErr, state.lastErrAt
+}
+
+// SetWatchdog enables a watchdog that detects stalls of the logger, so silent
+// stalls are noticed. The logger is considered stalled if the event channel is
+// full, which means log operations block, or if an EventWriter didn't complete
+// a Write, for longer then threshold. Every stall is reported once by calling
+// fn, from a separate goroutine. A threshold of zero, or a nil fn, disables the
+// watchdog, which is the default.
+//
+// Note: this must be called before Start and is not safe for concurrent use.
+func SetWatchdog(threshold time.Duration, fn func(Stall)) {
+	watchdogThreshold = threshold
+	watchdogFn = fn
+}
+
+// startWatchdog starts the watchdog goroutine, if enabled.
+func startWatchdog() {
+	if watchdogThreshold <= 0 || watchdogFn == nil {
+		return
+	}
+
+	watchdogDone = make(chan struct{})
+	go watchdog(watchdogThreshold, watchdogFn, eventChannel, eventWriters, writerStates, watchdogDone)
+}
+
+// stopWatchdog stops the watchdog goroutine, if running.
+func stopWatchdog() {
+	if watchdogDone != nil {
+		close(watchdogDone)
+		watchdogDone = nil
+	}
+}
+
+// watchdog checks for stalls until done is closed.
+func watchdog(threshold time.Duration, fn func(Stall), events chan Event, ews []EventWriter, states []*writerState, done <-chan struct{}) {
+	ticker := time.NewTicker(threshold / 4)
+	defer ticker.Stop()
+
+	d := stallDetector{
+		threshold: threshold,
+		fn:        fn,
+		ews:       ews,
+		states:    states,
+		reported:  make([]int64, len(states)),
+	}
+	for {
+		select {
+		case <-done:
+			return
+		case t := <-ticker.C:
+			d.checkChannel(t, len(events) < cap(events))
+			d.checkWriters(t)
+		}
+	}
+}
+
+// stallDetector holds the state of the watchdog.
+type stallDetector struct {
+	threshold time.Duration
+	fn        func(Stall)
+	ews       []EventWriter
+	states    []*writerState
+
+	fullSince       time.Time
+	channelReported bool
+	// Start time of the write that was last reported, per EventWriter.
+	reported []int64
+}
+
+// checkChannel reports a stall if the event channel is full for longer then
+// the threshold, once per stall.
+func (d *stallDetector) checkChannel(t time.Time, hasRoom bool) {
+	if hasRoom {
+		d.fullSince = time.Time{}
+		d.channelReported = false
+	} else if d.fullSince.IsZero() {
+		d.fullSince = t
+	} else if stalled := t.Sub(d.fullSince); stalled >= d.threshold && !d.channelReported {
+		d.channelReported = true
+		d.fn(Stall{Duration: stalled})
+	}
+}
+
+// checkWriters reports a stall of every EventWriter with a write taking
+// longer then the threshold, once per write.
+func (d *stallDetector) checkWriters(t time.Time) {
+	for i, state := range d.states {
+		start := atomic.LoadInt64(&state.writeStart)
+		if start == 0 || start == d.reported[i] {
+			continue
+		}
+		if stalled := t.Sub(time.Unix(0, start)); stalled >= d.threshold {
+			d.reported[i] = start
+			d.fn(Stall{Writer: d.ews[i], Duration: stalled})
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingEventWriter blocks in Write until unblock is closed.
+type blockingEventWriter struct {
+	eventWriter
+	unblock chan struct{}
+}
+
+func (ew *blockingEventWriter) Write(event Event) error {
+	<-ew.unblock
+	return ew.eventWriter.Write(event)
+}
+
+func TestWatchdog(t *testing.T) {
+	defer reset()
+
+	var mu sync.Mutex
+	var stalls []Stall
+	stalled := make(chan struct{}, 10)
+	SetWatchdog(20*time.Millisecond, func(stall Stall) {
+		mu.Lock()
+		stalls = append(stalls, stall)
+		mu.Unlock()
+		stalled <- struct{}{}
+	})
+
+	bew := blockingEventWriter{unblock: make(chan struct{})}
+	Start(&bew)
+
+	// Fill the sub channel of the EventWriter and the event channel.
+	logged := make(chan struct{})
+	go func() {
+		for i := 0; i < 2*defaultEventChannelSize+2; i++ {
+			Info(Tags{"watchdog"}, "Info message")
+		}
+		close(logged)
+	}()
+
+	// Both the blocking writer and the full channel should be reported, once.
+	<-stalled
+	<-stalled
+	time.Sleep(50 * time.Millisecond)
+	close(bew.unblock)
+	<-logged
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(stalls) != 2 {
+		t.Fatalf("Expected 2 stalls, but got %v", stalls)
+	}
+	var writerStall, channelStall bool
+	for _, stall := range stalls {
+		if stall.Duration < 20*time.Millisecond {
+			t.Errorf("Expected the stall to last at least the threshold, but got %v", stall.Duration)
+		}
+		if stall.Writer == &bew {
+			writerStall = true
+		} else if stall.Writer == nil {
+			channelStall = true
+		}
+	}
+	if !writerStall || !channelStall {
+		t.Fatalf("Expected a stall of the writer and the channel, but got %v", stalls)
+	}
+}
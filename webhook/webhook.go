@@ -0,0 +1,199 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+// Package webhook provides an EventWriter that sends every event, formatted as
+// JSON, in a separate HTTP request to a configurable URL. This can be used to
+// trigger any kind of automation downstream.
+package webhook
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+// Defaults used if the Config fields are not set.
+const (
+	DefaultMethod       = "POST"
+	DefaultMaxRetries   = 3
+	DefaultRetryBackoff = 100 * time.Millisecond
+	DefaultConcurrency  = 4
+	DefaultTimeout      = 10 * time.Second
+)
+
+// Config is the configuration for the EventWriter created by NewEventWriter.
+type Config struct {
+	// URL the events are send to.
+	URL string
+
+	// Method of the requests, defaults to DefaultMethod.
+	Method string
+
+	// Headers added to each request. The values are templates, see
+	// text/template, executed with the event, for example:
+	//
+	//	Headers: map[string]string{
+	//		"Authorization": "Bearer token",
+	//		"X-Event-Type":  "{{.Type}}",
+	//	}
+	Headers map[string]string
+
+	// MaxRetries is the maximum number of times a request is retried if it
+	// failed due to a network error, or a 429 or 5xx response. Defaults to
+	// DefaultMaxRetries, a negative number disables retrying.
+	MaxRetries int
+
+	// RetryBackoff is the time waited before the first retry, it doubles with
+	// each retry. Defaults to DefaultRetryBackoff.
+	RetryBackoff time.Duration
+
+	// Concurrency is the maximum number of requests in flight, defaults to
+	// DefaultConcurrency. If reached Write blocks until a request is done.
+	Concurrency int
+
+	// Timeout of a single request, defaults to DefaultTimeout.
+	Timeout time.Duration
+}
+
+type eventWriter struct {
+	config       Config
+	client       *http.Client
+	headers      map[string]*template.Template
+	semaphore    chan struct{}
+	wg           sync.WaitGroup
+	errorHandler func(error)
+	minType      logger.EventType
+}
+
+// Write starts the request in the background. Errors, after all retries, are
+// passed to the error handler.
+func (ew *eventWriter) Write(event logger.Event) error {
+	if event.Type < ew.minType {
+		return nil
+	}
+
+	body, err := event.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	header := make(http.Header, len(ew.headers)+1)
+	header.Set("Content-Type", "application/json")
+	var buf bytes.Buffer
+	for name, tmpl := range ew.headers {
+		buf.Reset()
+		if err := tmpl.Execute(&buf, event); err != nil {
+			return err
+		}
+		header.Set(name, buf.String())
+	}
+
+	ew.semaphore <- struct{}{}
+	ew.wg.Add(1)
+	go func() {
+		defer ew.wg.Done()
+		defer func() { <-ew.semaphore }()
+		if err := ew.send(header, body); err != nil {
+			ew.errorHandler(err)
+		}
+	}()
+	return nil
+}
+
+// Send sends the request, retrying it if possible.
+func (ew *eventWriter) send(header http.Header, body []byte) error {
+	backoff := ew.config.RetryBackoff
+	for retry := 0; ; retry++ {
+		temporary, err := ew.do(header, body)
+		if err == nil || !temporary || retry >= ew.config.MaxRetries {
+			return err
+		}
+		sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// Stubbed for testing.
+var sleep = time.Sleep
+
+// Do does a single request, it returns whether or not the request can be
+// retried and an error, if any.
+func (ew *eventWriter) do(header http.Header, body []byte) (bool, error) {
+	req, err := http.NewRequest(ew.config.Method, ew.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header = header
+
+	resp, err := ew.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		respBody, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		temporary := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode/100 == 5
+		return temporary, fmt.Errorf("webhook: unexpected response: %s: %s", resp.Status, respBody)
+	}
+	io.Copy(ioutil.Discard, resp.Body)
+	return false, nil
+}
+
+func (ew *eventWriter) HandleError(err error) {
+	ew.errorHandler(err)
+}
+
+// Close waits until all requests are done.
+func (ew *eventWriter) Close() error {
+	ew.wg.Wait()
+	return nil
+}
+
+// NewEventWriter creates a new EventWriter that sends every event, formatted
+// as JSON, to the configured URL, see Config. MinType is the minimal
+// EventType an event must have to be logged. For example if minType is
+// InfoEvent, then any events with an EventType of DebugEvent will not be
+// logged. It returns an error if one of the header templates is invalid.
+func NewEventWriter(minType logger.EventType, config Config, errorHandler func(error)) (logger.EventWriter, error) {
+	if config.Method == "" {
+		config.Method = DefaultMethod
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = DefaultMaxRetries
+	}
+	if config.RetryBackoff == 0 {
+		config.RetryBackoff = DefaultRetryBackoff
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = DefaultConcurrency
+	}
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+
+	headers := make(map[string]*template.Template, len(config.Headers))
+	for name, value := range config.Headers {
+		tmpl, err := template.New(name).Parse(value)
+		if err != nil {
+			return nil, err
+		}
+		headers[name] = tmpl
+	}
+
+	return &eventWriter{
+		config:       config,
+		client:       &http.Client{Timeout: config.Timeout},
+		headers:      headers,
+		semaphore:    make(chan struct{}, config.Concurrency),
+		errorHandler: errorHandler,
+		minType:      minType,
+	}, nil
+}
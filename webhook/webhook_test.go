@@ -0,0 +1,124 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package webhook
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Thomasdezeeuw/logger"
+)
+
+var t1 = time.Date(2015, 9, 1, 14, 22, 36, 0, time.UTC)
+
+func TestEventWriter(t *testing.T) {
+	var mu sync.Mutex
+	var bodies, headers []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		bodies = append(bodies, string(body))
+		headers = append(headers, r.Header.Get("X-Event"))
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Unexpected content type: %q", r.Header.Get("Content-Type"))
+		} else if r.Method != "PUT" {
+			t.Errorf("Unexpected method: %q", r.Method)
+		}
+	}))
+	defer server.Close()
+
+	config := Config{
+		URL:         server.URL,
+		Method:      "PUT",
+		Headers:     map[string]string{"X-Event": "{{.Type}} {{.Tags}}"},
+		Concurrency: 1,
+	}
+	ew, err := NewEventWriter(logger.InfoEvent, config, func(err error) {
+		t.Error("Unexpected error: " + err.Error())
+	})
+	if err != nil {
+		t.Fatal("Unexpected error creating EventWriter: " + err.Error())
+	}
+
+	events := []logger.Event{
+		{Type: logger.DebugEvent, Timestamp: t1, Message: "Debug message"},
+		{Type: logger.InfoEvent, Timestamp: t1, Tags: logger.Tags{"tag1", "tag2"}, Message: "Info message"},
+		{Type: logger.ErrorEvent, Timestamp: t1, Message: "Error message"},
+	}
+	for _, event := range events {
+		if err := ew.Write(event); err != nil {
+			t.Fatal("Unexpected error writing event: " + err.Error())
+		}
+	}
+	if err := ew.Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	// Concurrency of one, so the requests are in order.
+	expectedBodies := []string{
+		`{"schema_version": 1, "type": "Info", "timestamp": "2015-09-01T14:22:36Z", "tags": ["tag1", "tag2"], "message": "Info message"}`,
+		`{"schema_version": 1, "type": "Error", "timestamp": "2015-09-01T14:22:36Z", "tags": [], "message": "Error message"}`,
+	}
+	expectedHeaders := []string{"Info tag1, tag2", "Error"}
+	if !reflect.DeepEqual(bodies, expectedBodies) {
+		t.Fatalf("Expected request bodies %q, but got %q", expectedBodies, bodies)
+	} else if !reflect.DeepEqual(headers, expectedHeaders) {
+		t.Fatalf("Expected headers %q, but got %q", expectedHeaders, headers)
+	}
+}
+
+func TestEventWriterRetry(t *testing.T) {
+	oldSleep := sleep
+	defer func() { sleep = oldSleep }()
+	var sleeps []time.Duration
+	sleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+
+	var mu sync.Mutex
+	statuses := []int{503, 429, 200, 400}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.WriteHeader(statuses[0])
+		statuses = statuses[1:]
+	}))
+	defer server.Close()
+
+	var errs []error
+	config := Config{URL: server.URL, Concurrency: 1}
+	ew, err := NewEventWriter(logger.DebugEvent, config, func(err error) {
+		errs = append(errs, err)
+	})
+	if err != nil {
+		t.Fatal("Unexpected error creating EventWriter: " + err.Error())
+	}
+
+	// First event is retried twice, second event fails with a non temporary
+	// error.
+	ew.Write(logger.Event{Type: logger.InfoEvent, Timestamp: t1})
+	ew.Write(logger.Event{Type: logger.InfoEvent, Timestamp: t1})
+	ew.Close()
+
+	expectedSleeps := []time.Duration{DefaultRetryBackoff, 2 * DefaultRetryBackoff}
+	if len(sleeps) != len(expectedSleeps) || sleeps[0] != expectedSleeps[0] || sleeps[1] != expectedSleeps[1] {
+		t.Fatalf("Expected backoffs %v, but got %v", expectedSleeps, sleeps)
+	} else if len(errs) != 1 {
+		t.Fatalf("Expected a single error, but got %v", errs)
+	} else if len(statuses) != 0 {
+		t.Fatalf("Expected all responses to be used, but %d are left", len(statuses))
+	}
+}
+
+func TestNewEventWriterInvalidTemplate(t *testing.T) {
+	config := Config{Headers: map[string]string{"X-Event": "{{.Type"}}
+	if _, err := NewEventWriter(logger.DebugEvent, config, func(error) {}); err == nil {
+		t.Fatal("Expected an error creating EventWriter with an invalid template, but got none")
+	}
+}
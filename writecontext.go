@@ -0,0 +1,65 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"context"
+	"time"
+)
+
+// ContextEventWriter is an EventWriter that supports cancellation of writes,
+// e.g. an EventWriter that writes over the network. If an EventWriter
+// implements this interface WriteContext is called instead of Write, with a
+// context that expires after the write timeout, see SetWriteTimeout.
+//
+// WriteContext must return once the context is done, the returned error is
+// handled like any other error returned by Write. This way a slow EventWriter
+// doesn't block its events indefinitely.
+type ContextEventWriter interface {
+	EventWriter
+	WriteContext(ctx context.Context, event Event) error
+}
+
+var writeTimeout time.Duration
+
+// SetWriteTimeout sets the maximum duration of a single write to an
+// EventWriter that implements ContextEventWriter. A timeout of zero means the
+// writes never expire, which is the default. See Failover to write the events
+// elsewhere while an EventWriter is slow.
+//
+// Note: this must be called before Start and is not safe for concurrent use.
+func SetWriteTimeout(timeout time.Duration) {
+	writeTimeout = timeout
+}
+
+// write writes the event to the EventWriter, using WriteEncoded or
+// WriteContext if the EventWriter supports it. Encoded is the event in the
+// Encoding of the EventWriter, if nil it's encoded if required.
+func write(ew EventWriter, event Event, encoded []byte) error {
+	if eew, ok := ew.(EncodedEventWriter); ok {
+		if encoded == nil {
+			encoded = encodeEvent(nil, event, eventWriterEncoding(ew))
+		}
+		// Wrapping EventWriters, e.g. Filtered, implement EncodedEventWriter
+		// even if the wrapped EventWriter doesn't, in which case the Encoding
+		// is zero and the event isn't encoded.
+		if encoded != nil {
+			return eew.WriteEncoded(event, encoded)
+		}
+	}
+
+	cew, ok := ew.(ContextEventWriter)
+	if !ok {
+		return ew.Write(event)
+	}
+
+	ctx := context.Background()
+	if writeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, writeTimeout)
+		defer cancel()
+	}
+	return cew.WriteContext(ctx, event)
+}
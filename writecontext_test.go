@@ -0,0 +1,51 @@
+// Copyright (C) 2015-2016 Thomas de Zeeuw.
+//
+// Licensed under the MIT license that can be found in the LICENSE file.
+
+package logger
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// slowEventWriter is an eventWriter that blocks the first write until the
+// context is done.
+type slowEventWriter struct {
+	eventWriter
+	hasDeadline bool
+	slow        bool
+}
+
+func (ew *slowEventWriter) WriteContext(ctx context.Context, event Event) error {
+	_, ew.hasDeadline = ctx.Deadline()
+	if !ew.slow {
+		ew.slow = true
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	return ew.Write(event)
+}
+
+func TestContextEventWriter(t *testing.T) {
+	defer reset()
+	SetWriteTimeout(10 * time.Millisecond)
+	var ew slowEventWriter
+	Start(&ew)
+
+	Info(Tags{"context"}, "Info message")
+	if err := Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	if !ew.hasDeadline {
+		t.Fatal("Expected the context to have a deadline")
+	}
+	if len(ew.events) != 1 || ew.events[0].Message != "Info message" {
+		t.Fatalf("Expected the event to be written after the timeout, but got %v", ew.events)
+	}
+	if len(ew.errors) != 1 || ew.errors[0] != context.DeadlineExceeded {
+		t.Fatalf("Expected the write to be cancelled, but got errors %v", ew.errors)
+	}
+}
@@ -6,9 +6,9 @@ package logger
 
 import (
 	"bufio"
-	"encoding/json"
 	"io"
 	"os"
+	"time"
 )
 
 const (
@@ -17,26 +17,96 @@ const (
 )
 
 type fileEventWriter struct {
-	w       *bufio.Writer
-	f       *os.File
-	minType EventType
+	w               *bufio.Writer
+	f               *os.File
+	path            string
+	template        string
+	buf             []byte
+	timestampFormat TimestampFormat
+	escape          bool
+	json            bool
+	jsonNames       *jsonFieldNames
+	retention       *Retention
+	lastPurge       time.Time
+	sync            *SyncPolicy
+	unsynced        int
+	lastSync        time.Time
+	minType         EventType
 }
 
 func (ew *fileEventWriter) Write(event Event) error {
 	if event.Type < ew.minType {
 		return nil
 	}
-	bytes := append(event.Bytes(), '\n')
-	_, err := ew.w.Write(bytes)
-	return err
+
+	// Write is never called concurrently, so we can reuse the buffer.
+	if ew.json {
+		ew.buf = append(appendEventJSON(ew.buf[:0], event, true, ew.jsonNames), '\n')
+	} else {
+		ew.buf = append(appendText(ew.buf[:0], event, ew.timestampFormat, ew.escape), '\n')
+	}
+	if _, err := ew.w.Write(ew.buf); err != nil {
+		return err
+	}
+
+	if ew.retention != nil && now().Sub(ew.lastPurge) >= retentionCheckInterval {
+		ew.purge()
+	}
+
+	if ew.sync != nil {
+		ew.unsynced++
+		if ew.sync.due(ew.unsynced, ew.lastSync) {
+			if err := ew.syncFile(); err != nil {
+				// The event is written, returning the error would duplicate it.
+				ew.HandleError(err)
+			}
+		}
+	}
+	return nil
 }
 
 func (ew *fileEventWriter) HandleError(err error) {
-	msg := now().Format(TimeFormat) + " [Error] FileEventWriter: "
+	if ew.json {
+		event := Event{ErrorEvent, now(), Tags{"FileEventWriter"}, "Error writing to file: " + err.Error(), nil, 0}
+		ew.w.Write(append(appendEventJSON(nil, event, true, ew.jsonNames), '\n'))
+		return
+	}
+	msg := string(ew.timestampFormat.Append(nil, now())) + " [Error] FileEventWriter: "
 	msg += "Error writing to file: " + err.Error() + "\n"
 	ew.w.WriteString(msg)
 }
 
+func (ew *fileEventWriter) setTimestampFormat(format TimestampFormat) bool {
+	ew.timestampFormat = format
+	return !ew.json
+}
+
+func (ew *fileEventWriter) setEscaping() {
+	ew.escape = true
+}
+
+func (ew *fileEventWriter) Flush() error {
+	return ew.w.Flush()
+}
+
+// Rotate closes the file and opens the file at the path again, executing the
+// template of the path again.
+func (ew *fileEventWriter) Rotate() error {
+	path, err := expandFileName(ew.template)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, defaultFileFlag, defaultFilePermission)
+	if err != nil {
+		return err
+	}
+
+	err = ew.Close()
+	ew.w, ew.f, ew.path = bufio.NewWriter(f), f, path
+	return err
+}
+
 func (ew *fileEventWriter) Close() error {
 	flushErr := ew.w.Flush()
 	err := ew.f.Close()
@@ -50,65 +120,140 @@ func (ew *fileEventWriter) Close() error {
 // MinType is the minimal EventType an event must have to be logged. For example
 // if minType is InfoEvent, then any events with an EventType of DebugEvent will
 // not be logged.
+//
+// The path may be a template (see text/template) with FileNameData as data,
+// e.g. "app-{{.Date}}-{{.Host}}.log". This allows multiple instances writing to
+// a shared volume to each write to their own file. The template is executed
+// once, when the file is opened, or rotated, see Rotate.
 func NewFileEventWriter(minType EventType, path string) (EventWriter, error) {
+	template := path
+	path, err := expandFileName(template)
+	if err != nil {
+		return nil, err
+	}
+
 	f, err := os.OpenFile(path, defaultFileFlag, defaultFilePermission)
 	if err != nil {
 		return nil, err
 	}
 
-	return &fileEventWriter{bufio.NewWriter(f), f, minType}, nil
+	return &fileEventWriter{w: bufio.NewWriter(f), f: f, path: path, template: template, minType: minType}, nil
+}
+
+// NewJSONFileEventWriter does the same as NewFileEventWriter, but writes the
+// events in the JSON format, see Event.MarshalJSON, one event per line. Errors
+// are written to the file as JSON events as well. Like NewFileEventWriter it
+// supports WithRetention and WithSync, but not WithTimestampFormat.
+func NewJSONFileEventWriter(minType EventType, path string) (EventWriter, error) {
+	ew, err := NewFileEventWriter(minType, path)
+	if err != nil {
+		return nil, err
+	}
+	ew.(*fileEventWriter).json = true
+	return ew, nil
 }
 
 type consoleEventWriter struct {
-	w       io.Writer
-	errW    io.Writer
-	minType EventType
+	w               io.Writer
+	errW            io.Writer
+	stderrTypes     []EventType
+	buf             []byte
+	timestampFormat TimestampFormat
+	escape          bool
+	minType         EventType
 }
 
 func (ew *consoleEventWriter) Write(event Event) error {
 	if event.Type < ew.minType {
 		return nil
 	}
-	bytes := append(event.Bytes(), '\n')
-	_, err := ew.w.Write(bytes)
+
+	w := ew.w
+	for _, eventType := range ew.stderrTypes {
+		if event.Type == eventType {
+			w = ew.errW
+			break
+		}
+	}
+
+	// Write is never called concurrently, so we can reuse the buffer.
+	ew.buf = append(appendText(ew.buf[:0], event, ew.timestampFormat, ew.escape), '\n')
+	_, err := w.Write(ew.buf)
 	return err
 }
 
 func (ew *consoleEventWriter) HandleError(err error) {
-	msg := now().Format(TimeFormat) + " [Error] ConsoleEventWriter: "
+	msg := string(ew.timestampFormat.Append(nil, now())) + " [Error] ConsoleEventWriter: "
 	msg += "Error writing to console: " + err.Error() + "\n"
 	ew.errW.Write([]byte(msg))
 }
 
+func (ew *consoleEventWriter) setTimestampFormat(format TimestampFormat) bool {
+	ew.timestampFormat = format
+	return true
+}
+
+func (ew *consoleEventWriter) setEscaping() {
+	ew.escape = true
+}
+
 func (ew *consoleEventWriter) Close() error {
 	return nil
 }
 
+// appendText appends the event in the text format of Event.String, optionally
+// escaped, see Event.AppendEscapedText.
+func appendText(buf []byte, event Event, format TimestampFormat, escape bool) []byte {
+	if escape {
+		return event.AppendEscapedText(buf, format)
+	}
+	return event.AppendText(buf, format)
+}
+
 // Stubbed for testing
 var (
 	stdout io.Writer = os.Stdout
 	stderr io.Writer = os.Stderr
 )
 
-// NewConsoleEventWriter creates a new EventWriter that writes to standard out
-// and standard error. MinType is the minimal EventType an event must have to
-// be logged. For example if minType is InfoEvent, then any events with an
-// EventType of DebugEvent will not be logged.
+// NewConsoleEventWriter creates a new EventWriter that writes Warn, Error and
+// Fatal events to standard error and all other events to standard out. MinType
+// is the minimal EventType an event must have to be logged. For example if
+// minType is InfoEvent, then any events with an EventType of DebugEvent will
+// not be logged.
 func NewConsoleEventWriter(minType EventType) EventWriter {
-	return &consoleEventWriter{stdout, stderr, minType}
+	return NewSplitConsoleEventWriter(minType, WarnEvent, ErrorEvent, FatalEvent)
+}
+
+// NewSplitConsoleEventWriter does the same as NewConsoleEventWriter, but
+// allows the EventTypes that are written to standard error to be configured.
+// If no stderrTypes are given all events are written to standard out.
+func NewSplitConsoleEventWriter(minType EventType, stderrTypes ...EventType) EventWriter {
+	return &consoleEventWriter{
+		w:           stdout,
+		errW:        stderr,
+		stderrTypes: stderrTypes,
+		minType:     minType,
+	}
 }
 
 type jsonEventWriter struct {
-	enc          *json.Encoder
+	w            io.Writer
+	buf          []byte
 	errorHandler func(error)
 	minType      EventType
+	names        *jsonFieldNames
 }
 
 func (ew *jsonEventWriter) Write(event Event) error {
 	if event.Type < ew.minType {
 		return nil
 	}
-	return ew.enc.Encode(event)
+	// Write is never called concurrently, so we can reuse the buffer.
+	ew.buf = appendEventJSON(ew.buf[:0], event, true, ew.names)
+	ew.buf = append(ew.buf, '\n')
+	_, err := ew.w.Write(ew.buf)
+	return err
 }
 
 func (ew *jsonEventWriter) HandleError(err error) {
@@ -124,5 +269,5 @@ func (ew *jsonEventWriter) Close() error {
 // example if minType is InfoEvent, then any events with an EventType of
 // DebugEvent will not be logged.
 func NewJSONEventWriter(minType EventType, w io.Writer, errorHandler func(error)) EventWriter {
-	return &jsonEventWriter{json.NewEncoder(w), errorHandler, minType}
+	return &jsonEventWriter{w, nil, errorHandler, minType, nil}
 }
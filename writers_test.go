@@ -139,6 +139,59 @@ func TestConsoleEventWriter(t *testing.T) {
 	}
 }
 
+func TestConsoleEventWriterSplit(t *testing.T) {
+	tests := []struct {
+		ew             EventWriter
+		expectedStdout string
+		expectedStderr string
+	}{
+		{NewConsoleEventWriter(DebugEvent),
+			"2015-09-01 14:22:36 [Debug] tag: Debug message\n" +
+				"2015-09-01 14:22:36 [Info] tag: Info message\n" +
+				"2015-09-01 14:22:36 [Thumb] tag: Thumb message\n",
+			"2015-09-01 14:22:36 [Warn] tag: Warn message\n" +
+				"2015-09-01 14:22:36 [Error] tag: Error message\n" +
+				"2015-09-01 14:22:36 [Fatal] tag: Fatal message\n"},
+		{NewSplitConsoleEventWriter(InfoEvent, ErrorEvent),
+			"2015-09-01 14:22:36 [Info] tag: Info message\n" +
+				"2015-09-01 14:22:36 [Warn] tag: Warn message\n" +
+				"2015-09-01 14:22:36 [Fatal] tag: Fatal message\n" +
+				"2015-09-01 14:22:36 [Thumb] tag: Thumb message\n",
+			"2015-09-01 14:22:36 [Error] tag: Error message\n"},
+		{NewSplitConsoleEventWriter(ErrorEvent),
+			"2015-09-01 14:22:36 [Error] tag: Error message\n" +
+				"2015-09-01 14:22:36 [Fatal] tag: Fatal message\n" +
+				"2015-09-01 14:22:36 [Thumb] tag: Thumb message\n",
+			""},
+	}
+
+	eventTypes := []EventType{DebugEvent, InfoEvent, WarnEvent, ErrorEvent, FatalEvent, ThumbEvent}
+	for _, test := range tests {
+		var buf, errBuf bytes.Buffer
+		cew := test.ew.(*consoleEventWriter)
+		cew.w = &buf
+		cew.errW = &errBuf
+
+		for _, eventType := range eventTypes {
+			event := Event{
+				Type:      eventType,
+				Timestamp: now(),
+				Tags:      Tags{"tag"},
+				Message:   eventType.String() + " message",
+			}
+			if err := test.ew.Write(event); err != nil {
+				t.Fatal("Unexpected error writing to ConsoleEventWriter: " + err.Error())
+			}
+		}
+
+		if got := buf.String(); got != test.expectedStdout {
+			t.Fatalf("Expected standard out to contain:\n%s\nBut got:\n%s", test.expectedStdout, got)
+		} else if got := errBuf.String(); got != test.expectedStderr {
+			t.Fatalf("Expected standard error to contain:\n%s\nBut got:\n%s", test.expectedStderr, got)
+		}
+	}
+}
+
 func TestJSONEventWriter(t *testing.T) {
 	var buf bytes.Buffer
 	var errBuf bytes.Buffer
@@ -180,10 +233,47 @@ func TestJSONEventWriter(t *testing.T) {
 		t.Fatal("Unexpected error reading output buffer: " + err.Error())
 	}
 
-	expected := `{"type":"Info","timestamp":"2015-09-01T14:22:36Z","tags":` +
+	expected := `{"schema_version":1,"type":"Info","timestamp":"2015-09-01T14:22:36Z","tags":` +
 		`["TestJSONEventWriter"],"message":"Log message"}` + "\n"
 
 	if got := string(bytes); got != expected {
 		t.Fatalf("Expected buffer to contain:\n%s\nBut got:\n%s", expected, got)
 	}
 }
+
+func TestJSONFileEventWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	ew, err := NewJSONFileEventWriter(InfoEvent, path)
+	if err != nil {
+		t.Fatal("Unexpected error creating new JSON file event writer: " + err.Error())
+	}
+
+	events := []Event{
+		{Type: InfoEvent, Timestamp: now(), Tags: Tags{"json"}, Message: "Log message"},
+		{Type: DebugEvent, Timestamp: now(), Tags: Tags{"json"}, Message: "Never shows up"},
+	}
+	for _, event := range events {
+		if err := ew.Write(event); err != nil {
+			t.Fatal("Unexpected error writing to JSONFileEventWriter: " + err.Error())
+		}
+	}
+	ew.HandleError(errors.New("writing error"))
+	if err := ew.Close(); err != nil {
+		t.Fatal("Unexpected error closing: " + err.Error())
+	}
+
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal("Unexpected error reading file: " + err.Error())
+	}
+
+	expected := `{"schema_version":1,"type":"Info","timestamp":"2015-09-01T14:22:36Z","tags":["json"],"message":"Log message"}` + "\n" +
+		`{"schema_version":1,"type":"Error","timestamp":"2015-09-01T14:22:36Z","tags":["FileEventWriter"],"message":"Error writing to file: writing error"}` + "\n"
+	if got := string(bytes); got != expected {
+		t.Fatalf("Expected file to contain:\n%s\nBut got:\n%s", expected, got)
+	}
+
+	if _, err := WithTimestampFormat(ew, TimestampFormat{}); err != ErrTimestampFormatUnsupported {
+		t.Fatalf("Expected ErrTimestampFormatUnsupported, but got %v", err)
+	}
+}